@@ -0,0 +1,65 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+var refusedApexNSDeletionsTotal = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "external_dns",
+		Subsystem: "controller",
+		Name:      "refused_apex_ns_deletions_total",
+		Help:      "Number of planned deletions of the NS records of the apex of a domain which weren't applied",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(refusedApexNSDeletionsTotal)
+}
+
+// protectApexNS drops the deletions of the NS records of the apex of a domain, which would make
+// the whole domain unresolvable, e.g. because an endpoints file lost its NS endpoints. NS records
+// of subdomains, i.e. delegations, are deleted as planned. The apexes are the domains of the
+// domain filter, or the registrable domains without one, see recordDomain. Updates of apex NS
+// records are applied, so their name servers can still be changed.
+func (c *Controller) protectApexNS(changes *plan.Changes) *plan.Changes {
+	var deletions []*endpoint.Endpoint
+	for _, ep := range changes.Delete {
+		name := strings.ToLower(strings.TrimSuffix(ep.DNSName, "."))
+		if ep.RecordType == endpoint.RecordTypeNS && recordDomain(c.DomainFilter.Filters, name) == name {
+			refusedApexNSDeletionsTotal.Inc()
+			log.Errorf("Refusing to delete the NS record of the apex of domain %s", name)
+			continue
+		}
+		deletions = append(deletions, ep)
+	}
+	if len(deletions) == len(changes.Delete) {
+		return changes
+	}
+
+	result := *changes
+	result.Delete = deletions
+	return &result
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+func TestProtectApexNS(t *testing.T) {
+	ctrl := &Controller{DomainFilter: endpoint.NewDomainFilter([]string{"lab.example.org"})}
+	apex := endpoint.NewEndpoint("lab.example.org", endpoint.RecordTypeNS, "ns1.example.net", "ns2.example.net")
+	delegation := endpoint.NewEndpoint("team.lab.example.org", endpoint.RecordTypeNS, "ns1.team.example.net")
+	address := endpoint.NewEndpoint("lab.example.org", endpoint.RecordTypeA, "10.0.0.1")
+	updatedOld := endpoint.NewEndpoint("lab.example.org", endpoint.RecordTypeNS, "ns1.example.net")
+	updatedNew := endpoint.NewEndpoint("lab.example.org", endpoint.RecordTypeNS, "ns3.example.net")
+
+	refused := testutil.ToFloat64(refusedApexNSDeletionsTotal)
+
+	// Deletions of apex NS records are dropped, the other changes are applied.
+	changes := ctrl.protectApexNS(&plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{updatedOld},
+		UpdateNew: []*endpoint.Endpoint{updatedNew},
+		Delete:    []*endpoint.Endpoint{apex, delegation, address},
+	})
+	assert.Equal(t, &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{updatedOld},
+		UpdateNew: []*endpoint.Endpoint{updatedNew},
+		Delete:    []*endpoint.Endpoint{delegation, address},
+	}, changes)
+	assert.Equal(t, refused+1, testutil.ToFloat64(refusedApexNSDeletionsTotal))
+
+	// Without a domain filter, the apexes are the registrable domains.
+	ctrl = &Controller{}
+	planned := &plan.Changes{Delete: []*endpoint.Endpoint{apex}}
+	assert.Equal(t, planned, ctrl.protectApexNS(planned))
+	assert.Empty(t, ctrl.protectApexNS(&plan.Changes{
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("example.org", endpoint.RecordTypeNS, "ns1.example.net")},
+	}).Delete)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/fileutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// BackupVersion is the version of the format of backup archives. It's increased with
+// incompatible changes of the format.
+const BackupVersion = 1
+
+// Backup is an archive of the records of a provider.
+type Backup struct {
+	Version  int                  `json:"version"`
+	Time     time.Time            `json:"time"`
+	Provider string               `json:"provider"`
+	Records  []*endpoint.Endpoint `json:"records"`
+}
+
+// WriteBackup writes the records of the provider matching the domain filter to an archive,
+// including the records of the registry, and returns the number of records.
+func WriteBackup(ctx context.Context, p provider.Provider, providerName string, domainFilter endpoint.DomainFilter, path string) (int, error) {
+	records, err := p.Records(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	backup := Backup{Version: BackupVersion, Time: time.Now().UTC(), Provider: providerName, Records: []*endpoint.Endpoint{}}
+	for _, ep := range records {
+		if domainFilter.Match(ep.DNSName) {
+			backup.Records = append(backup.Records, ep)
+		}
+	}
+
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := fileutils.WriteFileAtomically(path, append(data, '\n')); err != nil {
+		return 0, err
+	}
+	return len(backup.Records), nil
+}
+
+// ReadBackup reads an archive written by WriteBackup.
+func ReadBackup(path string) (*Backup, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var backup Backup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("failed to decode backup %s: %v", path, err)
+	}
+	if backup.Version != BackupVersion {
+		return nil, fmt.Errorf("backup %s has unsupported version %d, expected %d", path, backup.Version, BackupVersion)
+	}
+	for _, ep := range backup.Records {
+		if ep.Labels == nil {
+			ep.Labels = endpoint.NewLabels()
+		}
+	}
+	return &backup, nil
+}
+
+// Restore recreates the records of the archive matching the domain filter in the provider.
+// Like Migrate, missing records are created and differing records are updated, while records
+// missing in the archive are kept. In dry-run mode the changes are only logged.
+func Restore(ctx context.Context, backup *Backup, to provider.Provider, domainFilter endpoint.DomainFilter, dryRun bool) (*plan.Changes, error) {
+	return copyRecords(ctx, backup.Records, to, domainFilter, dryRun)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+// TestBackupRestore tests that the records of a backup are recreated within the domain filter.
+func TestBackupRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "backup.json")
+	ctx := context.Background()
+
+	newProvider := func(records ...*endpoint.Endpoint) *inmemory.InMemoryProvider {
+		p := inmemory.NewInMemoryProvider(inmemory.InMemoryInitZones([]string{"example.org"}))
+		require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{Create: records}))
+		return p
+	}
+	backupRecords := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("api.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+		endpoint.NewEndpoint("api.example.org", endpoint.RecordTypeTXT, `"heritage=external-dns,external-dns/owner=default"`),
+		endpoint.NewEndpoint("internal.example.org", endpoint.RecordTypeA, "10.0.0.2"),
+	}
+	domainFilter := endpoint.NewDomainFilterWithExclusions([]string{"example.org"}, []string{"internal.example.org"})
+
+	n, err := WriteBackup(ctx, newProvider(backupRecords...), "inmemory", domainFilter, path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	backup, err := ReadBackup(path)
+	require.NoError(t, err)
+	assert.Equal(t, BackupVersion, backup.Version)
+	assert.Equal(t, "inmemory", backup.Provider)
+	assert.True(t, testutils.SameEndpoints(backup.Records, backupRecords[:2]))
+
+	// Restoring includes the excluded record, which is ignored.
+	backup.Records = backupRecords
+	to := newProvider(endpoint.NewEndpoint("legacy.example.org", endpoint.RecordTypeA, "10.1.0.4"))
+	changes, err := Restore(ctx, backup, to, domainFilter, true)
+	require.NoError(t, err)
+	assert.Len(t, changes.Create, 2)
+	records, err := to.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	_, err = Restore(ctx, backup, to, domainFilter, false)
+	require.NoError(t, err)
+	records, err = to.Records(ctx)
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		backupRecords[0],
+		backupRecords[1],
+		endpoint.NewEndpoint("legacy.example.org", endpoint.RecordTypeA, "10.1.0.4"),
+	}))
+}
+
+func TestReadBackupVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "backup.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"version": 2, "records": []}`), 0644))
+
+	_, err = ReadBackup(path)
+	assert.EqualError(t, err, "backup "+path+" has unsupported version 2, expected 1")
+}
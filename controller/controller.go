@@ -18,13 +18,19 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/trace"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/tracing"
 	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
 	"sigs.k8s.io/external-dns/registry"
@@ -72,6 +78,42 @@ var (
 			Help:      "Timestamp of last successful sync with the DNS provider",
 		},
 	)
+	domainLastChangeTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "domain_last_change_timestamp_seconds",
+			Help:      "Timestamp of the last changes applied to the DNS provider, partitioned by domain",
+		},
+		[]string{"domain"},
+	)
+	changesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "changes_total",
+			Help:      "Number of changes applied to the DNS provider, partitioned by action",
+		},
+		[]string{"action"},
+	)
+	applyDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "apply_duration_seconds",
+			Help:      "Duration of applying changes to the DNS provider, partitioned by the actions of the changes and the number of records",
+			Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120},
+		},
+		[]string{"action", "size"},
+	)
+	circuitBreakerOpen = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "circuit_breaker_open",
+			Help:      "Whether changes aren't applied after consecutive failures",
+		},
+	)
 	deprecatedRegistryErrors = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Subsystem: "registry",
@@ -94,10 +136,52 @@ func init() {
 	prometheus.MustRegister(sourceEndpointsTotal)
 	prometheus.MustRegister(registryEndpointsTotal)
 	prometheus.MustRegister(lastSyncTimestamp)
+	prometheus.MustRegister(domainLastChangeTimestamp)
+	prometheus.MustRegister(changesTotal)
+	prometheus.MustRegister(applyDuration)
+	prometheus.MustRegister(circuitBreakerOpen)
+	prometheus.MustRegister(propagationChecksTotal)
+	prometheus.MustRegister(heldDeletions)
 	prometheus.MustRegister(deprecatedRegistryErrors)
 	prometheus.MustRegister(deprecatedSourceErrors)
 }
 
+// Codes of the errors of failed synchronizations, logged in the error_code field.
+const (
+	ErrorCodeRegistryRecords = "registry_records"
+	ErrorCodeSourceEndpoints = "source_endpoints"
+	ErrorCodePlanImport      = "plan_import"
+	ErrorCodePlanOutput      = "plan_output"
+	ErrorCodeMaxDeletions    = "max_deletions"
+	ErrorCodeApplyChanges    = "apply_changes"
+	ErrorCodeCircuitOpen     = "circuit_open"
+	ErrorCodeUnknown         = "unknown"
+)
+
+// SyncError is the error of a failed synchronization with a code that stays stable across
+// releases, so logs can be parsed and alerted on.
+type SyncError struct {
+	Code string
+	Err  error
+}
+
+func (e *SyncError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SyncError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorFields returns the log fields of the error of a failed synchronization.
+func ErrorFields(err error) log.Fields {
+	var syncErr *SyncError
+	if errors.As(err, &syncErr) {
+		return log.Fields{"error_code": syncErr.Code}
+	}
+	return log.Fields{"error_code": ErrorCodeUnknown}
+}
+
 // Controller is responsible for orchestrating the different components.
 // It works in the following way:
 // * Ask the DNS provider for current list of endpoints.
@@ -113,61 +197,405 @@ type Controller struct {
 	Interval time.Duration
 	// The DomainFilter defines which DNS records to keep or exclude
 	DomainFilter endpoint.DomainFilter
+	// Whether every planned change is logged with the old and new values of the record
+	LogChanges bool
+	// The file the calculated changes are exported to instead of applying them
+	PlanExport string
+	// The file the changes to apply are imported from instead of calculating them
+	PlanImport string
+	// The format the changes are written to DiffOutput in instead of applying them, see plan.WriteDiff
+	DiffFormat string
+	// The writer the changes are written to with DiffFormat
+	DiffOutput io.Writer
+	// Whether the changes written to DiffOutput are colored
+	DiffColor bool
+	// The maximum number of records deleted at once, or 0 for no limit
+	MaxDeletions int
+	// The maximum number of records of a domain after applying the changes, or 0 for no limit
+	MaxRecordsPerDomain int
+	// Whether NS records are managed as well, see plan.Plan
+	ManageNSRecords bool
+	// Whether PTR records are managed as well, see plan.Plan
+	ManagePTRRecords bool
+	// Whether MX records are managed as well, see plan.Plan
+	ManageMXRecords bool
+	// Whether TXT records are managed as well, see plan.Plan
+	ManageTXTRecords bool
+	// Whether SRV records are managed as well, see plan.Plan
+	ManageSRVRecords bool
+	// The time a synchronization in progress gets to finish after its context was canceled
+	ShutdownTimeout time.Duration
+	// The time without events after which events trigger a synchronization, MinInterval if unset
+	EventsQuietPeriod time.Duration
+	// The number of consecutive failures to apply changes after which no changes are applied
+	// for ApplyFailureCooldown, or 0 to always apply changes
+	MaxApplyFailures int
+	// The time no changes are applied after MaxApplyFailures consecutive failures
+	ApplyFailureCooldown time.Duration
+	// The verifier of the propagation of applied changes, or nil to not verify them
+	PropagationVerifier *PropagationVerifier
+	// Whether updates removing targets add the new targets first and remove the old targets in a
+	// later synchronization, once the new targets are verified, see stageUpdates
+	CreateBeforeDelete bool
+	// The records updated to both their old and new targets whose propagation was verified
+	expandedRecords map[string]bool
+	// The sources reporting the results of applying the changes of their endpoints
+	ApplyResultReporters []source.ApplyResultReporter
+	// The time deletions are held back after they were first planned, or 0 to delete records right away
+	DeletionGracePeriod time.Duration
+	// The time the held back deletions were first planned, by record
+	pendingDeletions map[string]time.Time
+	// The number of consecutive failures to apply changes
+	applyFailures int
+	// The time until no changes are applied, or zero
+	circuitOpenUntil time.Time
+	// The circuitMux is for atomic updating of applyFailures and circuitOpenUntil
+	circuitMux sync.Mutex
+	// The number of changes applied or exported by the last synchronization
+	lastChanges int
 	// The nextRunAt used for throttling and batching reconciliation
 	nextRunAt time.Time
+	// The time of the first event since the last reconciliation, or zero without events
+	firstEventAt time.Time
 	// The nextRunAtMux is for atomic updating of nextRunAt
 	nextRunAtMux sync.Mutex
+	// The records read from the registry by the last synchronization
+	records []*endpoint.Endpoint
+	// The result of the last synchronization
+	lastSync *SyncStatus
+	// The time the last successful synchronization started
+	lastSuccessfulSync time.Time
+	// The statusMux is for atomic updating of records, lastSync and lastSuccessfulSync
+	statusMux sync.Mutex
 }
 
 // RunOnce runs a single iteration of a reconciliation loop.
 func (c *Controller) RunOnce(ctx context.Context) error {
-	records, err := c.Registry.Records(ctx)
+	start := time.Now()
+	result := &syncResult{}
+	ctx, span := tracing.Tracer().Start(ctx, "sync")
+	err := c.runOnce(ctx, result)
+	tracing.End(span, err)
+	c.recordSync(start, result, err)
+	return err
+}
+
+func (c *Controller) runOnce(ctx context.Context, result *syncResult) error {
+	c.lastChanges = 0
+
+	spanCtx, span := tracing.Tracer().Start(ctx, "registry.records")
+	records, err := c.Registry.Records(spanCtx)
+	span.SetAttributes(label.Int("records", len(records)))
+	tracing.End(span, err)
 	if err != nil {
 		registryErrorsTotal.Inc()
 		deprecatedRegistryErrors.Inc()
-		return err
+		return &SyncError{Code: ErrorCodeRegistryRecords, Err: err}
 	}
 	registryEndpointsTotal.Set(float64(len(records)))
+	result.records = records
 
 	ctx = context.WithValue(ctx, provider.RecordsContextKey, records)
 
-	endpoints, err := c.Source.Endpoints()
-	if err != nil {
-		sourceErrorsTotal.Inc()
-		deprecatedSourceErrors.Inc()
-		return err
+	var stateHash string
+	if c.PlanExport != "" {
+		// The hash must be taken before calculating the plan, which adds labels to the records.
+		stateHash = plan.StateHash(records)
+	}
+
+	var changes *plan.Changes
+	var expanding []*endpoint.Endpoint
+	if c.PlanImport != "" {
+		changes, err = plan.ImportChanges(c.PlanImport, records)
+		if err != nil {
+			return &SyncError{Code: ErrorCodePlanImport, Err: fmt.Errorf("refusing to apply plan: %v", err)}
+		}
+	} else {
+		_, span := tracing.Tracer().Start(ctx, "source.endpoints")
+		endpoints, err := c.Source.Endpoints()
+		span.SetAttributes(label.Int("endpoints", len(endpoints)))
+		tracing.End(span, err)
+		if err != nil {
+			sourceErrorsTotal.Inc()
+			deprecatedSourceErrors.Inc()
+			return &SyncError{Code: ErrorCodeSourceEndpoints, Err: err}
+		}
+		sourceEndpointsTotal.Set(float64(len(endpoints)))
+
+		plan := &plan.Plan{
+			Policies:           []plan.Policy{c.Policy},
+			Current:            records,
+			Desired:            endpoints,
+			DomainFilter:       c.DomainFilter,
+			PropertyComparator: c.Registry.PropertyValuesEqual,
+			ManageNS:           c.ManageNSRecords,
+			ManagePTR:          c.ManagePTRRecords,
+			ManageMX:           c.ManageMXRecords,
+			ManageTXT:          c.ManageTXTRecords,
+			ManageSRV:          c.ManageSRVRecords,
+		}
+
+		_, span = tracing.Tracer().Start(ctx, "plan.calculate")
+		changes = plan.Calculate().Changes
+		span.SetAttributes(changeAttributes(changes)...)
+		span.End()
+
+		if c.CreateBeforeDelete {
+			changes, expanding = c.stageUpdates(changes)
+		}
+		if c.DeletionGracePeriod > 0 {
+			changes = c.holdDeletions(time.Now(), changes)
+		}
+	}
+
+	changes = c.protectApexNS(changes)
+	if c.MaxRecordsPerDomain > 0 {
+		changes = c.limitDomainRecords(records, changes)
+	}
+
+	result.changes = changes
+
+	if c.LogChanges {
+		logChanges(changes)
+	}
+
+	if c.DiffFormat != "" {
+		if err := plan.WriteDiff(c.DiffOutput, changes, c.DiffFormat, c.DiffColor); err != nil {
+			return &SyncError{Code: ErrorCodePlanOutput, Err: err}
+		}
+		if c.PlanExport == "" {
+			c.lastChanges = countChanges(changes)
+			return nil
+		}
 	}
-	sourceEndpointsTotal.Set(float64(len(endpoints)))
 
-	plan := &plan.Plan{
-		Policies:           []plan.Policy{c.Policy},
-		Current:            records,
-		Desired:            endpoints,
-		DomainFilter:       c.DomainFilter,
-		PropertyComparator: c.Registry.PropertyValuesEqual,
+	if c.PlanExport != "" {
+		if err := plan.ExportChanges(c.PlanExport, changes, stateHash); err != nil {
+			return &SyncError{Code: ErrorCodePlanOutput, Err: err}
+		}
+		c.lastChanges = countChanges(changes)
+		log.Infof("Exported plan with %d changes to %s", c.lastChanges, c.PlanExport)
+		return nil
 	}
 
-	plan = plan.Calculate()
+	if c.MaxDeletions > 0 && len(changes.Delete) > c.MaxDeletions {
+		return &SyncError{Code: ErrorCodeMaxDeletions, Err: fmt.Errorf("refusing to delete %d records, more than the maximum of %d", len(changes.Delete), c.MaxDeletions)}
+	}
+
+	if until := c.circuitOpen(time.Now()); !until.IsZero() {
+		if countChanges(changes) == 0 {
+			return nil
+		}
+		return &SyncError{Code: ErrorCodeCircuitOpen, Err: fmt.Errorf("not applying %d changes until %s after %d consecutive failures", countChanges(changes), until.Format(time.RFC3339), c.MaxApplyFailures)}
+	}
 
-	err = c.Registry.ApplyChanges(ctx, plan.Changes)
+	spanCtx, span = tracing.Tracer().Start(ctx, "registry.apply_changes", trace.WithAttributes(changeAttributes(changes)...))
+	start := time.Now()
+	err = c.Registry.ApplyChanges(spanCtx, changes)
+	observeApplyDuration(changes, len(records), time.Since(start))
+	c.recordApplyResult(time.Now(), err)
+	tracing.End(span, err)
+	for _, reporter := range c.ApplyResultReporters {
+		reporter.ReportApplyResult(append(append([]*endpoint.Endpoint{}, changes.Create...), changes.UpdateNew...), err)
+	}
+	result.applied = err == nil
 	if err != nil {
 		registryErrorsTotal.Inc()
 		deprecatedRegistryErrors.Inc()
-		return err
+		return &SyncError{Code: ErrorCodeApplyChanges, Err: err}
+	}
+	changesTotal.WithLabelValues("create").Add(float64(len(changes.Create)))
+	changesTotal.WithLabelValues("update").Add(float64(len(changes.UpdateNew)))
+	changesTotal.WithLabelValues("delete").Add(float64(len(changes.Delete)))
+	for _, status := range c.domainStatuses(changes, true, nil) {
+		domainLastChangeTimestamp.WithLabelValues(status.Domain).SetToCurrentTime()
+	}
+	c.lastChanges = countChanges(changes)
+
+	var notVisible []*endpoint.Endpoint
+	if c.PropagationVerifier != nil {
+		_, span := tracing.Tracer().Start(ctx, "propagation.verify")
+		notVisible = c.PropagationVerifier.Verify(ctx, withExpansions(changes, expanding))
+		span.SetAttributes(label.Int("not_visible", len(notVisible)))
+		span.End()
+	}
+	if c.CreateBeforeDelete {
+		c.recordExpansions(expanding, notVisible)
 	}
 
 	lastSyncTimestamp.SetToCurrentTime()
 	return nil
 }
 
+// circuitOpen returns until when no changes are applied, or zero if changes are applied.
+func (c *Controller) circuitOpen(now time.Time) time.Time {
+	c.circuitMux.Lock()
+	defer c.circuitMux.Unlock()
+	if now.Before(c.circuitOpenUntil) {
+		return c.circuitOpenUntil
+	}
+	circuitBreakerOpen.Set(0)
+	return time.Time{}
+}
+
+// recordApplyResult stops applying changes for the cool-down once applying them failed too
+// often in a row. After the cool-down, the next failure stops applying changes again.
+func (c *Controller) recordApplyResult(now time.Time, err error) {
+	c.circuitMux.Lock()
+	defer c.circuitMux.Unlock()
+	if err == nil {
+		c.applyFailures = 0
+		return
+	}
+	c.applyFailures++
+	if c.MaxApplyFailures > 0 && c.applyFailures >= c.MaxApplyFailures {
+		c.circuitOpenUntil = now.Add(c.ApplyFailureCooldown)
+		circuitBreakerOpen.Set(1)
+		log.Warnf("Not applying changes until %s after %d consecutive failures", c.circuitOpenUntil.Format(time.RFC3339), c.applyFailures)
+	}
+}
+
+// ResetCircuitBreaker applies changes again right away after consecutive failures.
+func (c *Controller) ResetCircuitBreaker() {
+	c.circuitMux.Lock()
+	defer c.circuitMux.Unlock()
+	c.applyFailures = 0
+	c.circuitOpenUntil = time.Time{}
+	circuitBreakerOpen.Set(0)
+}
+
+// LastChanges returns the number of changes applied, exported or printed by the last synchronization.
+func (c *Controller) LastChanges() int {
+	return c.lastChanges
+}
+
+// observeApplyDuration records the duration of applying the changes once for each action
+// among the changes, as providers apply all of them at once.
+func observeApplyDuration(changes *plan.Changes, records int, duration time.Duration) {
+	size := sizeBucket(records)
+	for action, n := range map[string]int{"create": len(changes.Create), "update": len(changes.UpdateNew), "delete": len(changes.Delete)} {
+		if n > 0 {
+			applyDuration.WithLabelValues(action, size).Observe(duration.Seconds())
+		}
+	}
+}
+
+// sizeBucket returns the label for the number of records, so the latency of large
+// deployments can be told apart without a label per record count.
+func sizeBucket(records int) string {
+	switch {
+	case records < 100:
+		return "lt100"
+	case records < 1000:
+		return "lt1000"
+	case records < 10000:
+		return "lt10000"
+	default:
+		return "ge10000"
+	}
+}
+
+func changeAttributes(changes *plan.Changes) []label.KeyValue {
+	return []label.KeyValue{
+		label.Int("changes.create", len(changes.Create)),
+		label.Int("changes.update", len(changes.UpdateNew)),
+		label.Int("changes.delete", len(changes.Delete)),
+	}
+}
+
+func countChanges(changes *plan.Changes) int {
+	return len(changes.Create) + len(changes.UpdateNew) + len(changes.Delete)
+}
+
+// logChanges logs every change as a single entry with the old and new values side by side,
+// so the changes can be reconstructed from the logs alone.
+func logChanges(changes *plan.Changes) {
+	for _, ep := range changes.Create {
+		log.WithFields(changeFields("create", nil, ep)).Info("Planned change")
+	}
+	for i, ep := range changes.UpdateNew {
+		var old *endpoint.Endpoint
+		if i < len(changes.UpdateOld) {
+			old = changes.UpdateOld[i]
+		}
+		log.WithFields(changeFields("update", old, ep)).Info("Planned change")
+	}
+	for _, ep := range changes.Delete {
+		log.WithFields(changeFields("delete", ep, nil)).Info("Planned change")
+	}
+}
+
+func changeFields(action string, old, new *endpoint.Endpoint) log.Fields {
+	fields := log.Fields{"action": action}
+	for _, ep := range []*endpoint.Endpoint{new, old} {
+		if ep != nil {
+			fields["name"] = ep.DNSName
+			fields["type"] = ep.RecordType
+		}
+	}
+	if old != nil {
+		fields["old_targets"] = old.Targets.String()
+		fields["old_ttl"] = int64(old.RecordTTL)
+	}
+	if new != nil {
+		fields["new_targets"] = new.Targets.String()
+		fields["new_ttl"] = int64(new.RecordTTL)
+	}
+	if old != nil && new != nil {
+		fields["ttl_delta"] = int64(new.RecordTTL) - int64(old.RecordTTL)
+	}
+	return fields
+}
+
+// RunOnceGracefully runs a single iteration of a reconciliation loop like RunOnce. Canceling the
+// context doesn't cancel the iteration right away, but only after the shutdown timeout, so
+// changes in flight are finished rather than left half-applied.
+func (c *Controller) RunOnceGracefully(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+			return
+		}
+		log.Infof("Waiting up to %s for the synchronization in progress to finish", c.ShutdownTimeout)
+		select {
+		case <-time.After(c.ShutdownTimeout):
+			log.Warn("Canceling the synchronization in progress")
+			cancel()
+		case <-done:
+		}
+	}()
+
+	return c.RunOnce(runCtx)
+}
+
 // MinInterval is used as window for batching events
 const MinInterval = 5 * time.Second
 
-// RunOnceThrottled makes sure execution happens at most once per interval.
+// ScheduleRunOnce schedules a reconciliation once no further events occurred for the quiet
+// period, so bursts of events are batched into a single reconciliation. Continuous events
+// delay the reconciliation by at most the interval.
 func (c *Controller) ScheduleRunOnce(now time.Time) {
 	c.nextRunAtMux.Lock()
 	defer c.nextRunAtMux.Unlock()
-	c.nextRunAt = now.Add(MinInterval)
+
+	quietPeriod := c.EventsQuietPeriod
+	if quietPeriod <= 0 {
+		quietPeriod = MinInterval
+	}
+	if c.firstEventAt.IsZero() {
+		c.firstEventAt = now
+	}
+	c.nextRunAt = now.Add(quietPeriod)
+	if latest := c.firstEventAt.Add(c.Interval); c.Interval > 0 && c.nextRunAt.After(latest) {
+		c.nextRunAt = latest
+	}
 }
 
 func (c *Controller) ShouldRunOnce(now time.Time) bool {
@@ -177,6 +605,7 @@ func (c *Controller) ShouldRunOnce(now time.Time) bool {
 		return false
 	}
 	c.nextRunAt = now.Add(c.Interval)
+	c.firstEventAt = time.Time{}
 	return true
 }
 
@@ -186,8 +615,8 @@ func (c *Controller) Run(ctx context.Context) {
 	defer ticker.Stop()
 	for {
 		if c.ShouldRunOnce(time.Now()) {
-			if err := c.RunOnce(ctx); err != nil {
-				log.Error(err)
+			if err := c.RunOnceGracefully(ctx); err != nil {
+				log.WithFields(ErrorFields(err)).Error(err)
 			}
 		}
 		select {
@@ -17,8 +17,12 @@ limitations under the License.
 package controller
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -27,8 +31,14 @@ import (
 	"sigs.k8s.io/external-dns/internal/testutils"
 	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
+	"sigs.k8s.io/external-dns/provider/inmemory"
 	"sigs.k8s.io/external-dns/registry"
+	"sigs.k8s.io/external-dns/source"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -148,10 +158,386 @@ func TestRunOnce(t *testing.T) {
 		Policy:   &plan.SyncPolicy{},
 	}
 
+	creates := testutil.ToFloat64(changesTotal.WithLabelValues("create"))
+	updates := testutil.ToFloat64(changesTotal.WithLabelValues("update"))
+	deletes := testutil.ToFloat64(changesTotal.WithLabelValues("delete"))
+	applies := applyDurationCount(t, "create", "lt100")
+
 	assert.NoError(t, ctrl.RunOnce(context.Background()))
 
 	// Validate that the mock source was called.
 	source.AssertExpectations(t)
+
+	// Validate that the applied changes were counted.
+	assert.Equal(t, creates+1, testutil.ToFloat64(changesTotal.WithLabelValues("create")))
+	assert.Equal(t, updates+1, testutil.ToFloat64(changesTotal.WithLabelValues("update")))
+	assert.Equal(t, deletes+1, testutil.ToFloat64(changesTotal.WithLabelValues("delete")))
+	assert.Equal(t, 3, ctrl.LastChanges())
+
+	// Validate that the time of the changes was recorded.
+	assert.NotZero(t, testutil.ToFloat64(lastSyncTimestamp))
+	assert.NotZero(t, testutil.ToFloat64(domainLastChangeTimestamp.WithLabelValues("create-record")))
+	assert.NotZero(t, testutil.ToFloat64(domainLastChangeTimestamp.WithLabelValues("delete-record")))
+
+	// Validate that the duration of applying the changes was observed.
+	assert.Equal(t, applies+1, applyDurationCount(t, "create", "lt100"))
+}
+
+// applyDurationCount returns the number of observed durations of applying changes.
+func applyDurationCount(t *testing.T, action, size string) uint64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "external_dns_controller_apply_duration_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["action"] == action && labels["size"] == size {
+				return m.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+func TestSizeBucket(t *testing.T) {
+	assert.Equal(t, "lt100", sizeBucket(0))
+	assert.Equal(t, "lt1000", sizeBucket(100))
+	assert.Equal(t, "lt10000", sizeBucket(9999))
+	assert.Equal(t, "ge10000", sizeBucket(10000))
+}
+
+// TestRunOncePlanExportImport tests that exported plans are applied exactly on unchanged records.
+func TestRunOncePlanExportImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-plan")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "plan.json")
+
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "create-record", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}},
+		{DNSName: "update-record", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"8.8.4.4"}},
+	}, nil)
+	records := func() []*endpoint.Endpoint {
+		return []*endpoint.Endpoint{
+			{DNSName: "update-record", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"8.8.8.8"}},
+			{DNSName: "delete-record", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"4.3.2.1"}},
+		}
+	}
+	changes := &plan.Changes{
+		Create:    []*endpoint.Endpoint{{DNSName: "create-record", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}}},
+		UpdateNew: []*endpoint.Endpoint{{DNSName: "update-record", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"8.8.4.4"}}},
+		UpdateOld: []*endpoint.Endpoint{{DNSName: "update-record", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"8.8.8.8"}}},
+		Delete:    []*endpoint.Endpoint{{DNSName: "delete-record", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"4.3.2.1"}}},
+	}
+
+	run := func(provider provider.Provider, export, imp string) error {
+		r, err := registry.NewNoopRegistry(provider)
+		require.NoError(t, err)
+		ctrl := &Controller{
+			Source:     source,
+			Registry:   r,
+			Policy:     &plan.SyncPolicy{},
+			PlanExport: export,
+			PlanImport: imp,
+		}
+		return ctrl.RunOnce(context.Background())
+	}
+
+	// Exporting doesn't apply any changes.
+	require.NoError(t, run(newMockProvider(records(), &plan.Changes{}), path, ""))
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	// Importing applies the exported changes.
+	assert.NoError(t, run(newMockProvider(records(), changes), "", path))
+
+	// Importing refuses to apply the changes on changed records.
+	drifted := records()
+	drifted[0].Targets = endpoint.Targets{"9.9.9.9"}
+	assert.Error(t, run(newMockProvider(drifted, changes), "", path))
+
+	// Importing refuses to apply the changes on records that drifted otherwise.
+	drifted = append(records(), &endpoint.Endpoint{DNSName: "other-record", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"5.6.7.8"}})
+	assert.Error(t, run(newMockProvider(drifted, changes), "", path))
+}
+
+func TestErrorFields(t *testing.T) {
+	assert.Equal(t, log.Fields{"error_code": ErrorCodeApplyChanges}, ErrorFields(&SyncError{Code: ErrorCodeApplyChanges, Err: errors.New("failed")}))
+	assert.Equal(t, log.Fields{"error_code": ErrorCodeUnknown}, ErrorFields(errors.New("failed")))
+}
+
+// TestRunOnceDiff tests that changes are printed instead of applied.
+// TestRunOnceReverseSource tests that the PTR records of the reverse source are created.
+func TestRunOnceReverseSource(t *testing.T) {
+	forward := new(testutils.MockSource)
+	forward.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+	}, nil)
+	src, err := source.NewReverseSource(forward, []string{"10.in-addr.arpa"})
+	require.NoError(t, err)
+	p := inmemory.NewInMemoryProvider(inmemory.InMemoryInitZones([]string{"10.in-addr.arpa"}))
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:           src,
+		Registry:         r,
+		Policy:           &plan.SyncPolicy{},
+		ManagePTRRecords: true,
+	}
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	records, err := p.Records(context.Background())
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("1.0.0.10.in-addr.arpa", endpoint.RecordTypePTR, "foo.example.org"),
+	}), "records: %v", records)
+}
+
+func TestRunOnceDiff(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "create-record", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}},
+	}, nil)
+	r, err := registry.NewNoopRegistry(newMockProvider([]*endpoint.Endpoint{}, &plan.Changes{}))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	ctrl := &Controller{
+		Source:     source,
+		Registry:   r,
+		Policy:     &plan.SyncPolicy{},
+		DiffFormat: plan.DiffFormatText,
+		DiffOutput: &out,
+	}
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	assert.Equal(t, "+ create-record A 1.2.3.4 ttl=0\n", out.String())
+	assert.Equal(t, 1, ctrl.LastChanges())
+}
+
+// TestRunOnceMaxDeletions tests that changes deleting too many records aren't applied.
+func TestRunOnceMaxDeletions(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{}, nil)
+
+	provider := inmemory.NewInMemoryProvider(inmemory.InMemoryInitZones([]string{"example.org"}))
+	require.NoError(t, provider.ApplyChanges(context.Background(), &plan.Changes{Create: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "4.3.2.1"),
+	}}))
+	r, err := registry.NewNoopRegistry(provider)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:       source,
+		Registry:     r,
+		Policy:       &plan.SyncPolicy{},
+		MaxDeletions: 1,
+	}
+	err = ctrl.RunOnce(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, log.Fields{"error_code": ErrorCodeMaxDeletions}, ErrorFields(err))
+	records, err := provider.Records(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	ctrl.MaxDeletions = 2
+	assert.NoError(t, ctrl.RunOnce(context.Background()))
+	records, err = provider.Records(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+// failingRegistry fails to apply changes while fail is set.
+type failingRegistry struct {
+	registry.Registry
+	fail  bool
+	calls int
+}
+
+func (r *failingRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	r.calls++
+	if r.fail {
+		return errors.New("failed to apply changes")
+	}
+	return nil
+}
+
+// applyResults records the reported results of applying changes.
+type applyResults struct {
+	changed [][]*endpoint.Endpoint
+	errs    []error
+}
+
+func (r *applyResults) ReportApplyResult(changed []*endpoint.Endpoint, err error) {
+	r.changed = append(r.changed, changed)
+	r.errs = append(r.errs, err)
+}
+
+// TestRunOnceApplyResultReporters tests that the results of applying changes are reported.
+func TestRunOnceApplyResultReporters(t *testing.T) {
+	src := new(testutils.MockSource)
+	src.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+	}, nil)
+	noop, err := registry.NewNoopRegistry(inmemory.NewInMemoryProvider(inmemory.InMemoryInitZones([]string{"example.org"})))
+	require.NoError(t, err)
+	r := &failingRegistry{Registry: noop, fail: true}
+	results := &applyResults{}
+	ctrl := &Controller{
+		Source:               src,
+		Registry:             r,
+		Policy:               &plan.SyncPolicy{},
+		ApplyResultReporters: []source.ApplyResultReporter{results},
+	}
+
+	assert.Error(t, ctrl.RunOnce(context.Background()))
+	r.fail = false
+	assert.NoError(t, ctrl.RunOnce(context.Background()))
+
+	require.Len(t, results.changed, 2)
+	for _, changed := range results.changed {
+		require.Len(t, changed, 1)
+		assert.Equal(t, "foo.example.org", changed[0].DNSName)
+	}
+	assert.EqualError(t, results.errs[0], "failed to apply changes")
+	assert.NoError(t, results.errs[1])
+}
+
+// TestRunOnceCircuitBreaker tests that no changes are applied after consecutive failures.
+func TestRunOnceCircuitBreaker(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "create-record", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}},
+	}, nil)
+	noop, err := registry.NewNoopRegistry(newMockProvider(nil, nil))
+	require.NoError(t, err)
+	r := &failingRegistry{Registry: noop, fail: true}
+
+	ctrl := &Controller{
+		Source:               source,
+		Registry:             r,
+		Policy:               &plan.SyncPolicy{},
+		MaxApplyFailures:     2,
+		ApplyFailureCooldown: time.Hour,
+	}
+	for i := 0; i < 2; i++ {
+		err = ctrl.RunOnce(context.Background())
+		assert.Equal(t, log.Fields{"error_code": ErrorCodeApplyChanges}, ErrorFields(err))
+	}
+	assert.Equal(t, 1.0, testutil.ToFloat64(circuitBreakerOpen))
+
+	// Changes are still calculated, but not applied.
+	err = ctrl.RunOnce(context.Background())
+	assert.Equal(t, log.Fields{"error_code": ErrorCodeCircuitOpen}, ErrorFields(err))
+	assert.Equal(t, 2, r.calls)
+
+	ctrl.ResetCircuitBreaker()
+	r.fail = false
+	assert.NoError(t, ctrl.RunOnce(context.Background()))
+	assert.Equal(t, 3, r.calls)
+	assert.Equal(t, 0.0, testutil.ToFloat64(circuitBreakerOpen))
+
+	// After the cool-down, a single failure stops applying changes again.
+	now := time.Now()
+	ctrl.recordApplyResult(now, errors.New("failed"))
+	ctrl.recordApplyResult(now, errors.New("failed"))
+	assert.False(t, ctrl.circuitOpen(now.Add(59*time.Minute)).IsZero())
+	assert.True(t, ctrl.circuitOpen(now.Add(time.Hour)).IsZero())
+	ctrl.recordApplyResult(now.Add(time.Hour), errors.New("failed"))
+	assert.False(t, ctrl.circuitOpen(now.Add(time.Hour)).IsZero())
+}
+
+// blockingRegistry applies changes until it's released or its context is canceled.
+type blockingRegistry struct {
+	registry.Registry
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	close(r.started)
+	select {
+	case <-r.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TestRunOnceGracefully tests that canceling the context lets changes in flight finish until
+// the shutdown timeout.
+func TestRunOnceGracefully(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "create-record", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}},
+	}, nil)
+	noop, err := registry.NewNoopRegistry(newMockProvider(nil, nil))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		title           string
+		shutdownTimeout time.Duration
+		release         bool
+		expectErr       bool
+	}{
+		{title: "finishes within the timeout", shutdownTimeout: time.Minute, release: true},
+		{title: "is canceled after the timeout", shutdownTimeout: 10 * time.Millisecond, expectErr: true},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			r := &blockingRegistry{Registry: noop, started: make(chan struct{}), release: make(chan struct{})}
+			ctrl := &Controller{
+				Source:          source,
+				Registry:        r,
+				Policy:          &plan.SyncPolicy{},
+				ShutdownTimeout: tc.shutdownTimeout,
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				<-r.started
+				cancel()
+				if tc.release {
+					time.Sleep(50 * time.Millisecond)
+					close(r.release)
+				}
+			}()
+
+			err := ctrl.RunOnceGracefully(ctx)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLogChanges(t *testing.T) {
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+
+	logChanges(&plan.Changes{
+		Create:    []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("create-record", endpoint.RecordTypeA, 300, "1.2.3.4")},
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("update-record", endpoint.RecordTypeA, 300, "8.8.8.8")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("update-record", endpoint.RecordTypeA, 60, "8.8.4.4")},
+		Delete:    []*endpoint.Endpoint{endpoint.NewEndpoint("delete-record", endpoint.RecordTypeCNAME, "example.org")},
+	})
+
+	var fields []log.Fields
+	for _, entry := range hook.AllEntries() {
+		fields = append(fields, entry.Data)
+	}
+	assert.Equal(t, []log.Fields{
+		{"action": "create", "name": "create-record", "type": "A", "new_targets": "1.2.3.4", "new_ttl": int64(300)},
+		{"action": "update", "name": "update-record", "type": "A", "old_targets": "8.8.8.8", "new_targets": "8.8.4.4", "old_ttl": int64(300), "new_ttl": int64(60), "ttl_delta": int64(-240)},
+		{"action": "delete", "name": "delete-record", "type": "CNAME", "old_targets": "example.org", "old_ttl": int64(0)},
+	}, fields)
 }
 
 func TestShouldRunOnce(t *testing.T) {
@@ -192,3 +578,31 @@ func TestShouldRunOnce(t *testing.T) {
 	// But not two times
 	assert.False(t, ctrl.ShouldRunOnce(now))
 }
+
+func TestScheduleRunOnceQuietPeriod(t *testing.T) {
+	ctrl := &Controller{Interval: time.Minute, EventsQuietPeriod: 10 * time.Second}
+	now := time.Now()
+
+	// Fresh controller reconciles right away, afterwards only events do before the interval
+	assert.True(t, ctrl.ShouldRunOnce(now))
+
+	// Events in quick succession postpone the reconciliation until they are quiet
+	for i := 0; i < 3; i++ {
+		now = now.Add(5 * time.Second)
+		ctrl.ScheduleRunOnce(now)
+		assert.False(t, ctrl.ShouldRunOnce(now.Add(9*time.Second)))
+	}
+	assert.True(t, ctrl.ShouldRunOnce(now.Add(10*time.Second)))
+
+	// Continuous events delay the reconciliation by at most the interval after the first event
+	now = now.Add(10 * time.Second)
+	first := now
+	for now.Before(first.Add(2 * time.Minute)) {
+		ctrl.ScheduleRunOnce(now)
+		if ctrl.ShouldRunOnce(now) {
+			break
+		}
+		now = now.Add(5 * time.Second)
+	}
+	assert.Equal(t, first.Add(time.Minute), now)
+}
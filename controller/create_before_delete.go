@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// stageUpdates rolls out updates removing targets in two steps, so names keep resolving during
+// cutovers: first the record is updated to both its old and its new targets, and only once the
+// propagation of these targets is verified, a later synchronization removes the old targets.
+//
+// It returns the changes to apply and the expanded records to verify, which includes records
+// expanded by earlier synchronizations but not verified yet. CNAME records, which have a single
+// target, and records with set identifiers, whose propagation can't be verified, are updated
+// right away.
+func (c *Controller) stageUpdates(changes *plan.Changes) (*plan.Changes, []*endpoint.Endpoint) {
+	if c.expandedRecords == nil {
+		c.expandedRecords = map[string]bool{}
+	}
+
+	staged := &plan.Changes{Create: changes.Create, Delete: changes.Delete}
+	var expanding []*endpoint.Endpoint
+	for i, desired := range changes.UpdateNew {
+		current := changes.UpdateOld[i]
+		key := expandedRecordKey(desired)
+		if desired.RecordType == endpoint.RecordTypeCNAME || desired.SetIdentifier != "" || !removesTargets(current, desired) {
+			staged.UpdateOld = append(staged.UpdateOld, current)
+			staged.UpdateNew = append(staged.UpdateNew, desired)
+			continue
+		}
+		if c.expandedRecords[key] {
+			log.Infof("Removing the old targets of %s %s after verifying its new targets", desired.DNSName, desired.RecordType)
+			delete(c.expandedRecords, key)
+			staged.UpdateOld = append(staged.UpdateOld, current)
+			staged.UpdateNew = append(staged.UpdateNew, desired)
+			continue
+		}
+
+		expanded := desired.DeepCopy()
+		expanded.Targets = unionTargets(current.Targets, desired.Targets)
+		expanding = append(expanding, expanded)
+		if expanded.Targets.Same(current.Targets) {
+			// The record was expanded before, but its propagation wasn't verified yet.
+			continue
+		}
+		log.Infof("Adding the new targets of %s %s before removing its old targets", desired.DNSName, desired.RecordType)
+		staged.UpdateOld = append(staged.UpdateOld, current)
+		staged.UpdateNew = append(staged.UpdateNew, expanded)
+	}
+	return staged, expanding
+}
+
+// recordExpansions remembers the expanded records which are served, so a later synchronization
+// removes their old targets.
+func (c *Controller) recordExpansions(expanding, notVisible []*endpoint.Endpoint) {
+	invisible := map[*endpoint.Endpoint]bool{}
+	for _, ep := range notVisible {
+		invisible[ep] = true
+	}
+	for _, ep := range expanding {
+		if !invisible[ep] {
+			c.expandedRecords[expandedRecordKey(ep)] = true
+		}
+	}
+}
+
+// withExpansions returns the changes to verify: the applied changes and the expanded records,
+// including those expanded by earlier synchronizations.
+func withExpansions(changes *plan.Changes, expanding []*endpoint.Endpoint) *plan.Changes {
+	if len(expanding) == 0 {
+		return changes
+	}
+	verify := &plan.Changes{Create: changes.Create, UpdateNew: changes.UpdateNew, Delete: changes.Delete}
+	for _, ep := range expanding {
+		applied := false
+		for _, updated := range changes.UpdateNew {
+			applied = applied || updated == ep
+		}
+		if !applied {
+			verify.UpdateNew = append(verify.UpdateNew, ep)
+		}
+	}
+	return verify
+}
+
+func expandedRecordKey(ep *endpoint.Endpoint) string {
+	return ep.DNSName + "/" + ep.RecordType
+}
+
+// removesTargets returns whether updating the current record to the desired one removes targets.
+func removesTargets(current, desired *endpoint.Endpoint) bool {
+	for _, target := range current.Targets {
+		if !containsTarget(desired.Targets, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// unionTargets returns the targets of both records, the current ones first.
+func unionTargets(current, desired endpoint.Targets) endpoint.Targets {
+	union := append(endpoint.Targets{}, current...)
+	for _, target := range desired {
+		if !containsTarget(union, target) {
+			union = append(union, target)
+		}
+	}
+	return union
+}
+
+func containsTarget(targets endpoint.Targets, target string) bool {
+	for _, t := range targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// recordsRegistry keeps records with several targets in memory and records the applied changes.
+type recordsRegistry struct {
+	records []*endpoint.Endpoint
+	applied []*plan.Changes
+}
+
+func (r *recordsRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	records := make([]*endpoint.Endpoint, 0, len(r.records))
+	for _, ep := range r.records {
+		records = append(records, ep.DeepCopy())
+	}
+	return records, nil
+}
+
+func (r *recordsRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	r.applied = append(r.applied, changes)
+	for _, ep := range changes.UpdateNew {
+		for i, record := range r.records {
+			if record.DNSName == ep.DNSName && record.RecordType == ep.RecordType {
+				r.records[i] = ep.DeepCopy()
+			}
+		}
+	}
+	return nil
+}
+
+func (r *recordsRegistry) PropertyValuesEqual(name string, previous string, current string) bool {
+	return previous == current
+}
+
+// TestRunOnceCreateBeforeDelete tests that old targets are only removed once the new targets are served.
+func TestRunOnceCreateBeforeDelete(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "2.2.2.2"),
+		endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeCNAME, "new.example.com"),
+	}, nil)
+	r := &recordsRegistry{records: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+		endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeCNAME, "old.example.com"),
+	}}
+	served := map[string][]string{"foo.example.org": {"1.1.1.1"}, "bar.example.org": {"new.example.com."}}
+	ctrl := &Controller{
+		Source:   source,
+		Registry: r,
+		Policy:   &plan.SyncPolicy{},
+		PropagationVerifier: &PropagationVerifier{
+			Resolver: resolverFunc(func(ctx context.Context, name, recordType string) ([]string, error) {
+				return served[name], nil
+			}),
+		},
+		CreateBeforeDelete: true,
+	}
+	ctx := context.Background()
+
+	// The new target is added while the old one is kept, CNAME records are updated right away.
+	require.NoError(t, ctrl.RunOnce(ctx))
+	require.Len(t, r.applied, 1)
+	require.Len(t, r.applied[0].UpdateNew, 2)
+	for _, ep := range r.applied[0].UpdateNew {
+		if ep.RecordType == endpoint.RecordTypeA {
+			assert.Equal(t, endpoint.Targets{"1.1.1.1", "2.2.2.2"}, ep.Targets)
+		} else {
+			assert.Equal(t, endpoint.Targets{"new.example.com"}, ep.Targets)
+		}
+	}
+
+	// The old target isn't removed while the new target isn't served.
+	require.NoError(t, ctrl.RunOnce(ctx))
+	require.Len(t, r.applied, 2)
+	assert.Empty(t, r.applied[1].UpdateNew)
+
+	served["foo.example.org"] = []string{"2.2.2.2", "1.1.1.1"}
+	require.NoError(t, ctrl.RunOnce(ctx))
+	require.Len(t, r.applied, 3)
+	assert.Empty(t, r.applied[2].UpdateNew)
+
+	// Once the new target is served, the old target is removed.
+	served["foo.example.org"] = []string{"2.2.2.2"}
+	require.NoError(t, ctrl.RunOnce(ctx))
+	require.Len(t, r.applied, 4)
+	require.Len(t, r.applied[3].UpdateNew, 1)
+	assert.Equal(t, endpoint.Targets{"2.2.2.2"}, r.applied[3].UpdateNew[0].Targets)
+
+	require.NoError(t, ctrl.RunOnce(ctx))
+	assert.Empty(t, r.applied[4].UpdateNew)
+	assert.Empty(t, ctrl.expandedRecords)
+}
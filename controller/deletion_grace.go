@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/plan"
+)
+
+var heldDeletions = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "external_dns",
+		Subsystem: "controller",
+		Name:      "held_deletions",
+		Help:      "Number of planned deletions held back for the deletion grace period",
+	},
+)
+
+// holdDeletions holds back deletions until they were planned by every synchronization during
+// the deletion grace period, while creations and updates are applied right away. A source which
+// briefly loses its endpoints thus doesn't delete their records. Deletions which aren't planned
+// anymore are forgotten, so they have to wait for the whole grace period again.
+func (c *Controller) holdDeletions(now time.Time, changes *plan.Changes) *plan.Changes {
+	planned := make(map[string]time.Time, len(changes.Delete))
+	held := &plan.Changes{Create: changes.Create, UpdateOld: changes.UpdateOld, UpdateNew: changes.UpdateNew}
+	for _, ep := range changes.Delete {
+		key := ep.DNSName + "/" + ep.RecordType + "/" + ep.SetIdentifier
+		since, ok := c.pendingDeletions[key]
+		if !ok {
+			since = now
+		}
+		if now.Sub(since) >= c.DeletionGracePeriod {
+			held.Delete = append(held.Delete, ep)
+			continue
+		}
+		planned[key] = since
+		log.Infof("Holding back the deletion of %s %s until %s", ep.DNSName, ep.RecordType, since.Add(c.DeletionGracePeriod).Format(time.RFC3339))
+	}
+	c.pendingDeletions = planned
+	heldDeletions.Set(float64(len(planned)))
+	return held
+}
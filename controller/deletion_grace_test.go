@@ -0,0 +1,57 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+func TestHoldDeletions(t *testing.T) {
+	ctrl := &Controller{DeletionGracePeriod: 10 * time.Minute}
+	created := endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	foo := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	bar := endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	start := time.Now()
+
+	// Creations are applied right away, deletions are held back.
+	changes := ctrl.holdDeletions(start, &plan.Changes{Create: []*endpoint.Endpoint{created}, Delete: []*endpoint.Endpoint{foo, bar}})
+	assert.Equal(t, []*endpoint.Endpoint{created}, changes.Create)
+	assert.Empty(t, changes.Delete)
+	assert.Equal(t, 2.0, testutil.ToFloat64(heldDeletions))
+
+	// A deletion which isn't planned anymore is forgotten.
+	changes = ctrl.holdDeletions(start.Add(5*time.Minute), &plan.Changes{Delete: []*endpoint.Endpoint{foo}})
+	assert.Empty(t, changes.Delete)
+	assert.Equal(t, 1.0, testutil.ToFloat64(heldDeletions))
+
+	// Deletions planned for the whole grace period are applied.
+	changes = ctrl.holdDeletions(start.Add(10*time.Minute), &plan.Changes{Delete: []*endpoint.Endpoint{foo, bar}})
+	assert.Equal(t, []*endpoint.Endpoint{foo}, changes.Delete)
+	assert.Equal(t, 1.0, testutil.ToFloat64(heldDeletions))
+
+	changes = ctrl.holdDeletions(start.Add(20*time.Minute), &plan.Changes{Delete: []*endpoint.Endpoint{bar}})
+	assert.Equal(t, []*endpoint.Endpoint{bar}, changes.Delete)
+	assert.Empty(t, ctrl.pendingDeletions)
+	assert.Equal(t, 0.0, testutil.ToFloat64(heldDeletions))
+}
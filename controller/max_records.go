@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+var refusedDomainChangesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "external_dns",
+		Subsystem: "controller",
+		Name:      "refused_domain_changes_total",
+		Help:      "Number of synchronizations whose changes of a domain weren't applied because the domain would hold more than the maximum number of records",
+	},
+	[]string{"domain"},
+)
+
+func init() {
+	prometheus.MustRegister(refusedDomainChangesTotal)
+}
+
+// limitDomainRecords drops the changes of the domains which would hold more than the maximum
+// number of records after applying them, e.g. because a source generates endpoints in a loop,
+// while the changes of the other domains are applied. Domains are grouped like in the status,
+// see recordDomain. Changes which don't grow a domain, e.g. deletions, are applied even if the
+// domain already holds too many records.
+func (c *Controller) limitDomainRecords(records []*endpoint.Endpoint, changes *plan.Changes) *plan.Changes {
+	counts := map[string]int{}
+	for _, ep := range records {
+		counts[recordDomain(c.DomainFilter.Filters, ep.DNSName)]++
+	}
+	growth := map[string]int{}
+	for _, ep := range changes.Create {
+		growth[recordDomain(c.DomainFilter.Filters, ep.DNSName)]++
+	}
+	for _, ep := range changes.Delete {
+		growth[recordDomain(c.DomainFilter.Filters, ep.DNSName)]--
+	}
+
+	refused := map[string]bool{}
+	for domain, n := range growth {
+		if n > 0 && counts[domain]+n > c.MaxRecordsPerDomain {
+			refused[domain] = true
+			refusedDomainChangesTotal.WithLabelValues(domain).Inc()
+			log.Errorf("Refusing to apply the changes of domain %s: it would hold %d records, more than the maximum of %d", domain, counts[domain]+n, c.MaxRecordsPerDomain)
+		}
+	}
+	if len(refused) == 0 {
+		return changes
+	}
+
+	filter := func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+		var result []*endpoint.Endpoint
+		for _, ep := range endpoints {
+			if !refused[recordDomain(c.DomainFilter.Filters, ep.DNSName)] {
+				result = append(result, ep)
+			}
+		}
+		return result
+	}
+	// Updates are kept in pairs, the old and the new record have the same name.
+	return &plan.Changes{
+		Create:    filter(changes.Create),
+		UpdateOld: filter(changes.UpdateOld),
+		UpdateNew: filter(changes.UpdateNew),
+		Delete:    filter(changes.Delete),
+	}
+}
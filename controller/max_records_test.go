@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+func TestLimitDomainRecords(t *testing.T) {
+	ctrl := &Controller{
+		DomainFilter:        endpoint.NewDomainFilter([]string{"lab.example.org", "example.com"}),
+		MaxRecordsPerDomain: 2,
+	}
+	records := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.lab.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+		endpoint.NewEndpoint("bar.lab.example.org", endpoint.RecordTypeA, "10.0.0.2"),
+		endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeA, "10.0.1.1"),
+	}
+	createdLab := endpoint.NewEndpoint("baz.lab.example.org", endpoint.RecordTypeA, "10.0.0.3")
+	updatedLabOld := endpoint.NewEndpoint("foo.lab.example.org", endpoint.RecordTypeA, "10.0.0.1")
+	updatedLabNew := endpoint.NewEndpoint("foo.lab.example.org", endpoint.RecordTypeA, "10.0.0.4")
+	createdCom := endpoint.NewEndpoint("bar.example.com", endpoint.RecordTypeA, "10.0.1.2")
+
+	refused := testutil.ToFloat64(refusedDomainChangesTotal.WithLabelValues("lab.example.org"))
+
+	// The changes of a domain growing beyond the maximum are dropped, the others are applied.
+	changes := ctrl.limitDomainRecords(records, &plan.Changes{
+		Create:    []*endpoint.Endpoint{createdLab, createdCom},
+		UpdateOld: []*endpoint.Endpoint{updatedLabOld},
+		UpdateNew: []*endpoint.Endpoint{updatedLabNew},
+	})
+	assert.Equal(t, &plan.Changes{Create: []*endpoint.Endpoint{createdCom}}, changes)
+	assert.Equal(t, refused+1, testutil.ToFloat64(refusedDomainChangesTotal.WithLabelValues("lab.example.org")))
+
+	// Deletions make room for creations.
+	deleted := records[1]
+	planned := &plan.Changes{Create: []*endpoint.Endpoint{createdLab}, Delete: []*endpoint.Endpoint{deleted}}
+	assert.Equal(t, planned, ctrl.limitDomainRecords(records, planned))
+
+	// Changes which don't grow a domain holding too many records are applied.
+	ctrl.MaxRecordsPerDomain = 1
+	planned = &plan.Changes{UpdateOld: []*endpoint.Endpoint{updatedLabOld}, UpdateNew: []*endpoint.Endpoint{updatedLabNew}, Delete: []*endpoint.Endpoint{deleted}}
+	assert.Equal(t, planned, ctrl.limitDomainRecords(records, planned))
+}
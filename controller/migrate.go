@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// Migrate copies the records of one provider to another, e.g. to move zones between DNS
+// providers. Records missing in the target provider are created and records differing from the
+// source provider are updated, while records only present in the target provider are kept. Only
+// records matching the domain filter are copied. In dry-run mode the changes are only logged.
+func Migrate(ctx context.Context, from, to provider.Provider, domainFilter endpoint.DomainFilter, dryRun bool) (*plan.Changes, error) {
+	desired, err := from.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return copyRecords(ctx, desired, to, domainFilter, dryRun)
+}
+
+// copyRecords creates and updates the records of the provider to match the desired records.
+// Records are compared by name, type and set identifier rather than by planning, as the
+// desired records include those of the registry, e.g. TXT records next to A records.
+func copyRecords(ctx context.Context, desired []*endpoint.Endpoint, to provider.Provider, domainFilter endpoint.DomainFilter, dryRun bool) (*plan.Changes, error) {
+	current, err := to.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type recordKey struct {
+		dnsName, recordType, setIdentifier string
+	}
+	currentRecords := map[recordKey]*endpoint.Endpoint{}
+	for _, ep := range current {
+		currentRecords[recordKey{ep.DNSName, ep.RecordType, ep.SetIdentifier}] = ep
+	}
+
+	changes := &plan.Changes{}
+	for _, ep := range desired {
+		if !domainFilter.Match(ep.DNSName) {
+			continue
+		}
+		old, ok := currentRecords[recordKey{ep.DNSName, ep.RecordType, ep.SetIdentifier}]
+		if !ok {
+			changes.Create = append(changes.Create, ep)
+			continue
+		}
+		if !sameRecord(old, ep, to.PropertyValuesEqual) {
+			changes.UpdateOld = append(changes.UpdateOld, old)
+			changes.UpdateNew = append(changes.UpdateNew, ep)
+		}
+	}
+
+	logChanges(changes)
+	if dryRun {
+		return changes, nil
+	}
+	return changes, to.ApplyChanges(ctx, changes)
+}
+
+// sameRecord returns whether the current record has the targets, TTL and provider specific
+// properties of the desired record.
+func sameRecord(current, desired *endpoint.Endpoint, propertyValuesEqual func(name, previous, current string) bool) bool {
+	if !current.Targets.Same(desired.Targets) {
+		return false
+	}
+	if desired.RecordTTL.IsConfigured() && current.RecordTTL != desired.RecordTTL {
+		return false
+	}
+	for _, property := range desired.ProviderSpecific {
+		currentProperty, ok := current.GetProviderSpecificProperty(property.Name)
+		if !ok || !propertyValuesEqual(property.Name, currentProperty.Value, property.Value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+// TestMigrate tests that records are copied between providers within the domain filter.
+func TestMigrate(t *testing.T) {
+	newProvider := func(records ...*endpoint.Endpoint) *inmemory.InMemoryProvider {
+		p := inmemory.NewInMemoryProvider(inmemory.InMemoryInitZones([]string{"example.org"}))
+		require.NoError(t, p.ApplyChanges(context.Background(), &plan.Changes{Create: records}))
+		return p
+	}
+	sourceRecords := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("api.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+		endpoint.NewEndpoint("db.example.org", endpoint.RecordTypeA, "10.0.0.2"),
+		endpoint.NewEndpoint("internal.example.org", endpoint.RecordTypeA, "10.0.0.3"),
+	}
+	targetRecords := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("api.example.org", endpoint.RecordTypeA, "10.1.0.1"),
+		endpoint.NewEndpoint("legacy.example.org", endpoint.RecordTypeA, "10.1.0.4"),
+	}
+	domainFilter := endpoint.NewDomainFilterWithExclusions([]string{"example.org"}, []string{"internal.example.org"})
+
+	t.Run("applies changes", func(t *testing.T) {
+		to := newProvider(targetRecords...)
+		changes, err := Migrate(context.Background(), newProvider(sourceRecords...), to, domainFilter, false)
+		require.NoError(t, err)
+		assert.Len(t, changes.Create, 1)
+		assert.Len(t, changes.UpdateNew, 1)
+		assert.Empty(t, changes.Delete)
+
+		records, err := to.Records(context.Background())
+		require.NoError(t, err)
+		assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+			endpoint.NewEndpoint("api.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+			endpoint.NewEndpoint("db.example.org", endpoint.RecordTypeA, "10.0.0.2"),
+			endpoint.NewEndpoint("legacy.example.org", endpoint.RecordTypeA, "10.1.0.4"),
+		}))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		to := newProvider(targetRecords...)
+		changes, err := Migrate(context.Background(), newProvider(sourceRecords...), to, domainFilter, true)
+		require.NoError(t, err)
+		assert.Len(t, changes.Create, 1)
+
+		records, err := to.Records(context.Background())
+		require.NoError(t, err)
+		assert.True(t, testutils.SameEndpoints(records, targetRecords))
+	})
+}
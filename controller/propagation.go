@@ -0,0 +1,233 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// Results of the propagation checks of changed records, see propagationChecksTotal.
+const (
+	PropagationVisible    = "visible"
+	PropagationNotVisible = "not_visible"
+	PropagationError      = "error"
+)
+
+var propagationChecksTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "external_dns",
+		Subsystem: "controller",
+		Name:      "propagation_checks_total",
+		Help:      "Number of changed records checked for being served by the resolver after applying the changes, partitioned by result",
+	},
+	[]string{"result"},
+)
+
+// Resolver resolves the targets of the records of a name and type.
+type Resolver interface {
+	// Resolve returns the targets of the records, or none if there are no records
+	Resolve(ctx context.Context, name, recordType string) ([]string, error)
+}
+
+// DNSResolver is a Resolver querying a DNS server, e.g. the server serving the zones of the provider.
+type DNSResolver struct {
+	address string
+	client  *dns.Client
+}
+
+// NewDNSResolver returns a new DNSResolver querying the DNS server at the address, port 53 if
+// the address has none.
+func NewDNSResolver(address string, timeout time.Duration) *DNSResolver {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "53")
+	}
+	return &DNSResolver{address: address, client: &dns.Client{Timeout: timeout}}
+}
+
+// Resolve returns the targets of the records in presentation format, e.g. "10 mail.example.org."
+// for MX records.
+func (r *DNSResolver) Resolve(ctx context.Context, name, recordType string) ([]string, error) {
+	qtype, ok := dns.StringToType[recordType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported record type %s", recordType)
+	}
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	resp, _, err := r.client.ExchangeContext(ctx, m, r.address)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.Rcode {
+	case dns.RcodeSuccess:
+	case dns.RcodeNameError:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("failed to resolve %s %s: %s", name, recordType, dns.RcodeToString[resp.Rcode])
+	}
+
+	var targets []string
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype == qtype && strings.EqualFold(rr.Header().Name, dns.Fqdn(name)) {
+			targets = append(targets, strings.TrimPrefix(rr.String(), rr.Header().String()))
+		}
+	}
+	return targets, nil
+}
+
+// PropagationVerifier verifies that applied changes are served by a resolver, flagging changes
+// the provider accepted which aren't visible in DNS.
+type PropagationVerifier struct {
+	Resolver Resolver
+	// The time the changes get to become visible
+	Timeout time.Duration
+	// The interval between checks of records which aren't visible yet, 2s if unset
+	Interval time.Duration
+}
+
+const defaultPropagationInterval = 2 * time.Second
+
+// propagationCheck is a changed record checked for being served.
+type propagationCheck struct {
+	record  *endpoint.Endpoint
+	deleted bool
+}
+
+// Verify checks that the created and updated records are served with their targets and that
+// the deleted records aren't served anymore, checking records which aren't visible again until
+// the timeout. Records with set identifiers aren't checked. It returns the records which
+// aren't visible.
+func (v *PropagationVerifier) Verify(ctx context.Context, changes *plan.Changes) []*endpoint.Endpoint {
+	var pending []propagationCheck
+	changed := map[string]bool{}
+	for _, ep := range append(append([]*endpoint.Endpoint{}, changes.Create...), changes.UpdateNew...) {
+		changed[ep.DNSName+"/"+ep.RecordType] = true
+		// Records of several set identifiers share their name and type, so the answers of
+		// a resolver can't be attributed to one of them.
+		if ep.SetIdentifier == "" {
+			pending = append(pending, propagationCheck{record: ep})
+		}
+	}
+	for _, ep := range changes.Delete {
+		// A record changing its type is deleted and created under the same name.
+		if ep.SetIdentifier == "" && !changed[ep.DNSName+"/"+ep.RecordType] {
+			pending = append(pending, propagationCheck{record: ep, deleted: true})
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	interval := v.Interval
+	if interval <= 0 {
+		interval = defaultPropagationInterval
+	}
+	deadline := time.Now().Add(v.Timeout)
+	errs := map[*endpoint.Endpoint]error{}
+	for {
+		var retry []propagationCheck
+		for _, c := range pending {
+			visible, err := v.check(ctx, c)
+			errs[c.record] = err
+			if visible {
+				propagationChecksTotal.WithLabelValues(PropagationVisible).Inc()
+				continue
+			}
+			retry = append(retry, c)
+		}
+		pending = retry
+		if len(pending) == 0 || !time.Now().Add(interval).Before(deadline) {
+			break
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return notVisible(pending, errs)
+		}
+	}
+	return notVisible(pending, errs)
+}
+
+// check returns whether a changed record is served as changed.
+func (v *PropagationVerifier) check(ctx context.Context, c propagationCheck) (bool, error) {
+	answers, err := v.Resolver.Resolve(ctx, c.record.DNSName, c.record.RecordType)
+	if err != nil {
+		return false, err
+	}
+	if c.deleted {
+		return len(answers) == 0, nil
+	}
+	return sameTargets(c.record.RecordType, c.record.Targets, answers), nil
+}
+
+// notVisible logs and counts the records which aren't visible and returns them.
+func notVisible(pending []propagationCheck, errs map[*endpoint.Endpoint]error) []*endpoint.Endpoint {
+	records := make([]*endpoint.Endpoint, 0, len(pending))
+	for _, c := range pending {
+		action := "changed"
+		if c.deleted {
+			action = "deleted"
+		}
+		if err := errs[c.record]; err != nil {
+			propagationChecksTotal.WithLabelValues(PropagationError).Inc()
+			log.Warnf("Failed to check whether %s record %s %s is served: %v", action, c.record.DNSName, c.record.RecordType, err)
+		} else {
+			propagationChecksTotal.WithLabelValues(PropagationNotVisible).Inc()
+			log.Warnf("The %s record %s %s is not served as applied", action, c.record.DNSName, c.record.RecordType)
+		}
+		records = append(records, c.record)
+	}
+	return records
+}
+
+// sameTargets returns whether the targets of a record equal the answers of a resolver,
+// ignoring their order, case, trailing dots and the quotes of TXT records.
+func sameTargets(recordType string, targets endpoint.Targets, answers []string) bool {
+	if len(targets) != len(answers) {
+		return false
+	}
+	normalize := func(values []string) []string {
+		normalized := make([]string, len(values))
+		for i, v := range values {
+			if recordType == endpoint.RecordTypeTXT {
+				normalized[i] = strings.Trim(v, `"`)
+			} else {
+				normalized[i] = strings.ToLower(strings.TrimSuffix(v, "."))
+			}
+		}
+		sort.Strings(normalized)
+		return normalized
+	}
+	a, b := normalize(targets), normalize(answers)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+// resolverFunc is a Resolver calling a function.
+type resolverFunc func(ctx context.Context, name, recordType string) ([]string, error)
+
+func (f resolverFunc) Resolve(ctx context.Context, name, recordType string) ([]string, error) {
+	return f(ctx, name, recordType)
+}
+
+func TestDNSResolver(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	started := make(chan struct{})
+	srv := &dns.Server{PacketConn: conn, NotifyStartedFunc: func() { close(started) }, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		switch r.Question[0].Name {
+		case "foo.example.org.":
+			for _, s := range []string{"foo.example.org. 300 IN A 1.2.3.4", "foo.example.org. 300 IN A 5.6.7.8", "bar.example.org. 300 IN A 1.2.3.4"} {
+				rr, _ := dns.NewRR(s)
+				m.Answer = append(m.Answer, rr)
+			}
+		case "mail.example.org.":
+			rr, _ := dns.NewRR("mail.example.org. 300 IN MX 10 mx.example.org.")
+			m.Answer = append(m.Answer, rr)
+		case "broken.example.org.":
+			m.Rcode = dns.RcodeServerFailure
+		default:
+			m.Rcode = dns.RcodeNameError
+		}
+		w.WriteMsg(m)
+	})}
+	go srv.ActivateAndServe()
+	<-started
+	defer srv.Shutdown()
+
+	ctx := context.Background()
+	r := NewDNSResolver(conn.LocalAddr().String(), time.Second)
+
+	targets, err := r.Resolve(ctx, "foo.example.org", endpoint.RecordTypeA)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.4", "5.6.7.8"}, targets)
+
+	targets, err = r.Resolve(ctx, "mail.example.org", endpoint.RecordTypeMX)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10 mx.example.org."}, targets)
+
+	targets, err = r.Resolve(ctx, "missing.example.org", endpoint.RecordTypeA)
+	require.NoError(t, err)
+	assert.Empty(t, targets)
+
+	_, err = r.Resolve(ctx, "broken.example.org", endpoint.RecordTypeA)
+	assert.Error(t, err)
+
+	assert.Equal(t, "10.0.0.53:53", NewDNSResolver("10.0.0.53", time.Second).address)
+}
+
+func TestPropagationVerifier(t *testing.T) {
+	calls := 0
+	v := &PropagationVerifier{
+		Resolver: resolverFunc(func(ctx context.Context, name, recordType string) ([]string, error) {
+			calls++
+			switch name {
+			case "new.example.org":
+				// Becomes visible with the second check
+				if calls > 4 {
+					return []string{"1.2.3.4"}, nil
+				}
+				return nil, nil
+			case "txt.example.org":
+				return []string{`"heritage=external-dns"`}, nil
+			case "stale.example.org":
+				return []string{"old.example.com."}, nil
+			case "broken.example.org":
+				return nil, errors.New("timeout")
+			}
+			return nil, nil
+		}),
+		Timeout:  50 * time.Millisecond,
+		Interval: time.Millisecond,
+	}
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+			endpoint.NewEndpoint("txt.example.org", endpoint.RecordTypeTXT, "heritage=external-dns"),
+			endpoint.NewEndpoint("weighted.example.org", endpoint.RecordTypeA, "1.2.3.4").WithSetIdentifier("eu"),
+		},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpoint("stale.example.org", endpoint.RecordTypeCNAME, "new.example.com")},
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpoint("stale.example.org", endpoint.RecordTypeCNAME, "old.example.com")},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("deleted.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+			endpoint.NewEndpoint("broken.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		},
+	}
+
+	visible := testutil.ToFloat64(propagationChecksTotal.WithLabelValues(PropagationVisible))
+	notVisible := testutil.ToFloat64(propagationChecksTotal.WithLabelValues(PropagationNotVisible))
+	errs := testutil.ToFloat64(propagationChecksTotal.WithLabelValues(PropagationError))
+
+	assert.Equal(t, []*endpoint.Endpoint{changes.UpdateNew[0], changes.Delete[1]}, v.Verify(context.Background(), changes))
+	assert.Equal(t, visible+3, testutil.ToFloat64(propagationChecksTotal.WithLabelValues(PropagationVisible)))
+	assert.Equal(t, notVisible+1, testutil.ToFloat64(propagationChecksTotal.WithLabelValues(PropagationNotVisible)))
+	assert.Equal(t, errs+1, testutil.ToFloat64(propagationChecksTotal.WithLabelValues(PropagationError)))
+}
+
+func TestRunOncePropagationVerifier(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+	}, nil)
+	r, err := registry.NewNoopRegistry(inmemory.NewInMemoryProvider(inmemory.InMemoryInitZones([]string{"example.org"})))
+	require.NoError(t, err)
+
+	var resolved []string
+	ctrl := &Controller{
+		Source:   source,
+		Registry: r,
+		Policy:   &plan.SyncPolicy{},
+		PropagationVerifier: &PropagationVerifier{
+			Resolver: resolverFunc(func(ctx context.Context, name, recordType string) ([]string, error) {
+				resolved = append(resolved, name+" "+recordType)
+				return []string{"1.2.3.4"}, nil
+			}),
+		},
+	}
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	assert.Equal(t, []string{"new.example.org A"}, resolved)
+
+	// Without changes, nothing is verified.
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	assert.Len(t, resolved, 1)
+}
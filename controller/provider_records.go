@@ -0,0 +1,106 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// DomainRecords are the records of a domain read from the provider.
+type DomainRecords struct {
+	Domain  string               `json:"domain"`
+	ReadAt  time.Time            `json:"readAt"`
+	Records []*endpoint.Endpoint `json:"records"`
+}
+
+// ProviderRecordsStatus is the view of the provider on its records.
+type ProviderRecordsStatus struct {
+	LastRead      *time.Time      `json:"lastRead,omitempty"`
+	LastError     string          `json:"lastError,omitempty"`
+	LastErrorTime *time.Time      `json:"lastErrorTime,omitempty"`
+	Domains       []DomainRecords `json:"domains"`
+}
+
+// ProviderRecords wraps a Provider and keeps the records last read from it, so operators can
+// tell problems of the sources from problems reading the provider. A failed read keeps the
+// records of the last successful read.
+type ProviderRecords struct {
+	provider.Provider
+	domainFilter endpoint.DomainFilter
+
+	mux    sync.Mutex
+	status ProviderRecordsStatus
+}
+
+// NewProviderRecords returns a new ProviderRecords grouping the records by the domains of the
+// domain filter, see recordDomain.
+func NewProviderRecords(p provider.Provider, domainFilter endpoint.DomainFilter) *ProviderRecords {
+	return &ProviderRecords{Provider: p, domainFilter: domainFilter, status: ProviderRecordsStatus{Domains: []DomainRecords{}}}
+}
+
+// Records reads the records of the provider and keeps them.
+func (p *ProviderRecords) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	start := time.Now()
+	records, err := p.Provider.Records(ctx)
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if err != nil {
+		p.status.LastError = err.Error()
+		p.status.LastErrorTime = &start
+		return nil, err
+	}
+
+	// The records are copied, as the controller may change the records it reads.
+	domains := map[string]*DomainRecords{}
+	for _, ep := range records {
+		domain := recordDomain(p.domainFilter.Filters, ep.DNSName)
+		if domains[domain] == nil {
+			domains[domain] = &DomainRecords{Domain: domain, ReadAt: start, Records: []*endpoint.Endpoint{}}
+		}
+		domains[domain].Records = append(domains[domain].Records, ep.DeepCopy())
+	}
+	p.status.Domains = make([]DomainRecords, 0, len(domains))
+	for _, domain := range domains {
+		p.status.Domains = append(p.status.Domains, *domain)
+	}
+	sort.Slice(p.status.Domains, func(i, j int) bool { return p.status.Domains[i].Domain < p.status.Domains[j].Domain })
+	p.status.LastRead = &start
+	return records, nil
+}
+
+// Status returns the records last read from the provider.
+func (p *ProviderRecords) Status() ProviderRecordsStatus {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.status
+}
+
+// RegisterHandler serves the records last read from the provider on the mux as
+// /debug/records.
+func (p *ProviderRecords) RegisterHandler(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/records", func(w http.ResponseWriter, r *http.Request) {
+		writeStatusJSON(w, r, p.Status())
+	})
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+// failingProvider fails to read its records while fail is set.
+type failingProvider struct {
+	provider.Provider
+	fail bool
+}
+
+func (p *failingProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	if p.fail {
+		return nil, errors.New("failed to list records")
+	}
+	return p.Provider.Records(ctx)
+}
+
+func TestProviderRecords(t *testing.T) {
+	wrapped := &failingProvider{Provider: inmemory.NewInMemoryProvider(inmemory.InMemoryInitZones([]string{"example.org", "sub.example.org", "example.com"}))}
+	ctx := context.Background()
+	require.NoError(t, wrapped.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpoint("foo.sub.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeA, "1.2.3.4"),
+	}}))
+	p := NewProviderRecords(wrapped, endpoint.NewDomainFilter([]string{"example.org", "sub.example.org"}))
+	mux := http.NewServeMux()
+	p.RegisterHandler(mux)
+
+	var status ProviderRecordsStatus
+	require.Equal(t, http.StatusOK, getStatusJSON(t, mux, "/debug/records", &status))
+	assert.Nil(t, status.LastRead)
+	assert.Empty(t, status.Domains)
+
+	records, err := p.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	require.Equal(t, http.StatusOK, getStatusJSON(t, mux, "/debug/records", &status))
+	require.NotNil(t, status.LastRead)
+	require.Len(t, status.Domains, 3)
+	for i, domain := range []string{"example.com", "example.org", "sub.example.org"} {
+		assert.Equal(t, domain, status.Domains[i].Domain)
+		assert.True(t, status.Domains[i].ReadAt.Equal(*status.LastRead))
+		require.Len(t, status.Domains[i].Records, 1)
+		assert.Equal(t, "foo."+domain, status.Domains[i].Records[0].DNSName)
+	}
+
+	// A failed read keeps the records of the last successful read.
+	wrapped.fail = true
+	_, err = p.Records(ctx)
+	require.Error(t, err)
+	status = ProviderRecordsStatus{}
+	require.Equal(t, http.StatusOK, getStatusJSON(t, mux, "/debug/records", &status))
+	assert.Equal(t, "failed to list records", status.LastError)
+	require.NotNil(t, status.LastErrorTime)
+	assert.Len(t, status.Domains, 3)
+}
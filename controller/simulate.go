@@ -0,0 +1,80 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+// NewSimulationProvider returns an in-memory provider with the given zones holding the
+// records of the fixture, to run synchronizations against instead of a real DNS provider,
+// e.g. to test changes of the sources in CI.
+func NewSimulationProvider(ctx context.Context, zones []string, domainFilter endpoint.DomainFilter, fixture []*endpoint.Endpoint) (*inmemory.InMemoryProvider, error) {
+	p := inmemory.NewInMemoryProvider(inmemory.InMemoryInitZones(zones), inmemory.InMemoryWithDomain(domainFilter))
+	if len(fixture) == 0 {
+		return p, nil
+	}
+	if err := p.ApplyChanges(ctx, &plan.Changes{Create: fixture}); err != nil {
+		return nil, fmt.Errorf("failed to load the records of the fixture: %v", err)
+	}
+	// The in-memory provider ignores records outside of its zones.
+	records, err := p.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) != len(fixture) {
+		return nil, fmt.Errorf("failed to load the records of the fixture: %d of %d records are outside of the zones %v", len(fixture)-len(records), len(fixture), zones)
+	}
+	// Only log the changes of the synchronizations, not the ones loading the fixture.
+	inmemory.InMemoryWithLogging()(p)
+	return p, nil
+}
+
+// WriteZoneState writes the records sorted by name and type, one line per target in the
+// format of zone files, e.g.
+//
+//	foo.example.org 300 IN A 10.0.0.1
+func WriteZoneState(w io.Writer, records []*endpoint.Endpoint) error {
+	sorted := append([]*endpoint.Endpoint{}, records...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].DNSName != sorted[j].DNSName {
+			return sorted[i].DNSName < sorted[j].DNSName
+		}
+		if sorted[i].RecordType != sorted[j].RecordType {
+			return sorted[i].RecordType < sorted[j].RecordType
+		}
+		return sorted[i].SetIdentifier < sorted[j].SetIdentifier
+	})
+
+	for _, ep := range sorted {
+		targets := append(endpoint.Targets{}, ep.Targets...)
+		sort.Strings(targets)
+		for _, target := range targets {
+			if _, err := fmt.Fprintf(w, "%s %d IN %s %s\n", ep.DNSName, ep.RecordTTL, ep.RecordType, target); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+func TestSimulate(t *testing.T) {
+	ctx := context.Background()
+	p, err := NewSimulationProvider(ctx, []string{"example.org"}, endpoint.NewDomainFilter([]string{"example.org"}), []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("owned.example.org", endpoint.RecordTypeA, 300, "1.2.3.4"),
+		endpoint.NewEndpoint("owned.example.org", endpoint.RecordTypeTXT, `"heritage=external-dns,external-dns/owner=default"`),
+		endpoint.NewEndpoint("foreign.example.org", endpoint.RecordTypeCNAME, "lb.example.com"),
+	})
+	require.NoError(t, err)
+	r, err := registry.NewTXTRegistry(p, "", "", "default", 0, "", nil, "")
+	require.NoError(t, err)
+
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "5.6.7.8"),
+		endpoint.NewEndpoint("foreign.example.org", endpoint.RecordTypeCNAME, "other.example.com"),
+	}, nil)
+	ctrl := &Controller{
+		Source:       source,
+		Registry:     r,
+		Policy:       &plan.SyncPolicy{},
+		DomainFilter: endpoint.NewDomainFilter([]string{"example.org"}),
+	}
+	require.NoError(t, ctrl.RunOnce(ctx))
+
+	records, err := p.Records(ctx)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, WriteZoneState(&buf, records))
+	assert.Equal(t, `foreign.example.org 0 IN CNAME lb.example.com
+new.example.org 0 IN A 5.6.7.8
+new.example.org 0 IN TXT "heritage=external-dns,external-dns/owner=default"
+`, buf.String())
+}
+
+func TestNewSimulationProviderOutsideOfZones(t *testing.T) {
+	_, err := NewSimulationProvider(context.Background(), []string{"example.org"}, endpoint.NewDomainFilter([]string{"example.org"}), []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeA, "1.2.3.4"),
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// ChangeSummary counts the changes of a plan.
+type ChangeSummary struct {
+	Create int `json:"create"`
+	Update int `json:"update"`
+	Delete int `json:"delete"`
+}
+
+// DomainStatus is the result of applying the changes of a single domain.
+type DomainStatus struct {
+	Domain  string        `json:"domain"`
+	Changes ChangeSummary `json:"changes"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// SyncStatus is the result of a synchronization.
+type SyncStatus struct {
+	Time      time.Time     `json:"time"`
+	Duration  string        `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+	ErrorCode string        `json:"errorCode,omitempty"`
+	Changes   ChangeSummary `json:"changes"`
+	// Whether the changes were applied, as opposed to e.g. exported or refused
+	Applied bool           `json:"applied"`
+	Domains []DomainStatus `json:"domains,omitempty"`
+}
+
+// Status is the state of the controller.
+type Status struct {
+	Records                 int         `json:"records"`
+	LastSync                *SyncStatus `json:"lastSync,omitempty"`
+	LastSuccessfulSync      *time.Time  `json:"lastSuccessfulSync,omitempty"`
+	CircuitBreakerOpenUntil *time.Time  `json:"circuitBreakerOpenUntil,omitempty"`
+}
+
+// syncResult collects what a synchronization read and planned.
+type syncResult struct {
+	records []*endpoint.Endpoint
+	changes *plan.Changes
+	applied bool
+}
+
+// recordSync stores the result of a synchronization for the status API.
+func (c *Controller) recordSync(start time.Time, result *syncResult, err error) {
+	status := &SyncStatus{
+		Time:     start,
+		Duration: time.Since(start).String(),
+		Applied:  result.applied,
+	}
+	if err != nil {
+		status.Error = err.Error()
+		status.ErrorCode = ErrorCodeUnknown
+		var syncErr *SyncError
+		if errors.As(err, &syncErr) {
+			status.ErrorCode = syncErr.Code
+		}
+	}
+	if result.changes != nil {
+		status.Changes = summarizeChanges(result.changes)
+		status.Domains = c.domainStatuses(result.changes, result.applied, err)
+	}
+
+	// The records are copied, as the next synchronization may change cached records.
+	var records []*endpoint.Endpoint
+	if result.records != nil {
+		records = make([]*endpoint.Endpoint, 0, len(result.records))
+		for _, ep := range result.records {
+			records = append(records, ep.DeepCopy())
+		}
+	}
+
+	c.statusMux.Lock()
+	defer c.statusMux.Unlock()
+	if records != nil {
+		c.records = records
+	}
+	c.lastSync = status
+	if err == nil {
+		c.lastSuccessfulSync = start
+	}
+}
+
+// RecordsRead reports whether a synchronization ran and, if so, the error of reading the
+// records of the registry in the last one, so checks don't have to read them themselves.
+func (c *Controller) RecordsRead() (bool, error) {
+	c.statusMux.Lock()
+	defer c.statusMux.Unlock()
+
+	if c.lastSync == nil {
+		return false, nil
+	}
+	if c.lastSync.ErrorCode == ErrorCodeRegistryRecords {
+		return true, errors.New(c.lastSync.Error)
+	}
+	return true, nil
+}
+
+// domainStatuses groups the changes by domain. Changes are applied at once, so a failure
+// to apply them is reported for every domain with changes.
+func (c *Controller) domainStatuses(changes *plan.Changes, applied bool, err error) []DomainStatus {
+	domains := map[string]*DomainStatus{}
+	count := func(endpoints []*endpoint.Endpoint, add func(*ChangeSummary)) {
+		for _, ep := range endpoints {
+			domain := recordDomain(c.DomainFilter.Filters, ep.DNSName)
+			if domains[domain] == nil {
+				domains[domain] = &DomainStatus{Domain: domain}
+			}
+			add(&domains[domain].Changes)
+		}
+	}
+	count(changes.Create, func(s *ChangeSummary) { s.Create++ })
+	count(changes.UpdateNew, func(s *ChangeSummary) { s.Update++ })
+	count(changes.Delete, func(s *ChangeSummary) { s.Delete++ })
+
+	result := make([]DomainStatus, 0, len(domains))
+	for _, status := range domains {
+		if err != nil && !applied {
+			status.Error = err.Error()
+		}
+		result = append(result, *status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Domain < result[j].Domain })
+	return result
+}
+
+// recordDomain returns the longest domain of the domain filter the name is in, or the last
+// two labels of the name without a matching domain.
+func recordDomain(filters []string, name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	domain := ""
+	for _, filter := range filters {
+		filter = strings.TrimPrefix(filter, ".")
+		if filter != "" && (name == filter || strings.HasSuffix(name, "."+filter)) && len(filter) > len(domain) {
+			domain = filter
+		}
+	}
+	if domain != "" {
+		return domain
+	}
+	labels := strings.Split(name, ".")
+	if len(labels) > 2 {
+		labels = labels[len(labels)-2:]
+	}
+	return strings.Join(labels, ".")
+}
+
+func summarizeChanges(changes *plan.Changes) ChangeSummary {
+	return ChangeSummary{Create: len(changes.Create), Update: len(changes.UpdateNew), Delete: len(changes.Delete)}
+}
+
+// Status returns the state of the controller.
+func (c *Controller) Status() Status {
+	c.statusMux.Lock()
+	status := Status{Records: len(c.records), LastSync: c.lastSync}
+	if !c.lastSuccessfulSync.IsZero() {
+		lastSuccessfulSync := c.lastSuccessfulSync
+		status.LastSuccessfulSync = &lastSuccessfulSync
+	}
+	c.statusMux.Unlock()
+
+	if until := c.circuitOpen(time.Now()); !until.IsZero() {
+		status.CircuitBreakerOpenUntil = &until
+	}
+	return status
+}
+
+// Records returns the records read from the registry by the last synchronization.
+func (c *Controller) Records() []*endpoint.Endpoint {
+	c.statusMux.Lock()
+	defer c.statusMux.Unlock()
+	return c.records
+}
+
+// RegisterStatusHandlers serves the read-only status API on the mux:
+//
+// * /status reports the state of the controller
+// * /records lists the records read from the registry by the last synchronization
+// * /last-sync reports the result of the last synchronization
+func (c *Controller) RegisterStatusHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		writeStatusJSON(w, r, c.Status())
+	})
+	mux.HandleFunc("/records", func(w http.ResponseWriter, r *http.Request) {
+		records := c.Records()
+		if records == nil {
+			records = []*endpoint.Endpoint{}
+		}
+		writeStatusJSON(w, r, records)
+	})
+	mux.HandleFunc("/last-sync", func(w http.ResponseWriter, r *http.Request) {
+		lastSync := c.Status().LastSync
+		if lastSync == nil {
+			http.Error(w, "no synchronization yet", http.StatusNotFound)
+			return
+		}
+		writeStatusJSON(w, r, lastSync)
+	})
+}
+
+func writeStatusJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("Failed to write status: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+func getStatusJSON(t *testing.T, mux *http.ServeMux, path string, v interface{}) int {
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v))
+	}
+	return rec.Code
+}
+
+func TestStatusHandlers(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpoint("new.sub.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpoint("new.example.com", endpoint.RecordTypeA, "1.2.3.4"),
+	}, nil)
+
+	provider := inmemory.NewInMemoryProvider(inmemory.InMemoryInitZones([]string{"example.org", "sub.example.org", "example.com"}))
+	require.NoError(t, provider.ApplyChanges(context.Background(), &plan.Changes{Create: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("old.example.org", endpoint.RecordTypeA, "4.3.2.1"),
+		endpoint.NewEndpoint("old.sub.example.org", endpoint.RecordTypeA, "4.3.2.1"),
+	}}))
+	r, err := registry.NewNoopRegistry(provider)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:       source,
+		Registry:     r,
+		Policy:       &plan.SyncPolicy{},
+		DomainFilter: endpoint.NewDomainFilter([]string{"example.org", "sub.example.org", "example.com"}),
+		MaxDeletions: 1,
+	}
+	mux := http.NewServeMux()
+	ctrl.RegisterStatusHandlers(mux)
+
+	var status Status
+	require.Equal(t, http.StatusOK, getStatusJSON(t, mux, "/status", &status))
+	assert.Equal(t, Status{}, status)
+	assert.Equal(t, http.StatusNotFound, getStatusJSON(t, mux, "/last-sync", nil))
+
+	// The refused changes are reported for every domain.
+	require.Error(t, ctrl.RunOnce(context.Background()))
+	var lastSync SyncStatus
+	require.Equal(t, http.StatusOK, getStatusJSON(t, mux, "/last-sync", &lastSync))
+	assert.Equal(t, ErrorCodeMaxDeletions, lastSync.ErrorCode)
+	assert.False(t, lastSync.Applied)
+	assert.Equal(t, ChangeSummary{Create: 3, Delete: 2}, lastSync.Changes)
+	require.Len(t, lastSync.Domains, 3)
+	assert.Equal(t, "example.com", lastSync.Domains[0].Domain)
+	assert.Equal(t, ChangeSummary{Create: 1}, lastSync.Domains[0].Changes)
+	assert.Equal(t, "example.org", lastSync.Domains[1].Domain)
+	assert.Equal(t, ChangeSummary{Create: 1, Delete: 1}, lastSync.Domains[1].Changes)
+	assert.Equal(t, "sub.example.org", lastSync.Domains[2].Domain)
+	for _, domain := range lastSync.Domains {
+		assert.Equal(t, lastSync.Error, domain.Error)
+	}
+
+	require.Equal(t, http.StatusOK, getStatusJSON(t, mux, "/status", &status))
+	assert.Equal(t, 2, status.Records)
+	assert.Nil(t, status.LastSuccessfulSync)
+
+	ctrl.MaxDeletions = 0
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	lastSync = SyncStatus{}
+	require.Equal(t, http.StatusOK, getStatusJSON(t, mux, "/last-sync", &lastSync))
+	assert.Empty(t, lastSync.ErrorCode)
+	assert.True(t, lastSync.Applied)
+	for _, domain := range lastSync.Domains {
+		assert.Empty(t, domain.Error)
+	}
+	require.Equal(t, http.StatusOK, getStatusJSON(t, mux, "/status", &status))
+	assert.NotNil(t, status.LastSuccessfulSync)
+
+	// The records are those read before applying the changes.
+	var records []*endpoint.Endpoint
+	require.Equal(t, http.StatusOK, getStatusJSON(t, mux, "/records", &records))
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("old.example.org", endpoint.RecordTypeA, "4.3.2.1"),
+		endpoint.NewEndpoint("old.sub.example.org", endpoint.RecordTypeA, "4.3.2.1"),
+	}))
+}
+
+func TestRecordDomain(t *testing.T) {
+	filters := []string{"example.org", ".sub.example.org"}
+	assert.Equal(t, "example.org", recordDomain(filters, "foo.example.org."))
+	assert.Equal(t, "sub.example.org", recordDomain(filters, "foo.sub.example.org"))
+	assert.Equal(t, "example.com", recordDomain(filters, "foo.bar.example.com"))
+	assert.Equal(t, "localhost", recordDomain(nil, "localhost"))
+}
+
+func TestRecordsRead(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{}, nil)
+	provider := &failingProvider{Provider: inmemory.NewInMemoryProvider(inmemory.InMemoryInitZones([]string{"example.org"}))}
+	r, err := registry.NewNoopRegistry(provider)
+	require.NoError(t, err)
+	ctrl := &Controller{
+		Source:   source,
+		Registry: r,
+		Policy:   &plan.SyncPolicy{},
+	}
+
+	synchronized, err := ctrl.RecordsRead()
+	assert.False(t, synchronized)
+	assert.NoError(t, err)
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	synchronized, err = ctrl.RecordsRead()
+	assert.True(t, synchronized)
+	assert.NoError(t, err)
+
+	provider.fail = true
+	require.Error(t, ctrl.RunOnce(context.Background()))
+	synchronized, err = ctrl.RecordsRead()
+	assert.True(t, synchronized)
+	assert.EqualError(t, err, "failed to list records")
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// Statuses of verified records, see Verification.
+const (
+	// VerifyInSync records are stored and served as desired
+	VerifyInSync = "in_sync"
+	// VerifyNotStored records are desired but missing in the provider
+	VerifyNotStored = "not_stored"
+	// VerifyStoredDiffers records are stored with other targets than desired
+	VerifyStoredDiffers = "stored_differs"
+	// VerifyNotServed records are stored as desired but not served as stored
+	VerifyNotServed = "not_served"
+	// VerifyNotDesired records are owned and stored but not desired anymore
+	VerifyNotDesired = "not_desired"
+)
+
+// Verification compares the desired targets of a record to those stored by the provider and
+// served by a resolver. Records with set identifiers aren't resolved, as the answers of a
+// resolver can't be attributed to one of them.
+type Verification struct {
+	DNSName       string           `json:"dnsName"`
+	RecordType    string           `json:"recordType"`
+	SetIdentifier string           `json:"setIdentifier,omitempty"`
+	Status        string           `json:"status"`
+	Desired       endpoint.Targets `json:"desired"`
+	Stored        endpoint.Targets `json:"stored"`
+	Served        []string         `json:"served"`
+	ServedError   string           `json:"servedError,omitempty"`
+}
+
+// InSync returns whether the record is stored and served as desired.
+func (v Verification) InSync() bool {
+	return v.Status == VerifyInSync
+}
+
+// Verify compares the desired endpoints, e.g. of the sources, to the records of the registry and
+// to the answers of the resolver, sorted by record. Stored records which aren't desired are only
+// reported if they're owned by the owner ID, as the others are managed by someone else.
+func Verify(ctx context.Context, desired, stored []*endpoint.Endpoint, resolver Resolver, ownerID string) []Verification {
+	type key struct {
+		dnsName, recordType, setIdentifier string
+	}
+	storedRecords := map[key]*endpoint.Endpoint{}
+	for _, ep := range stored {
+		storedRecords[key{ep.DNSName, ep.RecordType, ep.SetIdentifier}] = ep
+	}
+
+	var verifications []Verification
+	seen := map[key]bool{}
+	for _, ep := range desired {
+		k := key{ep.DNSName, ep.RecordType, ep.SetIdentifier}
+		seen[k] = true
+		v := Verification{DNSName: ep.DNSName, RecordType: ep.RecordType, SetIdentifier: ep.SetIdentifier, Desired: ep.Targets}
+		if record, ok := storedRecords[k]; ok {
+			v.Stored = record.Targets
+		}
+		resolve(ctx, resolver, &v)
+		switch {
+		case v.Stored == nil:
+			v.Status = VerifyNotStored
+		case !v.Stored.Same(v.Desired):
+			v.Status = VerifyStoredDiffers
+		case v.SetIdentifier == "" && (v.ServedError != "" || !sameTargets(v.RecordType, v.Stored, v.Served)):
+			v.Status = VerifyNotServed
+		default:
+			v.Status = VerifyInSync
+		}
+		verifications = append(verifications, v)
+	}
+	for _, ep := range stored {
+		k := key{ep.DNSName, ep.RecordType, ep.SetIdentifier}
+		if seen[k] || ownerID == "" || ep.Labels[endpoint.OwnerLabelKey] != ownerID {
+			continue
+		}
+		v := Verification{DNSName: ep.DNSName, RecordType: ep.RecordType, SetIdentifier: ep.SetIdentifier, Status: VerifyNotDesired, Stored: ep.Targets}
+		resolve(ctx, resolver, &v)
+		verifications = append(verifications, v)
+	}
+
+	sort.SliceStable(verifications, func(i, j int) bool {
+		a, b := verifications[i], verifications[j]
+		if a.DNSName != b.DNSName {
+			return a.DNSName < b.DNSName
+		}
+		if a.RecordType != b.RecordType {
+			return a.RecordType < b.RecordType
+		}
+		return a.SetIdentifier < b.SetIdentifier
+	})
+	return verifications
+}
+
+// resolve sets the answers of the resolver for a record without a set identifier.
+func resolve(ctx context.Context, resolver Resolver, v *Verification) {
+	if v.SetIdentifier != "" {
+		return
+	}
+	served, err := resolver.Resolve(ctx, v.DNSName, v.RecordType)
+	if err != nil {
+		v.ServedError = err.Error()
+		return
+	}
+	v.Served = served
+}
+
+// WriteVerifications writes the verified records in a format of plan.WriteDiff: a line per
+// record for text, e.g.
+//
+//	not_served foo.example.org A desired=[10.0.0.1] stored=[10.0.0.1] served=[10.0.0.2]
+//
+// or a JSON object per record.
+func WriteVerifications(w io.Writer, verifications []Verification, format string) error {
+	list := func(values []string) string {
+		return "[" + strings.Join(values, " ") + "]"
+	}
+
+	switch format {
+	case plan.DiffFormatText:
+		for _, v := range verifications {
+			name := v.DNSName + " " + v.RecordType
+			if v.SetIdentifier != "" {
+				name += " " + v.SetIdentifier
+			}
+			served := list(v.Served)
+			if v.ServedError != "" {
+				served = "error: " + v.ServedError
+			} else if v.SetIdentifier != "" {
+				served = "unchecked"
+			}
+			if _, err := fmt.Fprintf(w, "%s %s desired=%s stored=%s served=%s\n", v.Status, name, list(v.Desired), list(v.Stored), served); err != nil {
+				return err
+			}
+		}
+		return nil
+	case plan.DiffFormatJSON:
+		enc := json.NewEncoder(w)
+		for _, v := range verifications {
+			if err := enc.Encode(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown verification format: %s", format)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+func TestVerify(t *testing.T) {
+	owned := func(ep *endpoint.Endpoint, owner string) *endpoint.Endpoint {
+		ep.Labels = endpoint.Labels{endpoint.OwnerLabelKey: owner}
+		return ep
+	}
+	desired := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("sync.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+		endpoint.NewEndpoint("missing.example.org", endpoint.RecordTypeA, "10.0.0.2"),
+		endpoint.NewEndpoint("differs.example.org", endpoint.RecordTypeA, "10.0.0.3"),
+		endpoint.NewEndpoint("stale.example.org", endpoint.RecordTypeA, "10.0.0.4"),
+		endpoint.NewEndpoint("broken.example.org", endpoint.RecordTypeA, "10.0.0.5"),
+		endpoint.NewEndpoint("weighted.example.org", endpoint.RecordTypeA, "10.0.0.6").WithSetIdentifier("a"),
+	}
+	stored := []*endpoint.Endpoint{
+		owned(endpoint.NewEndpoint("sync.example.org", endpoint.RecordTypeA, "10.0.0.1"), "default"),
+		owned(endpoint.NewEndpoint("differs.example.org", endpoint.RecordTypeA, "10.0.1.3"), "default"),
+		owned(endpoint.NewEndpoint("stale.example.org", endpoint.RecordTypeA, "10.0.0.4"), "default"),
+		owned(endpoint.NewEndpoint("broken.example.org", endpoint.RecordTypeA, "10.0.0.5"), "default"),
+		owned(endpoint.NewEndpoint("weighted.example.org", endpoint.RecordTypeA, "10.0.0.6").WithSetIdentifier("a"), "default"),
+		owned(endpoint.NewEndpoint("orphan.example.org", endpoint.RecordTypeA, "10.0.0.7"), "default"),
+		owned(endpoint.NewEndpoint("other.example.org", endpoint.RecordTypeA, "10.0.0.8"), "other"),
+	}
+	served := map[string][]string{
+		"sync.example.org":     {"10.0.0.1"},
+		"differs.example.org":  {"10.0.1.3"},
+		"stale.example.org":    {"10.0.1.4"},
+		"orphan.example.org":   {"10.0.0.7"},
+		"weighted.example.org": {"10.0.0.6"},
+	}
+	resolver := resolverFunc(func(ctx context.Context, name, recordType string) ([]string, error) {
+		if name == "weighted.example.org" {
+			t.Error("records with set identifiers must not be resolved")
+		}
+		if name == "broken.example.org" {
+			return nil, errors.New("timeout")
+		}
+		return served[name], nil
+	})
+
+	verifications := Verify(context.Background(), desired, stored, resolver, "default")
+	statuses := map[string]string{}
+	for _, v := range verifications {
+		statuses[v.DNSName] = v.Status
+	}
+	assert.Equal(t, map[string]string{
+		"sync.example.org":     VerifyInSync,
+		"missing.example.org":  VerifyNotStored,
+		"differs.example.org":  VerifyStoredDiffers,
+		"stale.example.org":    VerifyNotServed,
+		"broken.example.org":   VerifyNotServed,
+		"weighted.example.org": VerifyInSync,
+		"orphan.example.org":   VerifyNotDesired,
+	}, statuses)
+	assert.Equal(t, "broken.example.org", verifications[0].DNSName)
+	assert.Equal(t, "timeout", verifications[0].ServedError)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteVerifications(&buf, verifications[:2], plan.DiffFormatText))
+	assert.Equal(t, "not_served broken.example.org A desired=[10.0.0.5] stored=[10.0.0.5] served=error: timeout\n"+
+		"stored_differs differs.example.org A desired=[10.0.0.3] stored=[10.0.1.3] served=[10.0.1.3]\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteVerifications(&buf, verifications[1:2], plan.DiffFormatJSON))
+	assert.JSONEq(t, `{"dnsName": "differs.example.org", "recordType": "A", "status": "stored_differs", "desired": ["10.0.0.3"], "stored": ["10.0.1.3"], "served": ["10.0.1.3"]}`, buf.String())
+
+	assert.Error(t, WriteVerifications(&buf, verifications, "yaml"))
+}
@@ -32,11 +32,24 @@ type DomainFilter struct {
 func prepareFilters(filters []string) []string {
 	fs := make([]string, len(filters))
 	for i, domain := range filters {
-		fs[i] = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+		fs[i] = normalizeDomain(strings.TrimSpace(domain))
 	}
 	return fs
 }
 
+// normalizeDomain lowercases a domain and converts its internationalized labels to punycode, so
+// domains match regardless of their encoding. Invalid internationalized domains are only lowercased.
+func normalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if isASCII(domain) {
+		return domain
+	}
+	if ascii, err := ToPunycode(domain); err == nil {
+		return ascii
+	}
+	return domain
+}
+
 // NewDomainFilterWithExclusions returns a new DomainFilter, given a list of matches and exclusions
 func NewDomainFilterWithExclusions(domainFilters []string, excludeDomains []string) DomainFilter {
 	return DomainFilter{prepareFilters(domainFilters), prepareFilters(excludeDomains)}
@@ -60,8 +73,8 @@ func matchFilter(filters []string, domain string, emptyval bool) bool {
 		return emptyval
 	}
 
+	strippedDomain := normalizeDomain(domain)
 	for _, filter := range filters {
-		strippedDomain := strings.ToLower(strings.TrimSuffix(domain, "."))
 
 		if filter == "" {
 			return emptyval
@@ -210,6 +210,42 @@ var domainFilterTests = []domainFilterTest{
 		[]string{"foobar.API.Example.Org"},
 		false,
 	},
+	{
+		[]string{"bücher.example.org"},
+		[]string{""},
+		[]string{"xn--bcher-kva.example.org", "www.Bücher.example.org", "www.xn--bcher-kva.example.org"},
+		true,
+	},
+	{
+		[]string{"xn--bcher-kva.example.org"},
+		[]string{"intern.bücher.example.org"},
+		[]string{"BÜCHER.example.org", "www.bücher.example.org"},
+		true,
+	},
+	{
+		[]string{"xn--bcher-kva.example.org"},
+		[]string{"intern.bücher.example.org"},
+		[]string{"intern.xn--bcher-kva.example.org", "bucher.example.org"},
+		false,
+	},
+	{
+		[]string{"apps.example.org"},
+		[]string{""},
+		[]string{"*.apps.example.org", "*.foo.apps.example.org"},
+		true,
+	},
+	{
+		[]string{"example.org"},
+		[]string{"apps.example.org"},
+		[]string{"*.apps.example.org"},
+		false,
+	},
+	{
+		[]string{"apps.example.org"},
+		[]string{""},
+		[]string{"*.example.org"},
+		false,
+	},
 }
 
 func TestDomainFilterMatch(t *testing.T) {
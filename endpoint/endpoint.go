@@ -19,6 +19,7 @@ package endpoint
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -33,6 +34,12 @@ const (
 	RecordTypeTXT = "TXT"
 	// RecordTypeSRV is a RecordType enum value
 	RecordTypeSRV = "SRV"
+	// RecordTypeMX is a RecordType enum value
+	RecordTypeMX = "MX"
+	// RecordTypePTR is a RecordType enum value
+	RecordTypePTR = "PTR"
+	// RecordTypeNS is a RecordType enum value
+	RecordTypeNS = "NS"
 )
 
 // TTL is a structure defining the TTL of a DNS record
@@ -109,12 +116,89 @@ func (t Targets) IsLess(o Targets) bool {
 	return false
 }
 
+// MXTarget is the parsed target of an MX record. Targets of MX records hold the preference and
+// the mail exchange separated by a space, e.g. "10 mail.example.org".
+type MXTarget struct {
+	Preference uint16
+	Exchange   string
+}
+
+// NewMXTarget returns the target of an MX record with the given preference and mail exchange
+func NewMXTarget(preference uint16, exchange string) string {
+	return MXTarget{Preference: preference, Exchange: exchange}.String()
+}
+
+// ParseMXTarget parses the target of an MX record
+func ParseMXTarget(target string) (MXTarget, error) {
+	fields := strings.Fields(target)
+	if len(fields) != 2 {
+		return MXTarget{}, fmt.Errorf("invalid MX target %q: expected preference and mail exchange", target)
+	}
+	preference, err := strconv.ParseUint(fields[0], 10, 16)
+	if err != nil {
+		return MXTarget{}, fmt.Errorf("invalid MX target %q: invalid preference: %v", target, err)
+	}
+	return MXTarget{Preference: uint16(preference), Exchange: strings.TrimSuffix(fields[1], ".")}, nil
+}
+
+func (t MXTarget) String() string {
+	return fmt.Sprintf("%d %s", t.Preference, t.Exchange)
+}
+
+// SRVTarget is the parsed target of an SRV record. Targets of SRV records hold the priority, the
+// weight, the port and the target host separated by spaces, e.g. "10 5 5060 sip.example.org".
+type SRVTarget struct {
+	Priority uint16
+	Weight   uint16
+	Port     uint16
+	Target   string
+}
+
+// NewSRVTarget returns the target of an SRV record with the given priority, weight, port and target host
+func NewSRVTarget(priority, weight, port uint16, target string) string {
+	return SRVTarget{Priority: priority, Weight: weight, Port: port, Target: target}.String()
+}
+
+// ParseSRVTarget parses the target of an SRV record
+func ParseSRVTarget(target string) (SRVTarget, error) {
+	fields := strings.Fields(target)
+	if len(fields) != 4 {
+		return SRVTarget{}, fmt.Errorf("invalid SRV target %q: expected priority, weight, port and target", target)
+	}
+	var numbers [3]uint16
+	for i, name := range []string{"priority", "weight", "port"} {
+		n, err := strconv.ParseUint(fields[i], 10, 16)
+		if err != nil {
+			return SRVTarget{}, fmt.Errorf("invalid SRV target %q: invalid %s: %v", target, name, err)
+		}
+		numbers[i] = uint16(n)
+	}
+	return SRVTarget{Priority: numbers[0], Weight: numbers[1], Port: numbers[2], Target: strings.TrimSuffix(fields[3], ".")}, nil
+}
+
+func (t SRVTarget) String() string {
+	return fmt.Sprintf("%d %d %d %s", t.Priority, t.Weight, t.Port, t.Target)
+}
+
 // ProviderSpecificProperty holds the name and value of a configuration which is specific to individual DNS providers
 type ProviderSpecificProperty struct {
 	Name  string `json:"name,omitempty"`
 	Value string `json:"value,omitempty"`
 }
 
+// ProviderSpecificTargetWeightPrefix prefixes the names of provider specific properties holding
+// the weight of a single target, e.g. "weight/10.0.0.1" with the value "10", for providers
+// supporting weighted answers
+const ProviderSpecificTargetWeightPrefix = "weight/"
+
+// ProviderSpecificClass names the provider specific property holding the DNS class of a record
+// other than IN, e.g. "CH", for providers supporting other classes
+const ProviderSpecificClass = "class"
+
+// ProviderSpecificProvider names the provider specific property holding the name of the DNS
+// provider a record is routed to, e.g. "cloudflare", see provider.RoutingProvider
+const ProviderSpecificProvider = "provider"
+
 // ProviderSpecific holds configuration which is specific to individual DNS providers
 type ProviderSpecific []ProviderSpecificProperty
 
@@ -203,6 +287,30 @@ type DNSEndpointStatus struct {
 	// The generation observed by the external-dns controller.
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The results of applying the endpoints of the spec.
+	// +optional
+	Endpoints []DNSEndpointRecordStatus `json:"endpoints,omitempty"`
+}
+
+const (
+	// DNSEndpointRecordApplied is the status of an endpoint without changes failing to be applied
+	DNSEndpointRecordApplied = "Applied"
+	// DNSEndpointRecordFailed is the status of an endpoint whose changes failed to be applied
+	DNSEndpointRecordFailed = "Failed"
+	// DNSEndpointRecordInvalid is the status of an endpoint rejected by the source
+	DNSEndpointRecordInvalid = "Invalid"
+)
+
+// DNSEndpointRecordStatus is the result of applying an endpoint of a DNSEndpoint.
+type DNSEndpointRecordStatus struct {
+	DNSName       string `json:"dnsName"`
+	RecordType    string `json:"recordType,omitempty"`
+	SetIdentifier string `json:"setIdentifier,omitempty"`
+	// Applied, Failed or Invalid
+	Status string `json:"status"`
+	// The error of the last failure, if any
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // +genclient
@@ -75,3 +75,55 @@ func TestSameFailures(t *testing.T) {
 		}
 	}
 }
+
+func TestMXTarget(t *testing.T) {
+	if target := NewMXTarget(10, "mail.example.org"); target != "10 mail.example.org" {
+		t.Errorf("unexpected MX target %q", target)
+	}
+
+	for target, expected := range map[string]MXTarget{
+		"10 mail.example.org":      {Preference: 10, Exchange: "mail.example.org"},
+		"0  mail.example.org.":     {Preference: 0, Exchange: "mail.example.org"},
+		"65535 backup.example.org": {Preference: 65535, Exchange: "backup.example.org"},
+	} {
+		mx, err := ParseMXTarget(target)
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %v", target, err)
+		}
+		if mx != expected {
+			t.Errorf("expected %#v parsing %q, got %#v", expected, target, mx)
+		}
+	}
+
+	for _, target := range []string{"mail.example.org", "10", "-1 mail.example.org", "65536 mail.example.org", "10 mail example"} {
+		if _, err := ParseMXTarget(target); err == nil {
+			t.Errorf("expected error parsing %q", target)
+		}
+	}
+}
+
+func TestSRVTarget(t *testing.T) {
+	if target := NewSRVTarget(10, 5, 5060, "sip.example.org"); target != "10 5 5060 sip.example.org" {
+		t.Errorf("unexpected SRV target %q", target)
+	}
+
+	for target, expected := range map[string]SRVTarget{
+		"10 5 5060 sip.example.org":         {Priority: 10, Weight: 5, Port: 5060, Target: "sip.example.org"},
+		"0  0 443  sip.example.org.":        {Priority: 0, Weight: 0, Port: 443, Target: "sip.example.org"},
+		"65535 65535 65535 sip.example.org": {Priority: 65535, Weight: 65535, Port: 65535, Target: "sip.example.org"},
+	} {
+		srv, err := ParseSRVTarget(target)
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %v", target, err)
+		}
+		if srv != expected {
+			t.Errorf("expected %#v parsing %q, got %#v", expected, target, srv)
+		}
+	}
+
+	for _, target := range []string{"sip.example.org", "10 5 5060", "10 5 5060 sip example", "-1 5 5060 sip.example.org", "10 65536 5060 sip.example.org", "10 5 port sip.example.org"} {
+		if _, err := ParseSRVTarget(target); err == nil {
+			t.Errorf("expected error parsing %q", target)
+		}
+	}
+}
@@ -41,6 +41,15 @@ const (
 
 	// DualstackLabelKey is the name of the label that identifies dualstack endpoints
 	DualstackLabelKey = "dualstack"
+
+	// TXTEncryptionKeyLabel label responsible for storing which key the TXT record of an endpoint was encrypted with,
+	// if not the current one, supposed to be inserted and consumed by the TXT Registry to reconstruct the TXT record
+	TXTEncryptionKeyLabel = "txt-encryption-key"
+
+	// CreatedByLabelKey is the name of the label that identifies the instance of ExternalDNS which created the record
+	CreatedByLabelKey = "created-by"
+	// TimestampLabelKey is the name of the label that holds the time of the last change of the record
+	TimestampLabelKey = "timestamp"
 )
 
 // Labels store metadata related to the endpoint
@@ -0,0 +1,54 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// idnaProfile converts internationalized labels of names as done for lookups, e.g. by browsers.
+var idnaProfile = idna.New(idna.MapForLookup(), idna.BidiRule(), idna.Transitional(false))
+
+// ToPunycode converts the internationalized labels of a name to punycode, e.g. "bücher.example.org"
+// to "xn--bcher-kva.example.org", and checks that labels already in punycode are valid. Other
+// labels are kept as they are, so names may still contain e.g. underscores or wildcards.
+func ToPunycode(name string) (string, error) {
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		switch {
+		case !isASCII(label):
+			ascii, err := idnaProfile.ToASCII(label)
+			if err != nil {
+				return "", err
+			}
+			labels[i] = ascii
+		case strings.HasPrefix(strings.ToLower(label), "xn--"):
+			if _, err := idnaProfile.ToUnicode(label); err != nil {
+				return "", err
+			}
+		}
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// isASCII returns whether a name has no internationalized labels.
+func isASCII(name string) bool {
+	return utf8.RuneCountInString(name) == len(name)
+}
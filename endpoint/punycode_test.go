@@ -0,0 +1,50 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToPunycode(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		expected string
+		invalid  bool
+	}{
+		{name: "example.org", expected: "example.org"},
+		{name: "_acme-challenge.*.example.org", expected: "_acme-challenge.*.example.org"},
+		{name: "bücher.example.org", expected: "xn--bcher-kva.example.org"},
+		{name: "www.BÜCHER.example.org", expected: "www.xn--bcher-kva.example.org"},
+		{name: "xn--bcher-kva.example.org", expected: "xn--bcher-kva.example.org"},
+		{name: "日本語.jp", expected: "xn--wgv71a119e.jp"},
+		{name: "bü_cher.example.org", invalid: true},
+		{name: "xn--a.example.org", invalid: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			name, err := ToPunycode(tt.name)
+			if tt.invalid {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, name)
+		})
+	}
+}
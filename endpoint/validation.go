@@ -0,0 +1,146 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+const (
+	// ValidationReasonName rejects endpoints with invalid DNS names
+	ValidationReasonName = "name"
+	// ValidationReasonTargets rejects endpoints with missing, invalid or too many targets
+	ValidationReasonTargets = "targets"
+	// ValidationReasonTTL rejects endpoints with a TTL out of bounds
+	ValidationReasonTTL = "ttl"
+)
+
+// labelRegexp matches a label of a DNS name after RFC 1123, also allowing underscores
+// for names like _service._tcp.example.org.
+var labelRegexp = regexp.MustCompile(`^[a-zA-Z0-9_]([-a-zA-Z0-9_]{0,61}[a-zA-Z0-9_])?$`)
+
+// ValidationError is the reason an endpoint was rejected.
+type ValidationError struct {
+	Reason  string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// ValidationRules are the limits endpoints are validated against. Zero values disable a limit.
+type ValidationRules struct {
+	// The maximum number of targets of an endpoint
+	MaxTargets int
+	// The minimum TTL of endpoints with a configured TTL
+	MinTTL TTL
+	// The maximum TTL of endpoints with a configured TTL
+	MaxTTL TTL
+}
+
+// Validate returns a *ValidationError if the endpoint can't be published: if its DNS name
+// isn't a valid name after RFC 1123, if its targets don't suit its record type or exceed
+// the maximum number of targets, or if its TTL is out of bounds.
+func (r ValidationRules) Validate(ep *Endpoint) error {
+	if err := validateName(ep.DNSName, true); err != nil {
+		return &ValidationError{Reason: ValidationReasonName, Message: fmt.Sprintf("invalid name %q: %v", ep.DNSName, err)}
+	}
+
+	if len(ep.Targets) == 0 {
+		return &ValidationError{Reason: ValidationReasonTargets, Message: fmt.Sprintf("%s has no targets", ep.DNSName)}
+	}
+	if r.MaxTargets > 0 && len(ep.Targets) > r.MaxTargets {
+		return &ValidationError{Reason: ValidationReasonTargets, Message: fmt.Sprintf("%s has %d targets, more than the maximum of %d", ep.DNSName, len(ep.Targets), r.MaxTargets)}
+	}
+	if ep.RecordType == RecordTypeCNAME && len(ep.Targets) > 1 {
+		return &ValidationError{Reason: ValidationReasonTargets, Message: fmt.Sprintf("CNAME %s has %d targets", ep.DNSName, len(ep.Targets))}
+	}
+	for _, target := range ep.Targets {
+		if err := validateTarget(ep.RecordType, target); err != nil {
+			return &ValidationError{Reason: ValidationReasonTargets, Message: fmt.Sprintf("%s %s has invalid target %q: %v", ep.RecordType, ep.DNSName, target, err)}
+		}
+	}
+
+	if ep.RecordTTL.IsConfigured() {
+		if r.MinTTL.IsConfigured() && ep.RecordTTL < r.MinTTL {
+			return &ValidationError{Reason: ValidationReasonTTL, Message: fmt.Sprintf("%s has TTL %d, less than the minimum of %d", ep.DNSName, ep.RecordTTL, r.MinTTL)}
+		}
+		if r.MaxTTL.IsConfigured() && ep.RecordTTL > r.MaxTTL {
+			return &ValidationError{Reason: ValidationReasonTTL, Message: fmt.Sprintf("%s has TTL %d, more than the maximum of %d", ep.DNSName, ep.RecordTTL, r.MaxTTL)}
+		}
+	}
+	return nil
+}
+
+func validateTarget(recordType, target string) error {
+	switch recordType {
+	case RecordTypeA:
+		if ip := net.ParseIP(target); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("not an IPv4 address")
+		}
+	case "AAAA":
+		if ip := net.ParseIP(target); ip == nil || ip.To4() != nil {
+			return fmt.Errorf("not an IPv6 address")
+		}
+	case RecordTypeCNAME, RecordTypeNS:
+		return validateName(target, false)
+	case RecordTypeMX:
+		mx, err := ParseMXTarget(target)
+		if err != nil {
+			return err
+		}
+		return validateName(mx.Exchange, false)
+	case RecordTypeSRV:
+		srv, err := ParseSRVTarget(target)
+		if err != nil {
+			return err
+		}
+		return validateName(srv.Target, false)
+	case RecordTypeTXT:
+		// TXT records hold arbitrary text
+	default:
+		if target == "" {
+			return fmt.Errorf("empty target")
+		}
+	}
+	return nil
+}
+
+// validateName validates a DNS name after RFC 1123. Wildcards are only allowed as first
+// label of record names.
+func validateName(name string, allowWildcard bool) error {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return fmt.Errorf("empty name")
+	}
+	if len(name) > 253 {
+		return fmt.Errorf("longer than 253 characters")
+	}
+	for i, label := range strings.Split(name, ".") {
+		if label == "*" && i == 0 && allowWildcard {
+			continue
+		}
+		if !labelRegexp.MatchString(label) {
+			return fmt.Errorf("invalid label %q", label)
+		}
+	}
+	return nil
+}
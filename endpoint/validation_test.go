@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidationRulesValidate(t *testing.T) {
+	rules := ValidationRules{MaxTargets: 2, MinTTL: 60, MaxTTL: 3600}
+
+	for _, tc := range []struct {
+		title  string
+		ep     *Endpoint
+		reason string
+	}{
+		{title: "A record", ep: NewEndpoint("foo.example.org", RecordTypeA, "10.0.0.1", "10.0.0.2")},
+		{title: "wildcard", ep: NewEndpoint("*.example.org.", RecordTypeCNAME, "lb.example.org.")},
+		{title: "SRV name", ep: NewEndpoint("_sip._tcp.example.org", RecordTypeSRV, "10 5 5060 sip.example.org")},
+		{title: "TXT record", ep: NewEndpoint("foo.example.org", RecordTypeTXT, "heritage=external-dns,external-dns/owner=default")},
+		{title: "AAAA record", ep: NewEndpoint("foo.example.org", "AAAA", "2001:db8::1")},
+		{title: "NS delegation", ep: NewEndpoint("lab.example.org", RecordTypeNS, "ns1.example.net", "ns2.example.net")},
+		{title: "MX record", ep: NewEndpoint("example.org", RecordTypeMX, "10 mail.example.org")},
+		{title: "TTL within bounds", ep: NewEndpointWithTTL("foo.example.org", RecordTypeA, 60, "10.0.0.1")},
+		{title: "empty name", ep: NewEndpoint("", RecordTypeA, "10.0.0.1"), reason: ValidationReasonName},
+		{title: "invalid characters", ep: NewEndpoint("foo bar.example.org", RecordTypeA, "10.0.0.1"), reason: ValidationReasonName},
+		{title: "empty label", ep: NewEndpoint("foo..example.org", RecordTypeA, "10.0.0.1"), reason: ValidationReasonName},
+		{title: "hyphen at the end of a label", ep: NewEndpoint("foo-.example.org", RecordTypeA, "10.0.0.1"), reason: ValidationReasonName},
+		{title: "label too long", ep: NewEndpoint(strings.Repeat("a", 64)+".example.org", RecordTypeA, "10.0.0.1"), reason: ValidationReasonName},
+		{title: "wildcard in the middle", ep: NewEndpoint("foo.*.example.org", RecordTypeA, "10.0.0.1"), reason: ValidationReasonName},
+		{title: "no targets", ep: NewEndpoint("foo.example.org", RecordTypeA), reason: ValidationReasonTargets},
+		{title: "too many targets", ep: NewEndpoint("foo.example.org", RecordTypeA, "10.0.0.1", "10.0.0.2", "10.0.0.3"), reason: ValidationReasonTargets},
+		{title: "hostname in A record", ep: NewEndpoint("foo.example.org", RecordTypeA, "lb.example.org"), reason: ValidationReasonTargets},
+		{title: "IPv6 in A record", ep: NewEndpoint("foo.example.org", RecordTypeA, "2001:db8::1"), reason: ValidationReasonTargets},
+		{title: "IPv4 in AAAA record", ep: NewEndpoint("foo.example.org", "AAAA", "10.0.0.1"), reason: ValidationReasonTargets},
+		{title: "several CNAME targets", ep: NewEndpoint("foo.example.org", RecordTypeCNAME, "a.example.org", "b.example.org"), reason: ValidationReasonTargets},
+		{title: "invalid CNAME target", ep: NewEndpoint("foo.example.org", RecordTypeCNAME, "lb_example org"), reason: ValidationReasonTargets},
+		{title: "invalid MX target", ep: NewEndpoint("example.org", RecordTypeMX, "mail.example.org"), reason: ValidationReasonTargets},
+		{title: "invalid NS target", ep: NewEndpoint("lab.example.org", RecordTypeNS, "ns1 example.net"), reason: ValidationReasonTargets},
+		{title: "invalid SRV target", ep: NewEndpoint("_sip._tcp.example.org", RecordTypeSRV, "10 5 sip.example.org"), reason: ValidationReasonTargets},
+		{title: "invalid SRV port", ep: NewEndpoint("_sip._tcp.example.org", RecordTypeSRV, "10 5 65536 sip.example.org"), reason: ValidationReasonTargets},
+		{title: "TTL too low", ep: NewEndpointWithTTL("foo.example.org", RecordTypeA, 30, "10.0.0.1"), reason: ValidationReasonTTL},
+		{title: "TTL too high", ep: NewEndpointWithTTL("foo.example.org", RecordTypeA, 7200, "10.0.0.1"), reason: ValidationReasonTTL},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			err := rules.Validate(tc.ep)
+			if tc.reason == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			verr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("expected validation error, got %v", err)
+			}
+			if verr.Reason != tc.reason {
+				t.Errorf("expected reason %s, got %s: %v", tc.reason, verr.Reason, verr)
+			}
+		})
+	}
+
+	// Zero values disable the limits.
+	if err := (ValidationRules{}).Validate(NewEndpointWithTTL("foo.example.org", RecordTypeA, 1, "10.0.0.1", "10.0.0.2", "10.0.0.3")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
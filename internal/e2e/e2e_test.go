@@ -0,0 +1,383 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// step is a synchronization of a scenario.
+type step struct {
+	// The endpoints document written before synchronizing, or empty to keep the last one
+	endpoints string
+	// The changes applied to the provider behind the back of the controller before synchronizing
+	drift *plan.Changes
+	// The changes the synchronization applies, in the text format of plan.WriteDiff
+	diff string
+	// The records of the provider after synchronizing, in the format of controller.WriteZoneState
+	zone string
+}
+
+const (
+	ownerTXT = `"heritage=external-dns,external-dns/owner=default"`
+
+	initialEndpoints = `endpoints:
+  - dnsName: a.example.org
+    targets: [10.0.0.1]
+    recordTTL: 300
+  - dnsName: b.example.org
+    recordType: CNAME
+    targets: [lb.example.com]
+`
+	initialDiff = "+ a.example.org A 10.0.0.1 ttl=300\n" +
+		"+ a.example.org TXT " + ownerTXT + " ttl=0\n" +
+		"+ b.example.org CNAME lb.example.com ttl=0\n" +
+		"+ b.example.org TXT " + ownerTXT + " ttl=0\n"
+	initialZone = "a.example.org 300 IN A 10.0.0.1\n" +
+		"a.example.org 0 IN TXT " + ownerTXT + "\n" +
+		"b.example.org 0 IN CNAME lb.example.com\n" +
+		"b.example.org 0 IN TXT " + ownerTXT + "\n"
+)
+
+var scenarios = map[string][]step{
+	"Create": {
+		{endpoints: initialEndpoints, diff: initialDiff, zone: initialZone},
+		// Synchronizing again changes nothing.
+		{zone: initialZone},
+	},
+	"UpdateTargets": {
+		{endpoints: initialEndpoints, diff: initialDiff, zone: initialZone},
+		{
+			endpoints: `endpoints:
+  - dnsName: a.example.org
+    targets: [10.0.0.2]
+    recordTTL: 300
+  - dnsName: b.example.org
+    recordType: CNAME
+    targets: [lb2.example.com]
+`,
+			diff: "~ a.example.org A 10.0.0.1 -> 10.0.0.2 ttl=300 -> 300\n" +
+				"~ a.example.org TXT " + ownerTXT + " -> " + ownerTXT + " ttl=0 -> 0\n" +
+				"~ b.example.org CNAME lb.example.com -> lb2.example.com ttl=0 -> 0\n" +
+				"~ b.example.org TXT " + ownerTXT + " -> " + ownerTXT + " ttl=0 -> 0\n",
+			zone: "a.example.org 300 IN A 10.0.0.2\n" +
+				"a.example.org 0 IN TXT " + ownerTXT + "\n" +
+				"b.example.org 0 IN CNAME lb2.example.com\n" +
+				"b.example.org 0 IN TXT " + ownerTXT + "\n",
+		},
+	},
+	"ChangeTTL": {
+		{endpoints: initialEndpoints, diff: initialDiff, zone: initialZone},
+		{
+			endpoints: `endpoints:
+  - dnsName: a.example.org
+    targets: [10.0.0.1]
+    recordTTL: 3600
+  - dnsName: b.example.org
+    recordType: CNAME
+    targets: [lb.example.com]
+`,
+			diff: "~ a.example.org A 10.0.0.1 -> 10.0.0.1 ttl=300 -> 3600\n" +
+				"~ a.example.org TXT " + ownerTXT + " -> " + ownerTXT + " ttl=0 -> 0\n",
+			zone: "a.example.org 3600 IN A 10.0.0.1\n" +
+				"a.example.org 0 IN TXT " + ownerTXT + "\n" +
+				"b.example.org 0 IN CNAME lb.example.com\n" +
+				"b.example.org 0 IN TXT " + ownerTXT + "\n",
+		},
+	},
+	"Delete": {
+		{endpoints: initialEndpoints, diff: initialDiff, zone: initialZone},
+		{
+			endpoints: `endpoints:
+  - dnsName: a.example.org
+    targets: [10.0.0.1]
+    recordTTL: 300
+`,
+			diff: "- b.example.org CNAME lb.example.com ttl=0\n" +
+				"- b.example.org TXT " + ownerTXT + " ttl=0\n",
+			zone: "a.example.org 300 IN A 10.0.0.1\n" +
+				"a.example.org 0 IN TXT " + ownerTXT + "\n",
+		},
+	},
+	"Drift": {
+		{endpoints: initialEndpoints, diff: initialDiff, zone: initialZone},
+		// Records changed behind the back of the controller are restored.
+		{
+			drift: &plan.Changes{
+				UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("a.example.org", endpoint.RecordTypeA, 300, "10.0.0.1")},
+				UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("a.example.org", endpoint.RecordTypeA, 60, "10.0.0.9")},
+			},
+			diff: "~ a.example.org A 10.0.0.9 -> 10.0.0.1 ttl=60 -> 300\n" +
+				"~ a.example.org TXT " + ownerTXT + " -> " + ownerTXT + " ttl=0 -> 0\n",
+			zone: initialZone,
+		},
+	},
+	"Delegation": {
+		{
+			endpoints: `endpoints:
+  - dnsName: example.org
+    recordType: NS
+    targets: [ns1.example.net, ns2.example.net]
+  - dnsName: team.example.org
+    recordType: NS
+    targets: [ns1.team.example.net]
+`,
+			diff: "+ example.org NS ns1.example.net;ns2.example.net ttl=0\n" +
+				"+ example.org TXT " + ownerTXT + " ttl=0\n" +
+				"+ team.example.org NS ns1.team.example.net ttl=0\n" +
+				"+ team.example.org TXT " + ownerTXT + " ttl=0\n",
+			zone: "example.org 0 IN NS ns1.example.net\n" +
+				"example.org 0 IN TXT " + ownerTXT + "\n" +
+				"team.example.org 0 IN NS ns1.team.example.net\n" +
+				"team.example.org 0 IN TXT " + ownerTXT + "\n",
+		},
+		// Delegations are deleted, while the NS records of the apex are kept.
+		{
+			endpoints: "endpoints: []\n",
+			diff: "- team.example.org NS ns1.team.example.net ttl=0\n" +
+				"- team.example.org TXT " + ownerTXT + " ttl=0\n",
+			zone: "example.org 0 IN NS ns1.example.net\n" +
+				"example.org 0 IN TXT " + ownerTXT + "\n",
+		},
+	},
+	"Wildcard": {
+		// Wildcards are records of their own, next to the records of their parent.
+		{
+			endpoints: `endpoints:
+  - dnsName: "*.apps.example.org"
+    targets: [10.0.0.1]
+  - dnsName: apps.example.org
+    targets: [10.0.0.2]
+`,
+			diff: "+ *.apps.example.org A 10.0.0.1 ttl=0\n" +
+				"+ *.apps.example.org TXT " + ownerTXT + " ttl=0\n" +
+				"+ apps.example.org A 10.0.0.2 ttl=0\n" +
+				"+ apps.example.org TXT " + ownerTXT + " ttl=0\n",
+			zone: "*.apps.example.org 0 IN A 10.0.0.1\n" +
+				"*.apps.example.org 0 IN TXT " + ownerTXT + "\n" +
+				"apps.example.org 0 IN A 10.0.0.2\n" +
+				"apps.example.org 0 IN TXT " + ownerTXT + "\n",
+		},
+		{
+			endpoints: `endpoints:
+  - dnsName: "*.apps.example.org"
+    targets: [10.0.0.3]
+  - dnsName: apps.example.org
+    targets: [10.0.0.2]
+`,
+			diff: "~ *.apps.example.org A 10.0.0.1 -> 10.0.0.3 ttl=0 -> 0\n" +
+				"~ *.apps.example.org TXT " + ownerTXT + " -> " + ownerTXT + " ttl=0 -> 0\n",
+			zone: "*.apps.example.org 0 IN A 10.0.0.3\n" +
+				"*.apps.example.org 0 IN TXT " + ownerTXT + "\n" +
+				"apps.example.org 0 IN A 10.0.0.2\n" +
+				"apps.example.org 0 IN TXT " + ownerTXT + "\n",
+		},
+		// Deleting the wildcard keeps the record of its parent.
+		{
+			endpoints: `endpoints:
+  - dnsName: apps.example.org
+    targets: [10.0.0.2]
+`,
+			diff: "- *.apps.example.org A 10.0.0.3 ttl=0\n" +
+				"- *.apps.example.org TXT " + ownerTXT + " ttl=0\n",
+			zone: "apps.example.org 0 IN A 10.0.0.2\n" +
+				"apps.example.org 0 IN TXT " + ownerTXT + "\n",
+		},
+	},
+	"MX": {
+		// The A and MX records of a name share their TXT record.
+		{
+			endpoints: `endpoints:
+  - dnsName: example.org
+    targets: [10.0.0.1]
+  - dnsName: example.org
+    recordType: MX
+    targets: ["10 mail.example.org"]
+`,
+			diff: "+ example.org A 10.0.0.1 ttl=0\n" +
+				"+ example.org MX 10 mail.example.org ttl=0\n" +
+				"+ example.org TXT " + ownerTXT + " ttl=0\n",
+			zone: "example.org 0 IN A 10.0.0.1\n" +
+				"example.org 0 IN MX 10 mail.example.org\n" +
+				"example.org 0 IN TXT " + ownerTXT + "\n",
+		},
+		{
+			endpoints: `endpoints:
+  - dnsName: example.org
+    targets: [10.0.0.1]
+  - dnsName: example.org
+    recordType: MX
+    targets: ["20 mail.example.org"]
+`,
+			diff: "~ example.org MX 10 mail.example.org -> 20 mail.example.org ttl=0 -> 0\n" +
+				"~ example.org TXT " + ownerTXT + " -> " + ownerTXT + " ttl=0 -> 0\n",
+			zone: "example.org 0 IN A 10.0.0.1\n" +
+				"example.org 0 IN MX 20 mail.example.org\n" +
+				"example.org 0 IN TXT " + ownerTXT + "\n",
+		},
+		// Deleting the MX record keeps the TXT record of the A record.
+		{
+			endpoints: `endpoints:
+  - dnsName: example.org
+    targets: [10.0.0.1]
+`,
+			diff: "- example.org MX 20 mail.example.org ttl=0\n",
+			zone: "example.org 0 IN A 10.0.0.1\n" +
+				"example.org 0 IN TXT " + ownerTXT + "\n",
+		},
+	},
+	"SRV": {
+		{
+			endpoints: `endpoints:
+  - dnsName: _sip._tcp.example.org
+    recordType: SRV
+    targets: ["10 5 5060 sip.example.org"]
+`,
+			diff: "+ _sip._tcp.example.org SRV 10 5 5060 sip.example.org ttl=0\n" +
+				"+ _sip._tcp.example.org TXT " + ownerTXT + " ttl=0\n",
+			zone: "_sip._tcp.example.org 0 IN SRV 10 5 5060 sip.example.org\n" +
+				"_sip._tcp.example.org 0 IN TXT " + ownerTXT + "\n",
+		},
+		{
+			endpoints: `endpoints:
+  - dnsName: _sip._tcp.example.org
+    recordType: SRV
+    targets: ["10 5 5061 sip.example.org"]
+`,
+			diff: "~ _sip._tcp.example.org SRV 10 5 5060 sip.example.org -> 10 5 5061 sip.example.org ttl=0 -> 0\n" +
+				"~ _sip._tcp.example.org TXT " + ownerTXT + " -> " + ownerTXT + " ttl=0 -> 0\n",
+			zone: "_sip._tcp.example.org 0 IN SRV 10 5 5061 sip.example.org\n" +
+				"_sip._tcp.example.org 0 IN TXT " + ownerTXT + "\n",
+		},
+		{
+			endpoints: "endpoints: []\n",
+			diff: "- _sip._tcp.example.org SRV 10 5 5061 sip.example.org ttl=0\n" +
+				"- _sip._tcp.example.org TXT " + ownerTXT + " ttl=0\n",
+		},
+	},
+	"NotOwned": {
+		// Records of someone else are neither updated nor deleted.
+		{
+			drift: &plan.Changes{
+				Create: []*endpoint.Endpoint{endpoint.NewEndpoint("c.example.org", endpoint.RecordTypeA, "10.0.0.3")},
+			},
+			endpoints: `endpoints:
+  - dnsName: c.example.org
+    targets: [10.0.0.4]
+`,
+			zone: "c.example.org 0 IN A 10.0.0.3\n",
+		},
+		{
+			endpoints: "endpoints: []\n",
+			zone:      "c.example.org 0 IN A 10.0.0.3\n",
+		},
+	},
+}
+
+// txtScenarios are the scenarios with TXT records, which require a prefix of the TXT records of
+// the TXT registry.
+var txtScenarios = map[string][]step{
+	"TXT": {
+		// The A and TXT records of a name share their TXT record of the registry.
+		{
+			endpoints: `endpoints:
+  - dnsName: example.org
+    targets: [10.0.0.1]
+  - dnsName: example.org
+    recordType: TXT
+    targets: ["v=spf1 -all"]
+  - dnsName: mail._domainkey.example.org
+    recordType: TXT
+    encoding: base64
+    targets: [dj1ES0lNMTsgaz1yc2E7IHA9TUlJQg==]
+`,
+			diff: "+ example.org A 10.0.0.1 ttl=0\n" +
+				"+ example.org TXT \"v=spf1 -all\" ttl=0\n" +
+				"+ mail._domainkey.example.org TXT \"v=DKIM1; k=rsa; p=MIIB\" ttl=0\n" +
+				"+ txt-example.org TXT " + ownerTXT + " ttl=0\n" +
+				"+ txt-mail._domainkey.example.org TXT " + ownerTXT + " ttl=0\n",
+			zone: "example.org 0 IN A 10.0.0.1\n" +
+				"example.org 0 IN TXT \"v=spf1 -all\"\n" +
+				"mail._domainkey.example.org 0 IN TXT \"v=DKIM1; k=rsa; p=MIIB\"\n" +
+				"txt-example.org 0 IN TXT " + ownerTXT + "\n" +
+				"txt-mail._domainkey.example.org 0 IN TXT " + ownerTXT + "\n",
+		},
+		{
+			endpoints: `endpoints:
+  - dnsName: example.org
+    targets: [10.0.0.1]
+  - dnsName: example.org
+    recordType: TXT
+    targets: ["v=spf1 mx -all"]
+`,
+			diff: "~ example.org TXT \"v=spf1 -all\" -> \"v=spf1 mx -all\" ttl=0 -> 0\n" +
+				"- mail._domainkey.example.org TXT \"v=DKIM1; k=rsa; p=MIIB\" ttl=0\n" +
+				"~ txt-example.org TXT " + ownerTXT + " -> " + ownerTXT + " ttl=0 -> 0\n" +
+				"- txt-mail._domainkey.example.org TXT " + ownerTXT + " ttl=0\n",
+			zone: "example.org 0 IN A 10.0.0.1\n" +
+				"example.org 0 IN TXT \"v=spf1 mx -all\"\n" +
+				"txt-example.org 0 IN TXT " + ownerTXT + "\n",
+		},
+	},
+}
+
+func TestScenarios(t *testing.T) {
+	for name, steps := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			testScenario(t, steps, "")
+		})
+	}
+	for name, steps := range txtScenarios {
+		t.Run(name, func(t *testing.T) {
+			testScenario(t, steps, "txt-")
+		})
+	}
+}
+
+// testScenario runs the steps of a scenario against a new Harness with the given TXT prefix.
+func testScenario(t *testing.T, steps []step, txtPrefix string) {
+	ctx := context.Background()
+	h, err := NewHarness([]string{"example.org"}, "default", txtPrefix)
+	require.NoError(t, err)
+	defer h.Close()
+
+	for i, s := range steps {
+		if s.endpoints != "" {
+			require.NoError(t, h.WriteEndpoints(s.endpoints))
+		}
+		if s.drift != nil {
+			require.NoError(t, h.Provider.ApplyChanges(ctx, s.drift))
+		}
+		changes, err := h.Sync(ctx)
+		require.NoError(t, err, "step %d", i)
+
+		var diff bytes.Buffer
+		require.NoError(t, plan.WriteDiff(&diff, changes, plan.DiffFormatText, false))
+		assert.Equal(t, s.diff, diff.String(), "step %d", i)
+		zone, err := h.ZoneState(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, s.zone, zone, "step %d", i)
+	}
+}
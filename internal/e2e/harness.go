@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package e2e runs the synchronization of ExternalDNS in-process, from an endpoints file through
+// the files source, the planner and the TXT registry to an in-memory provider, so scenarios test
+// how changes of the desired endpoints are translated into changes of the records.
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/external-dns/controller"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+	"sigs.k8s.io/external-dns/registry"
+	"sigs.k8s.io/external-dns/source"
+)
+
+// Harness synchronizes the endpoints file in a temporary directory to the zones of an in-memory
+// provider with the sync policy, as ExternalDNS with --source=files --registry=txt
+// --manage-ns-records --manage-mx-records --manage-txt-records --manage-srv-records does.
+type Harness struct {
+	// The in-memory provider holding the records, e.g. to change them behind the back of the controller
+	Provider *inmemory.InMemoryProvider
+
+	dir        string
+	path       string
+	recorder   *recordingProvider
+	controller *controller.Controller
+}
+
+// NewHarness creates a new Harness managing the given zones as the owner, with the TXT records of
+// the TXT registry prefixed by txtPrefix, as with --txt-prefix. Close removes its temporary
+// directory.
+func NewHarness(zones []string, ownerID, txtPrefix string) (*Harness, error) {
+	dir, err := ioutil.TempDir("", "external-dns-e2e")
+	if err != nil {
+		return nil, err
+	}
+	h := &Harness{dir: dir, path: filepath.Join(dir, "endpoints.yaml")}
+	if err := h.WriteEndpoints("endpoints: []\n"); err != nil {
+		h.Close()
+		return nil, err
+	}
+
+	domainFilter := endpoint.NewDomainFilter(zones)
+	src, err := source.NewFilesSource([]string{h.path}, source.FilesConflictFail, domainFilter, 0, true)
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.Provider = inmemory.NewInMemoryProvider(inmemory.InMemoryInitZones(zones), inmemory.InMemoryWithDomain(domainFilter))
+	h.recorder = &recordingProvider{Provider: h.Provider}
+	r, err := registry.NewTXTRegistry(h.recorder, txtPrefix, "", ownerID, 0, "", nil, "")
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.controller = &controller.Controller{
+		Source:           source.NewDedupSource(src),
+		Registry:         r,
+		Policy:           plan.Policies["sync"],
+		DomainFilter:     domainFilter,
+		ManageNSRecords:  true,
+		ManageMXRecords:  true,
+		ManageTXTRecords: true,
+		ManageSRVRecords: true,
+	}
+	return h, nil
+}
+
+// WriteEndpoints replaces the endpoints file with the given endpoints document.
+func (h *Harness) WriteEndpoints(document string) error {
+	return ioutil.WriteFile(h.path, []byte(document), 0644)
+}
+
+// Sync runs a synchronization and returns the changes applied to the provider, which include the
+// records of the TXT registry.
+func (h *Harness) Sync(ctx context.Context) (*plan.Changes, error) {
+	h.recorder.changes = &plan.Changes{}
+	err := h.controller.RunOnce(ctx)
+	return h.recorder.changes, err
+}
+
+// ZoneState returns the records of the provider in the format of controller.WriteZoneState.
+func (h *Harness) ZoneState(ctx context.Context) (string, error) {
+	records, err := h.Provider.Records(ctx)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := controller.WriteZoneState(&buf, records); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Close removes the temporary directory of the endpoints file.
+func (h *Harness) Close() error {
+	return os.RemoveAll(h.dir)
+}
+
+// recordingProvider is a Provider recording the changes applied to the wrapped provider.
+type recordingProvider struct {
+	provider.Provider
+	changes *plan.Changes
+}
+
+func (p *recordingProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	p.changes = changes
+	return p.Provider.ApplyChanges(ctx, changes)
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"os/signal"
@@ -26,6 +29,10 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh/terminal"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"sigs.k8s.io/external-dns/provider/akamai"
@@ -57,8 +64,12 @@ import (
 
 	"sigs.k8s.io/external-dns/controller"
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/admission"
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns/validation"
+	"sigs.k8s.io/external-dns/pkg/health"
+	"sigs.k8s.io/external-dns/pkg/secrets"
+	"sigs.k8s.io/external-dns/pkg/tracing"
 	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
 	"sigs.k8s.io/external-dns/registry"
@@ -70,6 +81,20 @@ func main() {
 	if err := cfg.ParseFlags(os.Args[1:]); err != nil {
 		log.Fatalf("flag parsing error: %v", err)
 	}
+	// Sensitive flags may reference the secrets of secret managers instead of holding them.
+	secretResolver := secrets.NewCloudResolver()
+	resolvedSecrets := map[string]string{}
+	err := cfg.ResolveSecrets(func(value string) (string, error) {
+		if !secretResolver.IsReference(value) {
+			return value, nil
+		}
+		secret, err := secretResolver.Resolve(context.Background(), value)
+		resolvedSecrets[value] = secret
+		return secret, err
+	})
+	if err != nil {
+		log.Fatalf("failed to resolve secrets: %v", err)
+	}
 	log.Infof("config: %s", cfg)
 
 	if err := validation.ValidateConfig(cfg); err != nil {
@@ -91,8 +116,45 @@ func main() {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	go serveMetrics(cfg.MetricsAddress)
+	flushTraces := func() {}
+	if cfg.TracingZipkinURL != "" {
+		shutdown := tracing.Setup(cfg.TracingZipkinURL)
+		flushTraces = func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := shutdown(ctx); err != nil {
+				log.Errorf("Failed to export traces: %v", err)
+			}
+		}
+	}
+
+	// Checks are added to the readiness checker once the components they verify exist.
+	readiness := health.NewChecker()
+	go serveMetrics(cfg.MetricsAddress, readiness)
 	go handleSigterm(cancel)
+	if cfg.SecretsRefreshInterval > 0 && len(resolvedSecrets) > 0 {
+		go secretResolver.Watch(ctx, resolvedSecrets, cfg.SecretsRefreshInterval, func(reference string) {
+			log.Infof("Secret %s changed. Terminating to be restarted with it...", reference)
+			cancel()
+		}, func(err error) {
+			log.Warnf("Failed to refresh secrets: %v", err)
+		})
+	}
+
+	domainFilter := endpoint.NewDomainFilterWithExclusions(cfg.DomainFilter, cfg.ExcludeDomains)
+
+	if cfg.MigrateFrom != "" {
+		migrate(ctx, cfg, domainFilter)
+		os.Exit(0)
+	}
+	if cfg.Backup != "" {
+		backup(ctx, cfg, domainFilter)
+		os.Exit(0)
+	}
+	if cfg.Restore != "" {
+		restore(ctx, cfg, domainFilter)
+		os.Exit(0)
+	}
 
 	// Create a source.Config from the flags passed by the user.
 	sourceCfg := &source.Config{
@@ -118,6 +180,91 @@ func main() {
 		ContourLoadBalancerService:     cfg.ContourLoadBalancerService,
 		SkipperRouteGroupVersion:       cfg.SkipperRouteGroupVersion,
 		RequestTimeout:                 cfg.RequestTimeout,
+		RedisAddress:                   cfg.RedisSourceAddress,
+		RedisPassword:                  cfg.RedisSourcePassword,
+		RedisDB:                        cfg.RedisSourceDB,
+		RedisKey:                       cfg.RedisSourceKey,
+		RedisKeyspaceNotifications:     cfg.RedisSourceNotifications,
+		HTTPSourceURL:                  cfg.HTTPSourceURL,
+		HTTPSourceHeaders:              cfg.HTTPSourceHeaders,
+		HTTPSourcePollInterval:         cfg.HTTPSourcePollInterval,
+		HTTPSourceStaleWhileError:      cfg.HTTPSourceStaleWhileError,
+		PluginSocket:                   cfg.PluginSourceSocket,
+		LeaseSourcePath:                cfg.LeaseSourcePath,
+		LeaseSourceFormat:              cfg.LeaseSourceFormat,
+		LeaseSourceDomain:              cfg.LeaseSourceDomain,
+		LeaseSourceMaxTTL:              cfg.LeaseSourceMaxTTL,
+		KeaSourceURL:                   cfg.KeaSourceURL,
+		KeaSourceSubnets:               cfg.KeaSourceSubnets,
+		KeaSourceHostnameFilter:        cfg.KeaSourceHostnameFilter,
+		LibvirtSourceURI:               cfg.LibvirtSourceURI,
+		LibvirtSourceAddressSource:     cfg.LibvirtSourceAddressSource,
+		LibvirtSourceDomain:            cfg.LibvirtSourceDomain,
+		MQTTBroker:                     cfg.MQTTSourceBroker,
+		MQTTTopic:                      cfg.MQTTSourceTopic,
+		MQTTClientID:                   cfg.MQTTSourceClientID,
+		MQTTUsername:                   cfg.MQTTSourceUsername,
+		MQTTPassword:                   cfg.MQTTSourcePassword,
+		MQTTExpiry:                     cfg.MQTTSourceExpiry,
+		MQTTStateFile:                  cfg.MQTTSourceStateFile,
+		SFTPAddress:                    cfg.SFTPSourceAddress,
+		SFTPUser:                       cfg.SFTPSourceUser,
+		SFTPKeyFile:                    cfg.SFTPSourceKeyFile,
+		SFTPHostKey:                    cfg.SFTPSourceHostKey,
+		SFTPPath:                       cfg.SFTPSourcePath,
+		S3Endpoint:                     cfg.S3SourceEndpoint,
+		S3Region:                       cfg.S3SourceRegion,
+		S3Bucket:                       cfg.S3SourceBucket,
+		S3Key:                          cfg.S3SourceKey,
+		S3AccessKeyID:                  cfg.S3SourceAccessKeyID,
+		S3SecretAccessKey:              cfg.S3SourceSecretAccessKey,
+		VaultAddress:                   cfg.VaultSourceAddress,
+		VaultPaths:                     cfg.VaultSourcePaths,
+		VaultToken:                     cfg.VaultSourceToken,
+		VaultRoleID:                    cfg.VaultSourceRoleID,
+		VaultSecretID:                  cfg.VaultSourceSecretID,
+		NeighborTable:                  cfg.NeighborSourceTable,
+		NeighborSubnets:                cfg.NeighborSourceSubnets,
+		NeighborMACNames:               cfg.NeighborSourceMACNames,
+		NeighborDomain:                 cfg.NeighborSourceDomain,
+		MDNSServices:                   cfg.MDNSSourceServices,
+		MDNSDomain:                     cfg.MDNSSourceDomain,
+		MDNSTimeout:                    cfg.MDNSSourceTimeout,
+		SNMPTargets:                    cfg.SNMPSourceTargets,
+		SNMPCommunity:                  cfg.SNMPSourceCommunity,
+		SNMPDomain:                     cfg.SNMPSourceDomain,
+		FilesPaths:                     cfg.FilesSourcePaths,
+		FilesConflict:                  cfg.FilesSourceConflict,
+		FilesDomainFilter:              domainFilter,
+		FilesPollInterval:              cfg.FilesSourcePollInterval,
+		FilesStrict:                    cfg.FilesSourceStrict,
+		FilesMaxChange:                 cfg.FilesSourceMaxChange,
+		FilesConfirmAfter:              cfg.FilesSourceConfirmAfter,
+		FilesForceFile:                 cfg.FilesSourceForceFile,
+		FilesDeltaDir:                  cfg.FilesSourceDeltaDir,
+		FilesDeltaBase:                 cfg.FilesSourceDeltaBase,
+		FilesMaxAge:                    cfg.FilesSourceMaxAge,
+		FilesFreezeStale:               cfg.FilesSourceFreezeStale,
+		WebhookListenAddress:           cfg.WebhookSourceListenAddress,
+		WebhookToken:                   cfg.WebhookSourceToken,
+		WebhookFile:                    cfg.WebhookSourceFile,
+		TemplateFile:                   cfg.TemplateSourceTemplate,
+		TemplateDataFile:               cfg.TemplateSourceData,
+		DNSUpdateListenAddress:         cfg.DNSUpdateSourceListenAddr,
+		DNSUpdateZone:                  cfg.DNSUpdateSourceZone,
+		DNSUpdateTSIGKeyName:           cfg.DNSUpdateSourceTSIGKeyName,
+		DNSUpdateTSIGSecret:            cfg.DNSUpdateSourceTSIGSecret,
+		DNSUpdateFile:                  cfg.DNSUpdateSourceFile,
+		ReversePaths:                   cfg.ReverseSourcePaths,
+		ReverseZones:                   cfg.ReverseSourceZones,
+		SplitTargetsSources:            cfg.SplitTargetsSources,
+		NamePrefixes:                   cfg.SourceNamePrefixes,
+		NameSuffixes:                   cfg.SourceNameSuffixes,
+		NameStripSuffixes:              cfg.SourceNameStripSuffixes,
+		TargetRewriteSources:           cfg.TargetRewriteSources,
+		TargetRewrites:                 cfg.TargetRewrites,
+		DefaultTTLs:                    cfg.SourceDefaultTTLs,
+		ForcedTTLs:                     cfg.SourceForcedTTLs,
 	}
 
 	// Lookup all the selected sources by names and pass them the desired configuration.
@@ -136,16 +283,426 @@ func main() {
 		log.Fatal(err)
 	}
 
-	// Combine multiple sources into a single, deduplicated source.
-	endpointsSource := source.NewDedupSource(source.NewMultiSource(sources))
+	validationRules := endpoint.ValidationRules{
+		MaxTargets: cfg.EndpointMaxTargets,
+		MinTTL:     endpoint.TTL(cfg.EndpointMinTTL.Seconds()),
+		MaxTTL:     endpoint.TTL(cfg.EndpointMaxTTL.Seconds()),
+	}
 
-	domainFilter := endpoint.NewDomainFilterWithExclusions(cfg.DomainFilter, cfg.ExcludeDomains)
+	// Combine multiple sources into a single, deduplicated source and drop invalid endpoints.
+	combinedSource := source.NewMultiSource(sources)
+	if len(cfg.SourcePrecedence) > 0 {
+		combinedSource = source.NewConflictSource(sources, cfg.Sources, source.PrecedenceConflictResolver(cfg.SourcePrecedence))
+	}
+	if cfg.ManagePTRRecords {
+		combinedSource, err = source.NewPTRSource(combinedSource, cfg.PTRZones)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	endpointsSource := source.NewValidatingSource(source.NewDedupSource(combinedSource), validationRules)
+
+	if cfg.AdmissionListenAddress != "" {
+		go func() {
+			log.Fatal(admission.ListenAndServeTLS(cfg.AdmissionListenAddress, cfg.AdmissionTLSCertFile, cfg.AdmissionTLSKeyFile, &admission.Validator{
+				Rules:               validationRules,
+				DomainFilter:        domainFilter,
+				SupportedRecordType: provider.SupportedRecordType,
+			}))
+		}()
+	}
+
+	var p provider.Provider
+	if cfg.Simulate != "" {
+		p, err = newSimulationProvider(ctx, cfg, domainFilter)
+	} else {
+		p, err = newProvider(ctx, cfg, cfg.Provider, domainFilter)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.SecondaryProvider != "" {
+		secondary, err := newProvider(ctx, cfg, cfg.SecondaryProvider, domainFilter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		p, err = provider.NewRoutingProvider(cfg.Provider, map[string]provider.Provider{cfg.Provider: p, cfg.SecondaryProvider: secondary})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if cfg.TrimTrailingDots {
+		p = provider.NewTrimTrailingDotsProvider(p)
+	}
+	if cfg.FaultInjectionLatency > 0 || cfg.FaultInjectionErrorRate > 0 || cfg.FaultInjectionPartialRate > 0 {
+		log.Warn("Injecting faults into the calls to the DNS provider")
+		p = provider.NewFaultInjectionProvider(p, provider.FaultInjectionConfig{
+			Latency:     cfg.FaultInjectionLatency,
+			ErrorRate:   cfg.FaultInjectionErrorRate,
+			PartialRate: cfg.FaultInjectionPartialRate,
+		})
+	}
+	if cfg.ProviderStateFile != "" {
+		p, err = provider.NewStateFileProvider(p, cfg.ProviderStateFile, cfg.ProviderStateResyncInterval)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if cfg.MirrorFile != "" {
+		p, err = provider.NewMirrorFileProvider(p, cfg.MirrorFile, cfg.MirrorFileFormat)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if cfg.DebugRecords {
+		providerRecords := controller.NewProviderRecords(p, domainFilter)
+		providerRecords.RegisterHandler(http.DefaultServeMux)
+		p = providerRecords
+	}
+
+	var reporters []source.ApplyResultReporter
+	for i, src := range sources {
+		if checker, ok := src.(source.HealthChecker); ok {
+			readiness.Add("source-"+cfg.Sources[i], func(context.Context) error { return checker.CheckHealth() })
+		}
+		if reporter, ok := src.(source.ApplyResultReporter); ok {
+			reporters = append(reporters, reporter)
+		}
+	}
+	// Replicas which don't synchronize, e.g. without the leader lease, read the records at most
+	// once per interval to check the provider.
+	readProvider := health.Every(cfg.Interval, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, cfg.RequestTimeout)
+		defer cancel()
+		_, err := p.Records(ctx)
+		return err
+	})
+
+	var r registry.Registry
+	switch cfg.Registry {
+	case "noop":
+		r, err = registry.NewNoopRegistry(p)
+	case "txt":
+		var txtEncryptionKeys []string
+		if cfg.TXTEncryptAESKey != "" {
+			txtEncryptionKeys = append([]string{cfg.TXTEncryptAESKey}, cfg.TXTEncryptAESOldKeys...)
+		}
+		var createdBy string
+		if cfg.TXTMetadata {
+			if createdBy, err = os.Hostname(); err != nil {
+				log.Fatal(err)
+			}
+		}
+		r, err = registry.NewTXTRegistry(p, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTOwnerID, cfg.TXTCacheInterval, cfg.TXTWildcardReplacement, txtEncryptionKeys, createdBy)
+	case "file":
+		r, err = registry.NewFileRegistry(p, cfg.FileRegistryPath, cfg.TXTOwnerID)
+	case "single-writer":
+		r, err = registry.NewSingleWriterRegistry(p, domainFilter)
+	case "aws-sd":
+		r, err = registry.NewAWSSDRegistry(p.(*awssd.AWSSDProvider), cfg.TXTOwnerID)
+	default:
+		log.Fatalf("unknown registry: %s", cfg.Registry)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	policy, exists := plan.Policies[cfg.Policy]
+	if !exists {
+		log.Fatalf("unknown policy: %s", cfg.Policy)
+	}
+	if len(cfg.PolicyOverrides) > 0 {
+		domainPolicy := &plan.DomainPolicy{Default: policy, Domains: map[string]plan.Policy{}}
+		for domain, name := range cfg.PolicyOverrides {
+			override, exists := plan.Policies[name]
+			if !exists {
+				log.Fatalf("unknown policy for %s: %s", domain, name)
+			}
+			domainPolicy.Domains[domain] = override
+		}
+		policy = domainPolicy
+	}
+
+	if cfg.Verify != "" {
+		os.Exit(verify(ctx, cfg, endpointsSource, r, domainFilter))
+	}
+
+	ctrl := controller.Controller{
+		Source:               endpointsSource,
+		Registry:             r,
+		Policy:               policy,
+		Interval:             cfg.Interval,
+		DomainFilter:         domainFilter,
+		LogChanges:           cfg.LogChanges,
+		PlanExport:           cfg.PlanExport,
+		PlanImport:           cfg.PlanImport,
+		DiffFormat:           cfg.Diff,
+		DiffOutput:           os.Stdout,
+		DiffColor:            terminal.IsTerminal(int(os.Stdout.Fd())),
+		MaxDeletions:         cfg.MaxDeletions,
+		MaxRecordsPerDomain:  cfg.MaxRecordsPerDomain,
+		ManageNSRecords:      cfg.ManageNSRecords,
+		ManagePTRRecords:     managePTRRecords(cfg),
+		ManageMXRecords:      cfg.ManageMXRecords,
+		ManageTXTRecords:     cfg.ManageTXTRecords,
+		ManageSRVRecords:     cfg.ManageSRVRecords,
+		ShutdownTimeout:      cfg.ShutdownTimeout,
+		EventsQuietPeriod:    cfg.EventsQuietPeriod,
+		MaxApplyFailures:     cfg.MaxApplyFailures,
+		ApplyFailureCooldown: cfg.ApplyFailureCooldown,
+		CreateBeforeDelete:   cfg.CreateBeforeDelete,
+		DeletionGracePeriod:  cfg.DeletionGracePeriod,
+		ApplyResultReporters: reporters,
+	}
+	if cfg.PropagationResolver != "" {
+		ctrl.PropagationVerifier = &controller.PropagationVerifier{
+			Resolver: controller.NewDNSResolver(cfg.PropagationResolver, cfg.RequestTimeout),
+			Timeout:  cfg.PropagationTimeout,
+		}
+	}
+
+	if cfg.CircuitBreakerReset {
+		http.HandleFunc("/circuit-breaker/reset", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			ctrl.ResetCircuitBreaker()
+			log.Info("Circuit breaker reset")
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+	ctrl.RegisterStatusHandlers(http.DefaultServeMux)
+	readiness.Add("provider", func(ctx context.Context) error {
+		if synchronized, err := ctrl.RecordsRead(); synchronized {
+			return err
+		}
+		return readProvider(ctx)
+	})
+
+	if cfg.Once {
+		err := ctrl.RunOnceGracefully(ctx)
+		flushTraces()
+		if err != nil {
+			log.WithFields(controller.ErrorFields(err)).Fatal(err)
+		}
+
+		if cfg.Simulate != "" {
+			records, err := p.Records(ctx)
+			if err == nil {
+				err = controller.WriteZoneState(os.Stdout, records)
+			}
+			if err != nil {
+				log.Fatalf("failed to print the simulated records: %v", err)
+			}
+		}
+
+		if ctrl.LastChanges() > 0 {
+			if cfg.Diff != "" && cfg.OnceChangesExitCode == 0 {
+				os.Exit(2)
+			}
+			os.Exit(cfg.OnceChangesExitCode)
+		}
+		os.Exit(0)
+	}
+
+	if cfg.UpdateEvents {
+		// Add RunOnce as the handler function that will be called when ingress/service sources have changed.
+		// Note that k8s Informers will perform an initial list operation, which results in the handler
+		// function initially being called for every Service/Ingress that exists
+		ctrl.Source.AddEventHandler(ctx, func() { ctrl.ScheduleRunOnce(time.Now()) })
+	}
+
+	reload := make(chan struct{})
+	if cfg.ConfigReloadInterval > 0 {
+		go watchConfigFile(ctx, cfg.ConfigFile, cfg.ConfigReloadInterval, func() {
+			close(reload)
+			cancel()
+		})
+	}
+
+	ctrl.ScheduleRunOnce(time.Now())
+	if cfg.LeaderElection {
+		runWithLeaderElection(ctx, cfg, ctrl.Run)
+	} else {
+		ctrl.Run(ctx)
+	}
+	flushTraces()
+
+	select {
+	case <-reload:
+		restart()
+	default:
+	}
+}
+
+// watchConfigFile calls reload once the config file changed into a valid configuration.
+// Invalid changes are logged and ignored, so ExternalDNS keeps running with the last valid one.
+func watchConfigFile(ctx context.Context, path string, interval time.Duration, reload func()) {
+	last, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Errorf("Failed to read config file %s: %v", path, err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Errorf("Failed to read config file %s: %v", path, err)
+			continue
+		}
+		if bytes.Equal(data, last) {
+			continue
+		}
+		last = data
+
+		cfg := externaldns.NewConfig()
+		err = cfg.ParseFlags(os.Args[1:])
+		if err == nil {
+			err = validation.ValidateConfig(cfg)
+		}
+		if err != nil {
+			log.Errorf("Ignoring invalid change of config file %s: %v", path, err)
+			continue
+		}
+		log.Infof("Config file %s changed, restarting", path)
+		reload()
+		return
+	}
+}
+
+// restart replaces the process by a new one with the same arguments and environment, which
+// reads the config file again.
+func restart() {
+	executable, err := os.Executable()
+	if err != nil {
+		log.Fatalf("failed to restart: %v", err)
+	}
+	log.Fatalf("failed to restart: %v", syscall.Exec(executable, os.Args, os.Environ()))
+}
+
+// managePTRRecords returns whether PTR records are planned: with --manage-ptr-records, or with
+// the reverse source, which provides nothing but PTR records.
+func managePTRRecords(cfg *externaldns.Config) bool {
+	for _, name := range cfg.Sources {
+		if name == "reverse" {
+			return true
+		}
+	}
+	return cfg.ManagePTRRecords
+}
+
+// migrate copies the records of the --migrate-from provider to the --provider provider.
+func migrate(ctx context.Context, cfg *externaldns.Config, domainFilter endpoint.DomainFilter) {
+	from, err := newProvider(ctx, cfg, cfg.MigrateFrom, domainFilter)
+	if err != nil {
+		log.Fatal(err)
+	}
+	to, err := newProvider(ctx, cfg, cfg.Provider, domainFilter)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	changes, err := controller.Migrate(ctx, from, to, domainFilter, cfg.DryRun)
+	if err != nil {
+		log.Fatalf("failed to migrate records from %s to %s: %v", cfg.MigrateFrom, cfg.Provider, err)
+	}
+	log.Infof("Migrated records from %s to %s: %d created, %d updated", cfg.MigrateFrom, cfg.Provider, len(changes.Create), len(changes.UpdateNew))
+}
+
+// verify prints the comparison of the endpoints of the sources to the records of the registry and
+// those served by --propagation-resolver, and returns the exit code: 2 if any record differs.
+func verify(ctx context.Context, cfg *externaldns.Config, src source.Source, r registry.Registry, domainFilter endpoint.DomainFilter) int {
+	endpoints, err := src.Endpoints()
+	if err != nil {
+		log.Fatalf("failed to read the endpoints of the sources: %v", err)
+	}
+	var desired []*endpoint.Endpoint
+	for _, ep := range endpoints {
+		if domainFilter.Match(ep.DNSName) {
+			desired = append(desired, ep)
+		}
+	}
+	stored, err := r.Records(ctx)
+	if err != nil {
+		log.Fatalf("failed to read the records of %s: %v", cfg.Provider, err)
+	}
+
+	resolver := controller.NewDNSResolver(cfg.PropagationResolver, cfg.RequestTimeout)
+	verifications := controller.Verify(ctx, desired, stored, resolver, cfg.TXTOwnerID)
+	if err := controller.WriteVerifications(os.Stdout, verifications, cfg.Verify); err != nil {
+		log.Fatalf("failed to print the verification: %v", err)
+	}
+	for _, v := range verifications {
+		if !v.InSync() {
+			return 2
+		}
+	}
+	return 0
+}
+
+// backup writes the records of the --provider provider to the --backup archive.
+func backup(ctx context.Context, cfg *externaldns.Config, domainFilter endpoint.DomainFilter) {
+	p, err := newProvider(ctx, cfg, cfg.Provider, domainFilter)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	n, err := controller.WriteBackup(ctx, p, cfg.Provider, domainFilter, cfg.Backup)
+	if err != nil {
+		log.Fatalf("failed to back up records of %s: %v", cfg.Provider, err)
+	}
+	log.Infof("Backed up %d records of %s to %s", n, cfg.Provider, cfg.Backup)
+}
+
+// restore recreates the records of the --restore archive in the --provider provider.
+func restore(ctx context.Context, cfg *externaldns.Config, domainFilter endpoint.DomainFilter) {
+	backup, err := controller.ReadBackup(cfg.Restore)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if backup.Provider != cfg.Provider {
+		log.Infof("Restoring records of %s backed up from %s", cfg.Provider, backup.Provider)
+	}
+	p, err := newProvider(ctx, cfg, cfg.Provider, domainFilter)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	changes, err := controller.Restore(ctx, backup, p, domainFilter, cfg.DryRun)
+	if err != nil {
+		log.Fatalf("failed to restore records of %s: %v", cfg.Provider, err)
+	}
+	log.Infof("Restored records of %s from %s: %d created, %d updated", cfg.Provider, cfg.Restore, len(changes.Create), len(changes.UpdateNew))
+}
+
+// newSimulationProvider returns the in-memory provider of --simulate holding the records of
+// the fixture.
+func newSimulationProvider(ctx context.Context, cfg *externaldns.Config, domainFilter endpoint.DomainFilter) (provider.Provider, error) {
+	fixture, err := source.ReadEndpointsFile(cfg.Simulate)
+	if err != nil {
+		return nil, err
+	}
+	return controller.NewSimulationProvider(ctx, cfg.SimulationZones(), domainFilter, fixture)
+}
+
+// newProvider returns the provider with the given name, configured by the flags.
+func newProvider(ctx context.Context, cfg *externaldns.Config, name string, domainFilter endpoint.DomainFilter) (provider.Provider, error) {
 	zoneIDFilter := provider.NewZoneIDFilter(cfg.ZoneIDFilter)
 	zoneTypeFilter := provider.NewZoneTypeFilter(cfg.AWSZoneType)
 	zoneTagFilter := provider.NewZoneTagFilter(cfg.AWSZoneTagFilter)
 
 	var p provider.Provider
-	switch cfg.Provider {
+	var err error
+	switch name {
 	case "akamai":
 		p = akamai.NewAkamaiProvider(
 			akamai.AkamaiConfig{
@@ -286,59 +843,58 @@ func main() {
 	case "transip":
 		p, err = transip.NewTransIPProvider(cfg.TransIPAccountName, cfg.TransIPPrivateKeyFile, domainFilter, cfg.DryRun)
 	default:
-		log.Fatalf("unknown dns provider: %s", cfg.Provider)
+		return nil, fmt.Errorf("unknown dns provider: %s", name)
 	}
+	return p, err
+}
+
+// runWithLeaderElection runs the given function only while holding the leader election Lease.
+// Losing the Lease terminates the process, so it never keeps writing next to a new leader.
+func runWithLeaderElection(ctx context.Context, cfg *externaldns.Config, run func(ctx context.Context)) {
+	client, err := source.NewKubeClient(cfg.KubeConfig, cfg.Master, cfg.RequestTimeout)
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	var r registry.Registry
-	switch cfg.Registry {
-	case "noop":
-		r, err = registry.NewNoopRegistry(p)
-	case "txt":
-		r, err = registry.NewTXTRegistry(p, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTOwnerID, cfg.TXTCacheInterval)
-	case "aws-sd":
-		r, err = registry.NewAWSSDRegistry(p.(*awssd.AWSSDProvider), cfg.TXTOwnerID)
-	default:
-		log.Fatalf("unknown registry: %s", cfg.Registry)
-	}
-
+	identity, err := os.Hostname()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	policy, exists := plan.Policies[cfg.Policy]
-	if !exists {
-		log.Fatalf("unknown policy: %s", cfg.Policy)
-	}
-
-	ctrl := controller.Controller{
-		Source:       endpointsSource,
-		Registry:     r,
-		Policy:       policy,
-		Interval:     cfg.Interval,
-		DomainFilter: domainFilter,
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      cfg.LeaderElectionID,
+			Namespace: cfg.LeaderElectionNamespace,
+		},
+		Client:     client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
 	}
 
-	if cfg.Once {
-		err := ctrl.RunOnce(ctx)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		os.Exit(0)
-	}
-
-	if cfg.UpdateEvents {
-		// Add RunOnce as the handler function that will be called when ingress/service sources have changed.
-		// Note that k8s Informers will perform an initial list operation, which results in the handler
-		// function initially being called for every Service/Ingress that exists
-		ctrl.Source.AddEventHandler(ctx, func() { ctrl.ScheduleRunOnce(time.Now()) })
-	}
-
-	ctrl.ScheduleRunOnce(time.Now())
-	ctrl.Run(ctx)
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   15 * time.Second,
+		RenewDeadline:   10 * time.Second,
+		RetryPeriod:     2 * time.Second,
+		ReleaseOnCancel: true,
+		Name:            cfg.LeaderElectionID,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				log.Infof("Started leading as %s", identity)
+				run(ctx)
+			},
+			OnStoppedLeading: func() {
+				if ctx.Err() != nil {
+					log.Info("Released leadership")
+					return
+				}
+				log.Fatal("Lost leadership")
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					log.Infof("Waiting for leader %s", leader)
+				}
+			},
+		},
+	})
 }
 
 func handleSigterm(cancel func()) {
@@ -349,12 +905,14 @@ func handleSigterm(cancel func()) {
 	cancel()
 }
 
-func serveMetrics(address string) {
+func serveMetrics(address string, readiness http.Handler) {
 	http.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
 
+	http.Handle("/readyz", readiness)
+
 	http.Handle("/metrics", promhttp.Handler())
 
 	log.Fatal(http.ListenAndServe(address, nil))
@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// maxReviewSize limits the size of admission reviews
+const maxReviewSize = 4 << 20
+
+// Validator validates DNSEndpoint resources against the constraints of the controller, so
+// users get feedback when applying a resource instead of endpoints being skipped at sync time.
+type Validator struct {
+	// The rules every endpoint must pass
+	Rules endpoint.ValidationRules
+	// The domains endpoints must be in
+	DomainFilter endpoint.DomainFilter
+	// Reports whether the record type of an endpoint is supported
+	SupportedRecordType func(recordType string) bool
+}
+
+// Validate returns an error listing every invalid endpoint of the DNSEndpoint.
+func (v *Validator) Validate(dnsEndpoint *endpoint.DNSEndpoint) error {
+	var problems []string
+	for i, ep := range dnsEndpoint.Spec.Endpoints {
+		if ep == nil {
+			problems = append(problems, fmt.Sprintf("spec.endpoints[%d]: must not be null", i))
+			continue
+		}
+		if v.SupportedRecordType != nil && !v.SupportedRecordType(ep.RecordType) {
+			problems = append(problems, fmt.Sprintf("spec.endpoints[%d]: unsupported record type %q", i, ep.RecordType))
+			continue
+		}
+		if !v.DomainFilter.Match(ep.DNSName) {
+			problems = append(problems, fmt.Sprintf("spec.endpoints[%d]: %s is not in a managed domain", i, ep.DNSName))
+			continue
+		}
+		if err := v.Rules.Validate(ep); err != nil {
+			problems = append(problems, fmt.Sprintf("spec.endpoints[%d]: %v", i, err))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid endpoints: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ServeHTTP answers AdmissionReview requests for DNSEndpoint resources. The admission.k8s.io
+// v1 and v1beta1 reviews share their format, so both are answered in the version requested.
+func (v *Validator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxReviewSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var review admissionv1.AdmissionReview
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, "invalid admission review", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = v.review(review.Request)
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		log.Errorf("Failed to write admission review response: %v", err)
+	}
+}
+
+func (v *Validator) review(request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	response := &admissionv1.AdmissionResponse{UID: request.UID, Allowed: true}
+
+	// Deleted resources have no object and are always allowed.
+	if len(request.Object.Raw) == 0 {
+		return response
+	}
+
+	var dnsEndpoint endpoint.DNSEndpoint
+	err := json.Unmarshal(request.Object.Raw, &dnsEndpoint)
+	if err == nil {
+		err = v.Validate(&dnsEndpoint)
+	}
+	if err != nil {
+		log.Infof("Rejecting %s of DNSEndpoint %s/%s: %v", strings.ToLower(string(request.Operation)), request.Namespace, request.Name, err)
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		}
+	}
+	return response
+}
+
+// ListenAndServeTLS serves the validator on the /validate path of the given address.
+// Kubernetes only calls admission webhooks over HTTPS.
+func ListenAndServeTLS(address, certFile, keyFile string, v *Validator) error {
+	mux := http.NewServeMux()
+	mux.Handle("/validate", v)
+	server := &http.Server{Addr: address, Handler: mux}
+	return server.ListenAndServeTLS(certFile, keyFile)
+}
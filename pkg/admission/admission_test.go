@@ -0,0 +1,163 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+func newValidator() *Validator {
+	return &Validator{
+		Rules:               endpoint.ValidationRules{MinTTL: 60, MaxTTL: 3600},
+		DomainFilter:        endpoint.NewDomainFilter([]string{"example.org"}),
+		SupportedRecordType: provider.SupportedRecordType,
+	}
+}
+
+func TestValidatorValidate(t *testing.T) {
+	for _, tc := range []struct {
+		title     string
+		endpoints []*endpoint.Endpoint
+		errors    []string
+	}{
+		{
+			title: "valid endpoints",
+			endpoints: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 300, "1.2.3.4"),
+				endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeCNAME, "foo.example.org"),
+			},
+		},
+		{
+			title: "unsupported record type",
+			endpoints: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", "NAPTR", "100 10 \"u\" \"E2U+sip\" \"!^.*$!sip:info@example.org!\" ."),
+			},
+			errors: []string{`spec.endpoints[0]: unsupported record type "NAPTR"`},
+		},
+		{
+			title: "unmanaged domain",
+			endpoints: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+				endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeA, "1.2.3.4"),
+			},
+			errors: []string{"spec.endpoints[1]: foo.example.com is not in a managed domain"},
+		},
+		{
+			title: "every invalid endpoint is reported",
+			endpoints: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 10, "1.2.3.4"),
+				endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "bar.example.com"),
+			},
+			errors: []string{"spec.endpoints[0]: foo.example.org has TTL 10", "spec.endpoints[1]: A bar.example.org has invalid target"},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			err := newValidator().Validate(&endpoint.DNSEndpoint{Spec: endpoint.DNSEndpointSpec{Endpoints: tc.endpoints}})
+			if len(tc.errors) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, e := range tc.errors {
+				assert.Contains(t, err.Error(), e)
+			}
+		})
+	}
+}
+
+func TestValidatorServeHTTP(t *testing.T) {
+	for _, tc := range []struct {
+		title      string
+		apiVersion string
+		object     *endpoint.DNSEndpoint
+		allowed    bool
+	}{
+		{
+			title:      "valid resource",
+			apiVersion: "admission.k8s.io/v1",
+			object: &endpoint.DNSEndpoint{Spec: endpoint.DNSEndpointSpec{Endpoints: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+			}}},
+			allowed: true,
+		},
+		{
+			title:      "invalid resource",
+			apiVersion: "admission.k8s.io/v1beta1",
+			object: &endpoint.DNSEndpoint{Spec: endpoint.DNSEndpointSpec{Endpoints: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeA, "1.2.3.4"),
+			}}},
+		},
+		{
+			title:      "deleted resource",
+			apiVersion: "admission.k8s.io/v1",
+			allowed:    true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			review := admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{UID: types.UID("42"), Operation: admissionv1.Create}}
+			review.APIVersion = tc.apiVersion
+			review.Kind = "AdmissionReview"
+			if tc.object != nil {
+				raw, err := json.Marshal(tc.object)
+				require.NoError(t, err)
+				review.Request.Object = runtime.RawExtension{Raw: raw}
+			} else {
+				review.Request.Operation = admissionv1.Delete
+			}
+			body, err := json.Marshal(review)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			newValidator().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var response admissionv1.AdmissionReview
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, tc.apiVersion, response.APIVersion)
+			assert.Nil(t, response.Request)
+			require.NotNil(t, response.Response)
+			assert.Equal(t, types.UID("42"), response.Response.UID)
+			assert.Equal(t, tc.allowed, response.Response.Allowed)
+			if !tc.allowed {
+				assert.Contains(t, response.Response.Result.Message, "foo.example.com is not in a managed domain")
+			}
+		})
+	}
+}
+
+func TestValidatorServeHTTPBadRequest(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newValidator().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader([]byte("{}"))))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	newValidator().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/validate", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externaldns
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const configFileFlag = "config"
+
+// configFilePath returns the config file given on the command line or in the environment.
+func configFilePath(args []string) string {
+	for i, arg := range args {
+		if arg == "--"+configFileFlag && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, "--"+configFileFlag+"=") {
+			return strings.TrimPrefix(arg, "--"+configFileFlag+"=")
+		}
+	}
+	return os.Getenv(flagEnvVar(configFileFlag))
+}
+
+// configFileArgs returns the flags set in the config file as arguments, e.g.
+//
+//	provider: google
+//	source: [service, ingress]
+//	policy-override: {example.org: upsert-only}
+//	dry-run: true
+//
+// Flags given on the command line or in the environment take precedence and are left out.
+func configFileArgs(path string, args []string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to decode config file %s: %v", path, err)
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fileArgs []string
+	for _, name := range names {
+		if name == configFileFlag {
+			return nil, fmt.Errorf("config file %s cannot set %s", path, configFileFlag)
+		}
+		if flagGiven(name, args) {
+			continue
+		}
+		switch value := values[name].(type) {
+		case bool:
+			if value {
+				fileArgs = append(fileArgs, "--"+name)
+			} else {
+				fileArgs = append(fileArgs, "--no-"+name)
+			}
+		case []interface{}:
+			for _, v := range value {
+				fileArgs = append(fileArgs, fmt.Sprintf("--%s=%v", name, v))
+			}
+		case map[interface{}]interface{}:
+			entries := make(map[string]interface{}, len(value))
+			keys := make([]string, 0, len(value))
+			for k, v := range value {
+				key := fmt.Sprint(k)
+				if _, ok := entries[key]; ok {
+					return nil, fmt.Errorf("config file %s has the key %s of %s more than once", path, key, name)
+				}
+				entries[key] = v
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				fileArgs = append(fileArgs, fmt.Sprintf("--%s=%s=%v", name, key, entries[key]))
+			}
+		case nil:
+			return nil, fmt.Errorf("config file %s has no value for %s", path, name)
+		default:
+			fileArgs = append(fileArgs, fmt.Sprintf("--%s=%v", name, value))
+		}
+	}
+	return fileArgs, nil
+}
+
+// flagGiven returns whether the flag is set on the command line or in the environment.
+func flagGiven(name string, args []string) bool {
+	if os.Getenv(flagEnvVar(name)) != "" {
+		return true
+	}
+	for _, arg := range args {
+		if arg == "--"+name || arg == "--no-"+name || strings.HasPrefix(arg, "--"+name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// flagEnvVar returns the environment variable of a flag, see kingpin's DefaultEnvars.
+func flagEnvVar(name string) string {
+	return "EXTERNAL_DNS_" + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externaldns
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFlagsConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+source: [service, files]
+files-source-path: [base.json, staging.json]
+provider: google
+google-project: project
+domain-filter: example.org
+policy-override:
+  staging.example.org: upsert-only
+interval: 5m
+dry-run: true
+events: false
+`), 0644))
+
+	t.Run("flags of the config file", func(t *testing.T) {
+		cfg := NewConfig()
+		require.NoError(t, cfg.ParseFlags([]string{"--config", path}))
+		assert.Equal(t, path, cfg.ConfigFile)
+		assert.Equal(t, []string{"service", "files"}, cfg.Sources)
+		assert.Equal(t, []string{"base.json", "staging.json"}, cfg.FilesSourcePaths)
+		assert.Equal(t, "google", cfg.Provider)
+		assert.Equal(t, "project", cfg.GoogleProject)
+		assert.Equal(t, []string{"example.org"}, cfg.DomainFilter)
+		assert.Equal(t, map[string]string{"staging.example.org": "upsert-only"}, cfg.PolicyOverrides)
+		assert.Equal(t, 5*time.Minute, cfg.Interval)
+		assert.True(t, cfg.DryRun)
+		assert.False(t, cfg.UpdateEvents)
+	})
+
+	t.Run("command line and env vars take precedence", func(t *testing.T) {
+		originalEnv := setEnv(t, map[string]string{"EXTERNAL_DNS_GOOGLE_PROJECT": "env-project"})
+		defer func() { restoreEnv(t, originalEnv) }()
+
+		cfg := NewConfig()
+		require.NoError(t, cfg.ParseFlags([]string{"--config=" + path, "--source=ingress", "--no-dry-run"}))
+		assert.Equal(t, []string{"ingress"}, cfg.Sources)
+		assert.Equal(t, "env-project", cfg.GoogleProject)
+		assert.False(t, cfg.DryRun)
+		assert.Equal(t, "google", cfg.Provider)
+	})
+
+	t.Run("map values are ordered by key", func(t *testing.T) {
+		mapPath := filepath.Join(dir, "map.yaml")
+		require.NoError(t, ioutil.WriteFile(mapPath, []byte(`
+policy-override:
+  staging.example.org: upsert-only
+  dev.example.org: create-only
+  prod.example.org: sync
+  1: sync
+`), 0644))
+		for i := 0; i < 10; i++ {
+			args, err := configFileArgs(mapPath, nil)
+			require.NoError(t, err)
+			assert.Equal(t, []string{
+				"--policy-override=1=sync",
+				"--policy-override=dev.example.org=create-only",
+				"--policy-override=prod.example.org=sync",
+				"--policy-override=staging.example.org=upsert-only",
+			}, args)
+		}
+
+		require.NoError(t, ioutil.WriteFile(mapPath, []byte("policy-override:\n  1: sync\n  \"1\": create-only\n"), 0644))
+		_, err := configFileArgs(mapPath, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown flags fail", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(path, []byte("provider: google\nsource: service\nunknown-flag: 1\n"), 0644))
+		assert.Error(t, NewConfig().ParseFlags([]string{"--config", path}))
+	})
+
+	t.Run("missing files fail", func(t *testing.T) {
+		assert.Error(t, NewConfig().ParseFlags([]string{"--config", filepath.Join(dir, "missing.yaml")}))
+	})
+}
@@ -38,6 +38,8 @@ var (
 
 // Config is a project-wide configuration
 type Config struct {
+	ConfigFile                        string
+	ConfigReloadInterval              time.Duration
 	Master                            string
 	KubeConfig                        string
 	RequestTimeout                    time.Duration
@@ -45,6 +47,15 @@ type Config struct {
 	ContourLoadBalancerService        string
 	SkipperRouteGroupVersion          string
 	Sources                           []string
+	SplitTargetsSources               []string
+	SourcePrecedence                  []string
+	SourceNamePrefixes                map[string]string
+	SourceNameSuffixes                map[string]string
+	SourceNameStripSuffixes           map[string]string
+	TargetRewriteSources              []string
+	TargetRewrites                    map[string]string
+	SourceDefaultTTLs                 map[string]string
+	SourceForcedTTLs                  map[string]string
 	Namespace                         string
 	AnnotationFilter                  string
 	FQDNTemplate                      string
@@ -55,13 +66,102 @@ type Config struct {
 	PublishHostIP                     bool
 	AlwaysPublishNotReadyAddresses    bool
 	ConnectorSourceServer             string
+	RedisSourceAddress                string
+	RedisSourcePassword               string `secure:"yes"`
+	RedisSourceDB                     int
+	RedisSourceKey                    string
+	RedisSourceNotifications          bool
+	HTTPSourceURL                     string
+	HTTPSourceHeaders                 []string `secure:"yes"`
+	HTTPSourcePollInterval            time.Duration
+	HTTPSourceStaleWhileError         time.Duration
+	PluginSourceSocket                string
+	LeaseSourcePath                   string
+	LeaseSourceFormat                 string
+	LeaseSourceDomain                 string
+	LeaseSourceMaxTTL                 time.Duration
+	KeaSourceURL                      string
+	KeaSourceSubnets                  []int
+	KeaSourceHostnameFilter           string
+	LibvirtSourceURI                  string
+	LibvirtSourceAddressSource        string
+	LibvirtSourceDomain               string
+	MQTTSourceBroker                  string
+	MQTTSourceTopic                   string
+	MQTTSourceClientID                string
+	MQTTSourceUsername                string
+	MQTTSourcePassword                string `secure:"yes"`
+	MQTTSourceExpiry                  time.Duration
+	MQTTSourceStateFile               string
+	SFTPSourceAddress                 string
+	SFTPSourceUser                    string
+	SFTPSourceKeyFile                 string
+	SFTPSourceHostKey                 string
+	SFTPSourcePath                    string
+	S3SourceEndpoint                  string
+	S3SourceRegion                    string
+	S3SourceBucket                    string
+	S3SourceKey                       string
+	S3SourceAccessKeyID               string
+	S3SourceSecretAccessKey           string `secure:"yes"`
+	VaultSourceAddress                string
+	VaultSourcePaths                  []string
+	VaultSourceToken                  string `secure:"yes"`
+	VaultSourceRoleID                 string
+	VaultSourceSecretID               string `secure:"yes"`
+	NeighborSourceTable               string
+	NeighborSourceSubnets             []string
+	NeighborSourceMACNames            []string
+	NeighborSourceDomain              string
+	MDNSSourceServices                []string
+	MDNSSourceDomain                  string
+	MDNSSourceTimeout                 time.Duration
+	SNMPSourceTargets                 []string
+	SNMPSourceCommunity               string `secure:"yes"`
+	SNMPSourceDomain                  string
+	FilesSourcePaths                  []string
+	FilesSourceConflict               string
+	FilesSourcePollInterval           time.Duration
+	FilesSourceStrict                 bool
+	FilesSourceMaxChange              int
+	FilesSourceConfirmAfter           time.Duration
+	FilesSourceForceFile              string
+	FilesSourceDeltaDir               string
+	FilesSourceDeltaBase              string
+	FilesSourceMaxAge                 time.Duration
+	FilesSourceFreezeStale            bool
+	WebhookSourceListenAddress        string
+	WebhookSourceToken                string `secure:"yes"`
+	WebhookSourceFile                 string
+	TemplateSourceTemplate            string
+	TemplateSourceData                string
+	DNSUpdateSourceListenAddr         string
+	DNSUpdateSourceZone               string
+	DNSUpdateSourceTSIGKeyName        string
+	DNSUpdateSourceTSIGSecret         string `secure:"yes"`
+	DNSUpdateSourceFile               string
+	ReverseSourcePaths                []string
+	ReverseSourceZones                []string
 	Provider                          string
+	MigrateFrom                       string
+	SecondaryProvider                 string
+	Backup                            string
+	Restore                           string
 	GoogleProject                     string
 	GoogleBatchChangeSize             int
 	GoogleBatchChangeInterval         time.Duration
 	DomainFilter                      []string
 	ExcludeDomains                    []string
 	ZoneIDFilter                      []string
+	ProviderStateFile                 string
+	TrimTrailingDots                  bool
+	ProviderStateResyncInterval       time.Duration
+	MirrorFile                        string
+	MirrorFileFormat                  string
+	DebugRecords                      bool
+	FaultInjectionLatency             time.Duration
+	FaultInjectionErrorRate           float64
+	FaultInjectionPartialRate         float64
 	AlibabaCloudConfigFile            string
 	AlibabaCloudZoneType              string
 	AWSZoneType                       string
@@ -107,17 +207,52 @@ type Config struct {
 	TLSClientCert                     string
 	TLSClientCertKey                  string
 	Policy                            string
+	PolicyOverrides                   map[string]string
+	MaxDeletions                      int
+	MaxRecordsPerDomain               int
+	ManageNSRecords                   bool
+	ManagePTRRecords                  bool
+	ManageMXRecords                   bool
+	ManageTXTRecords                  bool
+	ManageSRVRecords                  bool
+	PTRZones                          []string
+	MaxApplyFailures                  int
+	ApplyFailureCooldown              time.Duration
+	CircuitBreakerReset               bool
+	PropagationResolver               string
+	PropagationTimeout                time.Duration
+	CreateBeforeDelete                bool
+	DeletionGracePeriod               time.Duration
 	Registry                          string
 	TXTOwnerID                        string
 	TXTPrefix                         string
 	TXTSuffix                         string
+	TXTWildcardReplacement            string
+	TXTEncryptAESKey                  string   `secure:"yes"`
+	TXTEncryptAESOldKeys              []string `secure:"yes"`
+	TXTMetadata                       bool
+	FileRegistryPath                  string
 	Interval                          time.Duration
+	ShutdownTimeout                   time.Duration
+	SecretsRefreshInterval            time.Duration
 	Once                              bool
+	OnceChangesExitCode               int
 	DryRun                            bool
+	PlanExport                        string
+	PlanImport                        string
+	Diff                              string
+	Verify                            string
+	Simulate                          string
 	UpdateEvents                      bool
+	EventsQuietPeriod                 time.Duration
+	LeaderElection                    bool
+	LeaderElectionNamespace           string
+	LeaderElectionID                  string
 	LogFormat                         string
 	MetricsAddress                    string
+	TracingZipkinURL                  string
 	LogLevel                          string
+	LogChanges                        bool
 	TXTCacheInterval                  time.Duration
 	ExoscaleEndpoint                  string
 	ExoscaleAPIKey                    string `secure:"yes"`
@@ -125,6 +260,12 @@ type Config struct {
 	CRDSourceAPIVersion               string
 	CRDSourceKind                     string
 	ServiceTypeFilter                 []string
+	EndpointMaxTargets                int
+	EndpointMinTTL                    time.Duration
+	EndpointMaxTTL                    time.Duration
+	AdmissionListenAddress            string
+	AdmissionTLSCertFile              string
+	AdmissionTLSKeyFile               string
 	CFAPIEndpoint                     string
 	CFUsername                        string
 	CFPassword                        string
@@ -145,6 +286,8 @@ type Config struct {
 }
 
 var defaultConfig = &Config{
+	ConfigFile:                  "",
+	ConfigReloadInterval:        0,
 	Master:                      "",
 	KubeConfig:                  "",
 	RequestTimeout:              time.Second * 30,
@@ -152,6 +295,15 @@ var defaultConfig = &Config{
 	ContourLoadBalancerService:  "heptio-contour/contour",
 	SkipperRouteGroupVersion:    "zalando.org/v1",
 	Sources:                     nil,
+	SplitTargetsSources:         []string{},
+	SourcePrecedence:            []string{},
+	SourceNamePrefixes:          map[string]string{},
+	SourceNameSuffixes:          map[string]string{},
+	SourceNameStripSuffixes:     map[string]string{},
+	TargetRewriteSources:        []string{},
+	TargetRewrites:              map[string]string{},
+	SourceDefaultTTLs:           map[string]string{},
+	SourceForcedTTLs:            map[string]string{},
 	Namespace:                   "",
 	AnnotationFilter:            "",
 	FQDNTemplate:                "",
@@ -161,12 +313,97 @@ var defaultConfig = &Config{
 	PublishInternal:             false,
 	PublishHostIP:               false,
 	ConnectorSourceServer:       "localhost:8080",
+	RedisSourceAddress:          "localhost:6379",
+	RedisSourcePassword:         "",
+	RedisSourceDB:               0,
+	RedisSourceKey:              "external-dns",
+	RedisSourceNotifications:    false,
+	HTTPSourceURL:               "",
+	HTTPSourceHeaders:           []string{},
+	HTTPSourcePollInterval:      0,
+	HTTPSourceStaleWhileError:   5 * time.Minute,
+	PluginSourceSocket:          "/var/run/external-dns/plugin.sock",
+	LeaseSourcePath:             "/var/lib/misc/dnsmasq.leases",
+	LeaseSourceFormat:           "dnsmasq",
+	LeaseSourceDomain:           "",
+	LeaseSourceMaxTTL:           time.Hour,
+	KeaSourceURL:                "http://localhost:8000",
+	KeaSourceSubnets:            []int{},
+	KeaSourceHostnameFilter:     "",
+	LibvirtSourceURI:            "qemu:///system",
+	LibvirtSourceAddressSource:  "lease",
+	LibvirtSourceDomain:         "",
+	MQTTSourceBroker:            "tcp://localhost:1883",
+	MQTTSourceTopic:             "external-dns/register",
+	MQTTSourceClientID:          "external-dns",
+	MQTTSourceUsername:          "",
+	MQTTSourcePassword:          "",
+	MQTTSourceExpiry:            time.Hour,
+	MQTTSourceStateFile:         "",
+	SFTPSourceAddress:           "",
+	SFTPSourceUser:              "external-dns",
+	SFTPSourceKeyFile:           "/etc/external-dns/ssh/id_rsa",
+	SFTPSourceHostKey:           "",
+	SFTPSourcePath:              "endpoints.json",
+	S3SourceEndpoint:            "",
+	S3SourceRegion:              "us-east-1",
+	S3SourceBucket:              "",
+	S3SourceKey:                 "endpoints.json",
+	S3SourceAccessKeyID:         "",
+	S3SourceSecretAccessKey:     "",
+	VaultSourceAddress:          "https://127.0.0.1:8200",
+	VaultSourcePaths:            []string{},
+	VaultSourceToken:            "",
+	VaultSourceRoleID:           "",
+	VaultSourceSecretID:         "",
+	NeighborSourceTable:         "/proc/net/arp",
+	NeighborSourceSubnets:       []string{},
+	NeighborSourceMACNames:      []string{},
+	NeighborSourceDomain:        "",
+	MDNSSourceServices:          []string{"_workstation._tcp"},
+	MDNSSourceDomain:            "",
+	MDNSSourceTimeout:           2 * time.Second,
+	SNMPSourceTargets:           []string{},
+	SNMPSourceCommunity:         "public",
+	SNMPSourceDomain:            "",
+	FilesSourcePaths:            []string{},
+	FilesSourceConflict:         "override",
+	FilesSourcePollInterval:     0,
+	FilesSourceStrict:           false,
+	FilesSourceMaxChange:        0,
+	FilesSourceConfirmAfter:     0,
+	FilesSourceForceFile:        "",
+	FilesSourceDeltaDir:         "",
+	FilesSourceDeltaBase:        "",
+	FilesSourceMaxAge:           0,
+	FilesSourceFreezeStale:      false,
+	WebhookSourceListenAddress:  ":7980",
+	WebhookSourceToken:          "",
+	WebhookSourceFile:           "",
+	TemplateSourceTemplate:      "",
+	TemplateSourceData:          "",
+	DNSUpdateSourceListenAddr:   ":10053",
+	DNSUpdateSourceZone:         "",
+	DNSUpdateSourceTSIGKeyName:  "",
+	DNSUpdateSourceTSIGSecret:   "",
+	DNSUpdateSourceFile:         "",
+	ReverseSourcePaths:          []string{},
+	ReverseSourceZones:          []string{},
 	Provider:                    "",
 	GoogleProject:               "",
 	GoogleBatchChangeSize:       1000,
 	GoogleBatchChangeInterval:   time.Second,
 	DomainFilter:                []string{},
 	ExcludeDomains:              []string{},
+	ProviderStateFile:           "",
+	TrimTrailingDots:            false,
+	ProviderStateResyncInterval: time.Hour,
+	MirrorFile:                  "",
+	MirrorFileFormat:            "hosts",
+	DebugRecords:                false,
+	FaultInjectionLatency:       0,
+	FaultInjectionErrorRate:     0,
+	FaultInjectionPartialRate:   0,
 	AlibabaCloudConfigFile:      "/etc/kubernetes/alibaba-cloud.json",
 	AWSZoneType:                 "",
 	AWSZoneTagFilter:            []string{},
@@ -206,24 +443,64 @@ var defaultConfig = &Config{
 	TLSClientCert:               "",
 	TLSClientCertKey:            "",
 	Policy:                      "sync",
+	PolicyOverrides:             map[string]string{},
+	MaxDeletions:                0,
+	MaxRecordsPerDomain:         0,
+	ManageNSRecords:             false,
+	ManagePTRRecords:            false,
+	ManageMXRecords:             false,
+	ManageTXTRecords:            false,
+	ManageSRVRecords:            false,
+	PTRZones:                    []string{},
+	MaxApplyFailures:            0,
+	ApplyFailureCooldown:        5 * time.Minute,
+	CircuitBreakerReset:         false,
+	PropagationResolver:         "",
+	PropagationTimeout:          30 * time.Second,
+	CreateBeforeDelete:          false,
+	DeletionGracePeriod:         0,
 	Registry:                    "txt",
 	TXTOwnerID:                  "default",
 	TXTPrefix:                   "",
 	TXTSuffix:                   "",
+	TXTWildcardReplacement:      "",
+	TXTEncryptAESKey:            "",
+	TXTMetadata:                 false,
+	FileRegistryPath:            "",
 	TXTCacheInterval:            0,
 	Interval:                    time.Minute,
+	ShutdownTimeout:             20 * time.Second,
+	SecretsRefreshInterval:      0,
 	Once:                        false,
+	OnceChangesExitCode:         0,
 	DryRun:                      false,
+	PlanExport:                  "",
+	PlanImport:                  "",
+	Diff:                        "",
+	Verify:                      "",
+	Simulate:                    "",
 	UpdateEvents:                false,
+	EventsQuietPeriod:           5 * time.Second,
+	LeaderElection:              false,
+	LeaderElectionNamespace:     "default",
+	LeaderElectionID:            "external-dns",
 	LogFormat:                   "text",
 	MetricsAddress:              ":7979",
+	TracingZipkinURL:            "",
 	LogLevel:                    logrus.InfoLevel.String(),
+	LogChanges:                  false,
 	ExoscaleEndpoint:            "https://api.exoscale.ch/dns",
 	ExoscaleAPIKey:              "",
 	ExoscaleAPISecret:           "",
 	CRDSourceAPIVersion:         "externaldns.k8s.io/v1alpha1",
 	CRDSourceKind:               "DNSEndpoint",
 	ServiceTypeFilter:           []string{},
+	EndpointMaxTargets:          0,
+	EndpointMinTTL:              0,
+	EndpointMaxTTL:              0,
+	AdmissionListenAddress:      "",
+	AdmissionTLSCertFile:        "",
+	AdmissionTLSKeyFile:         "",
 	CFAPIEndpoint:               "",
 	CFUsername:                  "",
 	CFPassword:                  "",
@@ -256,12 +533,18 @@ func (cfg *Config) String() string {
 	for i := 0; i < t.NumField(); i++ {
 		f := t.Field(i)
 		if val, ok := f.Tag.Lookup("secure"); ok && val == "yes" {
-			if f.Type.Kind() != reflect.String {
-				continue
-			}
 			v := reflect.ValueOf(&temp).Elem().Field(i)
-			if v.String() != "" {
-				v.SetString(passwordMask)
+			switch {
+			case f.Type.Kind() == reflect.String:
+				if v.String() != "" {
+					v.SetString(passwordMask)
+				}
+			case f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.String:
+				masked := make([]string, v.Len())
+				for j := range masked {
+					masked[j] = passwordMask
+				}
+				v.Set(reflect.ValueOf(masked))
 			}
 		}
 	}
@@ -269,6 +552,54 @@ func (cfg *Config) String() string {
 	return fmt.Sprintf("%+v", temp)
 }
 
+// ResolveSecrets replaces the values of the sensitive flags, e.g. references to the secrets of
+// secret managers, with the values returned by resolve.
+func (cfg *Config) ResolveSecrets(resolve func(value string) (string, error)) error {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if val, ok := f.Tag.Lookup("secure"); !ok || val != "yes" {
+			continue
+		}
+		field := v.Field(i)
+		switch {
+		case f.Type.Kind() == reflect.String:
+			resolved, err := resolve(field.String())
+			if err != nil {
+				return fmt.Errorf("%s: %v", f.Name, err)
+			}
+			field.SetString(resolved)
+		case f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.String:
+			for j := 0; j < field.Len(); j++ {
+				resolved, err := resolve(field.Index(j).String())
+				if err != nil {
+					return fmt.Errorf("%s: %v", f.Name, err)
+				}
+				field.Index(j).SetString(resolved)
+			}
+		}
+	}
+	return nil
+}
+
+// SimulationZones returns the zones of the in-memory provider of --simulate: those of
+// --inmemory-zone, or of --domain-filter if unset.
+func (cfg *Config) SimulationZones() []string {
+	for _, zones := range [][]string{cfg.InMemoryZones, cfg.DomainFilter} {
+		var result []string
+		for _, zone := range zones {
+			if zone != "" {
+				result = append(result, zone)
+			}
+		}
+		if len(result) > 0 {
+			return result
+		}
+	}
+	return nil
+}
+
 // allLogLevelsAsStrings returns all logrus levels as a list of strings
 func allLogLevelsAsStrings() []string {
 	var levels []string
@@ -284,6 +615,9 @@ func (cfg *Config) ParseFlags(args []string) error {
 	app.Version(Version)
 	app.DefaultEnvars()
 
+	app.Flag(configFileFlag, "A YAML file setting flags by their name, e.g. 'provider: google'; flags given on the command line or as env vars take precedence (optional)").Default(defaultConfig.ConfigFile).StringVar(&cfg.ConfigFile)
+	app.Flag("config-reload-interval", "When set, checks the --config file for changes in this interval and restarts ExternalDNS in place with the changed file once it's valid; invalid changes are logged and ignored (default: 0, disabled)").Default(defaultConfig.ConfigReloadInterval.String()).DurationVar(&cfg.ConfigReloadInterval)
+
 	// Flags related to Kubernetes
 	app.Flag("master", "The Kubernetes API server to connect to (default: auto-detect)").Default(defaultConfig.Master).StringVar(&cfg.Master)
 	app.Flag("kubeconfig", "Retrieve target cluster configuration from a Kubernetes configuration file (default: auto-detect)").Default(defaultConfig.KubeConfig).StringVar(&cfg.KubeConfig)
@@ -301,7 +635,22 @@ func (cfg *Config) ParseFlags(args []string) error {
 	app.Flag("skipper-routegroup-groupversion", "The resource version for skipper routegroup").Default(source.DefaultRoutegroupVersion).StringVar(&cfg.SkipperRouteGroupVersion)
 
 	// Flags related to processing sources
-	app.Flag("source", "The resource types that are queried for endpoints; specify multiple times for multiple sources (required, options: service, ingress, node, fake, connector, istio-gateway, cloudfoundry, contour-ingressroute, crd, empty, skipper-routegroup,openshift-route)").Required().PlaceHolder("source").EnumsVar(&cfg.Sources, "service", "ingress", "node", "istio-gateway", "cloudfoundry", "contour-ingressroute", "fake", "connector", "crd", "empty", "skipper-routegroup", "openshift-route")
+	app.Flag("source", "The resource types that are queried for endpoints; specify multiple times for multiple sources (required, options: service, ingress, node, fake, connector, istio-gateway, cloudfoundry, contour-ingressroute, crd, empty, skipper-routegroup,openshift-route, redis, http, plugin, lease, kea, libvirt, mqtt, sftp, s3, vault, neighbor, mdns, snmp, files, webhook, template, dnsupdate, reverse)").Required().PlaceHolder("source").EnumsVar(&cfg.Sources, "service", "ingress", "node", "istio-gateway", "cloudfoundry", "contour-ingressroute", "fake", "connector", "crd", "empty", "skipper-routegroup", "openshift-route", "redis", "http", "plugin", "lease", "kea", "libvirt", "mqtt", "sftp", "s3", "vault", "neighbor", "mdns", "snmp", "files", "webhook", "template", "dnsupdate", "reverse")
+	app.Flag("split-targets-source", "A source whose endpoints with several targets are split into one endpoint per target, told apart by set identifiers; specify multiple times for multiple sources (optional)").StringsVar(&cfg.SplitTargetsSources)
+	app.Flag("source-precedence", "A source whose endpoints win over the same endpoints, i.e. of the same name, record type and set identifier, of other sources; specify multiple times in order of decreasing precedence, sources which aren't specified have the lowest precedence in the order of --source (default: conflicts aren't resolved)").StringsVar(&cfg.SourcePrecedence)
+	cfg.SourceNamePrefixes = map[string]string{}
+	app.Flag("source-name-prefix", "Prepend a prefix to the names of the endpoints of a source as is, e.g. --source-name-prefix=files=lab-; specify multiple times for multiple sources (optional)").StringMapVar(&cfg.SourceNamePrefixes)
+	cfg.SourceNameSuffixes = map[string]string{}
+	app.Flag("source-name-suffix", "Append a domain to the names of the endpoints of a source, e.g. --source-name-suffix=files=example.org; specify multiple times for multiple sources (optional)").StringMapVar(&cfg.SourceNameSuffixes)
+	cfg.SourceNameStripSuffixes = map[string]string{}
+	app.Flag("source-name-strip-suffix", "Remove a domain from the names of the endpoints of a source ending with it before --source-name-prefix and --source-name-suffix are applied, e.g. --source-name-strip-suffix=files=lan; specify multiple times for multiple sources (optional)").StringMapVar(&cfg.SourceNameStripSuffixes)
+	app.Flag("target-rewrite-source", "A source whose targets of A records are rewritten by --target-rewrite; specify multiple times for multiple sources (optional)").StringsVar(&cfg.TargetRewriteSources)
+	cfg.TargetRewrites = map[string]string{}
+	app.Flag("target-rewrite", "Rewrite the addresses of a network, an address or the addresses of a host name to an address, or the addresses of a network to those of a network of the same size, e.g. --target-rewrite=10.0.0.0/24=203.0.113.0/24; the most specific network wins; specify multiple times for multiple rewrites (optional)").StringMapVar(&cfg.TargetRewrites)
+	cfg.SourceDefaultTTLs = map[string]string{}
+	app.Flag("source-default-ttl", "Set a TTL on the endpoints of a source which don't have one, e.g. --source-default-ttl=files=5m; specify multiple times for multiple sources (optional)").StringMapVar(&cfg.SourceDefaultTTLs)
+	cfg.SourceForcedTTLs = map[string]string{}
+	app.Flag("source-forced-ttl", "Set a TTL on all endpoints of a source, replacing their own, e.g. --source-forced-ttl=files=1m; specify multiple times for multiple sources (optional)").StringMapVar(&cfg.SourceForcedTTLs)
 
 	app.Flag("namespace", "Limit sources of endpoints to a specific namespace (default: all namespaces)").Default(defaultConfig.Namespace).StringVar(&cfg.Namespace)
 	app.Flag("annotation-filter", "Filter sources managed by external-dns via annotation using label selector semantics (default: all sources)").Default(defaultConfig.AnnotationFilter).StringVar(&cfg.AnnotationFilter)
@@ -313,15 +662,111 @@ func (cfg *Config) ParseFlags(args []string) error {
 	app.Flag("publish-host-ip", "Allow external-dns to publish host-ip for headless services (optional)").BoolVar(&cfg.PublishHostIP)
 	app.Flag("always-publish-not-ready-addresses", "Always publish also not ready addresses for headless services (optional)").BoolVar(&cfg.AlwaysPublishNotReadyAddresses)
 	app.Flag("connector-source-server", "The server to connect for connector source, valid only when using connector source").Default(defaultConfig.ConnectorSourceServer).StringVar(&cfg.ConnectorSourceServer)
+	app.Flag("redis-source-address", "The address of the redis server for the redis source, valid only when using redis source").Default(defaultConfig.RedisSourceAddress).StringVar(&cfg.RedisSourceAddress)
+	app.Flag("redis-source-password", "The password used to authenticate to the redis server, valid only when using redis source (optional)").Default(defaultConfig.RedisSourcePassword).StringVar(&cfg.RedisSourcePassword)
+	app.Flag("redis-source-db", "The redis database holding the endpoints key, valid only when using redis source").Default(strconv.Itoa(defaultConfig.RedisSourceDB)).IntVar(&cfg.RedisSourceDB)
+	app.Flag("redis-source-key", "The redis hash or set holding the endpoints, valid only when using redis source").Default(defaultConfig.RedisSourceKey).StringVar(&cfg.RedisSourceKey)
+	app.Flag("redis-source-notifications", "When enabled, subscribe to keyspace notifications of the redis source key to trigger a synchronization on changes; requires --events (default: disabled)").BoolVar(&cfg.RedisSourceNotifications)
+	app.Flag("http-source-url", "The URL of the endpoints document for the http source, valid only when using http source").Default(defaultConfig.HTTPSourceURL).StringVar(&cfg.HTTPSourceURL)
+	app.Flag("http-source-header", "A header sent with every request of the http source in the form \"Name: value\"; specify multiple times for multiple headers (optional)").StringsVar(&cfg.HTTPSourceHeaders)
+	app.Flag("http-source-poll-interval", "The interval in which the http source polls the endpoints document to trigger a synchronization on changes; requires --events (default: disabled)").Default(defaultConfig.HTTPSourcePollInterval.String()).DurationVar(&cfg.HTTPSourcePollInterval)
+	app.Flag("http-source-stale-while-error", "How long the http source keeps serving the last fetched endpoints while the endpoints document cannot be fetched (default: 5m)").Default(defaultConfig.HTTPSourceStaleWhileError.String()).DurationVar(&cfg.HTTPSourceStaleWhileError)
+	app.Flag("plugin-source-socket", "The unix socket of the plugin serving the EndpointSource gRPC service, valid only when using plugin source").Default(defaultConfig.PluginSourceSocket).StringVar(&cfg.PluginSourceSocket)
+	app.Flag("lease-source-path", "The DHCP lease file read by the lease source, valid only when using lease source").Default(defaultConfig.LeaseSourcePath).StringVar(&cfg.LeaseSourcePath)
+	app.Flag("lease-source-format", "The format of the DHCP lease file (default: dnsmasq, options: dnsmasq, isc)").Default(defaultConfig.LeaseSourceFormat).EnumVar(&cfg.LeaseSourceFormat, "dnsmasq", "isc")
+	app.Flag("lease-source-domain", "The domain appended to the hostnames of the DHCP leases (required when --source=lease, optional when --source=kea)").Default(defaultConfig.LeaseSourceDomain).StringVar(&cfg.LeaseSourceDomain)
+	app.Flag("lease-source-max-ttl", "The maximum TTL of the records created by the lease and kea sources; records of shorter leases expire with the lease (default: 1h)").Default(defaultConfig.LeaseSourceMaxTTL.String()).DurationVar(&cfg.LeaseSourceMaxTTL)
+	app.Flag("kea-source-url", "The URL of the Kea control agent queried by the kea source, valid only when using kea source").Default(defaultConfig.KeaSourceURL).StringVar(&cfg.KeaSourceURL)
+	app.Flag("kea-source-subnet-id", "Limit the kea source to leases of the given subnet ID; specify multiple times for multiple subnets (default: all subnets)").IntsVar(&cfg.KeaSourceSubnets)
+	app.Flag("kea-source-hostname-filter", "Limit the kea source to leases whose hostname matches this regular expression (default: all hostnames)").Default(defaultConfig.KeaSourceHostnameFilter).StringVar(&cfg.KeaSourceHostnameFilter)
+	app.Flag("libvirt-source-uri", "The libvirt connection URI of the hypervisor queried by the libvirt source, valid only when using libvirt source").Default(defaultConfig.LibvirtSourceURI).StringVar(&cfg.LibvirtSourceURI)
+	app.Flag("libvirt-source-address-source", "Where the libvirt source reads the addresses of the guests from (default: lease, options: lease, agent, arp)").Default(defaultConfig.LibvirtSourceAddressSource).EnumVar(&cfg.LibvirtSourceAddressSource, "lease", "agent", "arp")
+	app.Flag("libvirt-source-domain", "The domain appended to the names of the libvirt guests (required when --source=libvirt)").Default(defaultConfig.LibvirtSourceDomain).StringVar(&cfg.LibvirtSourceDomain)
+	app.Flag("mqtt-source-broker", "The MQTT broker the mqtt source subscribes to, valid only when using mqtt source").Default(defaultConfig.MQTTSourceBroker).StringVar(&cfg.MQTTSourceBroker)
+	app.Flag("mqtt-source-topic", "The MQTT topic devices publish their {\"name\", \"ip\"} registrations to, valid only when using mqtt source").Default(defaultConfig.MQTTSourceTopic).StringVar(&cfg.MQTTSourceTopic)
+	app.Flag("mqtt-source-client-id", "The client ID of the MQTT session, valid only when using mqtt source").Default(defaultConfig.MQTTSourceClientID).StringVar(&cfg.MQTTSourceClientID)
+	app.Flag("mqtt-source-username", "The username used to authenticate to the MQTT broker, valid only when using mqtt source (optional)").Default(defaultConfig.MQTTSourceUsername).StringVar(&cfg.MQTTSourceUsername)
+	app.Flag("mqtt-source-password", "The password used to authenticate to the MQTT broker, valid only when using mqtt source (optional)").Default(defaultConfig.MQTTSourcePassword).StringVar(&cfg.MQTTSourcePassword)
+	app.Flag("mqtt-source-expiry", "The time after which device registrations expire unless renewed; devices can override it per registration (default: 1h)").Default(defaultConfig.MQTTSourceExpiry.String()).DurationVar(&cfg.MQTTSourceExpiry)
+	app.Flag("mqtt-source-state-file", "When set, the registered devices are persisted to this file to survive restarts (default: in memory only)").Default(defaultConfig.MQTTSourceStateFile).StringVar(&cfg.MQTTSourceStateFile)
+	app.Flag("sftp-source-address", "The host[:port] of the SSH server the sftp source fetches the endpoints document from (required when --source=sftp)").Default(defaultConfig.SFTPSourceAddress).StringVar(&cfg.SFTPSourceAddress)
+	app.Flag("sftp-source-user", "The user the sftp source authenticates as, valid only when using sftp source").Default(defaultConfig.SFTPSourceUser).StringVar(&cfg.SFTPSourceUser)
+	app.Flag("sftp-source-key-file", "The private key the sftp source authenticates with, valid only when using sftp source").Default(defaultConfig.SFTPSourceKeyFile).StringVar(&cfg.SFTPSourceKeyFile)
+	app.Flag("sftp-source-host-key", "The pinned public key of the SSH server in authorized_keys format, e.g. `ssh-ed25519 AAAA...` (required when --source=sftp)").Default(defaultConfig.SFTPSourceHostKey).StringVar(&cfg.SFTPSourceHostKey)
+	app.Flag("sftp-source-path", "The path of the endpoints document on the SSH server, valid only when using sftp source").Default(defaultConfig.SFTPSourcePath).StringVar(&cfg.SFTPSourcePath)
+	app.Flag("s3-source-endpoint", "The URL of the S3 compatible object store the s3 source downloads the endpoints document from (default: AWS S3)").Default(defaultConfig.S3SourceEndpoint).StringVar(&cfg.S3SourceEndpoint)
+	app.Flag("s3-source-region", "The region of the bucket, valid only when using s3 source").Default(defaultConfig.S3SourceRegion).StringVar(&cfg.S3SourceRegion)
+	app.Flag("s3-source-bucket", "The bucket holding the endpoints document (required when --source=s3)").Default(defaultConfig.S3SourceBucket).StringVar(&cfg.S3SourceBucket)
+	app.Flag("s3-source-key", "The key of the endpoints document in the bucket, valid only when using s3 source").Default(defaultConfig.S3SourceKey).StringVar(&cfg.S3SourceKey)
+	app.Flag("s3-source-access-key-id", "The access key ID used to authenticate to the object store (default: the AWS credential chain)").Default(defaultConfig.S3SourceAccessKeyID).StringVar(&cfg.S3SourceAccessKeyID)
+	app.Flag("s3-source-secret-access-key", "The secret access key used to authenticate to the object store, valid only with --s3-source-access-key-id").Default(defaultConfig.S3SourceSecretAccessKey).StringVar(&cfg.S3SourceSecretAccessKey)
+	app.Flag("vault-source-address", "The address of the Vault server the vault source reads endpoints documents from, valid only when using vault source").Default(defaultConfig.VaultSourceAddress).StringVar(&cfg.VaultSourceAddress)
+	app.Flag("vault-source-path", "The KV path of a secret holding an endpoints document, including data/ for version 2 engines; specify multiple times for multiple secrets (required when --source=vault)").StringsVar(&cfg.VaultSourcePaths)
+	app.Flag("vault-source-token", "The token used to authenticate to Vault, valid only when using vault source (optional)").Default(defaultConfig.VaultSourceToken).StringVar(&cfg.VaultSourceToken)
+	app.Flag("vault-source-role-id", "The AppRole role ID used to authenticate to Vault when no token is given, valid only when using vault source").Default(defaultConfig.VaultSourceRoleID).StringVar(&cfg.VaultSourceRoleID)
+	app.Flag("vault-source-secret-id", "The AppRole secret ID used to authenticate to Vault when no token is given, valid only when using vault source").Default(defaultConfig.VaultSourceSecretID).StringVar(&cfg.VaultSourceSecretID)
+	app.Flag("neighbor-source-table", "The neighbor table read by the neighbor source, valid only when using neighbor source").Default(defaultConfig.NeighborSourceTable).StringVar(&cfg.NeighborSourceTable)
+	app.Flag("neighbor-source-subnet", "Limit the neighbor source to hosts in this subnet and probe all of its addresses; specify multiple times for multiple subnets (default: all neighbors, no probing)").StringsVar(&cfg.NeighborSourceSubnets)
+	app.Flag("neighbor-source-mac-name", "Name the host with the given MAC address, in the form <mac>=<name>, instead of reverse resolving its address; specify multiple times for multiple hosts").StringsVar(&cfg.NeighborSourceMACNames)
+	app.Flag("neighbor-source-domain", "The domain appended to unqualified host names of the neighbor source; hosts with unqualified names are skipped if empty (optional)").Default(defaultConfig.NeighborSourceDomain).StringVar(&cfg.NeighborSourceDomain)
+	app.Flag("mdns-source-service", "The mDNS service type browsed by the mdns source; specify multiple times for multiple service types (default: _workstation._tcp)").Default(defaultConfig.MDNSSourceServices...).StringsVar(&cfg.MDNSSourceServices)
+	app.Flag("mdns-source-domain", "The unicast domain the hosts found by the mdns source are published in, replacing .local (required when --source=mdns)").Default(defaultConfig.MDNSSourceDomain).StringVar(&cfg.MDNSSourceDomain)
+	app.Flag("mdns-source-timeout", "How long the mdns source waits for responses to its queries (default: 2s)").Default(defaultConfig.MDNSSourceTimeout.String()).DurationVar(&cfg.MDNSSourceTimeout)
+	app.Flag("snmp-source-target", "The host[:port] of a device polled by the snmp source; specify multiple times for multiple devices (required when --source=snmp)").StringsVar(&cfg.SNMPSourceTargets)
+	app.Flag("snmp-source-community", "The SNMP v2c community used to poll the devices, valid only when using snmp source").Default(defaultConfig.SNMPSourceCommunity).StringVar(&cfg.SNMPSourceCommunity)
+	app.Flag("snmp-source-domain", "The domain appended to unqualified device names of the snmp source (required when --source=snmp)").Default(defaultConfig.SNMPSourceDomain).StringVar(&cfg.SNMPSourceDomain)
+	app.Flag("files-source-path", "The path of an endpoints document merged by the files source, or of a directory of documents, e.g. a mounted ConfigMap; specify multiple times in order of increasing precedence (required when --source=files)").StringsVar(&cfg.FilesSourcePaths)
+	app.Flag("files-source-conflict", "How the files source handles endpoints defined in several files; override lets later files win, fail rejects the files (default: override, options: override, fail)").Default(defaultConfig.FilesSourceConflict).EnumVar(&cfg.FilesSourceConflict, "override", "fail")
+	app.Flag("files-source-poll-interval", "The interval in which the files source checks the files for changes to trigger a synchronization independently of --interval; requires --events (default: disabled)").Default(defaultConfig.FilesSourcePollInterval.String()).DurationVar(&cfg.FilesSourcePollInterval)
+	app.Flag("files-source-strict", "Reject the files of the files source with unknown fields, e.g. misspelled ones, instead of ignoring the fields; top-level fields starting with x- are still ignored (default: disabled)").BoolVar(&cfg.FilesSourceStrict)
+	app.Flag("files-source-max-change", "The maximum percentage by which the count of the endpoints of the files source may change between reads; larger changes, e.g. of truncated files, are held back until confirmed (default: disabled)").Default(strconv.Itoa(defaultConfig.FilesSourceMaxChange)).IntVar(&cfg.FilesSourceMaxChange)
+	app.Flag("files-source-confirm-after", "The time the endpoints of the files source held back by --files-source-max-change must be read for to be accepted (default: disabled, only --files-source-force-file accepts them)").Default(defaultConfig.FilesSourceConfirmAfter.String()).DurationVar(&cfg.FilesSourceConfirmAfter)
+	app.Flag("files-source-force-file", "While this file exists, the endpoints of the files source held back by --files-source-max-change are accepted right away (optional)").Default(defaultConfig.FilesSourceForceFile).StringVar(&cfg.FilesSourceForceFile)
+	app.Flag("files-source-delta-dir", "A directory of delta documents adding and removing endpoints, which the files source applies to --files-source-delta-base and deletes (optional)").Default(defaultConfig.FilesSourceDeltaDir).StringVar(&cfg.FilesSourceDeltaDir)
+	app.Flag("files-source-delta-base", "The endpoints document maintained by the files source by applying the deltas of --files-source-delta-dir, read after the documents of --files-source-path (required with --files-source-delta-dir)").Default(defaultConfig.FilesSourceDeltaBase).StringVar(&cfg.FilesSourceDeltaBase)
+	app.Flag("files-source-max-age", "The maximum age of the files of the files source, by their generatedAt field or else their modification time; older files are reported as stale (default: disabled)").Default(defaultConfig.FilesSourceMaxAge.String()).DurationVar(&cfg.FilesSourceMaxAge)
+	app.Flag("files-source-freeze-stale", "Keep the endpoints removed from the files of the files source while they are older than --files-source-max-age (default: disabled)").BoolVar(&cfg.FilesSourceFreezeStale)
+	app.Flag("webhook-source-listen-address", "The address the webhook source accepts pushed endpoints documents on (default: :7980)").Default(defaultConfig.WebhookSourceListenAddress).StringVar(&cfg.WebhookSourceListenAddress)
+	app.Flag("webhook-source-token", "The bearer token clients of the webhook source must authenticate with (required when --source=webhook)").Default(defaultConfig.WebhookSourceToken).StringVar(&cfg.WebhookSourceToken)
+	app.Flag("webhook-source-file", "The file the webhook source persists the pushed endpoints document to (required when --source=webhook)").Default(defaultConfig.WebhookSourceFile).StringVar(&cfg.WebhookSourceFile)
+	app.Flag("template-source-template", "The Go template the template source renders into an endpoints document (required when --source=template)").Default(defaultConfig.TemplateSourceTemplate).StringVar(&cfg.TemplateSourceTemplate)
+	app.Flag("template-source-data", "A YAML or JSON file the template of the template source is rendered with (optional)").Default(defaultConfig.TemplateSourceData).StringVar(&cfg.TemplateSourceData)
+	app.Flag("dnsupdate-source-listen-address", "The address the dnsupdate source accepts RFC2136 dynamic updates on via UDP and TCP (default: :10053)").Default(defaultConfig.DNSUpdateSourceListenAddr).StringVar(&cfg.DNSUpdateSourceListenAddr)
+	app.Flag("dnsupdate-source-zone", "The zone the dnsupdate source accepts dynamic updates for (required when --source=dnsupdate)").Default(defaultConfig.DNSUpdateSourceZone).StringVar(&cfg.DNSUpdateSourceZone)
+	app.Flag("dnsupdate-source-tsig-keyname", "The name of the TSIG key dynamic updates must be signed with (required when --source=dnsupdate)").Default(defaultConfig.DNSUpdateSourceTSIGKeyName).StringVar(&cfg.DNSUpdateSourceTSIGKeyName)
+	app.Flag("dnsupdate-source-tsig-secret", "The base64 encoded secret of the TSIG key dynamic updates must be signed with (required when --source=dnsupdate)").Default(defaultConfig.DNSUpdateSourceTSIGSecret).StringVar(&cfg.DNSUpdateSourceTSIGSecret)
+	app.Flag("dnsupdate-source-file", "The file the dnsupdate source persists the registered records to (optional)").Default(defaultConfig.DNSUpdateSourceFile).StringVar(&cfg.DNSUpdateSourceFile)
+	app.Flag("reverse-source-path", "The path of a forward endpoints document the reverse source derives PTR records from; specify multiple times in order of increasing precedence (required when --source=reverse)").StringsVar(&cfg.ReverseSourcePaths)
+	app.Flag("reverse-source-zone", "A reverse zone the reverse source manages PTR records in, e.g. 10.in-addr.arpa; specify multiple times for multiple zones (required when --source=reverse)").StringsVar(&cfg.ReverseSourceZones)
 	app.Flag("crd-source-apiversion", "API version of the CRD for crd source, e.g. `externaldns.k8s.io/v1alpha1`, valid only when using crd source").Default(defaultConfig.CRDSourceAPIVersion).StringVar(&cfg.CRDSourceAPIVersion)
 	app.Flag("crd-source-kind", "Kind of the CRD for the crd source in API group and version specified by crd-source-apiversion").Default(defaultConfig.CRDSourceKind).StringVar(&cfg.CRDSourceKind)
 	app.Flag("service-type-filter", "The service types to take care about (default: all, expected: ClusterIP, NodePort, LoadBalancer or ExternalName)").StringsVar(&cfg.ServiceTypeFilter)
+	app.Flag("endpoint-max-targets", "Skip endpoints of the sources with more than this number of targets (default: 0, unlimited)").Default(strconv.Itoa(defaultConfig.EndpointMaxTargets)).IntVar(&cfg.EndpointMaxTargets)
+	app.Flag("endpoint-min-ttl", "Skip endpoints of the sources with a TTL below this duration (default: 0, unlimited)").Default(defaultConfig.EndpointMinTTL.String()).DurationVar(&cfg.EndpointMinTTL)
+	app.Flag("endpoint-max-ttl", "Skip endpoints of the sources with a TTL above this duration (default: 0, unlimited)").Default(defaultConfig.EndpointMaxTTL.String()).DurationVar(&cfg.EndpointMaxTTL)
+	app.Flag("admission-listen-address", "The address to serve a validating admission webhook for DNSEndpoint resources on, with the path /validate; it rejects endpoints that would be skipped, unsupported record types and names outside of --domain-filter (optional)").Default(defaultConfig.AdmissionListenAddress).StringVar(&cfg.AdmissionListenAddress)
+	app.Flag("admission-tls-cert-file", "The TLS certificate the admission webhook is served with (required with --admission-listen-address)").Default(defaultConfig.AdmissionTLSCertFile).StringVar(&cfg.AdmissionTLSCertFile)
+	app.Flag("admission-tls-key-file", "The TLS key the admission webhook is served with (required with --admission-listen-address)").Default(defaultConfig.AdmissionTLSKeyFile).StringVar(&cfg.AdmissionTLSKeyFile)
 
 	// Flags related to providers
-	app.Flag("provider", "The DNS provider where the DNS records will be created (required, options: aws, aws-sd, google, azure, azure-dns, azure-private-dns, cloudflare, rcodezero, digitalocean, dnsimple, akamai, infoblox, dyn, designate, coredns, skydns, inmemory, ovh, pdns, oci, exoscale, linode, rfc2136, ns1, transip, vinyldns, rdns, vultr)").Required().PlaceHolder("provider").EnumVar(&cfg.Provider, "aws", "aws-sd", "google", "azure", "azure-dns", "azure-private-dns", "alibabacloud", "cloudflare", "rcodezero", "digitalocean", "dnsimple", "akamai", "infoblox", "dyn", "designate", "coredns", "skydns", "inmemory", "ovh", "pdns", "oci", "exoscale", "linode", "rfc2136", "ns1", "transip", "vinyldns", "rdns", "vultr")
+	providers := []string{"aws", "aws-sd", "google", "azure", "azure-dns", "azure-private-dns", "alibabacloud", "cloudflare", "rcodezero", "digitalocean", "dnsimple", "akamai", "infoblox", "dyn", "designate", "coredns", "skydns", "inmemory", "ovh", "pdns", "oci", "exoscale", "linode", "rfc2136", "ns1", "transip", "vinyldns", "rdns", "vultr"}
+	app.Flag("provider", "The DNS provider where the DNS records will be created (required, options: aws, aws-sd, google, azure, azure-dns, azure-private-dns, cloudflare, rcodezero, digitalocean, dnsimple, akamai, infoblox, dyn, designate, coredns, skydns, inmemory, ovh, pdns, oci, exoscale, linode, rfc2136, ns1, transip, vinyldns, rdns, vultr)").Required().PlaceHolder("provider").EnumVar(&cfg.Provider, providers...)
+	app.Flag("migrate-from", "Copy the records of this DNS provider to the one of --provider and exit instead of synchronizing the sources; records are only created and updated, honoring --domain-filter and --dry-run. Both providers are configured by their flags, so they must differ (optional, options: same as --provider)").PlaceHolder("provider").EnumVar(&cfg.MigrateFrom, providers...)
+	app.Flag("secondary-provider", "A second DNS provider the records of endpoints with its name as provider, e.g. \"provider\": \"cloudflare\" in endpoints files, are routed to, while the other records are routed to --provider. Both providers are configured by their flags, so they must differ (optional, options: same as --provider)").PlaceHolder("provider").EnumVar(&cfg.SecondaryProvider, providers...)
+	app.Flag("backup", "Write the records of the DNS provider within --domain-filter, including those of the registry, to this file as a JSON archive and exit instead of synchronizing the sources (optional)").StringVar(&cfg.Backup)
+	app.Flag("restore", "Recreate the records of this archive written with --backup in the DNS provider and exit instead of synchronizing the sources; records are only created and updated, honoring --domain-filter and --dry-run (optional)").StringVar(&cfg.Restore)
 	app.Flag("domain-filter", "Limit possible target zones by a domain suffix; specify multiple times for multiple domains (optional)").Default("").StringsVar(&cfg.DomainFilter)
 	app.Flag("exclude-domains", "Exclude subdomains (optional)").Default("").StringsVar(&cfg.ExcludeDomains)
 	app.Flag("zone-id-filter", "Filter target zones by hosted zone id; specify multiple times for multiple zones (optional)").Default("").StringsVar(&cfg.ZoneIDFilter)
+	app.Flag("provider-state-file", "Keep the records last listed from the DNS provider and the changes applied since in this file, and plan against them instead of listing the records every synchronization; for providers that are slow to list records (optional)").Default(defaultConfig.ProviderStateFile).StringVar(&cfg.ProviderStateFile)
+	app.Flag("provider-state-resync-interval", "The interval between listings of the records of the DNS provider with --provider-state-file, correcting changes made by others (default: 1h)").Default(defaultConfig.ProviderStateResyncInterval.String()).DurationVar(&cfg.ProviderStateResyncInterval)
+	app.Flag("mirror-file", "Mirror the records of the DNS provider to this local file with every synchronization and every applied change, e.g. for resolvers on the host (optional)").Default(defaultConfig.MirrorFile).StringVar(&cfg.MirrorFile)
+	app.Flag("mirror-file-format", "The format of --mirror-file; hosts writes the A records like /etc/hosts, zone writes all records like a zone file (default: hosts, options: hosts, zone)").Default(defaultConfig.MirrorFileFormat).EnumVar(&cfg.MirrorFileFormat, "hosts", "zone")
+	app.Flag("debug-records", "Serve the records last read from the DNS provider, by domain and with the time they were read, on /debug/records of the metrics server; not supported with the aws-sd registry (default: disabled)").BoolVar(&cfg.DebugRecords)
+	app.Flag("trim-trailing-dots", "Remove the trailing dots of the names and hostname targets of the records read from and written to the DNS provider, for providers storing names without trailing dots (default: disabled)").BoolVar(&cfg.TrimTrailingDots)
+	app.Flag("fault-injection-latency", "Delay every call to the DNS provider by a random duration up to this one, e.g. to test resilience in staging (default: 0, disabled)").Default(defaultConfig.FaultInjectionLatency.String()).DurationVar(&cfg.FaultInjectionLatency)
+	app.Flag("fault-injection-error-rate", "Fail this share of the calls to the DNS provider without calling it, from 0 to 1, e.g. to test resilience in staging (default: 0, disabled)").Default(strconv.FormatFloat(defaultConfig.FaultInjectionErrorRate, 'f', -1, 64)).Float64Var(&cfg.FaultInjectionErrorRate)
+	app.Flag("fault-injection-partial-rate", "Only partially succeed this share of the calls to the DNS provider, from 0 to 1: listing records returns only some of them, and applying changes only applies the creations and fails (default: 0, disabled)").Default(strconv.FormatFloat(defaultConfig.FaultInjectionPartialRate, 'f', -1, 64)).Float64Var(&cfg.FaultInjectionPartialRate)
 	app.Flag("google-project", "When using the Google provider, current project is auto-detected, when running on GCP. Specify other project with this. Must be specified when running outside GCP.").Default(defaultConfig.GoogleProject).StringVar(&cfg.GoogleProject)
 	app.Flag("google-batch-change-size", "When using the Google provider, set the maximum number of changes that will be applied in each batch.").Default(strconv.Itoa(defaultConfig.GoogleBatchChangeSize)).IntVar(&cfg.GoogleBatchChangeSize)
 	app.Flag("google-batch-change-interval", "When using the Google provider, set the interval between batch changes.").Default(defaultConfig.GoogleBatchChangeInterval.String()).DurationVar(&cfg.GoogleBatchChangeInterval)
@@ -396,24 +841,68 @@ func (cfg *Config) ParseFlags(args []string) error {
 
 	// Flags related to policies
 	app.Flag("policy", "Modify how DNS records are synchronized between sources and providers (default: sync, options: sync, upsert-only, create-only)").Default(defaultConfig.Policy).EnumVar(&cfg.Policy, "sync", "upsert-only", "create-only")
+	cfg.PolicyOverrides = map[string]string{}
+	app.Flag("policy-override", "Use a different policy for the records of a domain and its subdomains, e.g. --policy-override=prod.example.org=upsert-only; the longest matching domain wins (optional)").StringMapVar(&cfg.PolicyOverrides)
+	app.Flag("max-deletions", "Refuse to apply changes deleting more than this number of DNS records at once, e.g. after a source was emptied by mistake (default: 0, disabled; required with --registry=single-writer)").Default(strconv.Itoa(defaultConfig.MaxDeletions)).IntVar(&cfg.MaxDeletions)
+	app.Flag("max-records-per-domain", "Refuse to apply the changes of a domain, grouped by --domain-filter, which would then hold more than this number of DNS records, while the changes of other domains are applied (default: 0, disabled)").Default(strconv.Itoa(defaultConfig.MaxRecordsPerDomain)).IntVar(&cfg.MaxRecordsPerDomain)
+	app.Flag("manage-ns-records", "Also manage NS records, e.g. to delegate subdomains to other name servers; the NS records of the apex of the domains, grouped by --domain-filter, are never deleted (default: disabled)").BoolVar(&cfg.ManageNSRecords)
+	app.Flag("manage-ptr-records", "Also manage PTR records: add the PTR records of the addresses of the A records of the sources in the reverse zones of --ptr-zone, which --domain-filter must include if set; the PTR records of --source=reverse are managed without it (default: disabled)").BoolVar(&cfg.ManagePTRRecords)
+	app.Flag("manage-mx-records", "Also manage MX records; without it, MX records are neither created, updated nor deleted (default: disabled)").BoolVar(&cfg.ManageMXRecords)
+	app.Flag("manage-txt-records", "Also manage TXT records, e.g. SPF records; with --registry=txt, they require --txt-prefix or --txt-suffix. Without it, TXT records other than those of the registry are neither created, updated nor deleted (default: disabled)").BoolVar(&cfg.ManageTXTRecords)
+	app.Flag("manage-srv-records", "Also manage SRV records; without it, SRV records are neither created, updated nor deleted (default: disabled)").BoolVar(&cfg.ManageSRVRecords)
+	app.Flag("ptr-zone", "A reverse zone --manage-ptr-records manages PTR records in, e.g. 10.in-addr.arpa; specify multiple times for multiple zones (required when --manage-ptr-records)").StringsVar(&cfg.PTRZones)
+	app.Flag("max-apply-failures", "Stop applying changes for --apply-failure-cooldown after this number of consecutive failures to apply them, while records are still read (default: 0, disabled)").Default(strconv.Itoa(defaultConfig.MaxApplyFailures)).IntVar(&cfg.MaxApplyFailures)
+	app.Flag("apply-failure-cooldown", "The time no changes are applied after --max-apply-failures consecutive failures (default: 5m)").Default(defaultConfig.ApplyFailureCooldown.String()).DurationVar(&cfg.ApplyFailureCooldown)
+	app.Flag("circuit-breaker-reset", "When enabled, POST /circuit-breaker/reset on the metrics address applies changes again right away after --max-apply-failures consecutive failures; it isn't authenticated, so only enable it if untrusted clients can't reach the metrics address (default: disabled)").BoolVar(&cfg.CircuitBreakerReset)
+	app.Flag("propagation-resolver", "After applying changes, verify that the changed records are served by the DNS server at this address, e.g. one serving the zones of the provider, and report those which aren't in the logs and metrics (optional)").Default(defaultConfig.PropagationResolver).StringVar(&cfg.PropagationResolver)
+	app.Flag("propagation-timeout", "The time applied changes get to be served by --propagation-resolver (default: 30s)").Default(defaultConfig.PropagationTimeout.String()).DurationVar(&cfg.PropagationTimeout)
+	app.Flag("create-before-delete", "Roll out updates removing targets of records in two steps: add the new targets first and remove the old targets in a later synchronization, once --propagation-resolver serves the new targets; requires --propagation-resolver (default: disabled)").BoolVar(&cfg.CreateBeforeDelete)
+	app.Flag("deletion-grace-period", "Hold back the deletion of records until every synchronization planned it for this period, while creations and updates are applied right away, so sources briefly losing their endpoints don't delete their records; not supported with --once (default: 0, disabled)").Default(defaultConfig.DeletionGracePeriod.String()).DurationVar(&cfg.DeletionGracePeriod)
 
 	// Flags related to the registry
-	app.Flag("registry", "The registry implementation to use to keep track of DNS record ownership (default: txt, options: txt, noop, aws-sd)").Default(defaultConfig.Registry).EnumVar(&cfg.Registry, "txt", "noop", "aws-sd")
-	app.Flag("txt-owner-id", "When using the TXT registry, a name that identifies this instance of ExternalDNS (default: default)").Default(defaultConfig.TXTOwnerID).StringVar(&cfg.TXTOwnerID)
-	app.Flag("txt-prefix", "When using the TXT registry, a custom string that's prefixed to each ownership DNS record (optional). Mutual exclusive with txt-suffix!").Default(defaultConfig.TXTPrefix).StringVar(&cfg.TXTPrefix)
-	app.Flag("txt-suffix", "When using the TXT registry, a custom string that's suffixed to the host portion of each ownership DNS record (optional). Mutual exclusive with txt-prefix!").Default(defaultConfig.TXTSuffix).StringVar(&cfg.TXTSuffix)
+	app.Flag("registry", "The registry implementation to use to keep track of DNS record ownership (default: txt, options: txt, noop, aws-sd, file, single-writer)").Default(defaultConfig.Registry).EnumVar(&cfg.Registry, "txt", "noop", "aws-sd", "file", "single-writer")
+	app.Flag("txt-owner-id", "When using the TXT or file registry, a name that identifies this instance of ExternalDNS (default: default)").Default(defaultConfig.TXTOwnerID).StringVar(&cfg.TXTOwnerID)
+	app.Flag("txt-prefix", "When using the TXT registry, a custom string that's prefixed to each ownership DNS record, which may contain %{record_type} (optional). Mutual exclusive with txt-suffix!").Default(defaultConfig.TXTPrefix).StringVar(&cfg.TXTPrefix)
+	app.Flag("txt-suffix", "When using the TXT registry, a custom string that's suffixed to the host portion of each ownership DNS record, which may contain %{record_type} (optional). Mutual exclusive with txt-prefix!").Default(defaultConfig.TXTSuffix).StringVar(&cfg.TXTSuffix)
+	app.Flag("txt-wildcard-replacement", "When using the TXT registry, a custom string that replaces * in the names of ownership DNS records of wildcard records (optional)").Default(defaultConfig.TXTWildcardReplacement).StringVar(&cfg.TXTWildcardReplacement)
+	app.Flag("txt-encrypt-aes-key", "When using the TXT registry, a base64 encoded 32 byte AES key that encrypts the contents of ownership DNS records (optional)").Default(defaultConfig.TXTEncryptAESKey).StringVar(&cfg.TXTEncryptAESKey)
+	app.Flag("txt-encrypt-aes-old-key", "When using the TXT registry with encryption, a previous AES key that ownership DNS records may still be encrypted with, for rotating keys; specify multiple times for multiple keys (optional)").StringsVar(&cfg.TXTEncryptAESOldKeys)
+	app.Flag("txt-metadata", "When using the TXT registry, also store the host name of the instance which created a record and the time of its last change in its ownership DNS record (default: disabled)").BoolVar(&cfg.TXTMetadata)
+	app.Flag("file-registry-path", "When using the file registry, the file that stores the ownership of DNS records; may be shared by several instances of ExternalDNS (required when --registry=file)").Default(defaultConfig.FileRegistryPath).StringVar(&cfg.FileRegistryPath)
 
 	// Flags related to the main control loop
 	app.Flag("txt-cache-interval", "The interval between cache synchronizations in duration format (default: disabled)").Default(defaultConfig.TXTCacheInterval.String()).DurationVar(&cfg.TXTCacheInterval)
 	app.Flag("interval", "The interval between two consecutive synchronizations in duration format (default: 1m)").Default(defaultConfig.Interval.String()).DurationVar(&cfg.Interval)
+	app.Flag("shutdown-timeout", "On termination, the time a synchronization in progress gets to finish applying its changes before it's canceled (default: 20s)").Default(defaultConfig.ShutdownTimeout.String()).DurationVar(&cfg.ShutdownTimeout)
+	app.Flag("secrets-refresh-interval", "The interval in which the secrets referenced by sensitive flags, e.g. --pdns-api-key=awssm://external-dns#api-key, are fetched again; ExternalDNS terminates once one of them changed, so it's restarted with the rotated secret (default: disabled)").Default(defaultConfig.SecretsRefreshInterval.String()).DurationVar(&cfg.SecretsRefreshInterval)
 	app.Flag("once", "When enabled, exits the synchronization loop after the first iteration (default: disabled)").BoolVar(&cfg.Once)
+	app.Flag("once-changes-exit-code", "When running once, the exit code if records were changed, or changes were exported with --plan-export, so jobs can tell whether DNS changed; 0 is returned without changes and 1 on failure (default: 0, same as without changes)").Default(strconv.Itoa(defaultConfig.OnceChangesExitCode)).IntVar(&cfg.OnceChangesExitCode)
 	app.Flag("dry-run", "When enabled, prints DNS record changes rather than actually performing them (default: disabled)").BoolVar(&cfg.DryRun)
+	app.Flag("plan-export", "When set, writes the calculated DNS record changes to this file rather than performing them, for a review before they are applied with --plan-import; requires --once").Default(defaultConfig.PlanExport).StringVar(&cfg.PlanExport)
+	app.Flag("plan-import", "When set, performs the DNS record changes of this file, written by --plan-export, rather than calculating them, and refuses to if the records changed since the plan was calculated; requires --once").Default(defaultConfig.PlanImport).StringVar(&cfg.PlanImport)
+	app.Flag("diff", "When set, prints the DNS record changes in this format rather than performing them, and exits with --once-changes-exit-code, or 2 if unset, if there are changes; requires --once (optional, options: text, json)").EnumVar(&cfg.Diff, "text", "json")
+	app.Flag("verify", "When set, compares the endpoints of the sources to the records stored by the provider and served by --propagation-resolver rather than synchronizing them, prints the comparison of each record in this format, and exits with 2 if any record isn't stored or served as desired; requires --propagation-resolver (optional, options: text, json)").EnumVar(&cfg.Verify, "text", "json")
+	app.Flag("simulate", "When set, synchronizes the sources with an in-memory DNS provider holding the records of this endpoints document instead of --provider, and prints the resulting records; the zones are those of --inmemory-zone, or --domain-filter if unset. Requires --once (optional)").Default(defaultConfig.Simulate).StringVar(&cfg.Simulate)
 	app.Flag("events", "When enabled, in addition to running every interval, the reconciliation loop will get triggered when supported sources change (default: disabled)").BoolVar(&cfg.UpdateEvents)
+	app.Flag("events-quiet-period", "When events are enabled, the time without further events after which events trigger the reconciliation loop, so bursts of events are batched; continuous events delay it by at most the interval (default: 5s)").Default(defaultConfig.EventsQuietPeriod.String()).DurationVar(&cfg.EventsQuietPeriod)
+	app.Flag("leader-election", "When enabled, only the instance holding a Lease in the cluster runs the synchronization loop, so several replicas can run for availability (default: disabled)").BoolVar(&cfg.LeaderElection)
+	app.Flag("leader-election-namespace", "The namespace of the Lease used for leader election (default: default)").Default(defaultConfig.LeaderElectionNamespace).StringVar(&cfg.LeaderElectionNamespace)
+	app.Flag("leader-election-id", "The name of the Lease used for leader election; replicas with the same name elect a single leader (default: external-dns)").Default(defaultConfig.LeaderElectionID).StringVar(&cfg.LeaderElectionID)
 
 	// Miscellaneous flags
 	app.Flag("log-format", "The format in which log messages are printed (default: text, options: text, json)").Default(defaultConfig.LogFormat).EnumVar(&cfg.LogFormat, "text", "json")
 	app.Flag("metrics-address", "Specify where to serve the metrics and health check endpoint (default: :7979)").Default(defaultConfig.MetricsAddress).StringVar(&cfg.MetricsAddress)
+	app.Flag("tracing-zipkin-url", "When set, exports OpenTelemetry traces of the synchronizations to this Zipkin v2 API endpoint, e.g. http://localhost:9411/api/v2/spans (optional)").Default(defaultConfig.TracingZipkinURL).StringVar(&cfg.TracingZipkinURL)
 	app.Flag("log-level", "Set the level of logging. (default: info, options: panic, debug, info, warning, error, fatal").Default(defaultConfig.LogLevel).EnumVar(&cfg.LogLevel, allLogLevelsAsStrings()...)
+	app.Flag("log-changes", "When enabled, logs every planned change with the old and new values of the record side by side (default: disabled)").BoolVar(&cfg.LogChanges)
+
+	if path := configFilePath(args); path != "" {
+		fileArgs, err := configFileArgs(path, args)
+		if err != nil {
+			return err
+		}
+		args = append(fileArgs, args...)
+	}
 
 	_, err := app.Parse(args)
 	if err != nil {
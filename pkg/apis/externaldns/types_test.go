@@ -17,6 +17,7 @@ limitations under the License.
 package externaldns
 
 import (
+	"errors"
 	"os"
 	"strings"
 	"testing"
@@ -35,6 +36,12 @@ var (
 		ContourLoadBalancerService:  "heptio-contour/contour",
 		SkipperRouteGroupVersion:    "zalando.org/v1",
 		Sources:                     []string{"service"},
+		SourceNamePrefixes:          map[string]string{},
+		SourceNameSuffixes:          map[string]string{},
+		SourceNameStripSuffixes:     map[string]string{},
+		TargetRewrites:              map[string]string{},
+		SourceDefaultTTLs:           map[string]string{},
+		SourceForcedTTLs:            map[string]string{},
 		Namespace:                   "",
 		FQDNTemplate:                "",
 		Compatibility:               "",
@@ -44,6 +51,8 @@ var (
 		GoogleBatchChangeInterval:   time.Second,
 		DomainFilter:                []string{""},
 		ExcludeDomains:              []string{""},
+		ProviderStateResyncInterval: time.Hour,
+		MirrorFileFormat:            "hosts",
 		ZoneIDFilter:                []string{""},
 		AlibabaCloudConfigFile:      "/etc/kubernetes/alibaba-cloud.json",
 		AWSZoneType:                 "",
@@ -79,18 +88,52 @@ var (
 		PDNSServer:                  "http://localhost:8081",
 		PDNSAPIKey:                  "",
 		Policy:                      "sync",
+		PolicyOverrides:             map[string]string{},
 		Registry:                    "txt",
 		TXTOwnerID:                  "default",
 		TXTPrefix:                   "",
 		TXTCacheInterval:            0,
 		Interval:                    time.Minute,
+		ShutdownTimeout:             20 * time.Second,
+		ApplyFailureCooldown:        5 * time.Minute,
+		PropagationTimeout:          30 * time.Second,
 		Once:                        false,
 		DryRun:                      false,
 		UpdateEvents:                false,
+		EventsQuietPeriod:           5 * time.Second,
+		LeaderElectionNamespace:     "default",
+		LeaderElectionID:            "external-dns",
 		LogFormat:                   "text",
 		MetricsAddress:              ":7979",
 		LogLevel:                    logrus.InfoLevel.String(),
 		ConnectorSourceServer:       "localhost:8080",
+		RedisSourceAddress:          "localhost:6379",
+		RedisSourceKey:              "external-dns",
+		HTTPSourceStaleWhileError:   5 * time.Minute,
+		PluginSourceSocket:          "/var/run/external-dns/plugin.sock",
+		LeaseSourcePath:             "/var/lib/misc/dnsmasq.leases",
+		LeaseSourceFormat:           "dnsmasq",
+		LeaseSourceMaxTTL:           time.Hour,
+		KeaSourceURL:                "http://localhost:8000",
+		LibvirtSourceURI:            "qemu:///system",
+		LibvirtSourceAddressSource:  "lease",
+		MQTTSourceBroker:            "tcp://localhost:1883",
+		MQTTSourceTopic:             "external-dns/register",
+		MQTTSourceClientID:          "external-dns",
+		MQTTSourceExpiry:            time.Hour,
+		SFTPSourceUser:              "external-dns",
+		SFTPSourceKeyFile:           "/etc/external-dns/ssh/id_rsa",
+		SFTPSourcePath:              "endpoints.json",
+		S3SourceRegion:              "us-east-1",
+		S3SourceKey:                 "endpoints.json",
+		VaultSourceAddress:          "https://127.0.0.1:8200",
+		NeighborSourceTable:         "/proc/net/arp",
+		MDNSSourceServices:          []string{"_workstation._tcp"},
+		MDNSSourceTimeout:           2 * time.Second,
+		SNMPSourceCommunity:         "public",
+		FilesSourceConflict:         "override",
+		WebhookSourceListenAddress:  ":7980",
+		DNSUpdateSourceListenAddr:   ":10053",
 		ExoscaleEndpoint:            "https://api.exoscale.ch/dns",
 		ExoscaleAPIKey:              "",
 		ExoscaleAPISecret:           "",
@@ -103,23 +146,46 @@ var (
 	}
 
 	overriddenConfig = &Config{
+		ConfigReloadInterval:        time.Minute,
 		Master:                      "http://127.0.0.1:8080",
 		KubeConfig:                  "/some/path",
 		RequestTimeout:              time.Second * 77,
 		ContourLoadBalancerService:  "heptio-contour-other/contour-other",
 		SkipperRouteGroupVersion:    "zalando.org/v2",
 		Sources:                     []string{"service", "ingress", "connector"},
+		SplitTargetsSources:         []string{"service"},
+		SourcePrecedence:            []string{"ingress", "service"},
+		SourceNamePrefixes:          map[string]string{"connector": "lab-"},
+		SourceNameSuffixes:          map[string]string{"connector": "example.org"},
+		SourceNameStripSuffixes:     map[string]string{"connector": "lan"},
+		TargetRewriteSources:        []string{"connector"},
+		TargetRewrites:              map[string]string{"10.0.0.0/24": "203.0.113.0/24", "nas.example.org": "203.0.113.20"},
+		SourceDefaultTTLs:           map[string]string{"service": "5m"},
+		SourceForcedTTLs:            map[string]string{"ingress": "1m", "connector": "30s"},
 		Namespace:                   "namespace",
 		IgnoreHostnameAnnotation:    true,
 		FQDNTemplate:                "{{.Name}}.service.example.com",
 		Compatibility:               "mate",
 		Provider:                    "google",
+		MigrateFrom:                 "aws",
+		SecondaryProvider:           "cloudflare",
+		Backup:                      "backup.json",
+		Restore:                     "restore.json",
 		GoogleProject:               "project",
 		GoogleBatchChangeSize:       100,
 		GoogleBatchChangeInterval:   time.Second * 2,
 		DomainFilter:                []string{"example.org", "company.com"},
 		ExcludeDomains:              []string{"xapi.example.org", "xapi.company.com"},
 		ZoneIDFilter:                []string{"/hostedzone/ZTST1", "/hostedzone/ZTST2"},
+		ProviderStateFile:           "/var/lib/external-dns/state.json",
+		TrimTrailingDots:            true,
+		ProviderStateResyncInterval: 6 * time.Hour,
+		MirrorFile:                  "/etc/hosts.external-dns",
+		MirrorFileFormat:            "zone",
+		DebugRecords:                true,
+		FaultInjectionLatency:       time.Second,
+		FaultInjectionErrorRate:     0.1,
+		FaultInjectionPartialRate:   0.05,
 		AlibabaCloudConfigFile:      "/etc/kubernetes/alibaba-cloud.json",
 		AWSZoneType:                 "private",
 		AWSZoneTagFilter:            []string{"tag=foo"},
@@ -158,18 +224,134 @@ var (
 		TLSClientCert:               "/path/to/cert.pem",
 		TLSClientCertKey:            "/path/to/key.pem",
 		Policy:                      "upsert-only",
+		PolicyOverrides:             map[string]string{"lab.example.org": "sync", "prod.example.org": "create-only"},
+		MaxDeletions:                10,
+		MaxRecordsPerDomain:         500,
+		ManageNSRecords:             true,
+		ManagePTRRecords:            true,
+		ManageMXRecords:             true,
+		ManageTXTRecords:            true,
+		ManageSRVRecords:            true,
+		PTRZones:                    []string{"10.in-addr.arpa", "168.192.in-addr.arpa"},
+		EndpointMaxTargets:          5,
+		EndpointMinTTL:              time.Minute,
+		EndpointMaxTTL:              time.Hour,
+		AdmissionListenAddress:      ":8443",
+		AdmissionTLSCertFile:        "/etc/webhook/tls.crt",
+		AdmissionTLSKeyFile:         "/etc/webhook/tls.key",
+		MaxApplyFailures:            3,
+		ApplyFailureCooldown:        time.Minute,
+		CircuitBreakerReset:         true,
+		PropagationResolver:         "10.0.0.53:53",
+		PropagationTimeout:          time.Minute,
+		CreateBeforeDelete:          true,
+		DeletionGracePeriod:         10 * time.Minute,
 		Registry:                    "noop",
 		TXTOwnerID:                  "owner-1",
 		TXTPrefix:                   "associated-txt-record",
+		TXTWildcardReplacement:      "wildcard",
+		TXTEncryptAESKey:            "new-key",
+		TXTEncryptAESOldKeys:        []string{"old-key"},
+		TXTMetadata:                 true,
+		FileRegistryPath:            "/var/lib/external-dns/registry.json",
 		TXTCacheInterval:            12 * time.Hour,
 		Interval:                    10 * time.Minute,
+		ShutdownTimeout:             time.Minute,
+		SecretsRefreshInterval:      time.Hour,
 		Once:                        true,
+		OnceChangesExitCode:         2,
 		DryRun:                      true,
+		PlanExport:                  "plan.json",
+		Diff:                        "json",
+		Verify:                      "json",
+		Simulate:                    "fixture.yaml",
 		UpdateEvents:                true,
+		EventsQuietPeriod:           30 * time.Second,
+		LeaderElection:              true,
+		LeaderElectionNamespace:     "external-dns",
+		LeaderElectionID:            "external-dns-leader",
 		LogFormat:                   "json",
 		MetricsAddress:              "127.0.0.1:9099",
+		TracingZipkinURL:            "http://localhost:9411/api/v2/spans",
 		LogLevel:                    logrus.DebugLevel.String(),
+		LogChanges:                  true,
 		ConnectorSourceServer:       "localhost:8081",
+		RedisSourceAddress:          "redis.example.org:6380",
+		RedisSourcePassword:         "redis-password",
+		RedisSourceDB:               2,
+		RedisSourceKey:              "fleet",
+		RedisSourceNotifications:    true,
+		HTTPSourceURL:               "https://cmdb.example.org/endpoints.json",
+		HTTPSourceHeaders:           []string{"Authorization: Bearer token", "X-Tenant: dns"},
+		HTTPSourcePollInterval:      30 * time.Second,
+		HTTPSourceStaleWhileError:   time.Hour,
+		PluginSourceSocket:          "/tmp/plugin.sock",
+		LeaseSourcePath:             "/var/lib/dhcp/dhcpd.leases",
+		LeaseSourceFormat:           "isc",
+		LeaseSourceDomain:           "lan.example.org",
+		LeaseSourceMaxTTL:           10 * time.Minute,
+		KeaSourceURL:                "http://kea.example.org:8000",
+		KeaSourceSubnets:            []int{1, 2},
+		KeaSourceHostnameFilter:     "^web-",
+		LibvirtSourceURI:            "qemu+ssh://root@hypervisor/system",
+		LibvirtSourceAddressSource:  "agent",
+		LibvirtSourceDomain:         "vm.example.org",
+		MQTTSourceBroker:            "tls://mqtt.example.org:8883",
+		MQTTSourceTopic:             "devices/register",
+		MQTTSourceClientID:          "external-dns-lab",
+		MQTTSourceUsername:          "mqtt-user",
+		MQTTSourcePassword:          "mqtt-password",
+		MQTTSourceExpiry:            10 * time.Minute,
+		MQTTSourceStateFile:         "/var/lib/external-dns/devices.json",
+		SFTPSourceAddress:           "appliance.example.org:2222",
+		SFTPSourceUser:              "inventory",
+		SFTPSourceKeyFile:           "/etc/ssh/id_ed25519",
+		SFTPSourceHostKey:           "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKdJ8jFyJmNAvVvMBn9lAwpA3z8hHVq6Slq6W1eVN3fZ",
+		SFTPSourcePath:              "/var/lib/inventory/endpoints.json",
+		S3SourceEndpoint:            "https://minio.example.org",
+		S3SourceRegion:              "eu-central-1",
+		S3SourceBucket:              "inventory",
+		S3SourceKey:                 "clusters/endpoints.json",
+		S3SourceAccessKeyID:         "s3-access-key",
+		S3SourceSecretAccessKey:     "s3-secret-key",
+		VaultSourceAddress:          "https://vault.example.org:8200",
+		VaultSourcePaths:            []string{"secret/dns", "kv/data/dns"},
+		VaultSourceToken:            "vault-token",
+		VaultSourceRoleID:           "external-dns",
+		VaultSourceSecretID:         "vault-secret-id",
+		NeighborSourceTable:         "/host/proc/net/arp",
+		NeighborSourceSubnets:       []string{"192.168.1.0/24", "192.168.2.0/24"},
+		NeighborSourceMACNames:      []string{"aa:bb:cc:00:00:10=printer"},
+		NeighborSourceDomain:        "lab.example.org",
+		MDNSSourceServices:          []string{"_workstation._tcp", "_ipp._tcp"},
+		MDNSSourceDomain:            "lan.example.org",
+		MDNSSourceTimeout:           5 * time.Second,
+		SNMPSourceTargets:           []string{"10.0.0.1", "switch-2.example.org:1161"},
+		SNMPSourceCommunity:         "snmp-community",
+		SNMPSourceDomain:            "net.example.org",
+		FilesSourcePaths:            []string{"/etc/external-dns/base.json", "/etc/external-dns/staging.json"},
+		FilesSourceConflict:         "fail",
+		FilesSourcePollInterval:     15 * time.Second,
+		FilesSourceStrict:           true,
+		FilesSourceMaxChange:        20,
+		FilesSourceConfirmAfter:     10 * time.Minute,
+		FilesSourceForceFile:        "/etc/external-dns/force",
+		FilesSourceDeltaDir:         "/var/lib/external-dns/deltas",
+		FilesSourceDeltaBase:        "/var/lib/external-dns/base.json",
+		FilesSourceMaxAge:           time.Hour,
+		FilesSourceFreezeStale:      true,
+		WebhookSourceListenAddress:  "127.0.0.1:8081",
+		WebhookSourceToken:          "webhook-token",
+		WebhookSourceFile:           "/var/lib/external-dns/endpoints.json",
+		TemplateSourceTemplate:      "/etc/external-dns/endpoints.tmpl",
+		TemplateSourceData:          "/etc/external-dns/nodes.yaml",
+		DNSUpdateSourceListenAddr:   "127.0.0.1:5300",
+		DNSUpdateSourceZone:         "dhcp.example.org",
+		DNSUpdateSourceTSIGKeyName:  "dhcp-key",
+		DNSUpdateSourceTSIGSecret:   "dnsupdate-secret",
+		DNSUpdateSourceFile:         "/var/lib/external-dns/dnsupdate.json",
+		ReverseSourcePaths:          []string{"/etc/external-dns/forward.json"},
+		ReverseSourceZones:          []string{"10.in-addr.arpa", "168.192.in-addr.arpa"},
 		ExoscaleEndpoint:            "https://api.foo.ch/dns",
 		ExoscaleAPIKey:              "1",
 		ExoscaleAPISecret:           "2",
@@ -203,6 +385,7 @@ func TestParseFlags(t *testing.T) {
 		{
 			title: "override everything via flags",
 			args: []string{
+				"--config-reload-interval=1m",
 				"--master=http://127.0.0.1:8080",
 				"--kubeconfig=/some/path",
 				"--request-timeout=77s",
@@ -211,11 +394,27 @@ func TestParseFlags(t *testing.T) {
 				"--source=service",
 				"--source=ingress",
 				"--source=connector",
+				"--split-targets-source=service",
+				"--source-precedence=ingress",
+				"--source-precedence=service",
+				"--source-name-prefix=connector=lab-",
+				"--source-name-suffix=connector=example.org",
+				"--source-name-strip-suffix=connector=lan",
+				"--target-rewrite-source=connector",
+				"--target-rewrite=10.0.0.0/24=203.0.113.0/24",
+				"--target-rewrite=nas.example.org=203.0.113.20",
+				"--source-default-ttl=service=5m",
+				"--source-forced-ttl=ingress=1m",
+				"--source-forced-ttl=connector=30s",
 				"--namespace=namespace",
 				"--fqdn-template={{.Name}}.service.example.com",
 				"--ignore-hostname-annotation",
 				"--compatibility=mate",
 				"--provider=google",
+				"--migrate-from=aws",
+				"--secondary-provider=cloudflare",
+				"--backup=backup.json",
+				"--restore=restore.json",
 				"--google-project=project",
 				"--google-batch-change-size=100",
 				"--google-batch-change-interval=2s",
@@ -254,6 +453,15 @@ func TestParseFlags(t *testing.T) {
 				"--exclude-domains=xapi.company.com",
 				"--zone-id-filter=/hostedzone/ZTST1",
 				"--zone-id-filter=/hostedzone/ZTST2",
+				"--provider-state-file=/var/lib/external-dns/state.json",
+				"--trim-trailing-dots",
+				"--provider-state-resync-interval=6h",
+				"--mirror-file=/etc/hosts.external-dns",
+				"--mirror-file-format=zone",
+				"--debug-records",
+				"--fault-injection-latency=1s",
+				"--fault-injection-error-rate=0.1",
+				"--fault-injection-partial-rate=0.05",
 				"--aws-zone-type=private",
 				"--aws-zone-tags=tag=foo",
 				"--aws-assume-role=some-other-role",
@@ -263,18 +471,144 @@ func TestParseFlags(t *testing.T) {
 				"--aws-prefer-cname",
 				"--no-aws-evaluate-target-health",
 				"--policy=upsert-only",
+				"--policy-override=lab.example.org=sync",
+				"--policy-override=prod.example.org=create-only",
+				"--max-deletions=10",
+				"--max-records-per-domain=500",
+				"--manage-ns-records",
+				"--manage-ptr-records",
+				"--manage-mx-records",
+				"--manage-txt-records",
+				"--manage-srv-records",
+				"--ptr-zone=10.in-addr.arpa",
+				"--ptr-zone=168.192.in-addr.arpa",
+				"--endpoint-max-targets=5",
+				"--endpoint-min-ttl=1m",
+				"--endpoint-max-ttl=1h",
+				"--admission-listen-address=:8443",
+				"--admission-tls-cert-file=/etc/webhook/tls.crt",
+				"--admission-tls-key-file=/etc/webhook/tls.key",
+				"--max-apply-failures=3",
+				"--apply-failure-cooldown=1m",
+				"--circuit-breaker-reset",
+				"--propagation-resolver=10.0.0.53:53",
+				"--propagation-timeout=1m",
+				"--create-before-delete",
+				"--deletion-grace-period=10m",
 				"--registry=noop",
 				"--txt-owner-id=owner-1",
 				"--txt-prefix=associated-txt-record",
+				"--txt-wildcard-replacement=wildcard",
+				"--txt-encrypt-aes-key=new-key",
+				"--txt-encrypt-aes-old-key=old-key",
+				"--txt-metadata",
+				"--file-registry-path=/var/lib/external-dns/registry.json",
 				"--txt-cache-interval=12h",
 				"--interval=10m",
+				"--shutdown-timeout=1m",
+				"--secrets-refresh-interval=1h",
 				"--once",
+				"--once-changes-exit-code=2",
 				"--dry-run",
+				"--plan-export=plan.json",
+				"--diff=json",
+				"--verify=json",
+				"--simulate=fixture.yaml",
 				"--events",
+				"--events-quiet-period=30s",
+				"--leader-election",
+				"--leader-election-namespace=external-dns",
+				"--leader-election-id=external-dns-leader",
 				"--log-format=json",
 				"--metrics-address=127.0.0.1:9099",
+				"--tracing-zipkin-url=http://localhost:9411/api/v2/spans",
 				"--log-level=debug",
+				"--log-changes",
 				"--connector-source-server=localhost:8081",
+				"--redis-source-address=redis.example.org:6380",
+				"--redis-source-password=redis-password",
+				"--redis-source-db=2",
+				"--redis-source-key=fleet",
+				"--redis-source-notifications",
+				"--http-source-url=https://cmdb.example.org/endpoints.json",
+				"--http-source-header=Authorization: Bearer token",
+				"--http-source-header=X-Tenant: dns",
+				"--http-source-poll-interval=30s",
+				"--http-source-stale-while-error=1h",
+				"--plugin-source-socket=/tmp/plugin.sock",
+				"--lease-source-path=/var/lib/dhcp/dhcpd.leases",
+				"--lease-source-format=isc",
+				"--lease-source-domain=lan.example.org",
+				"--lease-source-max-ttl=10m",
+				"--kea-source-url=http://kea.example.org:8000",
+				"--kea-source-subnet-id=1",
+				"--kea-source-subnet-id=2",
+				"--kea-source-hostname-filter=^web-",
+				"--libvirt-source-uri=qemu+ssh://root@hypervisor/system",
+				"--libvirt-source-address-source=agent",
+				"--libvirt-source-domain=vm.example.org",
+				"--mqtt-source-broker=tls://mqtt.example.org:8883",
+				"--mqtt-source-topic=devices/register",
+				"--mqtt-source-client-id=external-dns-lab",
+				"--mqtt-source-username=mqtt-user",
+				"--mqtt-source-password=mqtt-password",
+				"--mqtt-source-expiry=10m",
+				"--mqtt-source-state-file=/var/lib/external-dns/devices.json",
+				"--sftp-source-address=appliance.example.org:2222",
+				"--sftp-source-user=inventory",
+				"--sftp-source-key-file=/etc/ssh/id_ed25519",
+				"--sftp-source-host-key=ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKdJ8jFyJmNAvVvMBn9lAwpA3z8hHVq6Slq6W1eVN3fZ",
+				"--sftp-source-path=/var/lib/inventory/endpoints.json",
+				"--s3-source-endpoint=https://minio.example.org",
+				"--s3-source-region=eu-central-1",
+				"--s3-source-bucket=inventory",
+				"--s3-source-key=clusters/endpoints.json",
+				"--s3-source-access-key-id=s3-access-key",
+				"--s3-source-secret-access-key=s3-secret-key",
+				"--vault-source-address=https://vault.example.org:8200",
+				"--vault-source-path=secret/dns",
+				"--vault-source-path=kv/data/dns",
+				"--vault-source-token=vault-token",
+				"--vault-source-role-id=external-dns",
+				"--vault-source-secret-id=vault-secret-id",
+				"--neighbor-source-table=/host/proc/net/arp",
+				"--neighbor-source-subnet=192.168.1.0/24",
+				"--neighbor-source-subnet=192.168.2.0/24",
+				"--neighbor-source-mac-name=aa:bb:cc:00:00:10=printer",
+				"--neighbor-source-domain=lab.example.org",
+				"--mdns-source-service=_workstation._tcp",
+				"--mdns-source-service=_ipp._tcp",
+				"--mdns-source-domain=lan.example.org",
+				"--mdns-source-timeout=5s",
+				"--snmp-source-target=10.0.0.1",
+				"--snmp-source-target=switch-2.example.org:1161",
+				"--snmp-source-community=snmp-community",
+				"--snmp-source-domain=net.example.org",
+				"--files-source-path=/etc/external-dns/base.json",
+				"--files-source-path=/etc/external-dns/staging.json",
+				"--files-source-conflict=fail",
+				"--files-source-poll-interval=15s",
+				"--files-source-strict",
+				"--files-source-max-change=20",
+				"--files-source-confirm-after=10m",
+				"--files-source-force-file=/etc/external-dns/force",
+				"--files-source-delta-dir=/var/lib/external-dns/deltas",
+				"--files-source-delta-base=/var/lib/external-dns/base.json",
+				"--files-source-max-age=1h",
+				"--files-source-freeze-stale",
+				"--webhook-source-listen-address=127.0.0.1:8081",
+				"--webhook-source-token=webhook-token",
+				"--webhook-source-file=/var/lib/external-dns/endpoints.json",
+				"--template-source-template=/etc/external-dns/endpoints.tmpl",
+				"--template-source-data=/etc/external-dns/nodes.yaml",
+				"--dnsupdate-source-listen-address=127.0.0.1:5300",
+				"--dnsupdate-source-zone=dhcp.example.org",
+				"--dnsupdate-source-tsig-keyname=dhcp-key",
+				"--dnsupdate-source-tsig-secret=dnsupdate-secret",
+				"--dnsupdate-source-file=/var/lib/external-dns/dnsupdate.json",
+				"--reverse-source-path=/etc/external-dns/forward.json",
+				"--reverse-source-zone=10.in-addr.arpa",
+				"--reverse-source-zone=168.192.in-addr.arpa",
 				"--exoscale-endpoint=https://api.foo.ch/dns",
 				"--exoscale-apikey=1",
 				"--exoscale-apisecret=2",
@@ -294,17 +628,31 @@ func TestParseFlags(t *testing.T) {
 			title: "override everything via environment variables",
 			args:  []string{},
 			envVars: map[string]string{
+				"EXTERNAL_DNS_CONFIG_RELOAD_INTERVAL":          "1m",
 				"EXTERNAL_DNS_MASTER":                          "http://127.0.0.1:8080",
 				"EXTERNAL_DNS_KUBECONFIG":                      "/some/path",
 				"EXTERNAL_DNS_REQUEST_TIMEOUT":                 "77s",
 				"EXTERNAL_DNS_CONTOUR_LOAD_BALANCER":           "heptio-contour-other/contour-other",
 				"EXTERNAL_DNS_SKIPPER_ROUTEGROUP_GROUPVERSION": "zalando.org/v2",
 				"EXTERNAL_DNS_SOURCE":                          "service\ningress\nconnector",
+				"EXTERNAL_DNS_SPLIT_TARGETS_SOURCE":            "service",
+				"EXTERNAL_DNS_SOURCE_PRECEDENCE":               "ingress\nservice",
+				"EXTERNAL_DNS_SOURCE_NAME_PREFIX":              "connector=lab-",
+				"EXTERNAL_DNS_SOURCE_NAME_SUFFIX":              "connector=example.org",
+				"EXTERNAL_DNS_SOURCE_NAME_STRIP_SUFFIX":        "connector=lan",
+				"EXTERNAL_DNS_TARGET_REWRITE_SOURCE":           "connector",
+				"EXTERNAL_DNS_TARGET_REWRITE":                  "10.0.0.0/24=203.0.113.0/24\nnas.example.org=203.0.113.20",
+				"EXTERNAL_DNS_SOURCE_DEFAULT_TTL":              "service=5m",
+				"EXTERNAL_DNS_SOURCE_FORCED_TTL":               "ingress=1m\nconnector=30s",
 				"EXTERNAL_DNS_NAMESPACE":                       "namespace",
 				"EXTERNAL_DNS_FQDN_TEMPLATE":                   "{{.Name}}.service.example.com",
 				"EXTERNAL_DNS_IGNORE_HOSTNAME_ANNOTATION":      "1",
 				"EXTERNAL_DNS_COMPATIBILITY":                   "mate",
 				"EXTERNAL_DNS_PROVIDER":                        "google",
+				"EXTERNAL_DNS_MIGRATE_FROM":                    "aws",
+				"EXTERNAL_DNS_SECONDARY_PROVIDER":              "cloudflare",
+				"EXTERNAL_DNS_BACKUP":                          "backup.json",
+				"EXTERNAL_DNS_RESTORE":                         "restore.json",
 				"EXTERNAL_DNS_GOOGLE_PROJECT":                  "project",
 				"EXTERNAL_DNS_GOOGLE_BATCH_CHANGE_SIZE":        "100",
 				"EXTERNAL_DNS_GOOGLE_BATCH_CHANGE_INTERVAL":    "2s",
@@ -340,6 +688,15 @@ func TestParseFlags(t *testing.T) {
 				"EXTERNAL_DNS_TLS_CLIENT_CERT":                 "/path/to/cert.pem",
 				"EXTERNAL_DNS_TLS_CLIENT_CERT_KEY":             "/path/to/key.pem",
 				"EXTERNAL_DNS_ZONE_ID_FILTER":                  "/hostedzone/ZTST1\n/hostedzone/ZTST2",
+				"EXTERNAL_DNS_PROVIDER_STATE_FILE":             "/var/lib/external-dns/state.json",
+				"EXTERNAL_DNS_TRIM_TRAILING_DOTS":              "1",
+				"EXTERNAL_DNS_PROVIDER_STATE_RESYNC_INTERVAL":  "6h",
+				"EXTERNAL_DNS_MIRROR_FILE":                     "/etc/hosts.external-dns",
+				"EXTERNAL_DNS_MIRROR_FILE_FORMAT":              "zone",
+				"EXTERNAL_DNS_DEBUG_RECORDS":                   "1",
+				"EXTERNAL_DNS_FAULT_INJECTION_LATENCY":         "1s",
+				"EXTERNAL_DNS_FAULT_INJECTION_ERROR_RATE":      "0.1",
+				"EXTERNAL_DNS_FAULT_INJECTION_PARTIAL_RATE":    "0.05",
 				"EXTERNAL_DNS_AWS_ZONE_TYPE":                   "private",
 				"EXTERNAL_DNS_AWS_ZONE_TAGS":                   "tag=foo",
 				"EXTERNAL_DNS_AWS_ASSUME_ROLE":                 "some-other-role",
@@ -349,18 +706,134 @@ func TestParseFlags(t *testing.T) {
 				"EXTERNAL_DNS_AWS_API_RETRIES":                 "13",
 				"EXTERNAL_DNS_AWS_PREFER_CNAME":                "true",
 				"EXTERNAL_DNS_POLICY":                          "upsert-only",
+				"EXTERNAL_DNS_POLICY_OVERRIDE":                 "lab.example.org=sync\nprod.example.org=create-only",
+				"EXTERNAL_DNS_MAX_DELETIONS":                   "10",
+				"EXTERNAL_DNS_MAX_RECORDS_PER_DOMAIN":          "500",
+				"EXTERNAL_DNS_MANAGE_NS_RECORDS":               "1",
+				"EXTERNAL_DNS_MANAGE_PTR_RECORDS":              "1",
+				"EXTERNAL_DNS_MANAGE_MX_RECORDS":               "1",
+				"EXTERNAL_DNS_MANAGE_TXT_RECORDS":              "1",
+				"EXTERNAL_DNS_MANAGE_SRV_RECORDS":              "1",
+				"EXTERNAL_DNS_PTR_ZONE":                        "10.in-addr.arpa\n168.192.in-addr.arpa",
+				"EXTERNAL_DNS_ENDPOINT_MAX_TARGETS":            "5",
+				"EXTERNAL_DNS_ENDPOINT_MIN_TTL":                "1m",
+				"EXTERNAL_DNS_ENDPOINT_MAX_TTL":                "1h",
+				"EXTERNAL_DNS_ADMISSION_LISTEN_ADDRESS":        ":8443",
+				"EXTERNAL_DNS_ADMISSION_TLS_CERT_FILE":         "/etc/webhook/tls.crt",
+				"EXTERNAL_DNS_ADMISSION_TLS_KEY_FILE":          "/etc/webhook/tls.key",
+				"EXTERNAL_DNS_MAX_APPLY_FAILURES":              "3",
+				"EXTERNAL_DNS_APPLY_FAILURE_COOLDOWN":          "1m",
+				"EXTERNAL_DNS_CIRCUIT_BREAKER_RESET":           "1",
+				"EXTERNAL_DNS_PROPAGATION_RESOLVER":            "10.0.0.53:53",
+				"EXTERNAL_DNS_PROPAGATION_TIMEOUT":             "1m",
+				"EXTERNAL_DNS_CREATE_BEFORE_DELETE":            "1",
+				"EXTERNAL_DNS_DELETION_GRACE_PERIOD":           "10m",
 				"EXTERNAL_DNS_REGISTRY":                        "noop",
 				"EXTERNAL_DNS_TXT_OWNER_ID":                    "owner-1",
 				"EXTERNAL_DNS_TXT_PREFIX":                      "associated-txt-record",
+				"EXTERNAL_DNS_TXT_WILDCARD_REPLACEMENT":        "wildcard",
+				"EXTERNAL_DNS_TXT_ENCRYPT_AES_KEY":             "new-key",
+				"EXTERNAL_DNS_TXT_ENCRYPT_AES_OLD_KEY":         "old-key",
+				"EXTERNAL_DNS_TXT_METADATA":                    "1",
+				"EXTERNAL_DNS_FILE_REGISTRY_PATH":              "/var/lib/external-dns/registry.json",
 				"EXTERNAL_DNS_TXT_CACHE_INTERVAL":              "12h",
 				"EXTERNAL_DNS_INTERVAL":                        "10m",
+				"EXTERNAL_DNS_SHUTDOWN_TIMEOUT":                "1m",
+				"EXTERNAL_DNS_SECRETS_REFRESH_INTERVAL":        "1h",
+				"EXTERNAL_DNS_ONCE_CHANGES_EXIT_CODE":          "2",
 				"EXTERNAL_DNS_ONCE":                            "1",
 				"EXTERNAL_DNS_DRY_RUN":                         "1",
+				"EXTERNAL_DNS_PLAN_EXPORT":                     "plan.json",
+				"EXTERNAL_DNS_DIFF":                            "json",
+				"EXTERNAL_DNS_VERIFY":                          "json",
+				"EXTERNAL_DNS_SIMULATE":                        "fixture.yaml",
 				"EXTERNAL_DNS_EVENTS":                          "1",
+				"EXTERNAL_DNS_EVENTS_QUIET_PERIOD":             "30s",
+				"EXTERNAL_DNS_LEADER_ELECTION":                 "1",
+				"EXTERNAL_DNS_LEADER_ELECTION_NAMESPACE":       "external-dns",
+				"EXTERNAL_DNS_LEADER_ELECTION_ID":              "external-dns-leader",
 				"EXTERNAL_DNS_LOG_FORMAT":                      "json",
 				"EXTERNAL_DNS_METRICS_ADDRESS":                 "127.0.0.1:9099",
+				"EXTERNAL_DNS_TRACING_ZIPKIN_URL":              "http://localhost:9411/api/v2/spans",
 				"EXTERNAL_DNS_LOG_LEVEL":                       "debug",
+				"EXTERNAL_DNS_LOG_CHANGES":                     "1",
 				"EXTERNAL_DNS_CONNECTOR_SOURCE_SERVER":         "localhost:8081",
+				"EXTERNAL_DNS_REDIS_SOURCE_ADDRESS":            "redis.example.org:6380",
+				"EXTERNAL_DNS_REDIS_SOURCE_PASSWORD":           "redis-password",
+				"EXTERNAL_DNS_REDIS_SOURCE_DB":                 "2",
+				"EXTERNAL_DNS_REDIS_SOURCE_KEY":                "fleet",
+				"EXTERNAL_DNS_REDIS_SOURCE_NOTIFICATIONS":      "1",
+				"EXTERNAL_DNS_HTTP_SOURCE_URL":                 "https://cmdb.example.org/endpoints.json",
+				"EXTERNAL_DNS_HTTP_SOURCE_HEADER":              "Authorization: Bearer token\nX-Tenant: dns",
+				"EXTERNAL_DNS_HTTP_SOURCE_POLL_INTERVAL":       "30s",
+				"EXTERNAL_DNS_HTTP_SOURCE_STALE_WHILE_ERROR":   "1h",
+				"EXTERNAL_DNS_PLUGIN_SOURCE_SOCKET":            "/tmp/plugin.sock",
+				"EXTERNAL_DNS_LEASE_SOURCE_PATH":               "/var/lib/dhcp/dhcpd.leases",
+				"EXTERNAL_DNS_LEASE_SOURCE_FORMAT":             "isc",
+				"EXTERNAL_DNS_LEASE_SOURCE_DOMAIN":             "lan.example.org",
+				"EXTERNAL_DNS_LEASE_SOURCE_MAX_TTL":            "10m",
+				"EXTERNAL_DNS_KEA_SOURCE_URL":                  "http://kea.example.org:8000",
+				"EXTERNAL_DNS_KEA_SOURCE_SUBNET_ID":            "1\n2",
+				"EXTERNAL_DNS_KEA_SOURCE_HOSTNAME_FILTER":      "^web-",
+				"EXTERNAL_DNS_LIBVIRT_SOURCE_URI":              "qemu+ssh://root@hypervisor/system",
+				"EXTERNAL_DNS_LIBVIRT_SOURCE_ADDRESS_SOURCE":   "agent",
+				"EXTERNAL_DNS_LIBVIRT_SOURCE_DOMAIN":           "vm.example.org",
+				"EXTERNAL_DNS_MQTT_SOURCE_BROKER":              "tls://mqtt.example.org:8883",
+				"EXTERNAL_DNS_MQTT_SOURCE_TOPIC":               "devices/register",
+				"EXTERNAL_DNS_MQTT_SOURCE_CLIENT_ID":           "external-dns-lab",
+				"EXTERNAL_DNS_MQTT_SOURCE_USERNAME":            "mqtt-user",
+				"EXTERNAL_DNS_MQTT_SOURCE_PASSWORD":            "mqtt-password",
+				"EXTERNAL_DNS_MQTT_SOURCE_EXPIRY":              "10m",
+				"EXTERNAL_DNS_MQTT_SOURCE_STATE_FILE":          "/var/lib/external-dns/devices.json",
+				"EXTERNAL_DNS_SFTP_SOURCE_ADDRESS":             "appliance.example.org:2222",
+				"EXTERNAL_DNS_SFTP_SOURCE_USER":                "inventory",
+				"EXTERNAL_DNS_SFTP_SOURCE_KEY_FILE":            "/etc/ssh/id_ed25519",
+				"EXTERNAL_DNS_SFTP_SOURCE_HOST_KEY":            "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKdJ8jFyJmNAvVvMBn9lAwpA3z8hHVq6Slq6W1eVN3fZ",
+				"EXTERNAL_DNS_SFTP_SOURCE_PATH":                "/var/lib/inventory/endpoints.json",
+				"EXTERNAL_DNS_S3_SOURCE_ENDPOINT":              "https://minio.example.org",
+				"EXTERNAL_DNS_S3_SOURCE_REGION":                "eu-central-1",
+				"EXTERNAL_DNS_S3_SOURCE_BUCKET":                "inventory",
+				"EXTERNAL_DNS_S3_SOURCE_KEY":                   "clusters/endpoints.json",
+				"EXTERNAL_DNS_S3_SOURCE_ACCESS_KEY_ID":         "s3-access-key",
+				"EXTERNAL_DNS_S3_SOURCE_SECRET_ACCESS_KEY":     "s3-secret-key",
+				"EXTERNAL_DNS_VAULT_SOURCE_ADDRESS":            "https://vault.example.org:8200",
+				"EXTERNAL_DNS_VAULT_SOURCE_PATH":               "secret/dns\nkv/data/dns",
+				"EXTERNAL_DNS_VAULT_SOURCE_TOKEN":              "vault-token",
+				"EXTERNAL_DNS_VAULT_SOURCE_ROLE_ID":            "external-dns",
+				"EXTERNAL_DNS_VAULT_SOURCE_SECRET_ID":          "vault-secret-id",
+				"EXTERNAL_DNS_NEIGHBOR_SOURCE_TABLE":           "/host/proc/net/arp",
+				"EXTERNAL_DNS_NEIGHBOR_SOURCE_SUBNET":          "192.168.1.0/24\n192.168.2.0/24",
+				"EXTERNAL_DNS_NEIGHBOR_SOURCE_MAC_NAME":        "aa:bb:cc:00:00:10=printer",
+				"EXTERNAL_DNS_NEIGHBOR_SOURCE_DOMAIN":          "lab.example.org",
+				"EXTERNAL_DNS_MDNS_SOURCE_SERVICE":             "_workstation._tcp\n_ipp._tcp",
+				"EXTERNAL_DNS_MDNS_SOURCE_DOMAIN":              "lan.example.org",
+				"EXTERNAL_DNS_MDNS_SOURCE_TIMEOUT":             "5s",
+				"EXTERNAL_DNS_SNMP_SOURCE_TARGET":              "10.0.0.1\nswitch-2.example.org:1161",
+				"EXTERNAL_DNS_SNMP_SOURCE_COMMUNITY":           "snmp-community",
+				"EXTERNAL_DNS_SNMP_SOURCE_DOMAIN":              "net.example.org",
+				"EXTERNAL_DNS_FILES_SOURCE_PATH":               "/etc/external-dns/base.json\n/etc/external-dns/staging.json",
+				"EXTERNAL_DNS_FILES_SOURCE_CONFLICT":           "fail",
+				"EXTERNAL_DNS_FILES_SOURCE_POLL_INTERVAL":      "15s",
+				"EXTERNAL_DNS_FILES_SOURCE_STRICT":             "1",
+				"EXTERNAL_DNS_FILES_SOURCE_MAX_CHANGE":         "20",
+				"EXTERNAL_DNS_FILES_SOURCE_CONFIRM_AFTER":      "10m",
+				"EXTERNAL_DNS_FILES_SOURCE_FORCE_FILE":         "/etc/external-dns/force",
+				"EXTERNAL_DNS_FILES_SOURCE_DELTA_DIR":          "/var/lib/external-dns/deltas",
+				"EXTERNAL_DNS_FILES_SOURCE_DELTA_BASE":         "/var/lib/external-dns/base.json",
+				"EXTERNAL_DNS_FILES_SOURCE_MAX_AGE":            "1h",
+				"EXTERNAL_DNS_FILES_SOURCE_FREEZE_STALE":       "1",
+				"EXTERNAL_DNS_WEBHOOK_SOURCE_LISTEN_ADDRESS":   "127.0.0.1:8081",
+				"EXTERNAL_DNS_WEBHOOK_SOURCE_TOKEN":            "webhook-token",
+				"EXTERNAL_DNS_WEBHOOK_SOURCE_FILE":             "/var/lib/external-dns/endpoints.json",
+				"EXTERNAL_DNS_TEMPLATE_SOURCE_TEMPLATE":        "/etc/external-dns/endpoints.tmpl",
+				"EXTERNAL_DNS_TEMPLATE_SOURCE_DATA":            "/etc/external-dns/nodes.yaml",
+				"EXTERNAL_DNS_DNSUPDATE_SOURCE_LISTEN_ADDRESS": "127.0.0.1:5300",
+				"EXTERNAL_DNS_DNSUPDATE_SOURCE_ZONE":           "dhcp.example.org",
+				"EXTERNAL_DNS_DNSUPDATE_SOURCE_TSIG_KEYNAME":   "dhcp-key",
+				"EXTERNAL_DNS_DNSUPDATE_SOURCE_TSIG_SECRET":    "dnsupdate-secret",
+				"EXTERNAL_DNS_DNSUPDATE_SOURCE_FILE":           "/var/lib/external-dns/dnsupdate.json",
+				"EXTERNAL_DNS_REVERSE_SOURCE_PATH":             "/etc/external-dns/forward.json",
+				"EXTERNAL_DNS_REVERSE_SOURCE_ZONE":             "10.in-addr.arpa\n168.192.in-addr.arpa",
 				"EXTERNAL_DNS_EXOSCALE_ENDPOINT":               "https://api.foo.ch/dns",
 				"EXTERNAL_DNS_EXOSCALE_APIKEY":                 "1",
 				"EXTERNAL_DNS_EXOSCALE_APISECRET":              "2",
@@ -408,10 +881,21 @@ func restoreEnv(t *testing.T, originalEnv map[string]string) {
 
 func TestPasswordsNotLogged(t *testing.T) {
 	cfg := Config{
-		DynPassword:          "dyn-pass",
-		InfobloxWapiPassword: "infoblox-pass",
-		PDNSAPIKey:           "pdns-api-key",
-		RFC2136TSIGSecret:    "tsig-secret",
+		DynPassword:               "dyn-pass",
+		InfobloxWapiPassword:      "infoblox-pass",
+		PDNSAPIKey:                "pdns-api-key",
+		RFC2136TSIGSecret:         "tsig-secret",
+		RedisSourcePassword:       "redis-pass",
+		HTTPSourceHeaders:         []string{"Authorization: Bearer http-token"},
+		MQTTSourcePassword:        "mqtt-pass",
+		S3SourceSecretAccessKey:   "s3-secret",
+		VaultSourceToken:          "vault-token",
+		VaultSourceSecretID:       "vault-secret-id",
+		SNMPSourceCommunity:       "snmp-community",
+		WebhookSourceToken:        "webhook-token",
+		DNSUpdateSourceTSIGSecret: "dnsupdate-secret",
+		TXTEncryptAESKey:          "txt-aes-key",
+		TXTEncryptAESOldKeys:      []string{"txt-aes-old-key"},
 	}
 
 	s := cfg.String()
@@ -420,4 +904,45 @@ func TestPasswordsNotLogged(t *testing.T) {
 	assert.False(t, strings.Contains(s, "infoblox-pass"))
 	assert.False(t, strings.Contains(s, "pdns-api-key"))
 	assert.False(t, strings.Contains(s, "tsig-secret"))
+	assert.False(t, strings.Contains(s, "redis-pass"))
+	assert.False(t, strings.Contains(s, "http-token"))
+	assert.False(t, strings.Contains(s, "mqtt-pass"))
+	assert.False(t, strings.Contains(s, "s3-secret"))
+	assert.False(t, strings.Contains(s, "vault-token"))
+	assert.False(t, strings.Contains(s, "vault-secret-id"))
+	assert.False(t, strings.Contains(s, "snmp-community"))
+	assert.False(t, strings.Contains(s, "webhook-token"))
+	assert.False(t, strings.Contains(s, "txt-aes-key"))
+	assert.False(t, strings.Contains(s, "txt-aes-old-key"))
+	assert.False(t, strings.Contains(s, "dnsupdate-secret"))
+}
+
+func TestResolveSecrets(t *testing.T) {
+	cfg := Config{
+		Provider:             "pdns",
+		PDNSAPIKey:           "awssm://external-dns#api-key",
+		DynPassword:          "dyn-pass",
+		TXTEncryptAESOldKeys: []string{"awssm://external-dns#old-key", "txt-aes-old-key"},
+	}
+	resolve := func(value string) (string, error) {
+		if strings.HasPrefix(value, "awssm://") {
+			return "resolved-" + strings.Split(value, "#")[1], nil
+		}
+		return value, nil
+	}
+
+	require.NoError(t, cfg.ResolveSecrets(resolve))
+	assert.Equal(t, "pdns", cfg.Provider)
+	assert.Equal(t, "resolved-api-key", cfg.PDNSAPIKey)
+	assert.Equal(t, "dyn-pass", cfg.DynPassword)
+	assert.Equal(t, []string{"resolved-old-key", "txt-aes-old-key"}, cfg.TXTEncryptAESOldKeys)
+
+	cfg.PDNSAPIKey = "awssm://missing"
+	err := cfg.ResolveSecrets(func(value string) (string, error) {
+		if strings.HasPrefix(value, "awssm://") {
+			return "", errors.New("not found")
+		}
+		return value, nil
+	})
+	assert.EqualError(t, err, "PDNSAPIKey: not found")
 }
@@ -19,10 +19,16 @@ package validation
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
 )
 
+// txtAffixRegexp matches the characters valid in the names of ownership TXT records
+var txtAffixRegexp = regexp.MustCompile(`^[A-Za-z0-9._-]*$`)
+
 // ValidateConfig performs validation on the Config object
 func ValidateConfig(cfg *externaldns.Config) error {
 	// TODO: Should probably return field.ErrorList
@@ -35,6 +41,118 @@ func ValidateConfig(cfg *externaldns.Config) error {
 	if cfg.Provider == "" {
 		return errors.New("no provider specified")
 	}
+	if cfg.PlanExport != "" && cfg.PlanImport != "" {
+		return errors.New("--plan-export and --plan-import are mutually exclusive")
+	}
+	if (cfg.PlanExport != "" || cfg.PlanImport != "") && !cfg.Once {
+		return errors.New("--plan-export and --plan-import require --once")
+	}
+	if cfg.ConfigReloadInterval > 0 && cfg.ConfigFile == "" {
+		return errors.New("--config-reload-interval requires --config")
+	}
+	if cfg.Diff != "" && !cfg.Once {
+		return errors.New("--diff requires --once")
+	}
+	if cfg.ManagePTRRecords && len(cfg.PTRZones) == 0 {
+		return errors.New("--manage-ptr-records requires --ptr-zone")
+	}
+	if len(cfg.PTRZones) > 0 && !cfg.ManagePTRRecords {
+		return errors.New("--ptr-zone requires --manage-ptr-records")
+	}
+	if cfg.Verify != "" && cfg.PropagationResolver == "" {
+		return errors.New("--verify requires --propagation-resolver")
+	}
+	if cfg.CreateBeforeDelete && cfg.PropagationResolver == "" {
+		return errors.New("--create-before-delete requires --propagation-resolver")
+	}
+	// The aws-sd registry needs the records of the provider itself, not of a wrapper.
+	if cfg.DebugRecords && (cfg.Registry == "aws-sd" || cfg.Provider == "aws-sd") {
+		return errors.New("--debug-records doesn't support the aws-sd registry")
+	}
+	if cfg.MaxRecordsPerDomain < 0 {
+		return errors.New("--max-records-per-domain must not be negative")
+	}
+	if cfg.SecretsRefreshInterval < 0 {
+		return errors.New("--secrets-refresh-interval must not be negative")
+	}
+	if cfg.CircuitBreakerReset && cfg.MaxApplyFailures <= 0 {
+		return errors.New("--circuit-breaker-reset requires --max-apply-failures")
+	}
+	if cfg.DeletionGracePeriod < 0 {
+		return errors.New("--deletion-grace-period must not be negative")
+	}
+	if cfg.DeletionGracePeriod > 0 && cfg.Once {
+		return errors.New("--deletion-grace-period is not supported with --once")
+	}
+	if cfg.FilesSourceMaxChange < 0 {
+		return errors.New("--files-source-max-change must not be negative")
+	}
+	if cfg.FilesSourceConfirmAfter < 0 {
+		return errors.New("--files-source-confirm-after must not be negative")
+	}
+	if (cfg.FilesSourceDeltaDir == "") != (cfg.FilesSourceDeltaBase == "") {
+		return errors.New("--files-source-delta-dir and --files-source-delta-base must be specified together")
+	}
+	if cfg.FilesSourceMaxAge < 0 {
+		return errors.New("--files-source-max-age must not be negative")
+	}
+	if cfg.FilesSourceFreezeStale && cfg.FilesSourceMaxAge == 0 {
+		return errors.New("--files-source-freeze-stale requires --files-source-max-age")
+	}
+	if cfg.Simulate != "" {
+		if !cfg.Once {
+			return errors.New("--simulate requires --once")
+		}
+		if len(cfg.SimulationZones()) == 0 {
+			return errors.New("--simulate requires --inmemory-zone or --domain-filter")
+		}
+		if cfg.Registry == "aws-sd" {
+			return errors.New("--simulate doesn't support the aws-sd registry")
+		}
+	}
+	if (cfg.MigrateFrom != "" && cfg.Backup != "") || (cfg.MigrateFrom != "" && cfg.Restore != "") || (cfg.Backup != "" && cfg.Restore != "") {
+		return errors.New("only one of --migrate-from, --backup and --restore can be given")
+	}
+	if cfg.MigrateFrom != "" && cfg.MigrateFrom == cfg.Provider {
+		return errors.New("--migrate-from must differ from --provider")
+	}
+	if cfg.SecondaryProvider != "" {
+		if cfg.SecondaryProvider == cfg.Provider {
+			return errors.New("--secondary-provider must differ from --provider")
+		}
+		if cfg.Simulate != "" {
+			return errors.New("--secondary-provider is not supported with --simulate")
+		}
+		if cfg.Provider == "aws-sd" || cfg.SecondaryProvider == "aws-sd" {
+			return errors.New("--secondary-provider doesn't support the aws-sd provider")
+		}
+	}
+	if cfg.OnceChangesExitCode != 0 {
+		if !cfg.Once {
+			return errors.New("--once-changes-exit-code requires --once")
+		}
+		if cfg.OnceChangesExitCode < 2 || cfg.OnceChangesExitCode > 125 {
+			return errors.New("--once-changes-exit-code must be between 2 and 125")
+		}
+	}
+	if cfg.EndpointMaxTargets < 0 || cfg.EndpointMinTTL < 0 || cfg.EndpointMaxTTL < 0 {
+		return errors.New("--endpoint-max-targets, --endpoint-min-ttl and --endpoint-max-ttl must not be negative")
+	}
+	if cfg.EndpointMaxTTL > 0 && cfg.EndpointMinTTL > cfg.EndpointMaxTTL {
+		return errors.New("--endpoint-min-ttl must not exceed --endpoint-max-ttl")
+	}
+	if cfg.ProviderStateFile != "" && cfg.ProviderStateResyncInterval <= 0 {
+		return errors.New("--provider-state-resync-interval must be positive")
+	}
+	if cfg.FaultInjectionLatency < 0 {
+		return errors.New("--fault-injection-latency must not be negative")
+	}
+	if cfg.FaultInjectionErrorRate < 0 || cfg.FaultInjectionErrorRate > 1 || cfg.FaultInjectionPartialRate < 0 || cfg.FaultInjectionPartialRate > 1 {
+		return errors.New("--fault-injection-error-rate and --fault-injection-partial-rate must be between 0 and 1")
+	}
+	if cfg.AdmissionListenAddress != "" && (cfg.AdmissionTLSCertFile == "" || cfg.AdmissionTLSKeyFile == "") {
+		return errors.New("--admission-listen-address requires --admission-tls-cert-file and --admission-tls-key-file")
+	}
 
 	// Azure provider specific validations
 	if cfg.Provider == "azure" {
@@ -88,6 +206,84 @@ func ValidateConfig(cfg *externaldns.Config) error {
 		}
 	}
 
+	for _, name := range cfg.SplitTargetsSources {
+		if !containsString(cfg.Sources, name) {
+			return fmt.Errorf("split targets source %q is not a source", name)
+		}
+	}
+	for i, name := range cfg.SourcePrecedence {
+		if !containsString(cfg.Sources, name) {
+			return fmt.Errorf("source precedence %q is not a source", name)
+		}
+		if containsString(cfg.SourcePrecedence[:i], name) {
+			return fmt.Errorf("source precedence %q is specified more than once", name)
+		}
+	}
+	for _, name := range cfg.TargetRewriteSources {
+		if !containsString(cfg.Sources, name) {
+			return fmt.Errorf("target rewrite source %q is not a source", name)
+		}
+	}
+	if len(cfg.TargetRewriteSources) > 0 && len(cfg.TargetRewrites) == 0 {
+		return errors.New("no target rewrite specified")
+	}
+	for flag, affixes := range map[string]map[string]string{"--source-name-prefix": cfg.SourceNamePrefixes, "--source-name-suffix": cfg.SourceNameSuffixes, "--source-name-strip-suffix": cfg.SourceNameStripSuffixes} {
+		for name := range affixes {
+			if !containsString(cfg.Sources, name) {
+				return fmt.Errorf("%s source %q is not a source", flag, name)
+			}
+		}
+	}
+	for flag, ttls := range map[string]map[string]string{"--source-default-ttl": cfg.SourceDefaultTTLs, "--source-forced-ttl": cfg.SourceForcedTTLs} {
+		for name, value := range ttls {
+			if !containsString(cfg.Sources, name) {
+				return fmt.Errorf("%s source %q is not a source", flag, name)
+			}
+			if ttl, err := time.ParseDuration(value); err != nil || ttl <= 0 {
+				return fmt.Errorf("%s of source %s must be a positive duration", flag, name)
+			}
+		}
+	}
+
+	for _, source := range cfg.Sources {
+		if source == "lease" && cfg.LeaseSourceDomain == "" {
+			return errors.New("no lease source domain specified")
+		}
+		if source == "libvirt" && cfg.LibvirtSourceDomain == "" {
+			return errors.New("no libvirt source domain specified")
+		}
+		if source == "sftp" && (cfg.SFTPSourceAddress == "" || cfg.SFTPSourceHostKey == "") {
+			return errors.New("no sftp source address or host key specified")
+		}
+		if source == "s3" && cfg.S3SourceBucket == "" {
+			return errors.New("no s3 source bucket specified")
+		}
+		if source == "vault" && len(cfg.VaultSourcePaths) == 0 {
+			return errors.New("no vault source path specified")
+		}
+		if source == "mdns" && cfg.MDNSSourceDomain == "" {
+			return errors.New("no mdns source domain specified")
+		}
+		if source == "snmp" && (len(cfg.SNMPSourceTargets) == 0 || cfg.SNMPSourceDomain == "") {
+			return errors.New("no snmp source target or domain specified")
+		}
+		if source == "files" && len(cfg.FilesSourcePaths) == 0 && cfg.FilesSourceDeltaDir == "" {
+			return errors.New("no files source path specified")
+		}
+		if source == "webhook" && (cfg.WebhookSourceToken == "" || cfg.WebhookSourceFile == "") {
+			return errors.New("no webhook source token or file specified")
+		}
+		if source == "template" && cfg.TemplateSourceTemplate == "" {
+			return errors.New("no template source template specified")
+		}
+		if source == "dnsupdate" && (cfg.DNSUpdateSourceZone == "" || cfg.DNSUpdateSourceTSIGKeyName == "" || cfg.DNSUpdateSourceTSIGSecret == "") {
+			return errors.New("no dnsupdate source zone or tsig key specified")
+		}
+		if source == "reverse" && (len(cfg.ReverseSourcePaths) == 0 || len(cfg.ReverseSourceZones) == 0) {
+			return errors.New("no reverse source path or zone specified")
+		}
+	}
+
 	if cfg.IgnoreHostnameAnnotation && cfg.FQDNTemplate == "" {
 		return errors.New("FQDN Template must be set if ignoring annotations")
 	}
@@ -95,6 +291,48 @@ func ValidateConfig(cfg *externaldns.Config) error {
 	if len(cfg.TXTPrefix) > 0 && len(cfg.TXTSuffix) > 0 {
 		return errors.New("txt-prefix and txt-suffix are mutual exclusive")
 	}
+	for _, affix := range []struct {
+		flag, value string
+		// Whether the affix replaces a single label, so it can't hold the record type or dots
+		label bool
+	}{
+		{"txt-prefix", cfg.TXTPrefix, false},
+		{"txt-suffix", cfg.TXTSuffix, false},
+		{"txt-wildcard-replacement", cfg.TXTWildcardReplacement, true},
+	} {
+		if affix.label && (strings.Contains(affix.value, "%{record_type}") || strings.Contains(affix.value, ".")) {
+			return fmt.Errorf("%s must be a single label without %%{record_type}", affix.flag)
+		}
+		if strings.Count(affix.value, "%{record_type}") > 1 {
+			return fmt.Errorf("%s must contain %%{record_type} at most once", affix.flag)
+		}
+		if !txtAffixRegexp.MatchString(strings.Replace(affix.value, "%{record_type}", "", 1)) {
+			return fmt.Errorf("%s may only contain letters, digits, '-', '_' and '.'", affix.flag)
+		}
+	}
+	if cfg.Registry == "single-writer" {
+		if len(cfg.DomainFilter) == 0 {
+			return errors.New("single-writer registry requires a domain filter")
+		}
+		if cfg.MaxDeletions <= 0 {
+			return errors.New("single-writer registry requires --max-deletions")
+		}
+	}
+	if cfg.Registry == "file" && cfg.FileRegistryPath == "" {
+		return errors.New("no file registry path specified")
+	}
+	if len(cfg.TXTEncryptAESOldKeys) > 0 && cfg.TXTEncryptAESKey == "" {
+		return errors.New("txt-encrypt-aes-old-key requires txt-encrypt-aes-key")
+	}
 
 	return nil
 }
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
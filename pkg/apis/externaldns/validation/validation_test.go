@@ -18,6 +18,7 @@ package validation
 
 import (
 	"testing"
+	"time"
 
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
 
@@ -150,3 +151,521 @@ func TestValidateGoodRfc2136Config(t *testing.T) {
 
 	assert.Nil(t, err)
 }
+
+func TestValidateBadLeaseSourceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"service", "lease"}
+	cfg.LeaseSourceDomain = ""
+
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateGoodLeaseSourceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"service", "lease"}
+	cfg.LeaseSourceDomain = "lan.example.org"
+
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateBadLibvirtSourceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"libvirt"}
+	cfg.LibvirtSourceDomain = ""
+
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateGoodLibvirtSourceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"libvirt"}
+	cfg.LibvirtSourceDomain = "vm.example.org"
+
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateBadSFTPSourceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"sftp"}
+	cfg.SFTPSourceAddress = "appliance.example.org"
+	cfg.SFTPSourceHostKey = ""
+
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateGoodSFTPSourceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"sftp"}
+	cfg.SFTPSourceAddress = "appliance.example.org"
+	cfg.SFTPSourceHostKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKdJ8jFyJmNAvVvMBn9lAwpA3z8hHVq6Slq6W1eVN3fZ"
+
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateBadS3SourceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"s3"}
+	cfg.S3SourceBucket = ""
+
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateBadVaultSourceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"vault"}
+	cfg.VaultSourcePaths = nil
+
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateBadMDNSSourceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"mdns"}
+	cfg.MDNSSourceDomain = ""
+
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateBadSNMPSourceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"snmp"}
+	cfg.SNMPSourceTargets = []string{"10.0.0.1"}
+	cfg.SNMPSourceDomain = ""
+
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateBadFilesSourceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"files"}
+
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateFilesSourceMaxChangeConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.FilesSourceMaxChange = 20
+	cfg.FilesSourceConfirmAfter = time.Hour
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.FilesSourceMaxChange = -20
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.FilesSourceMaxChange = 20
+	cfg.FilesSourceConfirmAfter = -time.Hour
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateFilesSourceDeltaConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"files"}
+	cfg.FilesSourceDeltaDir = "/var/lib/external-dns/deltas"
+	cfg.FilesSourceDeltaBase = "/var/lib/external-dns/base.json"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.FilesSourceDeltaBase = ""
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.FilesSourceDeltaDir = ""
+	cfg.FilesSourceDeltaBase = "/var/lib/external-dns/base.json"
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateFilesSourceMaxAgeConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"files"}
+	cfg.FilesSourcePaths = []string{"/etc/external-dns/endpoints.json"}
+	cfg.FilesSourceMaxAge = time.Hour
+	cfg.FilesSourceFreezeStale = true
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.FilesSourceMaxAge = -time.Hour
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.FilesSourceMaxAge = 0
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateBadReverseSourceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"reverse"}
+	cfg.ReverseSourcePaths = []string{"/etc/external-dns/forward.json"}
+
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateBadWebhookSourceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"webhook"}
+	cfg.WebhookSourceFile = "/var/lib/external-dns/endpoints.json"
+
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateBadTemplateSourceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"template"}
+	cfg.TemplateSourceData = "/etc/external-dns/nodes.yaml"
+
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateBadDNSUpdateSourceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"dnsupdate"}
+	cfg.DNSUpdateSourceZone = "dhcp.example.org"
+	cfg.DNSUpdateSourceTSIGKeyName = "dhcp-key"
+
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidatePlanExportImportConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.PlanExport = "plan.json"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.Once = true
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.PlanImport = "plan.json"
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateConfigReloadIntervalConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.ConfigReloadInterval = time.Minute
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.ConfigFile = "config.yaml"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateDiffConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Diff = "text"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.Once = true
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateVerifyConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Verify = "json"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.PropagationResolver = "10.0.0.53"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateManagePTRRecordsConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.ManagePTRRecords = true
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.PTRZones = []string{"10.in-addr.arpa"}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.ManagePTRRecords = false
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateMigrateFromConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.MigrateFrom = "aws"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.MigrateFrom = cfg.Provider
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateSecondaryProviderConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.SecondaryProvider = "aws"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.SecondaryProvider = cfg.Provider
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.SecondaryProvider = "aws-sd"
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateBackupRestoreConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Backup = "backup.json"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.Restore = "backup.json"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.Backup = ""
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.MigrateFrom = "aws"
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateOnceChangesExitCodeConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.OnceChangesExitCode = 2
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.Once = true
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.OnceChangesExitCode = 1
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateEndpointRulesConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.EndpointMaxTargets = 5
+	cfg.EndpointMinTTL = time.Minute
+	cfg.EndpointMaxTTL = time.Hour
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.EndpointMinTTL = 2 * time.Hour
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.EndpointMinTTL = time.Minute
+	cfg.EndpointMaxTargets = -1
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateAdmissionWebhookConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.AdmissionListenAddress = ":8443"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.AdmissionTLSCertFile = "/etc/webhook/tls.crt"
+	cfg.AdmissionTLSKeyFile = "/etc/webhook/tls.key"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateProviderStateConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.ProviderStateFile = "/var/lib/external-dns/state.json"
+	cfg.ProviderStateResyncInterval = time.Hour
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.ProviderStateResyncInterval = 0
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateSimulateConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Simulate = "fixture.yaml"
+	cfg.DomainFilter = []string{"example.org"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.Once = true
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.DomainFilter = []string{""}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.InMemoryZones = []string{"example.org"}
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateFaultInjectionConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.FaultInjectionLatency = time.Second
+	cfg.FaultInjectionErrorRate = 0.1
+	cfg.FaultInjectionPartialRate = 1
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.FaultInjectionErrorRate = 1.5
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.FaultInjectionErrorRate = 0
+	cfg.FaultInjectionLatency = -time.Second
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateSplitTargetsSourcesConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.SplitTargetsSources = []string{"test-source"}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.SplitTargetsSources = []string{"files"}
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateSourcePrecedenceConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"service", "files"}
+	cfg.FilesSourcePaths = []string{"/etc/external-dns/endpoints.json"}
+	cfg.SourcePrecedence = []string{"files", "service"}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.SourcePrecedence = []string{"files", "files"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.SourcePrecedence = []string{"ingress"}
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateSourceNamesConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"service", "files"}
+	cfg.FilesSourcePaths = []string{"/etc/external-dns/endpoints.json"}
+	cfg.SourceNameSuffixes = map[string]string{"files": "example.org"}
+	cfg.SourceNameStripSuffixes = map[string]string{"files": "lan"}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.SourceNamePrefixes = map[string]string{"ingress": "lab-"}
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateTargetRewriteConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"service", "files"}
+	cfg.FilesSourcePaths = []string{"/etc/external-dns/endpoints.json"}
+	cfg.TargetRewriteSources = []string{"files"}
+	cfg.TargetRewrites = map[string]string{"10.0.0.0/24": "203.0.113.0/24"}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.TargetRewriteSources = []string{"ingress"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TargetRewriteSources = []string{"files"}
+	cfg.TargetRewrites = map[string]string{}
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateSourceTTLsConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Sources = []string{"service", "files"}
+	cfg.FilesSourcePaths = []string{"/etc/external-dns/endpoints.json"}
+	cfg.SourceDefaultTTLs = map[string]string{"files": "5m"}
+	cfg.SourceForcedTTLs = map[string]string{"service": "1m"}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.SourceDefaultTTLs = map[string]string{"ingress": "5m"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.SourceDefaultTTLs = map[string]string{"files": "300"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.SourceDefaultTTLs = map[string]string{}
+	cfg.SourceForcedTTLs = map[string]string{"service": "-1m"}
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateCreateBeforeDeleteConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.CreateBeforeDelete = true
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.PropagationResolver = "10.0.0.53"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateDebugRecordsConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.DebugRecords = true
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.Registry = "aws-sd"
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateMaxRecordsPerDomainConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.MaxRecordsPerDomain = 500
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.MaxRecordsPerDomain = -1
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateSecretsRefreshIntervalConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.SecretsRefreshInterval = time.Hour
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.SecretsRefreshInterval = -time.Hour
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateCircuitBreakerResetConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.CircuitBreakerReset = true
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.MaxApplyFailures = 3
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateDeletionGracePeriodConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.DeletionGracePeriod = time.Minute
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.Once = true
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.Once = false
+	cfg.DeletionGracePeriod = -time.Minute
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateBadTXTAffixConfig(t *testing.T) {
+	for _, tc := range []struct {
+		prefix, suffix, wildcardReplacement string
+		valid                               bool
+	}{
+		{prefix: "_owner.%{record_type}.", valid: true},
+		{suffix: "-%{record_type}", wildcardReplacement: "wildcard", valid: true},
+		{prefix: "%{record_type}-%{record_type}-"},
+		{prefix: "txt*"},
+		{suffix: "-txt!"},
+		{wildcardReplacement: "any.wildcard"},
+		{wildcardReplacement: "%{record_type}"},
+	} {
+		cfg := newValidConfig(t)
+		cfg.TXTPrefix = tc.prefix
+		cfg.TXTSuffix = tc.suffix
+		cfg.TXTWildcardReplacement = tc.wildcardReplacement
+
+		if tc.valid {
+			assert.NoError(t, ValidateConfig(cfg))
+		} else {
+			assert.Error(t, ValidateConfig(cfg))
+		}
+	}
+}
+
+func TestValidateBadTXTEncryptionConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.TXTEncryptAESOldKeys = []string{"old-key"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TXTEncryptAESKey = "new-key"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateBadFileRegistryConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "file"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.FileRegistryPath = "registry.json"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateBadSingleWriterRegistryConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "single-writer"
+	cfg.MaxDeletions = 10
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.DomainFilter = []string{"example.org"}
+	cfg.MaxDeletions = 0
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.MaxDeletions = 10
+	assert.NoError(t, ValidateConfig(cfg))
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// StatusOK reports a passing check
+	StatusOK = "ok"
+	// StatusFailed reports a failing check
+	StatusFailed = "failed"
+)
+
+// Check verifies a component and returns an error if it isn't healthy.
+type Check func(ctx context.Context) error
+
+// CheckResult is the result of a single check.
+type CheckResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the result of all checks.
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Every returns a check running the given check at most once per interval, and the result of
+// its last run in between, e.g. for checks too expensive to run on every probe.
+func Every(interval time.Duration, check Check) Check {
+	var (
+		mutex   sync.Mutex
+		lastRun time.Time
+		lastErr error
+	)
+	return func(ctx context.Context) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if lastRun.IsZero() || time.Since(lastRun) >= interval {
+			lastErr = check(ctx)
+			lastRun = time.Now()
+		}
+		return lastErr
+	}
+}
+
+// Checker runs a set of named checks and serves their results as JSON.
+//
+// Checks can be added while the Checker is served, e.g. once the components they verify
+// have been created. A Checker without checks reports a failure, as nothing is ready yet.
+type Checker struct {
+	mutex  sync.Mutex
+	names  []string
+	checks map[string]Check
+}
+
+// NewChecker creates a new Checker without checks.
+func NewChecker() *Checker {
+	return &Checker{checks: map[string]Check{}}
+}
+
+// Add adds a check with the given name, replacing any check with the same name.
+func (c *Checker) Add(name string, check Check) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Run runs all checks in the order they were added.
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mutex.Lock()
+	names := append([]string(nil), c.names...)
+	checks := make(map[string]Check, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	c.mutex.Unlock()
+
+	report := Report{Status: StatusOK, Checks: []CheckResult{}}
+	if len(names) == 0 {
+		report.Status = StatusFailed
+	}
+	for _, name := range names {
+		result := CheckResult{Name: name, Status: StatusOK}
+		if err := checks[name](ctx); err != nil {
+			result.Status = StatusFailed
+			result.Error = err.Error()
+			report.Status = StatusFailed
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report
+}
+
+// ServeHTTP runs all checks and responds with their results, with status 503 if any
+// check failed.
+func (c *Checker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := c.Run(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status != StatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker(t *testing.T) {
+	checker := NewChecker()
+
+	serve := func() (int, Report) {
+		rec := httptest.NewRecorder()
+		checker.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		var report Report
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		return rec.Code, report
+	}
+
+	code, report := serve()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, Report{Status: StatusFailed, Checks: []CheckResult{}}, report)
+
+	fileErr := errors.New("endpoints.json: unexpected end of JSON input")
+	checker.Add("source-files", func(ctx context.Context) error { return fileErr })
+	checker.Add("provider", func(ctx context.Context) error { return nil })
+
+	code, report = serve()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, Report{Status: StatusFailed, Checks: []CheckResult{
+		{Name: "source-files", Status: StatusFailed, Error: fileErr.Error()},
+		{Name: "provider", Status: StatusOK},
+	}}, report)
+
+	checker.Add("source-files", func(ctx context.Context) error { return nil })
+
+	code, report = serve()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, Report{Status: StatusOK, Checks: []CheckResult{
+		{Name: "source-files", Status: StatusOK},
+		{Name: "provider", Status: StatusOK},
+	}}, report)
+}
+
+func TestEvery(t *testing.T) {
+	runs := 0
+	check := Every(time.Hour, func(context.Context) error {
+		runs++
+		return errors.New("unavailable")
+	})
+
+	// The result of the first run is returned until the interval passed.
+	assert.EqualError(t, check(context.Background()), "unavailable")
+	assert.EqualError(t, check(context.Background()), "unavailable")
+	assert.Equal(t, 1, runs)
+
+	check = Every(0, func(context.Context) error {
+		runs++
+		return nil
+	})
+	assert.NoError(t, check(context.Background()))
+	assert.NoError(t, check(context.Background()))
+	assert.Equal(t, 3, runs)
+}
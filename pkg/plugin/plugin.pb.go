@@ -0,0 +1,493 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: pkg/plugin/plugin.proto
+
+package plugin
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// ProviderSpecificProperty holds the name and value of a configuration which is specific to individual DNS providers.
+type ProviderSpecificProperty struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value                string   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProviderSpecificProperty) Reset()         { *m = ProviderSpecificProperty{} }
+func (m *ProviderSpecificProperty) String() string { return proto.CompactTextString(m) }
+func (*ProviderSpecificProperty) ProtoMessage()    {}
+func (*ProviderSpecificProperty) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6477768a0ab2da75, []int{0}
+}
+
+func (m *ProviderSpecificProperty) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProviderSpecificProperty.Unmarshal(m, b)
+}
+func (m *ProviderSpecificProperty) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProviderSpecificProperty.Marshal(b, m, deterministic)
+}
+func (m *ProviderSpecificProperty) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProviderSpecificProperty.Merge(m, src)
+}
+func (m *ProviderSpecificProperty) XXX_Size() int {
+	return xxx_messageInfo_ProviderSpecificProperty.Size(m)
+}
+func (m *ProviderSpecificProperty) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProviderSpecificProperty.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProviderSpecificProperty proto.InternalMessageInfo
+
+func (m *ProviderSpecificProperty) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ProviderSpecificProperty) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+// Endpoint is a high-level way of a connection between a service and an IP.
+type Endpoint struct {
+	// The hostname of the DNS record.
+	DnsName string `protobuf:"bytes,1,opt,name=dns_name,json=dnsName,proto3" json:"dns_name,omitempty"`
+	// The targets the DNS record points to.
+	Targets []string `protobuf:"bytes,2,rep,name=targets,proto3" json:"targets,omitempty"`
+	// RecordType type of record, e.g. CNAME, A, SRV, TXT etc. Inferred from the targets if empty.
+	RecordType string `protobuf:"bytes,3,opt,name=record_type,json=recordType,proto3" json:"record_type,omitempty"`
+	// Identifier to distinguish multiple records with the same name and type.
+	SetIdentifier string `protobuf:"bytes,4,opt,name=set_identifier,json=setIdentifier,proto3" json:"set_identifier,omitempty"`
+	// TTL for the record in seconds, 0 means the provider default.
+	RecordTtl int64 `protobuf:"varint,5,opt,name=record_ttl,json=recordTtl,proto3" json:"record_ttl,omitempty"`
+	// Labels stores labels defined for the Endpoint.
+	Labels map[string]string `protobuf:"bytes,6,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// ProviderSpecific stores provider specific config.
+	ProviderSpecific     []*ProviderSpecificProperty `protobuf:"bytes,7,rep,name=provider_specific,json=providerSpecific,proto3" json:"provider_specific,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                    `json:"-"`
+	XXX_unrecognized     []byte                      `json:"-"`
+	XXX_sizecache        int32                       `json:"-"`
+}
+
+func (m *Endpoint) Reset()         { *m = Endpoint{} }
+func (m *Endpoint) String() string { return proto.CompactTextString(m) }
+func (*Endpoint) ProtoMessage()    {}
+func (*Endpoint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6477768a0ab2da75, []int{1}
+}
+
+func (m *Endpoint) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Endpoint.Unmarshal(m, b)
+}
+func (m *Endpoint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Endpoint.Marshal(b, m, deterministic)
+}
+func (m *Endpoint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Endpoint.Merge(m, src)
+}
+func (m *Endpoint) XXX_Size() int {
+	return xxx_messageInfo_Endpoint.Size(m)
+}
+func (m *Endpoint) XXX_DiscardUnknown() {
+	xxx_messageInfo_Endpoint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Endpoint proto.InternalMessageInfo
+
+func (m *Endpoint) GetDnsName() string {
+	if m != nil {
+		return m.DnsName
+	}
+	return ""
+}
+
+func (m *Endpoint) GetTargets() []string {
+	if m != nil {
+		return m.Targets
+	}
+	return nil
+}
+
+func (m *Endpoint) GetRecordType() string {
+	if m != nil {
+		return m.RecordType
+	}
+	return ""
+}
+
+func (m *Endpoint) GetSetIdentifier() string {
+	if m != nil {
+		return m.SetIdentifier
+	}
+	return ""
+}
+
+func (m *Endpoint) GetRecordTtl() int64 {
+	if m != nil {
+		return m.RecordTtl
+	}
+	return 0
+}
+
+func (m *Endpoint) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *Endpoint) GetProviderSpecific() []*ProviderSpecificProperty {
+	if m != nil {
+		return m.ProviderSpecific
+	}
+	return nil
+}
+
+type ListEndpointsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListEndpointsRequest) Reset()         { *m = ListEndpointsRequest{} }
+func (m *ListEndpointsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListEndpointsRequest) ProtoMessage()    {}
+func (*ListEndpointsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6477768a0ab2da75, []int{2}
+}
+
+func (m *ListEndpointsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListEndpointsRequest.Unmarshal(m, b)
+}
+func (m *ListEndpointsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListEndpointsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListEndpointsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListEndpointsRequest.Merge(m, src)
+}
+func (m *ListEndpointsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListEndpointsRequest.Size(m)
+}
+func (m *ListEndpointsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListEndpointsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListEndpointsRequest proto.InternalMessageInfo
+
+type ListEndpointsResponse struct {
+	Endpoints            []*Endpoint `protobuf:"bytes,1,rep,name=endpoints,proto3" json:"endpoints,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *ListEndpointsResponse) Reset()         { *m = ListEndpointsResponse{} }
+func (m *ListEndpointsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListEndpointsResponse) ProtoMessage()    {}
+func (*ListEndpointsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6477768a0ab2da75, []int{3}
+}
+
+func (m *ListEndpointsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListEndpointsResponse.Unmarshal(m, b)
+}
+func (m *ListEndpointsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListEndpointsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListEndpointsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListEndpointsResponse.Merge(m, src)
+}
+func (m *ListEndpointsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListEndpointsResponse.Size(m)
+}
+func (m *ListEndpointsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListEndpointsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListEndpointsResponse proto.InternalMessageInfo
+
+func (m *ListEndpointsResponse) GetEndpoints() []*Endpoint {
+	if m != nil {
+		return m.Endpoints
+	}
+	return nil
+}
+
+type WatchEndpointsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchEndpointsRequest) Reset()         { *m = WatchEndpointsRequest{} }
+func (m *WatchEndpointsRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchEndpointsRequest) ProtoMessage()    {}
+func (*WatchEndpointsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6477768a0ab2da75, []int{4}
+}
+
+func (m *WatchEndpointsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchEndpointsRequest.Unmarshal(m, b)
+}
+func (m *WatchEndpointsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchEndpointsRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchEndpointsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchEndpointsRequest.Merge(m, src)
+}
+func (m *WatchEndpointsRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchEndpointsRequest.Size(m)
+}
+func (m *WatchEndpointsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchEndpointsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchEndpointsRequest proto.InternalMessageInfo
+
+type WatchEndpointsResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchEndpointsResponse) Reset()         { *m = WatchEndpointsResponse{} }
+func (m *WatchEndpointsResponse) String() string { return proto.CompactTextString(m) }
+func (*WatchEndpointsResponse) ProtoMessage()    {}
+func (*WatchEndpointsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6477768a0ab2da75, []int{5}
+}
+
+func (m *WatchEndpointsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchEndpointsResponse.Unmarshal(m, b)
+}
+func (m *WatchEndpointsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchEndpointsResponse.Marshal(b, m, deterministic)
+}
+func (m *WatchEndpointsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchEndpointsResponse.Merge(m, src)
+}
+func (m *WatchEndpointsResponse) XXX_Size() int {
+	return xxx_messageInfo_WatchEndpointsResponse.Size(m)
+}
+func (m *WatchEndpointsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchEndpointsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchEndpointsResponse proto.InternalMessageInfo
+
+func init() {
+	proto.RegisterType((*ProviderSpecificProperty)(nil), "externaldns.plugin.v1alpha1.ProviderSpecificProperty")
+	proto.RegisterType((*Endpoint)(nil), "externaldns.plugin.v1alpha1.Endpoint")
+	proto.RegisterMapType((map[string]string)(nil), "externaldns.plugin.v1alpha1.Endpoint.LabelsEntry")
+	proto.RegisterType((*ListEndpointsRequest)(nil), "externaldns.plugin.v1alpha1.ListEndpointsRequest")
+	proto.RegisterType((*ListEndpointsResponse)(nil), "externaldns.plugin.v1alpha1.ListEndpointsResponse")
+	proto.RegisterType((*WatchEndpointsRequest)(nil), "externaldns.plugin.v1alpha1.WatchEndpointsRequest")
+	proto.RegisterType((*WatchEndpointsResponse)(nil), "externaldns.plugin.v1alpha1.WatchEndpointsResponse")
+}
+
+func init() { proto.RegisterFile("pkg/plugin/plugin.proto", fileDescriptor_6477768a0ab2da75) }
+
+var fileDescriptor_6477768a0ab2da75 = []byte{
+	// 450 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x53, 0xcf, 0x6b, 0x13, 0x41,
+	0x18, 0x65, 0xb3, 0x6d, 0xd2, 0x7c, 0xa1, 0xa1, 0x0e, 0xfd, 0x31, 0x46, 0xc4, 0xb0, 0x12, 0xc8,
+	0xc5, 0x8d, 0x49, 0x11, 0xaa, 0x47, 0xb5, 0x87, 0x42, 0x91, 0xb2, 0x15, 0x04, 0x11, 0xc2, 0x66,
+	0xf7, 0x6b, 0x3a, 0x64, 0x9d, 0x19, 0xe7, 0x9b, 0x04, 0x17, 0xcf, 0xde, 0xfd, 0x93, 0x25, 0x9b,
+	0x59, 0xdb, 0xc6, 0xb8, 0x98, 0xd3, 0xce, 0xbc, 0x6f, 0xdf, 0x7b, 0xf3, 0xde, 0x30, 0x70, 0xa2,
+	0x67, 0xd3, 0x81, 0xce, 0xe6, 0x53, 0x21, 0xdd, 0x27, 0xd4, 0x46, 0x59, 0xc5, 0x9e, 0xe0, 0x77,
+	0x8b, 0x46, 0xc6, 0x59, 0x2a, 0x29, 0x74, 0x93, 0xc5, 0x30, 0xce, 0xf4, 0x6d, 0x3c, 0x0c, 0xde,
+	0x03, 0xbf, 0x32, 0x6a, 0x21, 0x52, 0x34, 0xd7, 0x1a, 0x13, 0x71, 0x23, 0x92, 0x2b, 0xa3, 0x34,
+	0x1a, 0x9b, 0x33, 0x06, 0x3b, 0x32, 0xfe, 0x8a, 0xdc, 0xeb, 0x7a, 0xfd, 0x66, 0x54, 0xac, 0xd9,
+	0x21, 0xec, 0x2e, 0xe2, 0x6c, 0x8e, 0xbc, 0x56, 0x80, 0xab, 0x4d, 0xf0, 0xcb, 0x87, 0xbd, 0x73,
+	0x99, 0x6a, 0x25, 0xa4, 0x65, 0x8f, 0x61, 0x2f, 0x95, 0x34, 0xbe, 0x47, 0x6d, 0xa4, 0x92, 0x3e,
+	0x2c, 0xd9, 0x1c, 0x1a, 0x36, 0x36, 0x53, 0xb4, 0xc4, 0x6b, 0x5d, 0x7f, 0x39, 0x71, 0x5b, 0xf6,
+	0x0c, 0x5a, 0x06, 0x13, 0x65, 0xd2, 0xb1, 0xcd, 0x35, 0x72, 0xbf, 0xe0, 0xc1, 0x0a, 0xfa, 0x98,
+	0x6b, 0x64, 0x3d, 0x68, 0x13, 0xda, 0xb1, 0x48, 0x51, 0x5a, 0x71, 0x23, 0xd0, 0xf0, 0x9d, 0xe2,
+	0x9f, 0x7d, 0x42, 0x7b, 0xf1, 0x07, 0x64, 0x4f, 0x01, 0x4a, 0x1d, 0x9b, 0xf1, 0xdd, 0xae, 0xd7,
+	0xf7, 0xa3, 0xa6, 0x93, 0xb1, 0x19, 0xbb, 0x80, 0x7a, 0x16, 0x4f, 0x30, 0x23, 0x5e, 0xef, 0xfa,
+	0xfd, 0xd6, 0x68, 0x18, 0x56, 0x94, 0x13, 0x96, 0x91, 0xc2, 0xcb, 0x82, 0x73, 0x2e, 0xad, 0xc9,
+	0x23, 0x27, 0xc0, 0x26, 0xf0, 0x48, 0xbb, 0xe6, 0xc6, 0xe4, 0xaa, 0xe3, 0x8d, 0x42, 0xf5, 0x55,
+	0xa5, 0xea, 0xbf, 0xfa, 0x8e, 0x0e, 0xf4, 0xda, 0xa4, 0xf3, 0x1a, 0x5a, 0xf7, 0xac, 0xd9, 0x01,
+	0xf8, 0x33, 0xcc, 0x5d, 0xa9, 0xcb, 0xe5, 0xe6, 0xeb, 0x78, 0x53, 0x3b, 0xf3, 0x82, 0x63, 0x38,
+	0xbc, 0x14, 0x64, 0xcb, 0x08, 0x14, 0xe1, 0xb7, 0x39, 0x92, 0x0d, 0xbe, 0xc0, 0xd1, 0x1a, 0x4e,
+	0x5a, 0x49, 0x42, 0xf6, 0x0e, 0x9a, 0x58, 0x82, 0xdc, 0x2b, 0x72, 0xf4, 0xfe, 0xab, 0x9d, 0xe8,
+	0x8e, 0x17, 0x9c, 0xc0, 0xd1, 0xa7, 0xd8, 0x26, 0xb7, 0x7f, 0xd9, 0x72, 0x38, 0x5e, 0x1f, 0xac,
+	0x7c, 0x47, 0x3f, 0x6b, 0xd0, 0x2e, 0xd1, 0x6b, 0x35, 0x37, 0x09, 0xb2, 0x05, 0xec, 0x3f, 0x38,
+	0x23, 0xab, 0xbe, 0xa6, 0x4d, 0x39, 0x3b, 0xa3, 0x6d, 0x28, 0xae, 0x82, 0x1f, 0xd0, 0x7e, 0x78,
+	0x48, 0x56, 0xad, 0xb2, 0x31, 0x6a, 0xe7, 0x74, 0x2b, 0xce, 0xca, 0xfa, 0xa5, 0xf7, 0xb6, 0xf7,
+	0xf9, 0x39, 0x89, 0x29, 0x85, 0xb3, 0x33, 0x0a, 0x85, 0x1a, 0x94, 0x1a, 0x2f, 0x52, 0x49, 0x83,
+	0xbb, 0xa7, 0x3d, 0xa9, 0x17, 0x8f, 0xfa, 0xf4, 0xf7, 0x00, 0x10, 0x4e, 0xef, 0xe4, 0xef, 0x03,
+	0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// EndpointSourceClient is the client API for EndpointSource service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type EndpointSourceClient interface {
+	// ListEndpoints returns all desired endpoints.
+	ListEndpoints(ctx context.Context, in *ListEndpointsRequest, opts ...grpc.CallOption) (*ListEndpointsResponse, error)
+	// WatchEndpoints streams a message whenever the desired endpoints changed.
+	WatchEndpoints(ctx context.Context, in *WatchEndpointsRequest, opts ...grpc.CallOption) (EndpointSource_WatchEndpointsClient, error)
+}
+
+type endpointSourceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewEndpointSourceClient(cc *grpc.ClientConn) EndpointSourceClient {
+	return &endpointSourceClient{cc}
+}
+
+func (c *endpointSourceClient) ListEndpoints(ctx context.Context, in *ListEndpointsRequest, opts ...grpc.CallOption) (*ListEndpointsResponse, error) {
+	out := new(ListEndpointsResponse)
+	err := c.cc.Invoke(ctx, "/externaldns.plugin.v1alpha1.EndpointSource/ListEndpoints", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endpointSourceClient) WatchEndpoints(ctx context.Context, in *WatchEndpointsRequest, opts ...grpc.CallOption) (EndpointSource_WatchEndpointsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_EndpointSource_serviceDesc.Streams[0], "/externaldns.plugin.v1alpha1.EndpointSource/WatchEndpoints", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &endpointSourceWatchEndpointsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type EndpointSource_WatchEndpointsClient interface {
+	Recv() (*WatchEndpointsResponse, error)
+	grpc.ClientStream
+}
+
+type endpointSourceWatchEndpointsClient struct {
+	grpc.ClientStream
+}
+
+func (x *endpointSourceWatchEndpointsClient) Recv() (*WatchEndpointsResponse, error) {
+	m := new(WatchEndpointsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EndpointSourceServer is the server API for EndpointSource service.
+type EndpointSourceServer interface {
+	// ListEndpoints returns all desired endpoints.
+	ListEndpoints(context.Context, *ListEndpointsRequest) (*ListEndpointsResponse, error)
+	// WatchEndpoints streams a message whenever the desired endpoints changed.
+	WatchEndpoints(*WatchEndpointsRequest, EndpointSource_WatchEndpointsServer) error
+}
+
+// UnimplementedEndpointSourceServer can be embedded to have forward compatible implementations.
+type UnimplementedEndpointSourceServer struct {
+}
+
+func (*UnimplementedEndpointSourceServer) ListEndpoints(ctx context.Context, req *ListEndpointsRequest) (*ListEndpointsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEndpoints not implemented")
+}
+func (*UnimplementedEndpointSourceServer) WatchEndpoints(req *WatchEndpointsRequest, srv EndpointSource_WatchEndpointsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEndpoints not implemented")
+}
+
+func RegisterEndpointSourceServer(s *grpc.Server, srv EndpointSourceServer) {
+	s.RegisterService(&_EndpointSource_serviceDesc, srv)
+}
+
+func _EndpointSource_ListEndpoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEndpointsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndpointSourceServer).ListEndpoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/externaldns.plugin.v1alpha1.EndpointSource/ListEndpoints",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndpointSourceServer).ListEndpoints(ctx, req.(*ListEndpointsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EndpointSource_WatchEndpoints_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEndpointsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EndpointSourceServer).WatchEndpoints(m, &endpointSourceWatchEndpointsServer{stream})
+}
+
+type EndpointSource_WatchEndpointsServer interface {
+	Send(*WatchEndpointsResponse) error
+	grpc.ServerStream
+}
+
+type endpointSourceWatchEndpointsServer struct {
+	grpc.ServerStream
+}
+
+func (x *endpointSourceWatchEndpointsServer) Send(m *WatchEndpointsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _EndpointSource_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "externaldns.plugin.v1alpha1.EndpointSource",
+	HandlerType: (*EndpointSourceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListEndpoints",
+			Handler:    _EndpointSource_ListEndpoints_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEndpoints",
+			Handler:       _EndpointSource_WatchEndpoints_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/plugin/plugin.proto",
+}
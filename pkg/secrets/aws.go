@@ -0,0 +1,88 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// awsSession creates the session of the AWS fetchers once they're used, with the default
+// credential chain, e.g. the role of the service account.
+var awsSession = struct {
+	once    sync.Once
+	session *session.Session
+	err     error
+}{}
+
+func newAWSSession() (*session.Session, error) {
+	awsSession.once.Do(func() {
+		awsSession.session, awsSession.err = session.NewSessionWithOptions(session.Options{
+			SharedConfigState: session.SharedConfigEnable,
+		})
+	})
+	return awsSession.session, awsSession.err
+}
+
+// AWSSecretsManagerFetcher fetches the secret strings of AWS Secrets Manager by their name or ARN.
+type AWSSecretsManagerFetcher struct{}
+
+// Fetch returns the secret string of the current version of the secret.
+func (AWSSecretsManagerFetcher) Fetch(ctx context.Context, name string) (string, error) {
+	sess, err := newAWSSession()
+	if err != nil {
+		return "", err
+	}
+	output, err := secretsmanager.New(sess).GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		// The name of awssm:///name has a leading slash.
+		SecretId: aws.String(strings.TrimPrefix(name, "/")),
+	})
+	if err != nil {
+		return "", err
+	}
+	if output.SecretString == nil {
+		return "", fmt.Errorf("secret has no secret string")
+	}
+	return aws.StringValue(output.SecretString), nil
+}
+
+// AWSParameterStoreFetcher fetches the parameters of AWS Systems Manager Parameter Store by their
+// name, decrypting secure strings.
+type AWSParameterStoreFetcher struct{}
+
+// Fetch returns the value of the parameter.
+func (AWSParameterStoreFetcher) Fetch(ctx context.Context, name string) (string, error) {
+	sess, err := newAWSSession()
+	if err != nil {
+		return "", err
+	}
+	output, err := ssm.New(sess).GetParameterWithContext(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(output.Parameter.Value), nil
+}
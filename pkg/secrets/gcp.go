@@ -0,0 +1,52 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	secretmanager "google.golang.org/api/secretmanager/v1beta1"
+)
+
+// GCPSecretManagerFetcher fetches the secrets of GCP Secret Manager by their resource name, e.g.
+// projects/p/secrets/token or projects/p/secrets/token/versions/3, with the application default
+// credentials, e.g. of workload identity. Secrets without a version are fetched in their latest
+// version.
+type GCPSecretManagerFetcher struct{}
+
+// Fetch returns the payload of the version of the secret.
+func (GCPSecretManagerFetcher) Fetch(ctx context.Context, name string) (string, error) {
+	name = strings.Trim(name, "/")
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	service, err := secretmanager.NewService(ctx)
+	if err != nil {
+		return "", err
+	}
+	response, err := service.Projects.Secrets.Versions.Access(name).Context(ctx).Do()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(response.Payload.Data)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
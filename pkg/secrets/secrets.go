@@ -0,0 +1,166 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fetcher fetches the value of a secret from a secret manager.
+type Fetcher interface {
+	// Fetch returns the value of the secret of the reference without its scheme.
+	Fetch(ctx context.Context, name string) (string, error)
+}
+
+// Resolver resolves references to the secrets of secret managers, e.g.
+//
+//	awssm://external-dns/token        the secret of AWS Secrets Manager
+//	awsssm:///external-dns/token      the parameter of AWS Systems Manager Parameter Store
+//	gcpsm://projects/p/secrets/token  the latest version of the secret of GCP Secret Manager
+//
+// A reference may select a field of a secret holding a JSON object, e.g.
+// awssm://external-dns#token. Values which aren't references are returned as they are.
+//
+// Secrets are cached until they are fetched again by Watch, so several references to the same
+// secret, e.g. to several of its fields, fetch it once.
+type Resolver struct {
+	fetchers map[string]Fetcher
+
+	mutex sync.Mutex
+	cache map[string]string
+}
+
+// NewResolver creates a new Resolver fetching the secrets of references with the schemes of the
+// fetchers.
+func NewResolver(fetchers map[string]Fetcher) *Resolver {
+	return &Resolver{fetchers: fetchers, cache: map[string]string{}}
+}
+
+// NewCloudResolver creates a new Resolver of the references to the secrets of AWS Secrets Manager
+// (awssm), AWS Systems Manager Parameter Store (awsssm) and GCP Secret Manager (gcpsm).
+func NewCloudResolver() *Resolver {
+	return NewResolver(map[string]Fetcher{
+		"awssm":  AWSSecretsManagerFetcher{},
+		"awsssm": AWSParameterStoreFetcher{},
+		"gcpsm":  GCPSecretManagerFetcher{},
+	})
+}
+
+// IsReference returns whether the value is a reference to a secret.
+func (r *Resolver) IsReference(value string) bool {
+	scheme, _, ok := splitReference(value)
+	if !ok {
+		return false
+	}
+	_, ok = r.fetchers[scheme]
+	return ok
+}
+
+// Resolve returns the secret the value references, or the value if it isn't a reference.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	if !r.IsReference(value) {
+		return value, nil
+	}
+	scheme, name, _ := splitReference(value)
+	field := ""
+	if i := strings.LastIndex(name, "#"); i >= 0 {
+		name, field = name[:i], name[i+1:]
+	}
+
+	secret, err := r.fetch(ctx, scheme, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch secret %s://%s: %v", scheme, name, err)
+	}
+	if field == "" {
+		return secret, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret %s://%s is not a JSON object: %v", scheme, name, err)
+	}
+	switch v := fields[field].(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", fmt.Errorf("secret %s://%s has no field %q", scheme, name, field)
+	default:
+		return "", fmt.Errorf("field %q of secret %s://%s is not a string", field, scheme, name)
+	}
+}
+
+func (r *Resolver) fetch(ctx context.Context, scheme, name string) (string, error) {
+	key := scheme + "://" + name
+	r.mutex.Lock()
+	value, ok := r.cache[key]
+	r.mutex.Unlock()
+	if ok {
+		return value, nil
+	}
+
+	value, err := r.fetchers[scheme].Fetch(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	r.mutex.Lock()
+	r.cache[key] = value
+	r.mutex.Unlock()
+	return value, nil
+}
+
+// Watch fetches the secrets of the references again in the interval and calls the handler once
+// the secret of one of them differs from the given value, e.g. because it was rotated. Failures
+// to fetch them are passed to the error handler and retried in the next interval.
+func (r *Resolver) Watch(ctx context.Context, resolved map[string]string, interval time.Duration, handler func(reference string), errorHandler func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.mutex.Lock()
+			r.cache = map[string]string{}
+			r.mutex.Unlock()
+			for reference, value := range resolved {
+				current, err := r.Resolve(ctx, reference)
+				if err != nil {
+					errorHandler(err)
+					continue
+				}
+				if current != value {
+					handler(reference)
+					return
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// splitReference splits a reference into its scheme and the name of the secret.
+func splitReference(value string) (string, string, bool) {
+	i := strings.Index(value, "://")
+	if i <= 0 {
+		return "", "", false
+	}
+	return value[:i], value[i+3:], true
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secrets
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFetcher returns the secrets of a map and counts the fetches.
+type fakeFetcher struct {
+	mutex   sync.Mutex
+	secrets map[string]string
+	fetches int
+}
+
+func (f *fakeFetcher) Fetch(ctx context.Context, name string) (string, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.fetches++
+	secret, ok := f.secrets[name]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return secret, nil
+}
+
+func (f *fakeFetcher) set(name, secret string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.secrets[name] = secret
+}
+
+func TestResolverResolve(t *testing.T) {
+	ctx := context.Background()
+	fetcher := &fakeFetcher{secrets: map[string]string{
+		"token":          "secret",
+		"/external-dns":  `{"user": "admin", "password": "secret", "port": 443}`,
+		"not-json-token": "secret",
+	}}
+	r := NewResolver(map[string]Fetcher{"fake": fetcher})
+
+	for _, tc := range []struct {
+		value    string
+		expected string
+		err      bool
+	}{
+		{value: "plain", expected: "plain"},
+		{value: "https://example.org", expected: "https://example.org"},
+		{value: "fake://token", expected: "secret"},
+		{value: "fake:///external-dns#user", expected: "admin"},
+		{value: "fake:///external-dns#password", expected: "secret"},
+		{value: "fake:///external-dns#port", err: true},
+		{value: "fake:///external-dns#missing", err: true},
+		{value: "fake://not-json-token#user", err: true},
+		{value: "fake://missing", err: true},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			resolved, err := r.Resolve(ctx, tc.value)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, resolved)
+		})
+	}
+
+	// Secrets are fetched once.
+	assert.Equal(t, 4, fetcher.fetches)
+}
+
+func TestResolverWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fetcher := &fakeFetcher{secrets: map[string]string{"token": "secret"}}
+	r := NewResolver(map[string]Fetcher{"fake": fetcher})
+
+	changed := make(chan string, 1)
+	go r.Watch(ctx, map[string]string{"fake://token": "secret"}, 10*time.Millisecond, func(reference string) {
+		changed <- reference
+	}, func(err error) {})
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, changed)
+
+	fetcher.set("token", "rotated")
+	select {
+	case reference := <-changed:
+		assert.Equal(t, "fake://token", reference)
+	case <-time.After(time.Second):
+		t.Fatal("rotated secret not detected")
+	}
+}
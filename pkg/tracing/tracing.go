@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	exporttrace "go.opentelemetry.io/otel/sdk/export/trace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName  = "sigs.k8s.io/external-dns"
+	serviceName = "external-dns"
+)
+
+// Tracer returns the tracer for the spans of ExternalDNS. Unless Setup was called, spans
+// aren't recorded.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// End ends the span, marking it as failed with a non-nil error.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Setup records all spans and exports them to the Zipkin v2 API at the given URL, which
+// Zipkin, Jaeger and the OpenTelemetry Collector accept, e.g.
+// http://localhost:9411/api/v2/spans. The returned function exports the remaining spans.
+func Setup(url string) func(context.Context) error {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithConfig(sdktrace.Config{DefaultSampler: sdktrace.AlwaysSample()}),
+		sdktrace.WithBatcher(newZipkinExporter(url)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown
+}
+
+// zipkinExporter exports spans in the JSON format of the Zipkin v2 API.
+type zipkinExporter struct {
+	url    string
+	client *http.Client
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type zipkinSpan struct {
+	TraceID       string            `json:"traceId"`
+	ID            string            `json:"id"`
+	ParentID      string            `json:"parentId,omitempty"`
+	Name          string            `json:"name"`
+	Timestamp     int64             `json:"timestamp"`
+	Duration      int64             `json:"duration"`
+	LocalEndpoint zipkinEndpoint    `json:"localEndpoint"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+func newZipkinExporter(url string) *zipkinExporter {
+	return &zipkinExporter{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// ExportSpans sends the spans to the Zipkin API.
+func (e *zipkinExporter) ExportSpans(ctx context.Context, spans []*exporttrace.SpanData) error {
+	zipkinSpans := make([]zipkinSpan, 0, len(spans))
+	for _, s := range spans {
+		zs := zipkinSpan{
+			TraceID:       s.SpanContext.TraceID.String(),
+			ID:            s.SpanContext.SpanID.String(),
+			Name:          s.Name,
+			Timestamp:     s.StartTime.UnixNano() / int64(time.Microsecond),
+			Duration:      int64(s.EndTime.Sub(s.StartTime) / time.Microsecond),
+			LocalEndpoint: zipkinEndpoint{ServiceName: serviceName},
+			Tags:          map[string]string{},
+		}
+		if s.ParentSpanID.IsValid() {
+			zs.ParentID = s.ParentSpanID.String()
+		}
+		for _, kv := range s.Attributes {
+			zs.Tags[string(kv.Key)] = kv.Value.Emit()
+		}
+		if s.StatusCode == codes.Error {
+			zs.Tags["error"] = s.StatusMessage
+		}
+		zipkinSpans = append(zipkinSpans, zs)
+	}
+
+	data, err := json.Marshal(zipkinSpans)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to export spans to %s: %s", e.url, resp.Status)
+	}
+	return nil
+}
+
+// Shutdown has nothing to clean up.
+func (e *zipkinExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
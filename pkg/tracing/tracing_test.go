@@ -0,0 +1,66 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/label"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestZipkinExporter(t *testing.T) {
+	var spans []zipkinSpan
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var batch []zipkinSpan
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		spans = append(spans, batch...)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithConfig(sdktrace.Config{DefaultSampler: sdktrace.AlwaysSample()}),
+		sdktrace.WithSyncer(newZipkinExporter(server.URL)),
+	)
+	tracer := provider.Tracer(tracerName)
+
+	ctx, parent := tracer.Start(context.Background(), "sync")
+	_, child := tracer.Start(ctx, "plan.calculate")
+	child.SetAttributes(label.Int("changes.create", 2))
+	End(child, errors.New("failed"))
+	require.Len(t, spans, 1)
+	End(parent, nil)
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "plan.calculate", spans[0].Name)
+	assert.Equal(t, parent.SpanContext().TraceID.String(), spans[0].TraceID)
+	assert.Equal(t, parent.SpanContext().SpanID.String(), spans[0].ParentID)
+	assert.Equal(t, serviceName, spans[0].LocalEndpoint.ServiceName)
+	assert.Equal(t, "2", spans[0].Tags["changes.create"])
+	assert.Equal(t, "failed", spans[0].Tags["error"])
+	assert.NotZero(t, spans[0].Timestamp)
+	assert.Empty(t, spans[1].ParentID)
+}
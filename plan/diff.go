@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	// DiffFormatText writes one line per change, prefixed with +, ~ or -
+	DiffFormatText = "text"
+	// DiffFormatJSON writes one JSON object per change
+	DiffFormatJSON = "json"
+)
+
+const (
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorReset  = "\x1b[0m"
+)
+
+// diffEntry is a single change of a diff.
+type diffEntry struct {
+	Action        string           `json:"action"`
+	DNSName       string           `json:"dnsName"`
+	RecordType    string           `json:"recordType"`
+	SetIdentifier string           `json:"setIdentifier,omitempty"`
+	OldTargets    endpoint.Targets `json:"oldTargets,omitempty"`
+	NewTargets    endpoint.Targets `json:"newTargets,omitempty"`
+	OldTTL        endpoint.TTL     `json:"oldTTL,omitempty"`
+	NewTTL        endpoint.TTL     `json:"newTTL,omitempty"`
+}
+
+// WriteDiff writes the changes in the given format, sorted by record. With color, the
+// lines of the text format are colored by action for terminals.
+func WriteDiff(w io.Writer, changes *Changes, format string, color bool) error {
+	entries := diffEntries(changes)
+
+	switch format {
+	case DiffFormatText:
+		for _, e := range entries {
+			if _, err := io.WriteString(w, e.text(color)+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	case DiffFormatJSON:
+		enc := json.NewEncoder(w)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown diff format: %s", format)
+	}
+}
+
+func diffEntries(changes *Changes) []diffEntry {
+	type key struct{ dnsName, recordType, setIdentifier string }
+	old := map[key]*endpoint.Endpoint{}
+	for _, ep := range changes.UpdateOld {
+		old[key{ep.DNSName, ep.RecordType, ep.SetIdentifier}] = ep
+	}
+
+	var entries []diffEntry
+	for _, ep := range changes.Create {
+		entries = append(entries, diffEntry{Action: "create", DNSName: ep.DNSName, RecordType: ep.RecordType, SetIdentifier: ep.SetIdentifier, NewTargets: ep.Targets, NewTTL: ep.RecordTTL})
+	}
+	for _, ep := range changes.UpdateNew {
+		e := diffEntry{Action: "update", DNSName: ep.DNSName, RecordType: ep.RecordType, SetIdentifier: ep.SetIdentifier, NewTargets: ep.Targets, NewTTL: ep.RecordTTL}
+		if o, ok := old[key{ep.DNSName, ep.RecordType, ep.SetIdentifier}]; ok {
+			e.OldTargets, e.OldTTL = o.Targets, o.RecordTTL
+		}
+		entries = append(entries, e)
+	}
+	for _, ep := range changes.Delete {
+		entries = append(entries, diffEntry{Action: "delete", DNSName: ep.DNSName, RecordType: ep.RecordType, SetIdentifier: ep.SetIdentifier, OldTargets: ep.Targets, OldTTL: ep.RecordTTL})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.DNSName != b.DNSName {
+			return a.DNSName < b.DNSName
+		}
+		if a.RecordType != b.RecordType {
+			return a.RecordType < b.RecordType
+		}
+		return a.SetIdentifier < b.SetIdentifier
+	})
+	return entries
+}
+
+func (e diffEntry) text(color bool) string {
+	name := e.DNSName + " " + e.RecordType
+	if e.SetIdentifier != "" {
+		name += " " + e.SetIdentifier
+	}
+
+	var line, c string
+	switch e.Action {
+	case "create":
+		line, c = fmt.Sprintf("+ %s %s ttl=%d", name, e.NewTargets, e.NewTTL), colorGreen
+	case "delete":
+		line, c = fmt.Sprintf("- %s %s ttl=%d", name, e.OldTargets, e.OldTTL), colorRed
+	default:
+		line, c = fmt.Sprintf("~ %s %s -> %s ttl=%d -> %d", name, e.OldTargets, e.NewTargets, e.OldTTL, e.NewTTL), colorYellow
+	}
+	if color {
+		return c + line + colorReset
+	}
+	return line
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestWriteDiff(t *testing.T) {
+	changes := &Changes{
+		Create:    []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("new.example.org", endpoint.RecordTypeA, 300, "10.0.0.1")},
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("api.example.org", endpoint.RecordTypeA, 300, "10.0.0.2")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("api.example.org", endpoint.RecordTypeA, 600, "10.0.0.3")},
+		Delete:    []*endpoint.Endpoint{endpoint.NewEndpoint("old.example.org", endpoint.RecordTypeCNAME, "api.example.org")},
+	}
+
+	for _, tc := range []struct {
+		title    string
+		format   string
+		color    bool
+		expected string
+	}{
+		{
+			title:  "text",
+			format: DiffFormatText,
+			expected: "~ api.example.org A 10.0.0.2 -> 10.0.0.3 ttl=300 -> 600\n" +
+				"+ new.example.org A 10.0.0.1 ttl=300\n" +
+				"- old.example.org CNAME api.example.org ttl=0\n",
+		},
+		{
+			title:  "colored text",
+			format: DiffFormatText,
+			color:  true,
+			expected: "\x1b[33m~ api.example.org A 10.0.0.2 -> 10.0.0.3 ttl=300 -> 600\x1b[0m\n" +
+				"\x1b[32m+ new.example.org A 10.0.0.1 ttl=300\x1b[0m\n" +
+				"\x1b[31m- old.example.org CNAME api.example.org ttl=0\x1b[0m\n",
+		},
+		{
+			title:  "json",
+			format: DiffFormatJSON,
+			expected: `{"action":"update","dnsName":"api.example.org","recordType":"A","oldTargets":["10.0.0.2"],"newTargets":["10.0.0.3"],"oldTTL":300,"newTTL":600}` + "\n" +
+				`{"action":"create","dnsName":"new.example.org","recordType":"A","newTargets":["10.0.0.1"],"newTTL":300}` + "\n" +
+				`{"action":"delete","dnsName":"old.example.org","recordType":"CNAME","oldTargets":["api.example.org"]}` + "\n",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, WriteDiff(&buf, changes, tc.format, tc.color))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+
+	assert.Error(t, WriteDiff(&bytes.Buffer{}, changes, "yaml", false))
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// exportedPlan is the document changes are exported to, so they can be reviewed
+// before they are applied in a later run.
+type exportedPlan struct {
+	// StateHash is the hash of the records the changes were calculated for
+	StateHash string   `json:"stateHash"`
+	Changes   *Changes `json:"changes"`
+}
+
+// StateHash returns a hash of the records that doesn't depend on their order.
+func StateHash(records []*endpoint.Endpoint) string {
+	lines := make([]string, 0, len(records))
+	for _, ep := range records {
+		targets := append(endpoint.Targets{}, ep.Targets...)
+		sort.Strings(targets)
+		var labels []string
+		for k, v := range ep.Labels {
+			// Missing labels equal empty ones, see sameRecord.
+			if v != "" {
+				labels = append(labels, k+"="+v)
+			}
+		}
+		sort.Strings(labels)
+		lines = append(lines, fmt.Sprintf("%q %q %q %d %q %q", ep.DNSName, ep.RecordType, ep.SetIdentifier, ep.RecordTTL, targets, labels))
+	}
+	sort.Strings(lines)
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ExportChanges writes the changes calculated for records with the given state hash to
+// the given file.
+func ExportChanges(path string, changes *Changes, stateHash string) error {
+	data, err := json.MarshalIndent(exportedPlan{StateHash: stateHash, Changes: changes}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// ImportChanges reads changes previously written by ExportChanges from the given file
+// and verifies that the current records are still the ones the changes were calculated for.
+func ImportChanges(path string, current []*endpoint.Endpoint) (*Changes, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var exported exportedPlan
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if exported.Changes == nil {
+		return nil, fmt.Errorf("%s: no changes found", path)
+	}
+	if len(exported.Changes.UpdateOld) != len(exported.Changes.UpdateNew) {
+		return nil, fmt.Errorf("%s: %d old records don't match %d new records", path, len(exported.Changes.UpdateOld), len(exported.Changes.UpdateNew))
+	}
+	if hash := StateHash(current); hash != exported.StateHash {
+		// Point at the record if the changes themselves are affected.
+		if err := exported.Changes.Verify(current); err != nil {
+			return nil, fmt.Errorf("%s: records changed since the plan was calculated: %v", path, err)
+		}
+		return nil, fmt.Errorf("%s: records changed since the plan was calculated: state hash %s differs from %s", path, hash, exported.StateHash)
+	}
+	return exported.Changes, nil
+}
+
+// Verify returns an error if the changes can't be applied exactly as they were
+// calculated, because the current records changed in the meantime: records to create
+// must not exist yet, and records to update or delete must be unchanged.
+func (c *Changes) Verify(current []*endpoint.Endpoint) error {
+	type key struct {
+		dnsName, recordType, setIdentifier string
+	}
+	records := map[key]*endpoint.Endpoint{}
+	for _, ep := range current {
+		records[key{ep.DNSName, ep.RecordType, ep.SetIdentifier}] = ep
+	}
+
+	for _, ep := range c.Create {
+		if _, ok := records[key{ep.DNSName, ep.RecordType, ep.SetIdentifier}]; ok {
+			return fmt.Errorf("record %s %s to create already exists", ep.DNSName, ep.RecordType)
+		}
+	}
+	for _, ep := range append(append([]*endpoint.Endpoint{}, c.UpdateOld...), c.Delete...) {
+		record, ok := records[key{ep.DNSName, ep.RecordType, ep.SetIdentifier}]
+		if !ok {
+			return fmt.Errorf("record %s %s doesn't exist anymore", ep.DNSName, ep.RecordType)
+		}
+		if !sameRecord(record, ep) {
+			return fmt.Errorf("record %s %s changed from %s to %s", ep.DNSName, ep.RecordType, ep, record)
+		}
+	}
+	return nil
+}
+
+// sameRecord returns true if both records have the same targets, TTL and labels. Missing
+// labels equal empty ones, as the plan adds empty owner labels to records without owner.
+func sameRecord(a, b *endpoint.Endpoint) bool {
+	if a.RecordTTL != b.RecordTTL || !a.Targets.Same(b.Targets) {
+		return false
+	}
+	for k, v := range a.Labels {
+		if b.Labels[k] != v {
+			return false
+		}
+	}
+	for k, v := range b.Labels {
+		if a.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
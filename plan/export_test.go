@@ -0,0 +1,128 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestExportImportChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-plan")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "plan.json")
+
+	changes := &Changes{
+		Create:    []*endpoint.Endpoint{endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "10.0.0.1")},
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("api.example.org", endpoint.RecordTypeA, 300, "10.0.0.2")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("api.example.org", endpoint.RecordTypeA, 60, "10.0.0.3")},
+		Delete:    []*endpoint.Endpoint{endpoint.NewEndpoint("old.example.org", endpoint.RecordTypeCNAME, "example.org")},
+	}
+	current := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("api.example.org", endpoint.RecordTypeA, 300, "10.0.0.2"),
+		endpoint.NewEndpoint("old.example.org", endpoint.RecordTypeCNAME, "example.org"),
+		endpoint.NewEndpoint("www.example.org", endpoint.RecordTypeA, "10.0.0.4", "10.0.0.5"),
+	}
+	require.NoError(t, ExportChanges(path, changes, StateHash(current)))
+
+	// The order of records and targets doesn't matter.
+	imported, err := ImportChanges(path, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("www.example.org", endpoint.RecordTypeA, "10.0.0.5", "10.0.0.4"),
+		endpoint.NewEndpoint("old.example.org", endpoint.RecordTypeCNAME, "example.org"),
+		endpoint.NewEndpointWithTTL("api.example.org", endpoint.RecordTypeA, 300, "10.0.0.2"),
+	})
+	require.NoError(t, err)
+	validateEntries(t, imported.Create, changes.Create)
+	validateEntries(t, imported.UpdateOld, changes.UpdateOld)
+	validateEntries(t, imported.UpdateNew, changes.UpdateNew)
+	validateEntries(t, imported.Delete, changes.Delete)
+
+	// Records not affected by the changes drifted.
+	_, err = ImportChanges(path, current[:2])
+	assert.Error(t, err)
+
+	_, err = ImportChanges(filepath.Join(dir, "missing.json"), current)
+	assert.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{}`), 0644))
+	_, err = ImportChanges(path, current)
+	assert.Error(t, err)
+}
+
+func TestChangesVerify(t *testing.T) {
+	changes := &Changes{
+		Create:    []*endpoint.Endpoint{endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "10.0.0.1")},
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("api.example.org", endpoint.RecordTypeA, 300, "10.0.0.2")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("api.example.org", endpoint.RecordTypeA, 60, "10.0.0.3")},
+		Delete:    []*endpoint.Endpoint{endpoint.NewEndpoint("old.example.org", endpoint.RecordTypeCNAME, "example.org")},
+	}
+
+	for _, tc := range []struct {
+		title     string
+		current   []*endpoint.Endpoint
+		expectErr bool
+	}{
+		{
+			title: "unchanged records",
+			current: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("api.example.org", endpoint.RecordTypeA, 300, "10.0.0.2"),
+				endpoint.NewEndpoint("old.example.org", endpoint.RecordTypeCNAME, "example.org"),
+			},
+		},
+		{
+			title: "record to create exists",
+			current: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+				endpoint.NewEndpointWithTTL("api.example.org", endpoint.RecordTypeA, 300, "10.0.0.2"),
+				endpoint.NewEndpoint("old.example.org", endpoint.RecordTypeCNAME, "example.org"),
+			},
+			expectErr: true,
+		},
+		{
+			title: "record to update changed",
+			current: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("api.example.org", endpoint.RecordTypeA, 600, "10.0.0.2"),
+				endpoint.NewEndpoint("old.example.org", endpoint.RecordTypeCNAME, "example.org"),
+			},
+			expectErr: true,
+		},
+		{
+			title: "record to delete is gone",
+			current: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("api.example.org", endpoint.RecordTypeA, 300, "10.0.0.2"),
+			},
+			expectErr: true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			err := changes.Verify(tc.current)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
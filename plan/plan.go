@@ -43,18 +43,30 @@ type Plan struct {
 	DomainFilter endpoint.DomainFilter
 	// Property comparator compares custom properties of providers
 	PropertyComparator PropertyComparator
+	// Whether NS records, e.g. delegations of subdomains, are planned as well. They share their
+	// names with other records, so they're planned separately from A and CNAME records.
+	ManageNS bool
+	// Whether PTR records are planned as well, separately like NS records
+	ManagePTR bool
+	// Whether MX records are planned as well, separately like NS records
+	ManageMX bool
+	// Whether TXT records are planned as well, separately like NS records. The TXT records of
+	// the TXT registry aren't passed to the plan.
+	ManageTXT bool
+	// Whether SRV records are planned as well, separately like NS records
+	ManageSRV bool
 }
 
 // Changes holds lists of actions to be executed by dns providers
 type Changes struct {
 	// Records that need to be created
-	Create []*endpoint.Endpoint
+	Create []*endpoint.Endpoint `json:"create,omitempty"`
 	// Records that need to be updated (current data)
-	UpdateOld []*endpoint.Endpoint
+	UpdateOld []*endpoint.Endpoint `json:"updateOld,omitempty"`
 	// Records that need to be updated (desired data)
-	UpdateNew []*endpoint.Endpoint
+	UpdateNew []*endpoint.Endpoint `json:"updateNew,omitempty"`
 	// Records that need to be deleted
-	Delete []*endpoint.Endpoint
+	Delete []*endpoint.Endpoint `json:"delete,omitempty"`
 }
 
 // planTable is a supplementary struct for Plan
@@ -117,13 +129,53 @@ func (t planTable) addCandidate(e *endpoint.Endpoint) {
 // state. It then passes those changes to the current policy for further
 // processing. It returns a copy of Plan with the changes populated.
 func (p *Plan) Calculate() *Plan {
+	changes := p.calculateChanges(filterRecordsForPlan(p.Current, p.DomainFilter), filterRecordsForPlan(p.Desired, p.DomainFilter))
+	// Records of other types share their names with A and CNAME records, so they're planned separately.
+	var separateTypes []string
+	if p.ManageMX {
+		separateTypes = append(separateTypes, endpoint.RecordTypeMX)
+	}
+	if p.ManageTXT {
+		separateTypes = append(separateTypes, endpoint.RecordTypeTXT)
+	}
+	if p.ManageSRV {
+		separateTypes = append(separateTypes, endpoint.RecordTypeSRV)
+	}
+	if p.ManageNS {
+		separateTypes = append(separateTypes, endpoint.RecordTypeNS)
+	}
+	if p.ManagePTR {
+		separateTypes = append(separateTypes, endpoint.RecordTypePTR)
+	}
+	for _, recordType := range separateTypes {
+		c := p.calculateChanges(filterRecordsOfType(p.Current, recordType, p.DomainFilter), filterRecordsOfType(p.Desired, recordType, p.DomainFilter))
+		changes.Create = append(changes.Create, c.Create...)
+		changes.UpdateOld = append(changes.UpdateOld, c.UpdateOld...)
+		changes.UpdateNew = append(changes.UpdateNew, c.UpdateNew...)
+		changes.Delete = append(changes.Delete, c.Delete...)
+	}
+	for _, pol := range p.Policies {
+		changes = pol.Apply(changes)
+	}
+
+	plan := &Plan{
+		Current: p.Current,
+		Desired: p.Desired,
+		Changes: changes,
+	}
+
+	return plan
+}
+
+// calculateChanges computes the changes moving the current records towards the desired records.
+func (p *Plan) calculateChanges(current, desired []*endpoint.Endpoint) *Changes {
 	t := newPlanTable()
 
-	for _, current := range filterRecordsForPlan(p.Current, p.DomainFilter) {
-		t.addCurrent(current)
+	for _, c := range current {
+		t.addCurrent(c)
 	}
-	for _, desired := range filterRecordsForPlan(p.Desired, p.DomainFilter) {
-		t.addCandidate(desired)
+	for _, d := range desired {
+		t.addCandidate(d)
 	}
 
 	changes := &Changes{}
@@ -150,17 +202,7 @@ func (p *Plan) Calculate() *Plan {
 			}
 		}
 	}
-	for _, pol := range p.Policies {
-		changes = pol.Apply(changes)
-	}
-
-	plan := &Plan{
-		Current: p.Current,
-		Desired: p.Desired,
-		Changes: changes,
-	}
-
-	return plan
+	return changes
 }
 
 func inheritOwner(from, to *endpoint.Endpoint) {
@@ -223,9 +265,9 @@ func (p *Plan) shouldUpdateProviderSpecific(desired, current *endpoint.Endpoint)
 	return false
 }
 
-// filterRecordsForPlan removes records that are not relevant to the planner.
-// Currently this just removes TXT records to prevent them from being
-// deleted erroneously by the planner (only the TXT registry should do this.)
+// filterRecordsForPlan returns the A and CNAME records, which are planned together. Records of
+// other types are planned separately, see Calculate. The TXT records of the TXT registry aren't
+// among the current records, so only the TXT registry deletes them.
 //
 // Per RFC 1034, CNAME records conflict with all other records - it is the
 // only record with this property. The behavior of the planner may need to be
@@ -252,6 +294,17 @@ func filterRecordsForPlan(records []*endpoint.Endpoint, domainFilter endpoint.Do
 	return filtered
 }
 
+// filterRecordsOfType returns the records of the record type matching the domain filter.
+func filterRecordsOfType(records []*endpoint.Endpoint, recordType string, domainFilter endpoint.DomainFilter) []*endpoint.Endpoint {
+	filtered := []*endpoint.Endpoint{}
+	for _, record := range records {
+		if record.RecordType == recordType && domainFilter.Match(record.DNSName) {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
+
 // normalizeDNSName converts a DNS name to a canonical form, so that we can use string equality
 // it: removes space, converts to lower case, ensures there is a trailing dot
 func normalizeDNSName(dnsName string) string {
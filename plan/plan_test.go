@@ -620,6 +620,140 @@ func (suite *PlanTestSuite) TestDomainFiltersUpdate() {
 	validateEntries(suite.T(), changes.Delete, expectedDelete)
 }
 
+func (suite *PlanTestSuite) TestManageNS() {
+	address := endpoint.NewEndpoint("lab.domain.tld", endpoint.RecordTypeA, "10.0.0.1")
+	delegation := endpoint.NewEndpoint("lab.domain.tld", endpoint.RecordTypeNS, "ns1.domain.tld")
+	updatedDelegation := endpoint.NewEndpoint("lab.domain.tld", endpoint.RecordTypeNS, "ns1.domain.tld", "ns2.domain.tld")
+	createdDelegation := endpoint.NewEndpoint("team.domain.tld", endpoint.RecordTypeNS, "ns1.team.domain.tld")
+	deletedDelegation := endpoint.NewEndpoint("old.domain.tld", endpoint.RecordTypeNS, "ns1.old.domain.tld")
+
+	p := &Plan{
+		Policies: []Policy{&SyncPolicy{}},
+		Current:  []*endpoint.Endpoint{address, delegation, deletedDelegation},
+		Desired:  []*endpoint.Endpoint{address, updatedDelegation, createdDelegation},
+	}
+
+	// NS records are ignored by default.
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{})
+
+	// NS records sharing their name with A records are planned separately.
+	p.ManageNS = true
+	changes = p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{createdDelegation})
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{updatedDelegation})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{delegation})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{deletedDelegation})
+}
+
+func (suite *PlanTestSuite) TestManagePTR() {
+	address := endpoint.NewEndpoint("foo.domain.tld", endpoint.RecordTypeA, "10.0.0.1")
+	ptr := endpoint.NewEndpoint("1.0.0.10.in-addr.arpa", endpoint.RecordTypePTR, "foo.domain.tld")
+	deletedPTR := endpoint.NewEndpoint("2.0.0.10.in-addr.arpa", endpoint.RecordTypePTR, "bar.domain.tld")
+
+	p := &Plan{
+		Policies: []Policy{&SyncPolicy{}},
+		Current:  []*endpoint.Endpoint{deletedPTR},
+		Desired:  []*endpoint.Endpoint{address, ptr},
+	}
+
+	// PTR records are ignored by default.
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{address})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{})
+
+	p.ManagePTR = true
+	changes = p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{address, ptr})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{deletedPTR})
+}
+
+func (suite *PlanTestSuite) TestMX() {
+	address := endpoint.NewEndpoint("domain.tld", endpoint.RecordTypeA, "10.0.0.1")
+	mx := endpoint.NewEndpoint("domain.tld", endpoint.RecordTypeMX, "10 mail.domain.tld")
+	reprioritizedMX := endpoint.NewEndpoint("domain.tld", endpoint.RecordTypeMX, "20 mail.domain.tld")
+	createdMX := endpoint.NewEndpoint("lab.domain.tld", endpoint.RecordTypeMX, "10 mail.lab.domain.tld")
+
+	p := &Plan{
+		Policies: []Policy{&SyncPolicy{}},
+		Current:  []*endpoint.Endpoint{address, mx},
+		Desired:  []*endpoint.Endpoint{address, reprioritizedMX, createdMX},
+	}
+
+	// Without ManageMX, MX records are left alone.
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{})
+
+	// MX records sharing their name with A records are planned separately.
+	p.ManageMX = true
+	changes = p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{createdMX})
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{reprioritizedMX})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{mx})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{})
+}
+
+func (suite *PlanTestSuite) TestSRV() {
+	address := endpoint.NewEndpoint("sip.domain.tld", endpoint.RecordTypeA, "10.0.0.1")
+	srv := endpoint.NewEndpoint("_sip._tcp.domain.tld", endpoint.RecordTypeSRV, "10 5 5060 sip.domain.tld")
+	movedSRV := endpoint.NewEndpoint("_sip._tcp.domain.tld", endpoint.RecordTypeSRV, "10 5 5061 sip.domain.tld")
+	createdSRV := endpoint.NewEndpoint("_sips._tcp.domain.tld", endpoint.RecordTypeSRV, "10 5 5061 sip.domain.tld")
+
+	p := &Plan{
+		Policies: []Policy{&SyncPolicy{}},
+		Current:  []*endpoint.Endpoint{address, srv},
+		Desired:  []*endpoint.Endpoint{address, movedSRV, createdSRV},
+	}
+
+	// Without ManageSRV, SRV records are left alone.
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{})
+
+	p.ManageSRV = true
+	changes = p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{createdSRV})
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{movedSRV})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{srv})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{})
+}
+
+func (suite *PlanTestSuite) TestTXT() {
+	address := endpoint.NewEndpoint("domain.tld", endpoint.RecordTypeA, "10.0.0.1")
+	spf := endpoint.NewEndpoint("domain.tld", endpoint.RecordTypeTXT, `"v=spf1 -all"`)
+	updatedSPF := endpoint.NewEndpoint("domain.tld", endpoint.RecordTypeTXT, `"v=spf1 mx -all"`)
+	dkim := endpoint.NewEndpoint("mail._domainkey.domain.tld", endpoint.RecordTypeTXT, `"v=DKIM1; k=rsa; p=MIIB..." "...IDAQAB"`)
+
+	p := &Plan{
+		Policies: []Policy{&SyncPolicy{}},
+		Current:  []*endpoint.Endpoint{address, spf},
+		Desired:  []*endpoint.Endpoint{address, updatedSPF, dkim},
+	}
+
+	// Without ManageTXT, TXT records are left alone.
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{})
+
+	// TXT records sharing their name with A records are planned separately.
+	p.ManageTXT = true
+	changes = p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, []*endpoint.Endpoint{dkim})
+	validateEntries(suite.T(), changes.UpdateNew, []*endpoint.Endpoint{updatedSPF})
+	validateEntries(suite.T(), changes.UpdateOld, []*endpoint.Endpoint{spf})
+	validateEntries(suite.T(), changes.Delete, []*endpoint.Endpoint{})
+}
+
 func TestPlan(t *testing.T) {
 	suite.Run(t, new(PlanTestSuite))
 }
@@ -16,6 +16,11 @@ limitations under the License.
 
 package plan
 
+import (
+	"sort"
+	"strings"
+)
+
 // Policy allows to apply different rules to a set of changes.
 type Policy interface {
 	Apply(changes *Changes) *Changes
@@ -57,3 +62,71 @@ func (p *CreateOnlyPolicy) Apply(changes *Changes) *Changes {
 		Create: changes.Create,
 	}
 }
+
+// DomainPolicy applies different policies to the changes of different domains, e.g. sync
+// for lab zones and upsert-only for production zones. The changes of a record are those of
+// the domain with the longest matching suffix, or those of the default policy if none matches.
+type DomainPolicy struct {
+	Default Policy
+	Domains map[string]Policy
+}
+
+// Apply applies the policy of each domain to its changes.
+func (p *DomainPolicy) Apply(changes *Changes) *Changes {
+	grouped := map[string]*Changes{}
+	group := func(dnsName string) *Changes {
+		domain := p.domainOf(dnsName)
+		if _, ok := grouped[domain]; !ok {
+			grouped[domain] = &Changes{}
+		}
+		return grouped[domain]
+	}
+
+	for _, ep := range changes.Create {
+		g := group(ep.DNSName)
+		g.Create = append(g.Create, ep)
+	}
+	for i, ep := range changes.UpdateNew {
+		g := group(ep.DNSName)
+		g.UpdateNew = append(g.UpdateNew, ep)
+		g.UpdateOld = append(g.UpdateOld, changes.UpdateOld[i])
+	}
+	for _, ep := range changes.Delete {
+		g := group(ep.DNSName)
+		g.Delete = append(g.Delete, ep)
+	}
+
+	domains := make([]string, 0, len(grouped))
+	for domain := range grouped {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	result := &Changes{}
+	for _, domain := range domains {
+		policy, ok := p.Domains[domain]
+		if !ok {
+			policy = p.Default
+		}
+		applied := policy.Apply(grouped[domain])
+		result.Create = append(result.Create, applied.Create...)
+		result.UpdateOld = append(result.UpdateOld, applied.UpdateOld...)
+		result.UpdateNew = append(result.UpdateNew, applied.UpdateNew...)
+		result.Delete = append(result.Delete, applied.Delete...)
+	}
+	return result
+}
+
+// domainOf returns the domain with the longest suffix matching the DNS name, or an empty
+// string if none matches.
+func (p *DomainPolicy) domainOf(dnsName string) string {
+	name := strings.ToLower(strings.TrimSuffix(dnsName, "."))
+	match, matchLen := "", 0
+	for domain := range p.Domains {
+		d := strings.ToLower(strings.TrimSuffix(domain, "."))
+		if (name == d || strings.HasSuffix(name, "."+d)) && len(d) > matchLen {
+			match, matchLen = domain, len(d)
+		}
+	}
+	return match
+}
@@ -34,6 +34,13 @@ func TestApply(t *testing.T) {
 	// another two simple entries
 	bar := []*endpoint.Endpoint{{DNSName: "bar", Targets: endpoint.Targets{"v1"}}}
 	baz := []*endpoint.Endpoint{{DNSName: "baz", Targets: endpoint.Targets{"v1"}}}
+	// entries in lab and production zones
+	labV1 := &endpoint.Endpoint{DNSName: "api.lab.example.org", Targets: endpoint.Targets{"v1"}}
+	labV2 := &endpoint.Endpoint{DNSName: "api.lab.example.org", Targets: endpoint.Targets{"v2"}}
+	lab := &endpoint.Endpoint{DNSName: "lab.example.org", Targets: endpoint.Targets{"v1"}}
+	prodV1 := &endpoint.Endpoint{DNSName: "api.example.org", Targets: endpoint.Targets{"v1"}}
+	prodV2 := &endpoint.Endpoint{DNSName: "api.example.org", Targets: endpoint.Targets{"v2"}}
+	prod := &endpoint.Endpoint{DNSName: "www.example.org.", Targets: endpoint.Targets{"v1"}}
 
 	for _, tc := range []struct {
 		policy   Policy
@@ -58,6 +65,34 @@ func TestApply(t *testing.T) {
 			&Changes{Create: baz, UpdateOld: fooV1, UpdateNew: fooV2, Delete: bar},
 			&Changes{Create: baz, UpdateOld: empty, UpdateNew: empty, Delete: empty},
 		},
+		{
+			// DomainPolicy applies the policy of the longest matching domain.
+			&DomainPolicy{
+				Default: &SyncPolicy{},
+				Domains: map[string]Policy{"example.org": &UpsertOnlyPolicy{}, "lab.example.org": &SyncPolicy{}},
+			},
+			&Changes{
+				Create:    baz,
+				UpdateOld: []*endpoint.Endpoint{labV1, prodV1},
+				UpdateNew: []*endpoint.Endpoint{labV2, prodV2},
+				Delete:    []*endpoint.Endpoint{lab, prod, bar[0]},
+			},
+			&Changes{
+				Create:    baz,
+				UpdateOld: []*endpoint.Endpoint{labV1, prodV1},
+				UpdateNew: []*endpoint.Endpoint{labV2, prodV2},
+				Delete:    []*endpoint.Endpoint{lab, bar[0]},
+			},
+		},
+		{
+			// DomainPolicy applies the default policy to records of other domains.
+			&DomainPolicy{
+				Default: &CreateOnlyPolicy{},
+				Domains: map[string]Policy{"lab.example.org": &SyncPolicy{}},
+			},
+			&Changes{Create: baz, UpdateOld: []*endpoint.Endpoint{labV1, prodV1}, UpdateNew: []*endpoint.Endpoint{labV2, prodV2}, Delete: []*endpoint.Endpoint{lab, prod}},
+			&Changes{Create: baz, UpdateOld: []*endpoint.Endpoint{labV1}, UpdateNew: []*endpoint.Endpoint{labV2}, Delete: []*endpoint.Endpoint{lab}},
+		},
 	} {
 		// apply policy
 		changes := tc.policy.Apply(tc.changes)
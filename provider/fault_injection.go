@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// ErrInjectedFault is returned by a FaultInjectionProvider instead of calling its provider.
+var ErrInjectedFault = errors.New("injected fault")
+
+// FaultInjectionConfig configures the faults injected by a FaultInjectionProvider.
+type FaultInjectionConfig struct {
+	// The maximum latency added to every call, the latency is random up to this duration
+	Latency time.Duration
+	// The probability of a call failing without calling the provider, from 0 to 1
+	ErrorRate float64
+	// The probability of a call only partially succeeding, from 0 to 1: Records returns only
+	// some of the records, and ApplyChanges only applies the creations and fails
+	PartialRate float64
+}
+
+// FaultInjectionProvider wraps a Provider and injects latencies, errors and partial responses,
+// e.g. to verify how failures of the provider are handled in staging.
+type FaultInjectionProvider struct {
+	provider Provider
+	config   FaultInjectionConfig
+
+	// The random source is shared by concurrent calls
+	randMux sync.Mutex
+	rand    *rand.Rand
+}
+
+// NewFaultInjectionProvider returns a new FaultInjectionProvider wrapping the provider.
+func NewFaultInjectionProvider(provider Provider, config FaultInjectionConfig) *FaultInjectionProvider {
+	return &FaultInjectionProvider{
+		provider: provider,
+		config:   config,
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Records returns the records of the provider after injecting a fault.
+func (p *FaultInjectionProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	fail, partial, err := p.inject(ctx, "Records")
+	if err != nil {
+		return nil, err
+	}
+	if fail {
+		return nil, ErrInjectedFault
+	}
+
+	records, err := p.provider.Records(ctx)
+	if err != nil || !partial || len(records) == 0 {
+		return records, err
+	}
+	n := p.intn(len(records))
+	log.Warnf("Injecting partial response: returning %d of %d records", n, len(records))
+	return records[:n], nil
+}
+
+// ApplyChanges applies the changes to the provider after injecting a fault.
+func (p *FaultInjectionProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	fail, partial, err := p.inject(ctx, "ApplyChanges")
+	if err != nil {
+		return err
+	}
+	if fail {
+		return ErrInjectedFault
+	}
+	if !partial {
+		return p.provider.ApplyChanges(ctx, changes)
+	}
+
+	log.Warnf("Injecting partial response: applying %d creations only", len(changes.Create))
+	if err := p.provider.ApplyChanges(ctx, &plan.Changes{Create: changes.Create}); err != nil {
+		return err
+	}
+	return ErrInjectedFault
+}
+
+// PropertyValuesEqual compares two attribute values for equality
+func (p *FaultInjectionProvider) PropertyValuesEqual(name string, previous string, current string) bool {
+	return p.provider.PropertyValuesEqual(name, previous, current)
+}
+
+// inject waits for the injected latency and returns whether the call fails or partially
+// succeeds. It returns an error if the context is done while waiting.
+func (p *FaultInjectionProvider) inject(ctx context.Context, call string) (fail, partial bool, err error) {
+	p.randMux.Lock()
+	latency := time.Duration(0)
+	if p.config.Latency > 0 {
+		latency = time.Duration(p.rand.Int63n(int64(p.config.Latency)))
+	}
+	fail = p.rand.Float64() < p.config.ErrorRate
+	partial = !fail && p.rand.Float64() < p.config.PartialRate
+	p.randMux.Unlock()
+
+	if latency > 0 {
+		log.Debugf("Injecting latency of %s into %s", latency, call)
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return false, false, ctx.Err()
+		}
+	}
+	if fail {
+		log.Warnf("Injecting error into %s", call)
+	}
+	return fail, partial, nil
+}
+
+func (p *FaultInjectionProvider) intn(n int) int {
+	p.randMux.Lock()
+	defer p.randMux.Unlock()
+	return p.rand.Intn(n)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+func TestFaultInjectionProvider(t *testing.T) {
+	ctx := context.Background()
+	records := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+	}
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+	}
+
+	t.Run("no faults", func(t *testing.T) {
+		wrapped := &countingProvider{records: records}
+		p := NewFaultInjectionProvider(wrapped, FaultInjectionConfig{})
+		result, err := p.Records(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, records, result)
+		require.NoError(t, p.ApplyChanges(ctx, changes))
+		assert.Equal(t, []*plan.Changes{changes}, wrapped.applied)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		wrapped := &countingProvider{records: records}
+		p := NewFaultInjectionProvider(wrapped, FaultInjectionConfig{ErrorRate: 1, PartialRate: 1})
+		_, err := p.Records(ctx)
+		assert.Equal(t, ErrInjectedFault, err)
+		assert.Equal(t, ErrInjectedFault, p.ApplyChanges(ctx, changes))
+		assert.Equal(t, 0, wrapped.listings)
+		assert.Empty(t, wrapped.applied)
+	})
+
+	t.Run("partial responses", func(t *testing.T) {
+		wrapped := &countingProvider{records: records}
+		p := NewFaultInjectionProvider(wrapped, FaultInjectionConfig{PartialRate: 1})
+		result, err := p.Records(ctx)
+		require.NoError(t, err)
+		assert.True(t, len(result) < len(records))
+		assert.Equal(t, ErrInjectedFault, p.ApplyChanges(ctx, changes))
+		assert.Equal(t, []*plan.Changes{{Create: changes.Create}}, wrapped.applied)
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		wrapped := &countingProvider{records: records}
+		p := NewFaultInjectionProvider(wrapped, FaultInjectionConfig{Latency: time.Hour})
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := p.Records(ctx)
+		assert.Equal(t, context.DeadlineExceeded, err)
+	})
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -43,6 +44,8 @@ var (
 
 // InMemoryProvider - dns provider only used for testing purposes
 // initialized as dns provider with no records
+// It is safe for concurrent use, e.g. by several controllers: the changes of a zone are applied
+// at once, and listed records are copies of the records in memory.
 type InMemoryProvider struct {
 	provider.BaseProvider
 	domain         endpoint.DomainFilter
@@ -133,8 +136,8 @@ func (im *InMemoryProvider) Records(ctx context.Context) ([]*endpoint.Endpoint,
 		}
 
 		for _, record := range records {
-			ep := endpoint.NewEndpoint(record.Name, record.Type, record.Target).WithSetIdentifier(record.SetIdentifier)
-			ep.Labels = record.Labels
+			ep := endpoint.NewEndpointWithTTL(record.Name, record.Type, record.TTL, record.Target).WithSetIdentifier(record.SetIdentifier)
+			ep.Labels = copyLabels(record.Labels)
 			endpoints = append(endpoints, ep)
 		}
 	}
@@ -209,13 +212,26 @@ func convertToInMemoryRecord(endpoints []*endpoint.Endpoint) []*inMemoryRecord {
 			Type:          ep.RecordType,
 			Name:          ep.DNSName,
 			Target:        ep.Targets[0],
+			TTL:           ep.RecordTTL,
 			SetIdentifier: ep.SetIdentifier,
-			Labels:        ep.Labels,
+			Labels:        copyLabels(ep.Labels),
 		})
 	}
 	return records
 }
 
+// copyLabels copies labels, so records in memory don't share their labels with callers.
+func copyLabels(labels endpoint.Labels) endpoint.Labels {
+	if labels == nil {
+		return nil
+	}
+	result := make(endpoint.Labels, len(labels))
+	for k, v := range labels {
+		result[k] = v
+	}
+	return result
+}
+
 type filter struct {
 	domain string
 }
@@ -248,11 +264,13 @@ func (f *filter) EndpointZoneID(endpoint *endpoint.Endpoint, zones map[string]st
 // Type - type of record
 // Name - DNS name assigned to the record
 // Target - target of the record
+// TTL - TTL of the record
 type inMemoryRecord struct {
 	Type          string
 	SetIdentifier string
 	Name          string
 	Target        string
+	TTL           endpoint.TTL
 	Labels        endpoint.Labels
 }
 
@@ -266,26 +284,39 @@ type inMemoryChange struct {
 }
 
 type inMemoryClient struct {
+	// The mutex guards the zones and their records
+	mutex sync.RWMutex
 	zones map[string]zone
 }
 
 func newInMemoryClient() *inMemoryClient {
-	return &inMemoryClient{map[string]zone{}}
+	return &inMemoryClient{zones: map[string]zone{}}
 }
 
+// Records returns copies of the records of the zone, as updates change records in place.
 func (c *inMemoryClient) Records(zone string) ([]*inMemoryRecord, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
 	if _, ok := c.zones[zone]; !ok {
 		return nil, ErrZoneNotFound
 	}
 
 	records := []*inMemoryRecord{}
 	for _, rec := range c.zones[zone] {
-		records = append(records, rec...)
+		for _, r := range rec {
+			record := *r
+			record.Labels = copyLabels(r.Labels)
+			records = append(records, &record)
+		}
 	}
 	return records, nil
 }
 
 func (c *inMemoryClient) Zones() map[string]string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
 	zones := map[string]string{}
 	for zone := range c.zones {
 		zones[zone] = zone
@@ -294,6 +325,9 @@ func (c *inMemoryClient) Zones() map[string]string {
 }
 
 func (c *inMemoryClient) CreateZone(zone string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
 	if _, ok := c.zones[zone]; ok {
 		return ErrZoneAlreadyExists
 	}
@@ -303,6 +337,9 @@ func (c *inMemoryClient) CreateZone(zone string) error {
 }
 
 func (c *inMemoryClient) ApplyChanges(ctx context.Context, zoneID string, changes *inMemoryChange) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
 	if err := c.validateChangeBatch(zoneID, changes); err != nil {
 		return err
 	}
@@ -316,6 +353,7 @@ func (c *inMemoryClient) ApplyChanges(ctx context.Context, zoneID string, change
 		for _, rec := range c.zones[zoneID][updateEndpoint.Name] {
 			if rec.Type == updateEndpoint.Type {
 				rec.Target = updateEndpoint.Target
+				rec.TTL = updateEndpoint.TTL
 				break
 			}
 		}
@@ -18,6 +18,8 @@ package inmemory
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -40,6 +42,8 @@ func TestInMemoryProvider(t *testing.T) {
 	t.Run("ApplyChanges", testInMemoryApplyChanges)
 	t.Run("NewInMemoryProvider", testNewInMemoryProvider)
 	t.Run("CreateZone", testInMemoryCreateZone)
+	t.Run("Concurrency", testInMemoryConcurrency)
+	t.Run("TTL", testInMemoryTTL)
 }
 
 func testInMemoryFindByType(t *testing.T) {
@@ -821,3 +825,86 @@ func testInMemoryCreateZone(t *testing.T) {
 	err = im.CreateZone("zone")
 	assert.EqualError(t, err, ErrZoneAlreadyExists.Error())
 }
+
+// testInMemoryConcurrency lists and changes records concurrently, run it with -race to detect
+// unguarded shared state.
+func testInMemoryConcurrency(t *testing.T) {
+	im := NewInMemoryProvider(InMemoryInitZones([]string{"example.org"}))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 4; i++ {
+		name := fmt.Sprintf("foo-%d.example.org", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				created := endpoint.NewEndpoint(name, endpoint.RecordTypeA, "1.2.3.4")
+				created.Labels[endpoint.OwnerLabelKey] = "default"
+				updated := endpoint.NewEndpoint(name, endpoint.RecordTypeA, "4.3.2.1")
+				for _, changes := range []*plan.Changes{
+					{Create: []*endpoint.Endpoint{created}},
+					{UpdateOld: []*endpoint.Endpoint{created}, UpdateNew: []*endpoint.Endpoint{updated}},
+					{Delete: []*endpoint.Endpoint{updated}},
+				} {
+					if err := im.ApplyChanges(ctx, changes); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				records, err := im.Records(ctx)
+				if err != nil {
+					errs <- err
+					return
+				}
+				// Listed records don't share state with the records in memory.
+				for _, ep := range records {
+					ep.Labels[endpoint.OwnerLabelKey] = "other"
+					ep.Targets[0] = "0.0.0.0"
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	records, err := im.Records(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+// testInMemoryTTL tests that the TTLs of records are kept.
+func testInMemoryTTL(t *testing.T) {
+	ctx := context.Background()
+	im := NewInMemoryProvider()
+	require.NoError(t, im.CreateZone("example.org"))
+
+	require.NoError(t, im.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 300, "10.0.0.1")},
+	}))
+	records, err := im.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, endpoint.TTL(300), records[0].RecordTTL)
+
+	require.NoError(t, im.ApplyChanges(ctx, &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 300, "10.0.0.1")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 3600, "10.0.0.1")},
+	}))
+	records, err = im.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, endpoint.TTL(3600), records[0].RecordTTL)
+}
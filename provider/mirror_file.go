@@ -0,0 +1,185 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/fileutils"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+const (
+	// MirrorFileFormatHosts writes the A records in the format of /etc/hosts
+	MirrorFileFormatHosts = "hosts"
+	// MirrorFileFormatZone writes all records in the format of RFC 1035 zone files
+	MirrorFileFormatZone = "zone"
+)
+
+// mirrorFileDefaultTTL is the TTL of the records of zone files without a configured TTL.
+const mirrorFileDefaultTTL = 300
+
+// MirrorFileProvider wraps a Provider and mirrors its records to a local file, e.g. for
+// resolvers on the host or as a fallback without access to the provider. The file is written
+// with the records last listed from the provider and again with every change applied to it,
+// so it always holds the current records without listing them again.
+type MirrorFileProvider struct {
+	provider Provider
+	path     string
+	format   string
+
+	mutex   sync.Mutex
+	records map[stateFileKey]*endpoint.Endpoint
+}
+
+// NewMirrorFileProvider returns a new MirrorFileProvider writing the file in the given format.
+func NewMirrorFileProvider(provider Provider, path, format string) (*MirrorFileProvider, error) {
+	if path == "" {
+		return nil, fmt.Errorf("mirror file cannot be empty")
+	}
+	if format != MirrorFileFormatHosts && format != MirrorFileFormatZone {
+		return nil, fmt.Errorf("invalid mirror file format %q", format)
+	}
+	return &MirrorFileProvider{provider: provider, path: path, format: format}, nil
+}
+
+// Records lists the records of the provider and mirrors them.
+func (p *MirrorFileProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	records, err := p.provider.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.records = make(map[stateFileKey]*endpoint.Endpoint, len(records))
+	for _, ep := range records {
+		p.records[newStateFileKey(ep)] = ep.DeepCopy()
+	}
+	p.write()
+	return records, nil
+}
+
+// ApplyChanges applies the changes to the provider and mirrors the changed records.
+func (p *MirrorFileProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	if err := p.provider.ApplyChanges(ctx, changes); err != nil {
+		// The file is written with the records listed by the next synchronization.
+		return err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.records == nil {
+		return nil
+	}
+	for _, ep := range append(append([]*endpoint.Endpoint{}, changes.UpdateOld...), changes.Delete...) {
+		delete(p.records, newStateFileKey(ep))
+	}
+	for _, ep := range append(append([]*endpoint.Endpoint{}, changes.Create...), changes.UpdateNew...) {
+		p.records[newStateFileKey(ep)] = ep.DeepCopy()
+	}
+	p.write()
+	return nil
+}
+
+// PropertyValuesEqual compares two attribute values for equality
+func (p *MirrorFileProvider) PropertyValuesEqual(name string, previous string, current string) bool {
+	return p.provider.PropertyValuesEqual(name, previous, current)
+}
+
+// write mirrors the records to the file. Failures are only logged, so the file never keeps
+// the provider from being updated.
+func (p *MirrorFileProvider) write() {
+	var lines []string
+	for _, ep := range p.records {
+		if p.format == MirrorFileFormatHosts {
+			lines = append(lines, hostsLines(ep)...)
+		} else {
+			lines = append(lines, zoneLines(ep)...)
+		}
+	}
+	// Sort for stable files, which are easier to review and diff.
+	sort.Strings(lines)
+
+	data := "# Mirrored by ExternalDNS, changes are overwritten\n" + strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		data += "\n"
+	}
+	if err := fileutils.WriteFileAtomically(p.path, []byte(data)); err != nil {
+		log.Errorf("Failed to write mirror file %s: %v", p.path, err)
+	}
+}
+
+// hostsLines returns the lines of a hosts file for the targets of an A record.
+func hostsLines(ep *endpoint.Endpoint) []string {
+	if ep.RecordType != endpoint.RecordTypeA {
+		return nil
+	}
+	lines := make([]string, 0, len(ep.Targets))
+	for _, target := range ep.Targets {
+		lines = append(lines, target+"\t"+strings.TrimSuffix(ep.DNSName, "."))
+	}
+	return lines
+}
+
+// zoneLines returns the lines of a zone file for the targets of a record. Targets which aren't
+// valid resource data of the record type are skipped.
+func zoneLines(ep *endpoint.Endpoint) []string {
+	ttl := int64(mirrorFileDefaultTTL)
+	if ep.RecordTTL.IsConfigured() {
+		ttl = int64(ep.RecordTTL)
+	}
+	lines := make([]string, 0, len(ep.Targets))
+	for _, target := range ep.Targets {
+		rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", dns.Fqdn(ep.DNSName), ttl, ep.RecordType, zoneTarget(ep.RecordType, target)))
+		if err != nil || rr == nil {
+			log.Warnf("Not mirroring target %s of %s %s: %v", target, ep.DNSName, ep.RecordType, err)
+			continue
+		}
+		lines = append(lines, rr.String())
+	}
+	return lines
+}
+
+// zoneTarget returns a target in the format of zone files: names are fully qualified and the
+// strings of TXT records quoted.
+func zoneTarget(recordType, target string) string {
+	switch recordType {
+	case endpoint.RecordTypeCNAME:
+		return dns.Fqdn(target)
+	case endpoint.RecordTypeMX, endpoint.RecordTypeSRV:
+		fields := strings.Fields(target)
+		if len(fields) > 0 {
+			fields[len(fields)-1] = dns.Fqdn(fields[len(fields)-1])
+		}
+		return strings.Join(fields, " ")
+	case endpoint.RecordTypeTXT:
+		if strings.HasPrefix(target, `"`) {
+			return target
+		}
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(target) + `"`
+	}
+	return target
+}
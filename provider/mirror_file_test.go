@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+func TestMirrorFileProviderHosts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mirror-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "hosts")
+
+	wrapped := &countingProvider{records: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4", "5.6.7.8"),
+		endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpoint("www.example.org", endpoint.RecordTypeCNAME, "foo.example.org"),
+	}}
+	p, err := NewMirrorFileProvider(wrapped, path, MirrorFileFormatHosts)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Changes applied before the records were listed aren't mirrored.
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{}))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = p.Records(ctx)
+	require.NoError(t, err)
+	assertFileContent(t, path, "# Mirrored by ExternalDNS, changes are overwritten\n1.2.3.4\tbar.example.org\n1.2.3.4\tfoo.example.org\n5.6.7.8\tfoo.example.org\n")
+
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create:    []*endpoint.Endpoint{endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "9.9.9.9")},
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4", "5.6.7.8")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "4.3.2.1")},
+		Delete:    []*endpoint.Endpoint{endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+	}))
+	assertFileContent(t, path, "# Mirrored by ExternalDNS, changes are overwritten\n4.3.2.1\tfoo.example.org\n9.9.9.9\tnew.example.org\n")
+	assert.Equal(t, 1, wrapped.listings)
+
+	// Failed changes aren't mirrored.
+	wrapped.fail = true
+	assert.Error(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("failed.example.org", endpoint.RecordTypeA, "9.9.9.9")},
+	}))
+	assertFileContent(t, path, "# Mirrored by ExternalDNS, changes are overwritten\n4.3.2.1\tfoo.example.org\n9.9.9.9\tnew.example.org\n")
+}
+
+func TestMirrorFileProviderZone(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mirror-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "example.org.zone")
+
+	wrapped := &countingProvider{records: []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 60, "1.2.3.4"),
+		endpoint.NewEndpoint("www.example.org", endpoint.RecordTypeCNAME, "foo.example.org"),
+		endpoint.NewEndpoint("example.org", endpoint.RecordTypeMX, "10 mail.example.org"),
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeTXT, `heritage=external-dns,external-dns/owner="default"`),
+		endpoint.NewEndpoint("bad.example.org", endpoint.RecordTypeA, "not-an-address"),
+	}}
+	p, err := NewMirrorFileProvider(wrapped, path, MirrorFileFormatZone)
+	require.NoError(t, err)
+
+	_, err = p.Records(context.Background())
+	require.NoError(t, err)
+	assertFileContent(t, path, "# Mirrored by ExternalDNS, changes are overwritten\n"+
+		"example.org.\t300\tIN\tMX\t10 mail.example.org.\n"+
+		"foo.example.org.\t300\tIN\tTXT\t\"heritage=external-dns,external-dns/owner=\\\"default\\\"\"\n"+
+		"foo.example.org.\t60\tIN\tA\t1.2.3.4\n"+
+		"www.example.org.\t300\tIN\tCNAME\tfoo.example.org.\n")
+}
+
+func TestNewMirrorFileProvider(t *testing.T) {
+	_, err := NewMirrorFileProvider(&countingProvider{}, "", MirrorFileFormatHosts)
+	assert.Error(t, err)
+	_, err = NewMirrorFileProvider(&countingProvider{}, "/etc/hosts", "bind")
+	assert.Error(t, err)
+}
+
+func assertFileContent(t *testing.T, path, expected string) {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(data))
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// RoutingProvider wraps several named providers and routes every record to the provider named by
+// its endpoint.ProviderSpecificProvider property, or to the default provider without one, so a
+// single set of sources can manage the records of several providers.
+//
+// The records read from the providers are marked with the name of their provider, so records
+// routed to another provider are updated, which deletes them from their old provider and creates
+// them in their new one.
+type RoutingProvider struct {
+	defaultName string
+	providers   map[string]Provider
+}
+
+// NewRoutingProvider returns a new RoutingProvider routing the records without a provider to the
+// provider of the default name.
+func NewRoutingProvider(defaultName string, providers map[string]Provider) (*RoutingProvider, error) {
+	if _, ok := providers[defaultName]; !ok {
+		return nil, fmt.Errorf("default provider %q is not configured", defaultName)
+	}
+	return &RoutingProvider{defaultName: defaultName, providers: providers}, nil
+}
+
+// Records returns the records of all providers, marked with the names of their providers.
+func (p *RoutingProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	var result []*endpoint.Endpoint
+	for _, name := range p.names() {
+		records, err := p.providers[name].Records(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("provider %s: %v", name, err)
+		}
+		for _, ep := range records {
+			result = append(result, withRoute(ep, name))
+		}
+	}
+	return result, nil
+}
+
+// ApplyChanges applies the changes of every record to the provider it's routed to. Updates of
+// records routed to another provider are applied as deletions and creations. Changes of records
+// routed to unknown providers fail all changes, while failures of one provider don't keep the
+// changes of the others from being applied.
+func (p *RoutingProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	routed := map[string]*plan.Changes{}
+	for _, name := range p.names() {
+		routed[name] = &plan.Changes{}
+	}
+	route := func(ep *endpoint.Endpoint) (*plan.Changes, *endpoint.Endpoint, error) {
+		name := p.route(ep)
+		if _, ok := p.providers[name]; !ok {
+			return nil, nil, fmt.Errorf("record %s %s is routed to unknown provider %q", ep.DNSName, ep.RecordType, name)
+		}
+		return routed[name], withoutRoute(ep), nil
+	}
+
+	for _, ep := range changes.Create {
+		c, routedEp, err := route(ep)
+		if err != nil {
+			return err
+		}
+		c.Create = append(c.Create, routedEp)
+	}
+	for i := range changes.UpdateNew {
+		oldChanges, oldEp, err := route(changes.UpdateOld[i])
+		if err != nil {
+			return err
+		}
+		newChanges, newEp, err := route(changes.UpdateNew[i])
+		if err != nil {
+			return err
+		}
+		if oldChanges == newChanges {
+			newChanges.UpdateOld = append(newChanges.UpdateOld, oldEp)
+			newChanges.UpdateNew = append(newChanges.UpdateNew, newEp)
+			continue
+		}
+		oldChanges.Delete = append(oldChanges.Delete, oldEp)
+		newChanges.Create = append(newChanges.Create, newEp)
+	}
+	for _, ep := range changes.Delete {
+		c, routedEp, err := route(ep)
+		if err != nil {
+			return err
+		}
+		c.Delete = append(c.Delete, routedEp)
+	}
+
+	records, _ := ctx.Value(RecordsContextKey).([]*endpoint.Endpoint)
+	var failures []string
+	for _, name := range p.names() {
+		c := routed[name]
+		if len(c.Create) == 0 && len(c.UpdateNew) == 0 && len(c.Delete) == 0 {
+			continue
+		}
+		// Providers looking up the cached records only see their own.
+		providerCtx := ctx
+		if records != nil {
+			var own []*endpoint.Endpoint
+			for _, ep := range records {
+				if p.route(ep) == name {
+					own = append(own, withoutRoute(ep))
+				}
+			}
+			providerCtx = context.WithValue(ctx, RecordsContextKey, own)
+		}
+		if err := p.providers[name].ApplyChanges(providerCtx, c); err != nil {
+			failures = append(failures, fmt.Sprintf("provider %s: %v", name, err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to apply changes: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// PropertyValuesEqual compares two attribute values for equality. Empty provider names are the
+// name of the default provider, other properties are compared by the default provider.
+func (p *RoutingProvider) PropertyValuesEqual(name string, previous string, current string) bool {
+	if name == endpoint.ProviderSpecificProvider {
+		return p.routeName(previous) == p.routeName(current)
+	}
+	return p.providers[p.defaultName].PropertyValuesEqual(name, previous, current)
+}
+
+// names returns the names of the providers, the default provider first.
+func (p *RoutingProvider) names() []string {
+	names := make([]string, 0, len(p.providers))
+	for name := range p.providers {
+		if name != p.defaultName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{p.defaultName}, names...)
+}
+
+// route returns the name of the provider a record is routed to.
+func (p *RoutingProvider) route(ep *endpoint.Endpoint) string {
+	property, _ := ep.GetProviderSpecificProperty(endpoint.ProviderSpecificProvider)
+	return p.routeName(property.Value)
+}
+
+func (p *RoutingProvider) routeName(name string) string {
+	if name == "" {
+		return p.defaultName
+	}
+	return name
+}
+
+// withRoute returns a copy of the endpoint marked with the name of its provider.
+func withRoute(ep *endpoint.Endpoint, name string) *endpoint.Endpoint {
+	routed := withoutRoute(ep)
+	routed.ProviderSpecific = append(routed.ProviderSpecific, endpoint.ProviderSpecificProperty{Name: endpoint.ProviderSpecificProvider, Value: name})
+	return routed
+}
+
+// withoutRoute returns a copy of the endpoint without the name of its provider, which the
+// providers don't know.
+func withoutRoute(ep *endpoint.Endpoint) *endpoint.Endpoint {
+	result := ep.DeepCopy()
+	result.ProviderSpecific = nil
+	for _, property := range ep.ProviderSpecific {
+		if property.Name != endpoint.ProviderSpecificProvider {
+			result.ProviderSpecific = append(result.ProviderSpecific, property)
+		}
+	}
+	return result
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+func TestRoutingProvider(t *testing.T) {
+	ctx := context.Background()
+	primary := &countingProvider{records: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+	}}
+	secondary := &countingProvider{records: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("baz.example.org", endpoint.RecordTypeA, "5.6.7.8"),
+	}}
+	_, err := NewRoutingProvider("unknown", map[string]Provider{"aws": primary})
+	assert.Error(t, err)
+	p, err := NewRoutingProvider("aws", map[string]Provider{"aws": primary, "cloudflare": secondary})
+	require.NoError(t, err)
+
+	// The records are marked with the names of their providers.
+	records, err := p.Records(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4").WithProviderSpecific(endpoint.ProviderSpecificProvider, "aws"),
+		endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "1.2.3.4").WithProviderSpecific(endpoint.ProviderSpecificProvider, "aws"),
+		endpoint.NewEndpoint("baz.example.org", endpoint.RecordTypeA, "5.6.7.8").WithProviderSpecific(endpoint.ProviderSpecificProvider, "cloudflare"),
+	}, records)
+	assert.Empty(t, primary.records[0].ProviderSpecific, "records of the provider shouldn't be modified")
+
+	// Records without a provider are routed to the default provider.
+	assert.True(t, p.PropertyValuesEqual(endpoint.ProviderSpecificProvider, "aws", ""))
+	assert.False(t, p.PropertyValuesEqual(endpoint.ProviderSpecificProvider, "aws", "cloudflare"))
+
+	// Records routed to another provider are moved.
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+			endpoint.NewEndpoint("other.example.org", endpoint.RecordTypeA, "5.6.7.8").WithProviderSpecific(endpoint.ProviderSpecificProvider, "cloudflare"),
+		},
+		UpdateOld: []*endpoint.Endpoint{records[0], records[2]},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4").WithProviderSpecific(endpoint.ProviderSpecificProvider, "cloudflare"),
+			endpoint.NewEndpoint("baz.example.org", endpoint.RecordTypeA, "8.7.6.5").WithProviderSpecific(endpoint.ProviderSpecificProvider, "cloudflare"),
+		},
+		Delete: []*endpoint.Endpoint{records[1]},
+	}))
+	assert.Equal(t, []*plan.Changes{{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+			endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		},
+	}}, primary.applied)
+	assert.Equal(t, []*plan.Changes{{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("other.example.org", endpoint.RecordTypeA, "5.6.7.8"),
+			endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		},
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpoint("baz.example.org", endpoint.RecordTypeA, "5.6.7.8")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpoint("baz.example.org", endpoint.RecordTypeA, "8.7.6.5")},
+	}}, secondary.applied)
+
+	// Records routed to unknown providers fail all changes.
+	err = p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+			endpoint.NewEndpoint("other.example.org", endpoint.RecordTypeA, "1.2.3.4").WithProviderSpecific(endpoint.ProviderSpecificProvider, "google"),
+		},
+	})
+	assert.Error(t, err)
+	assert.Len(t, primary.applied, 1)
+
+	// Failures of one provider don't keep the changes of the others from being applied.
+	primary.fail = true
+	err = p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+			endpoint.NewEndpoint("other.example.org", endpoint.RecordTypeA, "1.2.3.4").WithProviderSpecific(endpoint.ProviderSpecificProvider, "cloudflare"),
+		},
+	})
+	assert.EqualError(t, err, "failed to apply changes: provider aws: failed to apply changes")
+	assert.Len(t, secondary.applied, 2)
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/fileutils"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// stateFileDocument is the content of the file of a StateFileProvider.
+type stateFileDocument struct {
+	// The time the records were last read from the provider
+	LastResync time.Time            `json:"lastResync"`
+	Records    []*endpoint.Endpoint `json:"records"`
+}
+
+type stateFileKey struct {
+	dnsName, recordType, setIdentifier string
+}
+
+func newStateFileKey(ep *endpoint.Endpoint) stateFileKey {
+	return stateFileKey{ep.DNSName, ep.RecordType, ep.SetIdentifier}
+}
+
+// StateFileProvider wraps a Provider whose records are slow to list. It keeps the records
+// last read from the provider and the changes applied since in a local file, and returns
+// them instead of listing the records of the provider.
+//
+// The records are only listed from the provider again once the resync interval elapsed since
+// the last listing, or after failing to apply changes, to correct changes made by others.
+type StateFileProvider struct {
+	provider       Provider
+	path           string
+	resyncInterval time.Duration
+
+	mutex sync.Mutex
+	state *stateFileDocument
+}
+
+// NewStateFileProvider returns a new StateFileProvider starting from the state of the file,
+// if any.
+func NewStateFileProvider(provider Provider, path string, resyncInterval time.Duration) (*StateFileProvider, error) {
+	if path == "" {
+		return nil, errors.New("state file cannot be empty")
+	}
+	if resyncInterval <= 0 {
+		return nil, errors.New("state file resync interval must be positive")
+	}
+
+	p := &StateFileProvider{
+		provider:       provider,
+		path:           path,
+		resyncInterval: resyncInterval,
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state stateFileDocument
+	if err := json.Unmarshal(data, &state); err != nil {
+		// The records are read from the provider, which replaces the file.
+		log.Warnf("Ignoring state file %s: %v", path, err)
+		return p, nil
+	}
+	for _, ep := range state.Records {
+		if ep.Labels == nil {
+			ep.Labels = endpoint.NewLabels()
+		}
+	}
+	p.state = &state
+	return p, nil
+}
+
+// Records returns the records of the state, or lists the records of the provider once the
+// resync interval elapsed.
+func (p *StateFileProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.state != nil && time.Since(p.state.LastResync) < p.resyncInterval {
+		return copyEndpoints(p.state.Records), nil
+	}
+
+	log.Debugf("Listing records of the provider to resync state file %s", p.path)
+	start := time.Now()
+	records, err := p.provider.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.state = &stateFileDocument{LastResync: start, Records: copyEndpoints(records)}
+	p.write()
+	return records, nil
+}
+
+// ApplyChanges applies the changes to the provider and the state.
+func (p *StateFileProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if err := p.provider.ApplyChanges(ctx, changes); err != nil {
+		// Some of the changes may have been applied, so the state is unknown.
+		p.state = nil
+		if err := os.Remove(p.path); err != nil && !os.IsNotExist(err) {
+			log.Errorf("Failed to remove state file %s: %v", p.path, err)
+		}
+		return err
+	}
+	if p.state == nil {
+		return nil
+	}
+
+	records := map[stateFileKey]*endpoint.Endpoint{}
+	for _, ep := range p.state.Records {
+		records[newStateFileKey(ep)] = ep
+	}
+	for _, ep := range append(append([]*endpoint.Endpoint{}, changes.UpdateOld...), changes.Delete...) {
+		delete(records, newStateFileKey(ep))
+	}
+	for _, ep := range append(append([]*endpoint.Endpoint{}, changes.Create...), changes.UpdateNew...) {
+		ep = ep.DeepCopy()
+		// Providers don't return the labels of the registry.
+		ep.Labels = endpoint.NewLabels()
+		records[newStateFileKey(ep)] = ep
+	}
+
+	p.state.Records = make([]*endpoint.Endpoint, 0, len(records))
+	for _, ep := range records {
+		p.state.Records = append(p.state.Records, ep)
+	}
+	p.write()
+	return nil
+}
+
+// PropertyValuesEqual compares two attribute values for equality
+func (p *StateFileProvider) PropertyValuesEqual(name string, previous string, current string) bool {
+	return p.provider.PropertyValuesEqual(name, previous, current)
+}
+
+// write persists the state. Failures are only logged: the state is kept in memory, and the
+// file is written again with the next changes.
+func (p *StateFileProvider) write() {
+	// Sort for stable files, which are easier to review and diff.
+	sort.Slice(p.state.Records, func(i, j int) bool {
+		a, b := p.state.Records[i], p.state.Records[j]
+		if a.DNSName != b.DNSName {
+			return a.DNSName < b.DNSName
+		}
+		if a.RecordType != b.RecordType {
+			return a.RecordType < b.RecordType
+		}
+		return a.SetIdentifier < b.SetIdentifier
+	})
+	data, err := json.MarshalIndent(p.state, "", "  ")
+	if err == nil {
+		err = fileutils.WriteFileAtomically(p.path, append(data, '\n'))
+	}
+	if err != nil {
+		log.Errorf("Failed to write state file %s: %v", p.path, err)
+	}
+}
+
+func copyEndpoints(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	result := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		result = append(result, ep.DeepCopy())
+	}
+	return result
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// countingProvider counts the listings of its records, records the applied changes and fails
+// to apply changes while fail is set.
+type countingProvider struct {
+	BaseProvider
+	records  []*endpoint.Endpoint
+	listings int
+	applied  []*plan.Changes
+	fail     bool
+}
+
+func (p *countingProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	p.listings++
+	return p.records, nil
+}
+
+func (p *countingProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	if p.fail {
+		return errors.New("failed to apply changes")
+	}
+	p.applied = append(p.applied, changes)
+	return nil
+}
+
+func TestStateFileProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	wrapped := &countingProvider{records: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+	}}
+	p, err := NewStateFileProvider(wrapped, path, time.Hour)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	records, err := p.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, 1, wrapped.listings)
+
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create:    []*endpoint.Endpoint{{DNSName: "new.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"5.6.7.8"}, Labels: endpoint.Labels{endpoint.OwnerLabelKey: "default"}}},
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "4.3.2.1")},
+		Delete:    []*endpoint.Endpoint{endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+	}))
+
+	expected := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "4.3.2.1"),
+		endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "5.6.7.8"),
+	}
+	records, err = p.Records(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, expected, records)
+	assert.Equal(t, 1, wrapped.listings)
+
+	// The state survives restarts.
+	p, err = NewStateFileProvider(wrapped, path, time.Hour)
+	require.NoError(t, err)
+	records, err = p.Records(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, expected, records)
+	assert.Equal(t, 1, wrapped.listings)
+
+	// Failing to apply changes lists the records of the provider again.
+	wrapped.fail = true
+	assert.Error(t, p.ApplyChanges(ctx, &plan.Changes{Delete: expected}))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	records, err = p.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, 2, wrapped.listings)
+
+	// So does the resync interval.
+	p.state.LastResync = time.Now().Add(-time.Hour)
+	_, err = p.Records(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, wrapped.listings)
+}
+
+func TestStateFileProviderInvalidFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte("{"), 0644))
+
+	wrapped := &countingProvider{}
+	p, err := NewStateFileProvider(wrapped, path, time.Hour)
+	require.NoError(t, err)
+	_, err = p.Records(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, wrapped.listings)
+
+	_, err = NewStateFileProvider(wrapped, "", time.Hour)
+	assert.Error(t, err)
+	_, err = NewStateFileProvider(wrapped, path, 0)
+	assert.Error(t, err)
+}
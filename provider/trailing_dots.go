@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// TrimTrailingDotsProvider wraps a Provider and removes the trailing dots of the names and
+// hostname targets of the records read from and written to it, for providers storing names
+// without trailing dots. Otherwise names of sources with trailing dots never compare equal to
+// the records of the provider, which are created and deleted over and over again.
+type TrimTrailingDotsProvider struct {
+	provider Provider
+}
+
+// NewTrimTrailingDotsProvider returns a new TrimTrailingDotsProvider wrapping the provider.
+func NewTrimTrailingDotsProvider(provider Provider) *TrimTrailingDotsProvider {
+	return &TrimTrailingDotsProvider{provider: provider}
+}
+
+// Records returns the records of the provider without trailing dots.
+func (p *TrimTrailingDotsProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	records, err := p.provider.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return trimTrailingDots(records), nil
+}
+
+// ApplyChanges applies the changes without trailing dots to the provider.
+func (p *TrimTrailingDotsProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	return p.provider.ApplyChanges(ctx, &plan.Changes{
+		Create:    trimTrailingDots(changes.Create),
+		UpdateOld: trimTrailingDots(changes.UpdateOld),
+		UpdateNew: trimTrailingDots(changes.UpdateNew),
+		Delete:    trimTrailingDots(changes.Delete),
+	})
+}
+
+// PropertyValuesEqual compares two attribute values for equality
+func (p *TrimTrailingDotsProvider) PropertyValuesEqual(name string, previous string, current string) bool {
+	return p.provider.PropertyValuesEqual(name, previous, current)
+}
+
+// trimTrailingDots returns copies of the endpoints without the trailing dots of their names and
+// targets. The targets of TXT records are kept as they are, as they aren't names.
+func trimTrailingDots(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	if endpoints == nil {
+		return nil
+	}
+	result := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		trimmed := ep.DeepCopy()
+		trimmed.DNSName = strings.TrimSuffix(trimmed.DNSName, ".")
+		if trimmed.RecordType != endpoint.RecordTypeTXT {
+			for i, target := range trimmed.Targets {
+				trimmed.Targets[i] = strings.TrimSuffix(target, ".")
+			}
+		}
+		result = append(result, trimmed)
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+func TestTrimTrailingDotsProvider(t *testing.T) {
+	ctx := context.Background()
+	wrapped := &countingProvider{records: []*endpoint.Endpoint{
+		{DNSName: "foo.example.org.", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}},
+		{DNSName: "bar.example.org.", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"foo.example.org."}},
+		{DNSName: "bar.example.org", RecordType: endpoint.RecordTypeTXT, Targets: endpoint.Targets{"trailing."}},
+	}}
+	p := NewTrimTrailingDotsProvider(wrapped)
+
+	records, err := p.Records(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*endpoint.Endpoint{
+		{DNSName: "foo.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}},
+		{DNSName: "bar.example.org", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"foo.example.org"}},
+		{DNSName: "bar.example.org", RecordType: endpoint.RecordTypeTXT, Targets: endpoint.Targets{"trailing."}},
+	}, records)
+	assert.Equal(t, "foo.example.org.", wrapped.records[0].DNSName, "records of the provider shouldn't be modified")
+
+	changes := &plan.Changes{
+		Create:    []*endpoint.Endpoint{{DNSName: "new.example.org.", RecordType: endpoint.RecordTypeMX, Targets: endpoint.Targets{"10 mail.example.org."}}},
+		UpdateOld: []*endpoint.Endpoint{{DNSName: "bar.example.org", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"foo.example.org"}}},
+		UpdateNew: []*endpoint.Endpoint{{DNSName: "bar.example.org.", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"baz.example.org."}}},
+	}
+	require.NoError(t, p.ApplyChanges(ctx, changes))
+	assert.Equal(t, []*plan.Changes{{
+		Create:    []*endpoint.Endpoint{{DNSName: "new.example.org", RecordType: endpoint.RecordTypeMX, Targets: endpoint.Targets{"10 mail.example.org"}}},
+		UpdateOld: []*endpoint.Endpoint{{DNSName: "bar.example.org", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"foo.example.org"}}},
+		UpdateNew: []*endpoint.Endpoint{{DNSName: "bar.example.org", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"baz.example.org"}}},
+	}}, wrapped.applied)
+	assert.Equal(t, "new.example.org.", changes.Create[0].DNSName, "changes of the plan shouldn't be modified")
+}
@@ -16,7 +16,11 @@ limitations under the License.
 
 package provider
 
-import "strings"
+import (
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
 
 type ZoneIDName map[string]string
 
@@ -24,14 +28,28 @@ func (z ZoneIDName) Add(zoneID, zoneName string) {
 	z[zoneID] = zoneName
 }
 
+// FindZone returns the zone with the longest name containing the hostname. Internationalized
+// hostnames and zone names match regardless of whether they are in Unicode or punycode.
 func (z ZoneIDName) FindZone(hostname string) (suitableZoneID, suitableZoneName string) {
+	hostname = punycodeName(hostname)
+	suitableLength := 0
 	for zoneID, zoneName := range z {
-		if hostname == zoneName || strings.HasSuffix(hostname, "."+zoneName) {
-			if suitableZoneName == "" || len(zoneName) > len(suitableZoneName) {
+		name := punycodeName(zoneName)
+		if hostname == name || strings.HasSuffix(hostname, "."+name) {
+			if suitableZoneName == "" || len(name) > suitableLength {
 				suitableZoneID = zoneID
 				suitableZoneName = zoneName
+				suitableLength = len(name)
 			}
 		}
 	}
 	return
 }
+
+// punycodeName returns a name in punycode, or the name itself if it isn't a valid internationalized name.
+func punycodeName(name string) string {
+	if ascii, err := endpoint.ToPunycode(name); err == nil {
+		return ascii
+	}
+	return name
+}
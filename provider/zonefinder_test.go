@@ -62,4 +62,15 @@ func TestZoneIDName(t *testing.T) {
 	zoneID, zoneName = z.FindZone("foo.qux.baz")
 	assert.Equal(t, "foo.qux.baz", zoneName)
 	assert.Equal(t, "654321", zoneID)
+
+	// internationalized entry and zone match regardless of their encoding
+	z.Add("987654", "bücher.qux.baz")
+	zoneID, zoneName = z.FindZone("www.xn--bcher-kva.qux.baz")
+	assert.Equal(t, "bücher.qux.baz", zoneName)
+	assert.Equal(t, "987654", zoneID)
+
+	z.Add("987654", "xn--bcher-kva.qux.baz")
+	zoneID, zoneName = z.FindZone("www.bücher.qux.baz")
+	assert.Equal(t, "xn--bcher-kva.qux.baz", zoneName)
+	assert.Equal(t, "987654", zoneID)
 }
@@ -0,0 +1,220 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/fileutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// FileRegistry implements registry interface with ownership stored in a local file instead of
+// additional records, for DNS providers where no TXT records can be created.
+//
+// Several controllers can share the file, e.g. on a persistent volume. Each controller only
+// changes the entries of its own records and re-reads the file right before writing it.
+// Records are claimed in the file before they are created, and records claimed by another
+// owner aren't created at all.
+type FileRegistry struct {
+	provider provider.Provider
+	ownerID  string
+	path     string
+}
+
+// fileRegistryDocument is the content of the file of a FileRegistry.
+type fileRegistryDocument struct {
+	Records []fileRegistryRecord `json:"records"`
+}
+
+// fileRegistryRecord holds the labels of a single record.
+type fileRegistryRecord struct {
+	DNSName       string          `json:"dnsName"`
+	RecordType    string          `json:"recordType"`
+	SetIdentifier string          `json:"setIdentifier,omitempty"`
+	Labels        endpoint.Labels `json:"labels"`
+}
+
+type fileRegistryKey struct {
+	dnsName, recordType, setIdentifier string
+}
+
+func newFileRegistryKey(ep *endpoint.Endpoint) fileRegistryKey {
+	return fileRegistryKey{ep.DNSName, ep.RecordType, ep.SetIdentifier}
+}
+
+// NewFileRegistry returns new FileRegistry object
+func NewFileRegistry(provider provider.Provider, path, ownerID string) (*FileRegistry, error) {
+	if ownerID == "" {
+		return nil, errors.New("owner id cannot be empty")
+	}
+	if path == "" {
+		return nil, errors.New("registry file cannot be empty")
+	}
+
+	return &FileRegistry{
+		provider: provider,
+		ownerID:  ownerID,
+		path:     path,
+	}, nil
+}
+
+// Records returns the current records from the dns provider with the labels stored in the file
+func (im *FileRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	records, err := im.provider.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	labelMap, err := im.read()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ep := range records {
+		if ep.Labels == nil {
+			ep.Labels = endpoint.NewLabels()
+		}
+		for k, v := range labelMap[newFileRegistryKey(ep)] {
+			ep.Labels[k] = v
+		}
+	}
+
+	return records, nil
+}
+
+// ApplyChanges updates dns provider with the changes and the file with the ownership of the
+// created and deleted records
+func (im *FileRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	labelMap, err := im.read()
+	if err != nil {
+		return err
+	}
+
+	filteredChanges := &plan.Changes{
+		UpdateNew: filterOwnedRecords(im.ownerID, changes.UpdateNew),
+		UpdateOld: filterOwnedRecords(im.ownerID, changes.UpdateOld),
+		Delete:    filterOwnedRecords(im.ownerID, changes.Delete),
+	}
+	for _, r := range changes.Create {
+		if labels, ok := labelMap[newFileRegistryKey(r)]; ok && labels[endpoint.OwnerLabelKey] != im.ownerID {
+			log.Warnf("Skipping creation of %s %s claimed by owner %q", r.DNSName, r.RecordType, labels[endpoint.OwnerLabelKey])
+			continue
+		}
+		if r.Labels == nil {
+			r.Labels = make(map[string]string)
+		}
+		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
+		filteredChanges.Create = append(filteredChanges.Create, r)
+	}
+
+	// Claim records before creating them, so other owners leave them alone.
+	if len(filteredChanges.Create) > 0 || len(filteredChanges.UpdateNew) > 0 {
+		if err := im.update(func(labelMap map[fileRegistryKey]endpoint.Labels) {
+			for _, r := range append(append([]*endpoint.Endpoint{}, filteredChanges.Create...), filteredChanges.UpdateNew...) {
+				labelMap[newFileRegistryKey(r)] = r.Labels
+			}
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := im.provider.ApplyChanges(ctx, filteredChanges); err != nil {
+		return err
+	}
+
+	if len(filteredChanges.Delete) > 0 {
+		return im.update(func(labelMap map[fileRegistryKey]endpoint.Labels) {
+			for _, r := range filteredChanges.Delete {
+				delete(labelMap, newFileRegistryKey(r))
+			}
+		})
+	}
+	return nil
+}
+
+// PropertyValuesEqual compares two attribute values for equality
+func (im *FileRegistry) PropertyValuesEqual(name string, previous string, current string) bool {
+	return im.provider.PropertyValuesEqual(name, previous, current)
+}
+
+// read returns the labels stored in the file. A missing file holds no labels.
+func (im *FileRegistry) read() (map[fileRegistryKey]endpoint.Labels, error) {
+	labelMap := map[fileRegistryKey]endpoint.Labels{}
+
+	data, err := ioutil.ReadFile(im.path)
+	if os.IsNotExist(err) {
+		return labelMap, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var doc fileRegistryDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode registry file %s: %v", im.path, err)
+	}
+	for _, r := range doc.Records {
+		labelMap[fileRegistryKey{r.DNSName, r.RecordType, r.SetIdentifier}] = r.Labels
+	}
+	return labelMap, nil
+}
+
+// update re-reads the file, modifies its labels and writes it.
+func (im *FileRegistry) update(modify func(map[fileRegistryKey]endpoint.Labels)) error {
+	labelMap, err := im.read()
+	if err != nil {
+		return err
+	}
+	modify(labelMap)
+
+	doc := fileRegistryDocument{Records: make([]fileRegistryRecord, 0, len(labelMap))}
+	for k, labels := range labelMap {
+		doc.Records = append(doc.Records, fileRegistryRecord{
+			DNSName:       k.dnsName,
+			RecordType:    k.recordType,
+			SetIdentifier: k.setIdentifier,
+			Labels:        labels,
+		})
+	}
+	// Sort for stable files, which are easier to review and diff.
+	sort.Slice(doc.Records, func(i, j int) bool {
+		a, b := doc.Records[i], doc.Records[j]
+		if a.DNSName != b.DNSName {
+			return a.DNSName < b.DNSName
+		}
+		if a.RecordType != b.RecordType {
+			return a.RecordType < b.RecordType
+		}
+		return a.SetIdentifier < b.SetIdentifier
+	})
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutils.WriteFileAtomically(im.path, append(data, '\n'))
+}
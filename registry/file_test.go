@@ -0,0 +1,156 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+func TestFileRegistry(t *testing.T) {
+	t.Run("TestNewFileRegistry", testFileRegistryNew)
+	t.Run("TestRecords", testFileRegistryRecords)
+	t.Run("TestApplyChanges", testFileRegistryApplyChanges)
+}
+
+func testFileRegistryNew(t *testing.T) {
+	p := inmemory.NewInMemoryProvider()
+	_, err := NewFileRegistry(p, "registry.json", "")
+	assert.Error(t, err)
+
+	_, err = NewFileRegistry(p, "", "owner")
+	assert.Error(t, err)
+
+	r, err := NewFileRegistry(p, "registry.json", "owner")
+	require.NoError(t, err)
+	assert.Equal(t, p, r.provider)
+}
+
+func testFileRegistryRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-registry")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "registry.json")
+
+	ctx := context.Background()
+	newProvider := func() *inmemory.InMemoryProvider {
+		p := inmemory.NewInMemoryProvider()
+		p.CreateZone(testZone)
+		p.ApplyChanges(ctx, &plan.Changes{
+			Create: []*endpoint.Endpoint{
+				newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+				newEndpointWithOwner("bar.test-zone.example.org", "my-domain.com", endpoint.RecordTypeCNAME, ""),
+				newEndpointWithOwner("qux.test-zone.example.org", "random", endpoint.RecordTypeTXT, ""),
+			},
+		})
+		return p
+	}
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"records": [
+		{"dnsName": "foo.test-zone.example.org", "recordType": "A", "labels": {"owner": "owner", "resource": "ingress/default/foo"}},
+		{"dnsName": "bar.test-zone.example.org", "recordType": "CNAME", "labels": {"owner": "owner-2"}},
+		{"dnsName": "gone.test-zone.example.org", "recordType": "A", "labels": {"owner": "owner"}}
+	]}`), 0644))
+	expectedRecords := []*endpoint.Endpoint{
+		newEndpointWithOwnerResource("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner", "ingress/default/foo"),
+		newEndpointWithOwner("bar.test-zone.example.org", "my-domain.com", endpoint.RecordTypeCNAME, "owner-2"),
+		newEndpointWithOwner("qux.test-zone.example.org", "random", endpoint.RecordTypeTXT, ""),
+	}
+
+	r, _ := NewFileRegistry(newProvider(), path, "owner")
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
+
+	// A missing file holds no ownership.
+	r, _ = NewFileRegistry(newProvider(), filepath.Join(dir, "missing.json"), "owner")
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	for _, record := range records {
+		assert.Empty(t, record.Labels[endpoint.OwnerLabelKey])
+	}
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{`), 0644))
+	r, _ = NewFileRegistry(newProvider(), path, "owner")
+	_, err = r.Records(ctx)
+	assert.Error(t, err)
+}
+
+func testFileRegistryApplyChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-registry")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "registry.json")
+
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("bar.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, ""),
+		},
+	})
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"records": [
+		{"dnsName": "foo.test-zone.example.org", "recordType": "A", "labels": {"owner": "owner"}},
+		{"dnsName": "bar.test-zone.example.org", "recordType": "A", "labels": {"owner": "owner-2"}},
+		{"dnsName": "claimed.test-zone.example.org", "recordType": "A", "labels": {"owner": "owner-2"}}
+	]}`), 0644))
+
+	var applied *plan.Changes
+	p.OnApplyChanges = func(ctx context.Context, changes *plan.Changes) {
+		applied = changes
+	}
+
+	r, _ := NewFileRegistry(p, path, "owner")
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("new.test-zone.example.org", "1.1.1.1", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("claimed.test-zone.example.org", "2.2.2.2", endpoint.RecordTypeA, ""),
+		},
+		Delete: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+			newEndpointWithOwner("bar.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, "owner-2"),
+		},
+	}))
+
+	// Records claimed or owned by other owners are left alone.
+	assert.True(t, testutils.SameEndpoints(applied.Create, []*endpoint.Endpoint{
+		newEndpointWithOwner("new.test-zone.example.org", "1.1.1.1", endpoint.RecordTypeA, "owner"),
+	}))
+	assert.True(t, testutils.SameEndpoints(applied.Delete, []*endpoint.Endpoint{
+		newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+	}))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"records": [
+		{"dnsName": "bar.test-zone.example.org", "recordType": "A", "labels": {"owner": "owner-2"}},
+		{"dnsName": "claimed.test-zone.example.org", "recordType": "A", "labels": {"owner": "owner-2"}},
+		{"dnsName": "new.test-zone.example.org", "recordType": "A", "labels": {"owner": "owner"}}
+	]}`, string(data))
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// SingleWriterRegistry implements registry interface without ownership records for setups
+// where ExternalDNS is the only writer of its domains, e.g. with a file as the single source
+// of truth. As every record within the domain filter is considered owned, the domain filter
+// is enforced strictly: changes of any record outside of it are refused.
+type SingleWriterRegistry struct {
+	provider     provider.Provider
+	domainFilter endpoint.DomainFilter
+}
+
+// NewSingleWriterRegistry returns new SingleWriterRegistry object
+func NewSingleWriterRegistry(provider provider.Provider, domainFilter endpoint.DomainFilter) (*SingleWriterRegistry, error) {
+	if !domainFilter.IsConfigured() {
+		return nil, errors.New("single writer registry requires a domain filter")
+	}
+
+	return &SingleWriterRegistry{
+		provider:     provider,
+		domainFilter: domainFilter,
+	}, nil
+}
+
+// Records returns the current records from the dns provider
+func (im *SingleWriterRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	return im.provider.Records(ctx)
+}
+
+// ApplyChanges propagates changes to the dns provider if all of them are within the domain filter
+func (im *SingleWriterRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	for _, endpoints := range [][]*endpoint.Endpoint{changes.Create, changes.UpdateOld, changes.UpdateNew, changes.Delete} {
+		for _, ep := range endpoints {
+			if !im.domainFilter.Match(ep.DNSName) {
+				return fmt.Errorf("refusing to change %s %s outside of the domain filter", ep.DNSName, ep.RecordType)
+			}
+		}
+	}
+	return im.provider.ApplyChanges(ctx, changes)
+}
+
+// PropertyValuesEqual compares two property values for equality
+func (im *SingleWriterRegistry) PropertyValuesEqual(attribute string, previous string, current string) bool {
+	return im.provider.PropertyValuesEqual(attribute, previous, current)
+}
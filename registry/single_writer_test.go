@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+var _ Registry = &SingleWriterRegistry{}
+
+func TestSingleWriterRegistry(t *testing.T) {
+	t.Run("NewSingleWriterRegistry", testSingleWriterInit)
+	t.Run("ApplyChanges", testSingleWriterApplyChanges)
+}
+
+func testSingleWriterInit(t *testing.T) {
+	p := inmemory.NewInMemoryProvider()
+	_, err := NewSingleWriterRegistry(p, endpoint.DomainFilter{})
+	assert.Error(t, err)
+
+	r, err := NewSingleWriterRegistry(p, endpoint.NewDomainFilter([]string{"example.org"}))
+	require.NoError(t, err)
+	assert.Equal(t, p, r.provider)
+}
+
+func testSingleWriterApplyChanges(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone("org")
+	p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+			endpoint.NewEndpoint("foo.other.org", endpoint.RecordTypeA, "1.2.3.4"),
+		},
+	})
+
+	r, _ := NewSingleWriterRegistry(p, endpoint.NewDomainFilterWithExclusions([]string{"example.org"}, []string{"internal.example.org"}))
+
+	// All records are returned, including those outside of the domain filter.
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	for _, changes := range []*plan.Changes{
+		{Create: []*endpoint.Endpoint{endpoint.NewEndpoint("bar.other.org", endpoint.RecordTypeA, "1.2.3.4")}},
+		{Create: []*endpoint.Endpoint{endpoint.NewEndpoint("bar.internal.example.org", endpoint.RecordTypeA, "1.2.3.4")}},
+		{
+			Create: []*endpoint.Endpoint{endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+			Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.other.org", endpoint.RecordTypeA, "1.2.3.4")},
+		},
+	} {
+		assert.Error(t, r.ApplyChanges(ctx, changes))
+	}
+
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "5.6.7.8")},
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+	}))
+	records, _ = p.Records(ctx)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "5.6.7.8"),
+		endpoint.NewEndpoint("foo.other.org", endpoint.RecordTypeA, "1.2.3.4"),
+	}))
+}
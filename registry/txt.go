@@ -20,6 +20,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -35,15 +37,30 @@ type TXTRegistry struct {
 	provider provider.Provider
 	ownerID  string //refers to the owner id of the current instance
 	mapper   nameMapper
+	// encryption encrypts the labels in TXT records, if enabled
+	encryption *txtEncryption
+	// createdBy identifies this instance in the metadata of the records, if enabled
+	createdBy string
+	now       func() time.Time
 
 	// cache the records in memory and update on an interval instead.
 	recordsCache            []*endpoint.Endpoint
 	recordsCacheRefreshTime time.Time
 	cacheInterval           time.Duration
+
+	// owned counts the records of this owner returned by the last call of Records, see
+	// txtShareKey. The next call of ApplyChanges consumes it.
+	owned map[string]int
 }
 
-// NewTXTRegistry returns new TXTRegistry object
-func NewTXTRegistry(provider provider.Provider, txtPrefix, txtSuffix, ownerID string, cacheInterval time.Duration) (*TXTRegistry, error) {
+// NewTXTRegistry returns new TXTRegistry object. The prefix or suffix may contain the record
+// type template %{record_type}, so records of different types get different TXT records, and
+// the wildcard replacement, if set, replaces * in the names of TXT records, which many
+// providers reject. If encryption keys are given, the labels in TXT records are encrypted
+// with the first key, and TXT records encrypted with any of the keys or not encrypted at all
+// are read. If createdBy is set, the TXT records also hold metadata on the records: the
+// instance which created them and the time of their last change.
+func NewTXTRegistry(provider provider.Provider, txtPrefix, txtSuffix, ownerID string, cacheInterval time.Duration, txtWildcardReplacement string, txtEncryptionKeys []string, createdBy string) (*TXTRegistry, error) {
 	if ownerID == "" {
 		return nil, errors.New("owner id cannot be empty")
 	}
@@ -52,12 +69,24 @@ func NewTXTRegistry(provider provider.Provider, txtPrefix, txtSuffix, ownerID st
 		return nil, errors.New("txt-prefix and txt-suffix are mutual exclusive")
 	}
 
-	mapper := newaffixNameMapper(txtPrefix, txtSuffix)
+	mapper := newaffixNameMapper(txtPrefix, txtSuffix, txtWildcardReplacement)
+
+	var encryption *txtEncryption
+	if len(txtEncryptionKeys) > 0 {
+		var err error
+		encryption, err = newTXTEncryption(txtEncryptionKeys)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return &TXTRegistry{
 		provider:      provider,
 		ownerID:       ownerID,
 		mapper:        mapper,
+		encryption:    encryption,
+		createdBy:     createdBy,
+		now:           time.Now,
 		cacheInterval: cacheInterval,
 	}, nil
 }
@@ -70,6 +99,7 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 	// last given interval, then just use the cached results.
 	if im.recordsCache != nil && time.Since(im.recordsCacheRefreshTime) < im.cacheInterval {
 		log.Debug("Using cached records.")
+		im.owned = im.countOwned(im.recordsCache)
 		return im.recordsCache, nil
 	}
 
@@ -88,7 +118,18 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 			continue
 		}
 		// We simply assume that TXT records for the registry will always have only one target.
-		labels, err := endpoint.NewLabelsFromString(record.Targets[0])
+		text, encryptionKey := record.Targets[0], ""
+		if im.encryption != nil {
+			if decrypted, index, err := im.encryption.decrypt(text); err == nil {
+				text = decrypted
+				if index > 0 {
+					encryptionKey = strconv.Itoa(index)
+				}
+			} else {
+				encryptionKey = txtEncryptionKeyNone
+			}
+		}
+		labels, err := endpoint.NewLabelsFromString(text)
 		if err == endpoint.ErrInvalidHeritage {
 			//if no heritage is found or it is invalid
 			//case when value of txt record cannot be identified
@@ -99,7 +140,11 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 		if err != nil {
 			return nil, err
 		}
-		key := fmt.Sprintf("%s::%s", im.mapper.toEndpointName(record.DNSName), record.SetIdentifier)
+		if encryptionKey != "" {
+			labels[endpoint.TXTEncryptionKeyLabel] = encryptionKey
+		}
+		endpointName, recordType := im.mapper.toEndpointName(record.DNSName)
+		key := fmt.Sprintf("%s::%s::%s", endpointName, recordType, record.SetIdentifier)
 		labelMap[key] = labels
 	}
 
@@ -107,10 +152,14 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 		if ep.Labels == nil {
 			ep.Labels = endpoint.NewLabels()
 		}
-		key := fmt.Sprintf("%s::%s", ep.DNSName, ep.SetIdentifier)
-		if labels, ok := labelMap[key]; ok {
-			for k, v := range labels {
-				ep.Labels[k] = v
+		// TXT records of the record type take precedence over those of all types.
+		for _, recordType := range []string{ep.RecordType, ""} {
+			key := fmt.Sprintf("%s::%s::%s", ep.DNSName, recordType, ep.SetIdentifier)
+			if labels, ok := labelMap[key]; ok {
+				for k, v := range labels {
+					ep.Labels[k] = v
+				}
+				break
 			}
 		}
 	}
@@ -121,6 +170,7 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 		im.recordsCacheRefreshTime = time.Now()
 	}
 
+	im.owned = im.countOwned(endpoints)
 	return endpoints, nil
 }
 
@@ -128,19 +178,21 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 // for each created/deleted record it will also take into account TXT records for creation/deletion
 func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
 	filteredChanges := &plan.Changes{
-		Create:    changes.Create,
+		Create:    im.filterOwnTXTNames(changes.Create),
 		UpdateNew: filterOwnedRecords(im.ownerID, changes.UpdateNew),
 		UpdateOld: filterOwnedRecords(im.ownerID, changes.UpdateOld),
 		Delete:    filterOwnedRecords(im.ownerID, changes.Delete),
 	}
+	txtChanges := &plan.Changes{}
 	for _, r := range filteredChanges.Create {
 		if r.Labels == nil {
 			r.Labels = make(map[string]string)
 		}
 		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
-		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), endpoint.RecordTypeTXT, r.Labels.Serialize(true)).WithSetIdentifier(r.SetIdentifier)
+		im.setMetadata(r, "")
+		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName, r.RecordType), endpoint.RecordTypeTXT, im.txtTarget(r.Labels)).WithSetIdentifier(r.SetIdentifier)
 		txt.ProviderSpecific = r.ProviderSpecific
-		filteredChanges.Create = append(filteredChanges.Create, txt)
+		txtChanges.Create = append(txtChanges.Create, txt)
 
 		if im.cacheInterval > 0 {
 			im.addToCache(r)
@@ -148,12 +200,12 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 	}
 
 	for _, r := range filteredChanges.Delete {
-		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), endpoint.RecordTypeTXT, r.Labels.Serialize(true)).WithSetIdentifier(r.SetIdentifier)
+		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName, r.RecordType), endpoint.RecordTypeTXT, im.txtTarget(r.Labels)).WithSetIdentifier(r.SetIdentifier)
 		txt.ProviderSpecific = r.ProviderSpecific
 
 		// when we delete TXT records for which value has changed (due to new label) this would still work because
 		// !!! TXT record value is uniquely generated from the Labels of the endpoint. Hence old TXT record can be uniquely reconstructed
-		filteredChanges.Delete = append(filteredChanges.Delete, txt)
+		txtChanges.Delete = append(txtChanges.Delete, txt)
 
 		if im.cacheInterval > 0 {
 			im.removeFromCache(r)
@@ -162,11 +214,11 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 
 	// make sure TXT records are consistently updated as well
 	for _, r := range filteredChanges.UpdateOld {
-		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), endpoint.RecordTypeTXT, r.Labels.Serialize(true)).WithSetIdentifier(r.SetIdentifier)
+		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName, r.RecordType), endpoint.RecordTypeTXT, im.txtTarget(r.Labels)).WithSetIdentifier(r.SetIdentifier)
 		txt.ProviderSpecific = r.ProviderSpecific
 		// when we updateOld TXT records for which value has changed (due to new label) this would still work because
 		// !!! TXT record value is uniquely generated from the Labels of the endpoint. Hence old TXT record can be uniquely reconstructed
-		filteredChanges.UpdateOld = append(filteredChanges.UpdateOld, txt)
+		txtChanges.UpdateOld = append(txtChanges.UpdateOld, txt)
 		// remove old version of record from cache
 		if im.cacheInterval > 0 {
 			im.removeFromCache(r)
@@ -174,16 +226,36 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 	}
 
 	// make sure TXT records are consistently updated as well
+	createdBy := map[string]string{}
+	for _, r := range filteredChanges.UpdateOld {
+		createdBy[r.DNSName+"::"+r.RecordType+"::"+r.SetIdentifier] = r.Labels[endpoint.CreatedByLabelKey]
+	}
 	for _, r := range filteredChanges.UpdateNew {
-		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), endpoint.RecordTypeTXT, r.Labels.Serialize(true)).WithSetIdentifier(r.SetIdentifier)
+		im.setMetadata(r, createdBy[r.DNSName+"::"+r.RecordType+"::"+r.SetIdentifier])
+		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName, r.RecordType), endpoint.RecordTypeTXT, im.txtTarget(r.Labels)).WithSetIdentifier(r.SetIdentifier)
 		txt.ProviderSpecific = r.ProviderSpecific
-		filteredChanges.UpdateNew = append(filteredChanges.UpdateNew, txt)
+		txtChanges.UpdateNew = append(txtChanges.UpdateNew, txt)
 		// add new version of record to cache
 		if im.cacheInterval > 0 {
 			im.addToCache(r)
 		}
 	}
 
+	if !im.mapper.typed() {
+		// The records are only read again if ApplyChanges isn't called right after Records.
+		if im.owned == nil {
+			if _, err := im.Records(ctx); err != nil {
+				return err
+			}
+		}
+		txtChanges = im.shareTXTChanges(filteredChanges, txtChanges, im.owned)
+		im.owned = nil
+	}
+	filteredChanges.Create = append(filteredChanges.Create, txtChanges.Create...)
+	filteredChanges.UpdateNew = append(filteredChanges.UpdateNew, txtChanges.UpdateNew...)
+	filteredChanges.UpdateOld = append(filteredChanges.UpdateOld, txtChanges.UpdateOld...)
+	filteredChanges.Delete = append(filteredChanges.Delete, txtChanges.Delete...)
+
 	// when caching is enabled, disable the provider from using the cache
 	if im.cacheInterval > 0 {
 		ctx = context.WithValue(ctx, provider.RecordsContextKey, nil)
@@ -191,6 +263,138 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 	return im.provider.ApplyChanges(ctx, filteredChanges)
 }
 
+// filterOwnTXTNames drops the TXT records whose TXT record of the registry would have their own
+// name, i.e. those of TXT registries without a prefix or suffix, as one would replace the other.
+func (im *TXTRegistry) filterOwnTXTNames(records []*endpoint.Endpoint) []*endpoint.Endpoint {
+	filtered := []*endpoint.Endpoint{}
+	for _, r := range records {
+		if r.RecordType == endpoint.RecordTypeTXT && im.mapper.toTXTName(r.DNSName, r.RecordType) == r.DNSName {
+			log.Warnf("Skipping creation of TXT record %s: TXT records require --txt-prefix or --txt-suffix", r.DNSName)
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	return filtered
+}
+
+// countOwned returns the number of records of this owner by name and set identifier, see
+// txtShareKey.
+func (im *TXTRegistry) countOwned(records []*endpoint.Endpoint) map[string]int {
+	owned := map[string]int{}
+	for _, r := range records {
+		if r.Labels[endpoint.OwnerLabelKey] == im.ownerID {
+			owned[txtShareKey(r)]++
+		}
+	}
+	return owned
+}
+
+// txtShareKey identifies the records sharing a TXT record if the TXT names don't hold the record type.
+func txtShareKey(r *endpoint.Endpoint) string {
+	return strings.ToLower(strings.TrimSuffix(r.DNSName, ".")) + "::" + r.SetIdentifier
+}
+
+// shareTXTChanges adapts the changes of the TXT records to TXT records shared by the records of
+// all types of a name, e.g. an A and an MX record of the apex: the TXT record is created with
+// the first owned record of the name, deleted with the last one and changed once, with the
+// labels of all updated records of the name. The TXT changes are those of the records at the
+// same index.
+func (im *TXTRegistry) shareTXTChanges(changes, txtChanges *plan.Changes, owned map[string]int) *plan.Changes {
+	remaining := map[string]int{}
+	for key, count := range owned {
+		remaining[key] = count
+	}
+	for _, r := range changes.Create {
+		remaining[txtShareKey(r)]++
+	}
+	for _, r := range changes.Delete {
+		remaining[txtShareKey(r)]--
+	}
+
+	shared := &plan.Changes{}
+	created := map[string]bool{}
+	for i, r := range changes.Create {
+		if key := txtShareKey(r); owned[key] == 0 && !created[key] {
+			created[key] = true
+			shared.Create = append(shared.Create, txtChanges.Create[i])
+		}
+	}
+	deleted := map[string]bool{}
+	for i, r := range changes.Delete {
+		if key := txtShareKey(r); remaining[key] <= 0 && !deleted[key] {
+			deleted[key] = true
+			shared.Delete = append(shared.Delete, txtChanges.Delete[i])
+		}
+	}
+	updated := map[string]endpoint.Labels{}
+	var updatedKeys []string
+	for i, r := range changes.UpdateNew {
+		key := txtShareKey(r)
+		labels, ok := updated[key]
+		if !ok {
+			labels = endpoint.Labels{}
+			updated[key] = labels
+			updatedKeys = append(updatedKeys, key)
+			shared.UpdateOld = append(shared.UpdateOld, txtChanges.UpdateOld[i])
+			shared.UpdateNew = append(shared.UpdateNew, txtChanges.UpdateNew[i])
+		}
+		for k, v := range r.Labels {
+			labels[k] = v
+		}
+	}
+	for i, key := range updatedKeys {
+		shared.UpdateNew[i].Targets = endpoint.Targets{im.txtTarget(updated[key])}
+	}
+	return shared
+}
+
+// setMetadata sets the metadata labels of a created or updated record, if enabled. Records
+// keep the instance which created them, if known.
+func (im *TXTRegistry) setMetadata(r *endpoint.Endpoint, createdBy string) {
+	if im.createdBy == "" {
+		return
+	}
+	if r.Labels == nil {
+		r.Labels = endpoint.NewLabels()
+	}
+	if createdBy == "" {
+		createdBy = im.createdBy
+	}
+	r.Labels[endpoint.CreatedByLabelKey] = createdBy
+	r.Labels[endpoint.TimestampLabelKey] = im.now().UTC().Format(time.RFC3339)
+}
+
+// txtEncryptionKeyNone is the value of the TXTEncryptionKeyLabel of TXT records that aren't encrypted
+const txtEncryptionKeyNone = "none"
+
+// txtTarget returns the target of the TXT record storing the labels. If encryption is enabled,
+// it's encrypted with the key the TXT record was read with, so existing TXT records are
+// reconstructed exactly, and with the current key otherwise.
+func (im *TXTRegistry) txtTarget(labels endpoint.Labels) string {
+	if im.encryption == nil {
+		return labels.Serialize(true)
+	}
+
+	stored := endpoint.Labels{}
+	for k, v := range labels {
+		if k != endpoint.TXTEncryptionKeyLabel {
+			stored[k] = v
+		}
+	}
+
+	key, ok := labels[endpoint.TXTEncryptionKeyLabel]
+	if key == txtEncryptionKeyNone {
+		return stored.Serialize(true)
+	}
+	index := 0
+	if ok {
+		if i, err := strconv.Atoi(key); err == nil && i < len(im.encryption.aeads) {
+			index = i
+		}
+	}
+	return im.encryption.encrypt(stored.Serialize(false), index)
+}
+
 // PropertyValuesEqual compares two attribute values for equality
 func (im *TXTRegistry) PropertyValuesEqual(name string, previous string, current string) bool {
 	return im.provider.PropertyValuesEqual(name, previous, current)
@@ -206,39 +410,98 @@ func (im *TXTRegistry) PropertyValuesEqual(name string, previous string, current
 */
 
 type nameMapper interface {
-	toEndpointName(string) string
-	toTXTName(string) string
+	// toEndpointName returns the endpoint name and, if known, the record type
+	toEndpointName(string) (string, string)
+	toTXTName(string, string) string
+	// typed reports whether the TXT names hold the record type, i.e. whether records of
+	// different types of a name get different TXT records
+	typed() bool
 }
 
+// recordTypeTemplate is replaced with the record type in the prefix or suffix
+const recordTypeTemplate = "%{record_type}"
+
 type affixNameMapper struct {
-	prefix string
-	suffix string
+	prefix              string
+	suffix              string
+	wildcardReplacement string
+	// affix matches the affixed part of TXT names, i.e. the whole name for prefixes and the
+	// first label for suffixes, with the groups "name" and optionally "type"
+	affix *regexp.Regexp
 }
 
 var _ nameMapper = affixNameMapper{}
 
-func newaffixNameMapper(prefix string, suffix string) affixNameMapper {
-	return affixNameMapper{prefix: strings.ToLower(prefix), suffix: strings.ToLower(suffix)}
+func newaffixNameMapper(prefix string, suffix string, wildcardReplacement string) affixNameMapper {
+	pr := affixNameMapper{
+		prefix:              strings.ToLower(prefix),
+		suffix:              strings.ToLower(suffix),
+		wildcardReplacement: strings.ToLower(wildcardReplacement),
+	}
+	if len(pr.suffix) > 0 {
+		pr.affix = regexp.MustCompile("^(?P<name>.*)" + affixPattern(pr.suffix) + "$")
+	} else {
+		pr.affix = regexp.MustCompile("^" + affixPattern(pr.prefix) + "(?P<name>.*)$")
+	}
+	return pr
 }
 
-func (pr affixNameMapper) toEndpointName(txtDNSName string) string {
-	lowerDNSName := strings.ToLower(txtDNSName)
-	if strings.HasPrefix(lowerDNSName, pr.prefix) && len(pr.suffix) == 0 {
-		return strings.TrimPrefix(lowerDNSName, pr.prefix)
+// affixPattern returns a regular expression matching the affix, capturing the record type
+// of the template.
+func affixPattern(affix string) string {
+	parts := strings.SplitN(affix, recordTypeTemplate, 2)
+	if len(parts) == 1 {
+		return regexp.QuoteMeta(affix)
 	}
+	return regexp.QuoteMeta(parts[0]) + "(?P<type>[a-z0-9]+)" + regexp.QuoteMeta(parts[1])
+}
+
+func (pr affixNameMapper) toEndpointName(txtDNSName string) (string, string) {
+	lowerDNSName := strings.ToLower(txtDNSName)
 
+	DNSName := []string{lowerDNSName}
 	if len(pr.suffix) > 0 {
-		DNSName := strings.SplitN(lowerDNSName, ".", 2)
-		if strings.HasSuffix(DNSName[0], pr.suffix) {
-			return strings.TrimSuffix(DNSName[0], pr.suffix) + "." + DNSName[1]
+		DNSName = strings.SplitN(lowerDNSName, ".", 2)
+	}
+	match := pr.affix.FindStringSubmatch(DNSName[0])
+	if match == nil {
+		return "", ""
+	}
+
+	var recordType string
+	for i, name := range pr.affix.SubexpNames() {
+		switch name {
+		case "name":
+			DNSName[0] = match[i]
+		case "type":
+			recordType = strings.ToUpper(match[i])
 		}
 	}
-	return ""
+
+	endpointName := strings.Join(DNSName, ".")
+	if len(pr.wildcardReplacement) > 0 {
+		labels := strings.SplitN(endpointName, ".", 2)
+		if labels[0] == pr.wildcardReplacement {
+			labels[0] = "*"
+			endpointName = strings.Join(labels, ".")
+		}
+	}
+	return endpointName, recordType
 }
 
-func (pr affixNameMapper) toTXTName(endpointDNSName string) string {
+func (pr affixNameMapper) toTXTName(endpointDNSName string, recordType string) string {
 	DNSName := strings.SplitN(endpointDNSName, ".", 2)
-	return pr.prefix + DNSName[0] + pr.suffix + "." + DNSName[1]
+	if DNSName[0] == "*" && len(pr.wildcardReplacement) > 0 {
+		DNSName[0] = pr.wildcardReplacement
+	}
+	prefix := strings.Replace(pr.prefix, recordTypeTemplate, strings.ToLower(recordType), 1)
+	suffix := strings.Replace(pr.suffix, recordTypeTemplate, strings.ToLower(recordType), 1)
+	DNSName[0] = prefix + DNSName[0] + suffix
+	return strings.Join(DNSName, ".")
+}
+
+func (pr affixNameMapper) typed() bool {
+	return strings.Contains(pr.prefix+pr.suffix, recordTypeTemplate)
 }
 
 func (im *TXTRegistry) addToCache(ep *endpoint.Endpoint) {
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// txtEncryption encrypts the labels stored in TXT records with AES-256-GCM, so ownership
+// metadata in public zones can't be read by outsiders.
+//
+// The nonce is derived from the key and the labels, so the TXT record of the same labels
+// stays the same and can be reconstructed to update or delete it. The first key encrypts,
+// all keys decrypt, which allows rotating keys without losing the ownership of records.
+type txtEncryption struct {
+	aeads     []cipher.AEAD
+	nonceKeys [][]byte
+}
+
+// newTXTEncryption creates a new txtEncryption from base64 encoded 32 byte keys.
+func newTXTEncryption(keys []string) (*txtEncryption, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no TXT encryption keys specified")
+	}
+
+	e := &txtEncryption{}
+	for i, key := range keys {
+		raw, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("TXT encryption key %d isn't base64 encoded: %v", i, err)
+		}
+		if len(raw) != 32 {
+			return nil, fmt.Errorf("TXT encryption key %d must be 32 bytes long, got %d", i, len(raw))
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		// Don't use the encryption key for deriving nonces as well.
+		nonceKey := sha256.Sum256(append([]byte("txt-encryption-nonce:"), raw...))
+
+		e.aeads = append(e.aeads, aead)
+		e.nonceKeys = append(e.nonceKeys, nonceKey[:])
+	}
+	return e, nil
+}
+
+// encrypt encrypts the text with the key of the given index and returns the quoted target
+// of the TXT record.
+func (e *txtEncryption) encrypt(text string, index int) string {
+	mac := hmac.New(sha256.New, e.nonceKeys[index])
+	mac.Write([]byte(text))
+	nonce := mac.Sum(nil)[:e.aeads[index].NonceSize()]
+
+	sealed := e.aeads[index].Seal(nonce, nonce, []byte(text), nil)
+	return fmt.Sprintf("\"%s\"", base64.StdEncoding.EncodeToString(sealed))
+}
+
+// decrypt decrypts the target of a TXT record and returns the text and the index of the
+// key it was encrypted with.
+func (e *txtEncryption) decrypt(target string) (string, int, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.Trim(target, "\""))
+	if err != nil {
+		return "", 0, err
+	}
+	for i, aead := range e.aeads {
+		if len(sealed) < aead.NonceSize() {
+			break
+		}
+		text, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+		if err == nil {
+			return string(text), i, nil
+		}
+	}
+	return "", 0, errors.New("TXT record isn't encrypted with any of the keys")
+}
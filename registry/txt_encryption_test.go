@@ -0,0 +1,130 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+var (
+	txtEncryptionKey    = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+	txtEncryptionOldKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("o", 32)))
+)
+
+func TestTXTEncryption(t *testing.T) {
+	_, err := newTXTEncryption(nil)
+	assert.Error(t, err)
+	_, err = newTXTEncryption([]string{"not base64!"})
+	assert.Error(t, err)
+	_, err = newTXTEncryption([]string{base64.StdEncoding.EncodeToString([]byte("short"))})
+	assert.Error(t, err)
+
+	e, err := newTXTEncryption([]string{txtEncryptionKey, txtEncryptionOldKey})
+	require.NoError(t, err)
+
+	text := "heritage=external-dns,external-dns/owner=owner"
+	encrypted := e.encrypt(text, 0)
+	assert.NotContains(t, encrypted, "owner")
+	assert.Equal(t, encrypted, e.encrypt(text, 0), "encryption must be deterministic")
+	assert.NotEqual(t, encrypted, e.encrypt(text, 1))
+
+	for index := range e.aeads {
+		decrypted, i, err := e.decrypt(e.encrypt(text, index))
+		require.NoError(t, err)
+		assert.Equal(t, text, decrypted)
+		assert.Equal(t, index, i)
+	}
+
+	_, _, err = e.decrypt("\"" + text + "\"")
+	assert.Error(t, err)
+
+	other, err := newTXTEncryption([]string{txtEncryptionOldKey})
+	require.NoError(t, err)
+	_, _, err = other.decrypt(encrypted)
+	assert.Error(t, err)
+}
+
+func TestTXTRegistryEncryption(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+
+	// Records are created with the old key and one without encryption exists.
+	r, err := NewTXTRegistry(p, "txt.", "", "owner", 0, "", []string{txtEncryptionOldKey}, "")
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "")},
+	}))
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("bar.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+		},
+	}))
+
+	stored := map[string]string{}
+	records, err := p.Records(ctx)
+	require.NoError(t, err)
+	for _, record := range records {
+		if record.RecordType == endpoint.RecordTypeTXT {
+			stored[record.DNSName] = record.Targets[0]
+		}
+	}
+	assert.NotContains(t, stored["txt.foo.test-zone.example.org"], "owner")
+
+	// After rotating the key, all records are still owned.
+	r, err = NewTXTRegistry(p, "txt.", "", "owner", 0, "", []string{txtEncryptionKey, txtEncryptionOldKey}, "")
+	require.NoError(t, err)
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	for _, record := range records {
+		assert.Equal(t, "owner", record.Labels[endpoint.OwnerLabelKey])
+	}
+
+	// The TXT records of the old key and without encryption are reconstructed exactly.
+	var deleted []*endpoint.Endpoint
+	p.OnApplyChanges = func(ctx context.Context, changes *plan.Changes) {
+		deleted = changes.Delete
+	}
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Delete: records}))
+	for _, ep := range deleted {
+		if ep.RecordType == endpoint.RecordTypeTXT {
+			assert.Equal(t, stored[ep.DNSName], ep.Targets[0])
+		}
+	}
+
+	// New records are created with the current key.
+	p.OnApplyChanges = func(ctx context.Context, changes *plan.Changes) {}
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{newEndpointWithOwner("baz.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "")},
+	}))
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, endpoint.Labels{endpoint.OwnerLabelKey: "owner"}, records[0].Labels)
+}
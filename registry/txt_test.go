@@ -19,6 +19,7 @@ package registry
 import (
 	"context"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -44,20 +45,20 @@ func TestTXTRegistry(t *testing.T) {
 
 func testTXTRegistryNew(t *testing.T) {
 	p := inmemory.NewInMemoryProvider()
-	_, err := NewTXTRegistry(p, "txt", "", "", time.Hour)
+	_, err := NewTXTRegistry(p, "txt", "", "", time.Hour, "", nil, "")
 	require.Error(t, err)
 
-	_, err = NewTXTRegistry(p, "", "txt", "", time.Hour)
+	_, err = NewTXTRegistry(p, "", "txt", "", time.Hour, "", nil, "")
 	require.Error(t, err)
 
-	r, err := NewTXTRegistry(p, "txt", "", "owner", time.Hour)
+	r, err := NewTXTRegistry(p, "txt", "", "owner", time.Hour, "", nil, "")
 	require.NoError(t, err)
 	assert.Equal(t, p, r.provider)
 
-	r, err = NewTXTRegistry(p, "", "txt", "owner", time.Hour)
+	r, err = NewTXTRegistry(p, "", "txt", "owner", time.Hour, "", nil, "")
 	require.NoError(t, err)
 
-	_, err = NewTXTRegistry(p, "txt", "txt", "owner", time.Hour)
+	_, err = NewTXTRegistry(p, "txt", "txt", "owner", time.Hour, "", nil, "")
 	require.Error(t, err)
 
 	_, ok := r.mapper.(affixNameMapper)
@@ -65,7 +66,7 @@ func testTXTRegistryNew(t *testing.T) {
 	assert.Equal(t, "owner", r.ownerID)
 	assert.Equal(t, p, r.provider)
 
-	r, err = NewTXTRegistry(p, "", "", "owner", time.Hour)
+	r, err = NewTXTRegistry(p, "", "", "owner", time.Hour, "", nil, "")
 	require.NoError(t, err)
 
 	_, ok = r.mapper.(affixNameMapper)
@@ -76,6 +77,7 @@ func testTXTRegistryRecords(t *testing.T) {
 	t.Run("With prefix", testTXTRegistryRecordsPrefixed)
 	t.Run("With suffix", testTXTRegistryRecordsSuffixed)
 	t.Run("No prefix", testTXTRegistryRecordsNoPrefix)
+	t.Run("With record type template", testTXTRegistryRecordsTemplated)
 }
 
 func testTXTRegistryRecordsPrefixed(t *testing.T) {
@@ -171,13 +173,13 @@ func testTXTRegistryRecordsPrefixed(t *testing.T) {
 		},
 	}
 
-	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour)
+	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "", nil, "")
 	records, _ := r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
 
 	// Ensure prefix is case-insensitive
-	r, _ = NewTXTRegistry(p, "TxT.", "", "owner", time.Hour)
+	r, _ = NewTXTRegistry(p, "TxT.", "", "owner", time.Hour, "", nil, "")
 	records, _ = r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpointLabels(records, expectedRecords))
@@ -276,13 +278,13 @@ func testTXTRegistryRecordsSuffixed(t *testing.T) {
 		},
 	}
 
-	r, _ := NewTXTRegistry(p, "", "-txt", "owner", time.Hour)
+	r, _ := NewTXTRegistry(p, "", "-txt", "owner", time.Hour, "", nil, "")
 	records, _ := r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
 
 	// Ensure prefix is case-insensitive
-	r, _ = NewTXTRegistry(p, "", "-TxT", "owner", time.Hour)
+	r, _ = NewTXTRegistry(p, "", "-TxT", "owner", time.Hour, "", nil, "")
 	records, _ = r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpointLabels(records, expectedRecords))
@@ -357,7 +359,54 @@ func testTXTRegistryRecordsNoPrefix(t *testing.T) {
 		},
 	}
 
-	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour)
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", nil, "")
+	records, _ := r.Records(ctx)
+
+	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
+}
+
+func testTXTRegistryRecordsTemplated(t *testing.T) {
+	p := inmemory.NewInMemoryProvider()
+	ctx := context.Background()
+	p.CreateZone(testZone)
+	p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+			newEndpointWithOwner("owner-a.foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("owner.foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner-2\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("*.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("owner-a.wildcard.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+		},
+	})
+	expectedRecords := []*endpoint.Endpoint{
+		{
+			DNSName:    "foo.test-zone.example.org",
+			Targets:    endpoint.Targets{"1.2.3.4"},
+			RecordType: endpoint.RecordTypeA,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey: "owner",
+			},
+		},
+		{
+			DNSName:    "foo.test-zone.example.org",
+			Targets:    endpoint.Targets{"foo.loadbalancer.com"},
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey: "",
+			},
+		},
+		{
+			DNSName:    "*.test-zone.example.org",
+			Targets:    endpoint.Targets{"5.6.7.8"},
+			RecordType: endpoint.RecordTypeA,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey: "owner",
+			},
+		},
+	}
+
+	r, _ := NewTXTRegistry(p, "owner-%{record_type}.", "", "owner", time.Hour, "wildcard", nil, "")
 	records, _ := r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
@@ -394,7 +443,7 @@ func testTXTRegistryApplyChangesWithPrefix(t *testing.T) {
 			newEndpointWithOwner("txt.multiple.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "").WithSetIdentifier("test-set-2"),
 		},
 	})
-	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour)
+	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "", nil, "")
 
 	changes := &plan.Changes{
 		Create: []*endpoint.Endpoint{
@@ -485,7 +534,7 @@ func testTXTRegistryApplyChangesWithSuffix(t *testing.T) {
 			newEndpointWithOwner("multiple-txt.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "").WithSetIdentifier("test-set-2"),
 		},
 	})
-	r, _ := NewTXTRegistry(p, "", "-txt", "owner", time.Hour)
+	r, _ := NewTXTRegistry(p, "", "-txt", "owner", time.Hour, "", nil, "")
 
 	changes := &plan.Changes{
 		Create: []*endpoint.Endpoint{
@@ -572,7 +621,7 @@ func testTXTRegistryApplyChangesNoPrefix(t *testing.T) {
 			newEndpointWithOwner("foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
 		},
 	})
-	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour)
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", nil, "")
 
 	changes := &plan.Changes{
 		Create: []*endpoint.Endpoint{
@@ -620,6 +669,36 @@ func testTXTRegistryApplyChangesNoPrefix(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestAffixNameMapper(t *testing.T) {
+	for _, tc := range []struct {
+		prefix, suffix, wildcardReplacement string
+		endpointName, recordType            string
+		txtName                             string
+	}{
+		{prefix: "txt.", endpointName: "foo.example.org", recordType: "A", txtName: "txt.foo.example.org"},
+		{suffix: "-txt", endpointName: "foo.example.org", recordType: "A", txtName: "foo-txt.example.org"},
+		{prefix: "%{record_type}-", endpointName: "foo.example.org", recordType: "CNAME", txtName: "cname-foo.example.org"},
+		{prefix: "_owner.%{record_type}.", endpointName: "foo.example.org", recordType: "A", txtName: "_owner.a.foo.example.org"},
+		{suffix: "-%{record_type}", endpointName: "foo.example.org", recordType: "TXT", txtName: "foo-txt.example.org"},
+		{prefix: "txt-", wildcardReplacement: "wildcard", endpointName: "*.example.org", recordType: "A", txtName: "txt-wildcard.example.org"},
+		{suffix: "-%{record_type}", wildcardReplacement: "any", endpointName: "*.example.org", recordType: "A", txtName: "any-a.example.org"},
+		{endpointName: "example", recordType: "A", txtName: "example"},
+	} {
+		mapper := newaffixNameMapper(tc.prefix, tc.suffix, tc.wildcardReplacement)
+
+		txtName := mapper.toTXTName(tc.endpointName, tc.recordType)
+		assert.Equal(t, tc.txtName, txtName)
+
+		endpointName, recordType := mapper.toEndpointName(txtName)
+		assert.Equal(t, tc.endpointName, endpointName)
+		if strings.Contains(tc.prefix+tc.suffix, recordTypeTemplate) {
+			assert.Equal(t, tc.recordType, recordType)
+		} else {
+			assert.Empty(t, recordType)
+		}
+	}
+}
+
 func TestCacheMethods(t *testing.T) {
 	cache := []*endpoint.Endpoint{
 		newEndpointWithOwner("thing.com", "1.2.3.4", "A", "owner"),
@@ -687,6 +766,180 @@ helper methods
 
 */
 
+func TestTXTRegistryMetadata(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	created := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+
+	// Created records hold the instance which created them and the time of creation.
+	r, err := NewTXTRegistry(p, "txt.", "", "owner", 0, "", nil, "pod-1")
+	require.NoError(t, err)
+	r.now = func() time.Time { return created }
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "")},
+	}))
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, endpoint.Labels{
+		endpoint.OwnerLabelKey:     "owner",
+		endpoint.CreatedByLabelKey: "pod-1",
+		endpoint.TimestampLabelKey: "2020-05-01T12:00:00Z",
+	}, records[0].Labels)
+
+	// Updated records keep the instance which created them and hold the time of the update.
+	r, err = NewTXTRegistry(p, "txt.", "", "owner", 0, "", nil, "pod-2")
+	require.NoError(t, err)
+	r.now = func() time.Time { return updated }
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		UpdateOld: records,
+		UpdateNew: []*endpoint.Endpoint{newEndpointWithOwner("foo.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, "owner")},
+	}))
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, endpoint.Labels{
+		endpoint.OwnerLabelKey:     "owner",
+		endpoint.CreatedByLabelKey: "pod-1",
+		endpoint.TimestampLabelKey: "2020-05-01T13:00:00Z",
+	}, records[0].Labels)
+
+	// The TXT records are reconstructed exactly for deletion.
+	p.OnApplyChanges = func(ctx context.Context, changes *plan.Changes) {
+		require.Len(t, changes.Delete, 2)
+		assert.Equal(t, "\"heritage=external-dns,external-dns/created-by=pod-1,external-dns/owner=owner,external-dns/timestamp=2020-05-01T13:00:00Z\"", changes.Delete[1].Targets[0])
+	}
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Delete: records}))
+}
+
+func TestTXTRegistrySharedTXT(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	r, err := NewTXTRegistry(p, "", "", "owner", 0, "", nil, "")
+	require.NoError(t, err)
+	txt := newEndpointWithOwner("test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "")
+
+	// Records of several types of a name create their TXT record once.
+	p.OnApplyChanges = func(ctx context.Context, changes *plan.Changes) {
+		assert.True(t, testutils.SameEndpoints(changes.Create, []*endpoint.Endpoint{
+			newEndpointWithOwner("test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+			newEndpointWithOwner("test-zone.example.org", "10 mail.example.org", endpoint.RecordTypeMX, "owner"),
+			txt,
+		}))
+	}
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("test-zone.example.org", "10 mail.example.org", endpoint.RecordTypeMX, ""),
+		},
+	}))
+
+	// The TXT record is kept until the last record of the name is deleted.
+	mx := newEndpointWithOwner("test-zone.example.org", "10 mail.example.org", endpoint.RecordTypeMX, "owner")
+	p.OnApplyChanges = func(ctx context.Context, changes *plan.Changes) {
+		assert.True(t, testutils.SameEndpoints(changes.Delete, []*endpoint.Endpoint{mx}))
+	}
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Delete: []*endpoint.Endpoint{mx}}))
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "owner", records[0].Labels[endpoint.OwnerLabelKey])
+
+	a := newEndpointWithOwner("test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner")
+	p.OnApplyChanges = func(ctx context.Context, changes *plan.Changes) {
+		assert.True(t, testutils.SameEndpoints(changes.Delete, []*endpoint.Endpoint{a, txt}))
+	}
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Delete: []*endpoint.Endpoint{a}}))
+}
+
+// recordsCounter counts the reads of the records of a provider.
+type recordsCounter struct {
+	provider.Provider
+	reads int
+}
+
+func (p *recordsCounter) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	p.reads++
+	return p.Provider.Records(ctx)
+}
+
+func TestTXTRegistrySharedTXTUpdates(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	counter := &recordsCounter{Provider: p}
+	r, err := NewTXTRegistry(counter, "", "", "owner", 0, "", nil, "")
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("test-zone.example.org", "10 mail.example.org", endpoint.RecordTypeMX, ""),
+		},
+	}))
+
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	reads := counter.reads
+
+	// The updates of the records of a name change their TXT record once, with the labels of all of them.
+	var updateNew []*endpoint.Endpoint
+	for _, record := range records {
+		updated := record.DeepCopy()
+		updated.Labels[endpoint.ResourceLabelKey] = "resource/" + strings.ToLower(record.RecordType)
+		if record.RecordType == endpoint.RecordTypeMX {
+			updated.Labels["mail"] = "true"
+		}
+		updateNew = append(updateNew, updated)
+	}
+	p.OnApplyChanges = func(ctx context.Context, changes *plan.Changes) {
+		var txts []*endpoint.Endpoint
+		for _, r := range changes.UpdateNew {
+			if r.RecordType == endpoint.RecordTypeTXT {
+				txts = append(txts, r)
+			}
+		}
+		require.Len(t, txts, 1)
+		labels, err := endpoint.NewLabelsFromString(txts[0].Targets[0])
+		require.NoError(t, err)
+		assert.Equal(t, "true", labels["mail"])
+		assert.Contains(t, []string{"resource/a", "resource/mx"}, labels[endpoint.ResourceLabelKey])
+	}
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{UpdateOld: records, UpdateNew: updateNew}))
+
+	// ApplyChanges reuses the owners read by Records.
+	assert.Equal(t, reads, counter.reads)
+}
+
+func TestTXTRegistryTXTRecords(t *testing.T) {
+	ctx := context.Background()
+	spf := newEndpointWithOwner("test-zone.example.org", "\"v=spf1 -all\"", endpoint.RecordTypeTXT, "")
+
+	// Without a prefix or suffix, the TXT record of the registry would replace the TXT record.
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	r, err := NewTXTRegistry(p, "", "", "owner", 0, "", nil, "")
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{spf.DeepCopy()}}))
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	p = inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	r, err = NewTXTRegistry(p, "txt.", "", "owner", 0, "", nil, "")
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{spf.DeepCopy()}}))
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		newEndpointWithOwner("test-zone.example.org", "\"v=spf1 -all\"", endpoint.RecordTypeTXT, "owner"),
+	}))
+}
+
 func newEndpointWithOwner(dnsName, target, recordType, ownerID string) *endpoint.Endpoint {
 	return newEndpointWithOwnerAndLabels(dnsName, target, recordType, ownerID, nil)
 }
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+var sourceConflictsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "external_dns",
+		Subsystem: "source",
+		Name:      "conflicts_total",
+		Help:      "Number of endpoints dropped because another source defines the same endpoint, partitioned by the winning and the losing source",
+	},
+	[]string{"winner", "loser"},
+)
+
+func init() {
+	prometheus.MustRegister(sourceConflictsTotal)
+}
+
+// ConflictResolver chooses which of several sources defining the same endpoint wins.
+type ConflictResolver interface {
+	// Resolve returns the name of the winning source of the given sources, which are in the
+	// order of the sources of the conflictSource.
+	Resolve(sources []string) string
+}
+
+// PrecedenceConflictResolver is a ConflictResolver letting the source listed first win. Sources
+// which aren't listed lose against listed ones, and the first of them in the order of the
+// sources wins against the others.
+type PrecedenceConflictResolver []string
+
+// Resolve returns the source of the highest precedence.
+func (r PrecedenceConflictResolver) Resolve(sources []string) string {
+	winner, winnerRank := "", len(r)+1
+	for _, source := range sources {
+		rank := len(r)
+		for i, name := range r {
+			if name == source {
+				rank = i
+				break
+			}
+		}
+		if rank < winnerRank {
+			winner, winnerRank = source, rank
+		}
+	}
+	return winner
+}
+
+// conflictSource is a Source that merges the endpoints of its named nested Sources like
+// multiSource, but resolves conflicts of endpoints of the same name, record type and set
+// identifier defined by several sources deterministically: only the endpoints of the source
+// chosen by the resolver are kept. Several endpoints of a single source aren't conflicts.
+type conflictSource struct {
+	children []Source
+	names    []string
+	resolver ConflictResolver
+}
+
+// NewConflictSource creates a new conflictSource of the nested Sources with the given names.
+func NewConflictSource(children []Source, names []string, resolver ConflictResolver) Source {
+	return &conflictSource{children: children, names: names, resolver: resolver}
+}
+
+// Endpoints collects endpoints of all nested Sources and returns them without conflicts.
+func (cs *conflictSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	type key struct {
+		dnsName, recordType, setIdentifier string
+	}
+
+	var endpoints []*endpoint.Endpoint
+	var origins []string
+	sources := map[key][]string{}
+	for i, s := range cs.children {
+		children, err := s.Endpoints()
+		if err != nil {
+			return nil, err
+		}
+		for _, ep := range children {
+			k := key{ep.DNSName, ep.RecordType, ep.SetIdentifier}
+			if !containsString(sources[k], cs.names[i]) {
+				sources[k] = append(sources[k], cs.names[i])
+			}
+			endpoints = append(endpoints, ep)
+			origins = append(origins, cs.names[i])
+		}
+	}
+
+	result := []*endpoint.Endpoint{}
+	for i, ep := range endpoints {
+		k := key{ep.DNSName, ep.RecordType, ep.SetIdentifier}
+		if len(sources[k]) > 1 {
+			if winner := cs.resolver.Resolve(sources[k]); winner != origins[i] {
+				sourceConflictsTotal.WithLabelValues(winner, origins[i]).Inc()
+				log.Debugf("Dropping endpoint %s of source %s, which conflicts with source %s", ep, origins[i], winner)
+				continue
+			}
+		}
+		result = append(result, ep)
+	}
+
+	return result, nil
+}
+
+func (cs *conflictSource) AddEventHandler(ctx context.Context, handler func()) {
+	for _, s := range cs.children {
+		s.AddEventHandler(ctx, handler)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
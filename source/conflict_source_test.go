@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+func TestConflictSource(t *testing.T) {
+	t.Run("Interface", testConflictSourceImplementsSource)
+	t.Run("Endpoints", testConflictSourceEndpoints)
+	t.Run("EndpointsWithError", testConflictSourceEndpointsWithError)
+	t.Run("PrecedenceConflictResolver", testPrecedenceConflictResolver)
+}
+
+// testConflictSourceImplementsSource tests that conflictSource is a valid Source.
+func testConflictSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(conflictSource))
+}
+
+// testConflictSourceEndpoints tests that only the endpoints of the winning source of conflicts are returned.
+func testConflictSourceEndpoints(t *testing.T) {
+	serviceFoo := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "10.0.0.1")
+	serviceBar := endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "10.0.0.2")
+	serviceBarEU := endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "10.0.0.3").WithSetIdentifier("eu")
+	filesFoo := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "192.168.0.1")
+	filesFooTXT := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeTXT, "owner=files")
+	ingressFoo := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "10.0.1.1")
+	ingressFooOther := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "10.0.1.2")
+	ingressBar := endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "10.0.1.3")
+
+	var sources []Source
+	for _, endpoints := range [][]*endpoint.Endpoint{
+		{serviceFoo, serviceBar, serviceBarEU},
+		{filesFoo, filesFooTXT},
+		{ingressFoo, ingressFooOther, ingressBar},
+	} {
+		src := new(testutils.MockSource)
+		src.On("Endpoints").Return(endpoints, nil)
+		sources = append(sources, src)
+	}
+
+	lost := testutil.ToFloat64(sourceConflictsTotal.WithLabelValues("files", "service"))
+
+	cs := NewConflictSource(sources, []string{"service", "files", "ingress"}, PrecedenceConflictResolver{"files"})
+	endpoints, err := cs.Endpoints()
+	require.NoError(t, err)
+	// The files win for foo, the service wins for bar as the first unlisted source, and
+	// endpoints of other record types or set identifiers don't conflict.
+	assert.Equal(t, []*endpoint.Endpoint{serviceBar, serviceBarEU, filesFoo, filesFooTXT}, endpoints)
+	assert.Equal(t, lost+1, testutil.ToFloat64(sourceConflictsTotal.WithLabelValues("files", "service")))
+
+	cs = NewConflictSource(sources, []string{"service", "files", "ingress"}, PrecedenceConflictResolver{"ingress", "files"})
+	endpoints, err = cs.Endpoints()
+	require.NoError(t, err)
+	// Several endpoints of the winning source are all kept.
+	assert.Equal(t, []*endpoint.Endpoint{serviceBarEU, filesFooTXT, ingressFoo, ingressFooOther, ingressBar}, endpoints)
+}
+
+// testConflictSourceEndpointsWithError tests that an error by a child source is bubbled up.
+func testConflictSourceEndpointsWithError(t *testing.T) {
+	src := new(testutils.MockSource)
+	src.On("Endpoints").Return(nil, errors.New("some error"))
+
+	_, err := NewConflictSource([]Source{src}, []string{"files"}, PrecedenceConflictResolver{}).Endpoints()
+	assert.EqualError(t, err, "some error")
+}
+
+func testPrecedenceConflictResolver(t *testing.T) {
+	r := PrecedenceConflictResolver{"files", "ingress"}
+	assert.Equal(t, "files", r.Resolve([]string{"service", "ingress", "files"}))
+	assert.Equal(t, "ingress", r.Resolve([]string{"service", "ingress"}))
+	assert.Equal(t, "service", r.Resolve([]string{"service", "crd"}))
+	assert.Equal(t, "crd", r.Resolve([]string{"crd", "service"}))
+}
@@ -20,7 +20,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"reflect"
 	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -37,12 +39,26 @@ import (
 
 // crdSource is an implementation of Source that provides endpoints by listing
 // specified CRD and fetching Endpoints embedded in Spec.
+//
+// It reports the results of applying the endpoints in the status of the resources, see
+// ReportApplyResult.
 type crdSource struct {
 	crdClient        rest.Interface
 	namespace        string
 	crdResource      string
 	codec            runtime.ParameterCodec
 	annotationFilter string
+
+	// The resources of the last listing, whose status reports the results of applying them
+	listedMux sync.Mutex
+	listed    []listedDNSEndpoint
+}
+
+// listedDNSEndpoint is a listed DNSEndpoint resource.
+type listedDNSEndpoint struct {
+	object endpoint.DNSEndpoint
+	// The reasons the source rejected endpoints of the spec, by index
+	invalid map[int]string
 }
 
 func addKnownTypes(scheme *runtime.Scheme, groupVersion schema.GroupVersion) error {
@@ -129,12 +145,14 @@ func (cs *crdSource) Endpoints() ([]*endpoint.Endpoint, error) {
 		return nil, err
 	}
 
+	var listed []listedDNSEndpoint
 	for _, dnsEndpoint := range result.Items {
-		// Make sure that all endpoints have targets for A or CNAME type
 		crdEndpoints := []*endpoint.Endpoint{}
-		for _, ep := range dnsEndpoint.Spec.Endpoints {
-			if (ep.RecordType == "CNAME" || ep.RecordType == "A" || ep.RecordType == "AAAA") && len(ep.Targets) < 1 {
-				log.Warnf("Endpoint %s with DNSName %s has an empty list of targets", dnsEndpoint.ObjectMeta.Name, ep.DNSName)
+		invalid := map[int]string{}
+		for i, ep := range dnsEndpoint.Spec.Endpoints {
+			if err := (endpoint.ValidationRules{}).Validate(ep); err != nil {
+				log.Warnf("Endpoint %s with DNSName %s is invalid: %v", dnsEndpoint.ObjectMeta.Name, ep.DNSName, err)
+				invalid[i] = err.Error()
 				continue
 			}
 
@@ -146,6 +164,7 @@ func (cs *crdSource) Endpoints() ([]*endpoint.Endpoint, error) {
 				}
 			}
 			if illegalTarget {
+				invalid[i] = "targets must not end with a dot"
 				log.Warnf("Endpoint %s with DNSName %s has an illegal target. The subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com')", dnsEndpoint.ObjectMeta.Name, ep.DNSName)
 				continue
 			}
@@ -160,19 +179,78 @@ func (cs *crdSource) Endpoints() ([]*endpoint.Endpoint, error) {
 		cs.setResourceLabel(&dnsEndpoint, crdEndpoints)
 		endpoints = append(endpoints, crdEndpoints...)
 
-		if dnsEndpoint.Status.ObservedGeneration == dnsEndpoint.Generation {
+		if dnsEndpoint.Status.ObservedGeneration != dnsEndpoint.Generation {
+			dnsEndpoint.Status.ObservedGeneration = dnsEndpoint.Generation
+			// Update the ObservedGeneration
+			updated, err := cs.UpdateStatus(&dnsEndpoint)
+			if err != nil {
+				log.Warnf("Could not update ObservedGeneration of the CRD: %v", err)
+			} else {
+				dnsEndpoint.ResourceVersion = updated.ResourceVersion
+			}
+		}
+		listed = append(listed, listedDNSEndpoint{object: dnsEndpoint, invalid: invalid})
+	}
+
+	cs.listedMux.Lock()
+	cs.listed = listed
+	cs.listedMux.Unlock()
+
+	return endpoints, nil
+}
+
+// ReportApplyResult reports the result of applying the endpoints of the resources of the last
+// listing in their status: endpoints the source rejected are Invalid, endpoints whose changes
+// failed to be applied are Failed, and all others are Applied. Statuses are only updated when
+// they change.
+func (cs *crdSource) ReportApplyResult(changed []*endpoint.Endpoint, err error) {
+	failed := map[string]bool{}
+	if err != nil {
+		for _, ep := range changed {
+			failed[recordStatusKey(ep.DNSName, ep.RecordType, ep.SetIdentifier)] = true
+		}
+	}
+
+	cs.listedMux.Lock()
+	defer cs.listedMux.Unlock()
+	for i := range cs.listed {
+		listed := &cs.listed[i]
+		status := listed.object.Status.DeepCopy()
+		status.Endpoints = nil
+		for j, ep := range listed.object.Spec.Endpoints {
+			recordStatus := endpoint.DNSEndpointRecordStatus{
+				DNSName:       ep.DNSName,
+				RecordType:    ep.RecordType,
+				SetIdentifier: ep.SetIdentifier,
+				Status:        endpoint.DNSEndpointRecordApplied,
+			}
+			if reason, ok := listed.invalid[j]; ok {
+				recordStatus.Status = endpoint.DNSEndpointRecordInvalid
+				recordStatus.Message = reason
+			} else if failed[recordStatusKey(ep.DNSName, ep.RecordType, ep.SetIdentifier)] {
+				recordStatus.Status = endpoint.DNSEndpointRecordFailed
+				recordStatus.Message = err.Error()
+			}
+			status.Endpoints = append(status.Endpoints, recordStatus)
+		}
+		if reflect.DeepEqual(*status, listed.object.Status) {
 			continue
 		}
 
-		dnsEndpoint.Status.ObservedGeneration = dnsEndpoint.Generation
-		// Update the ObservedGeneration
-		_, err = cs.UpdateStatus(&dnsEndpoint)
+		object := listed.object.DeepCopy()
+		object.Status = *status
+		updated, err := cs.UpdateStatus(object)
 		if err != nil {
-			log.Warnf("Could not update ObservedGeneration of the CRD: %v", err)
+			log.Warnf("Could not update the status of %s/%s: %v", object.Namespace, object.Name, err)
+			continue
 		}
+		listed.object.Status = *status
+		listed.object.ResourceVersion = updated.ResourceVersion
 	}
+}
 
-	return endpoints, nil
+func recordStatusKey(dnsName, recordType, setIdentifier string) string {
+	return strings.TrimSuffix(dnsName, ".") + "/" + recordType + "/" + setIdentifier
 }
 
 func (cs *crdSource) setResourceLabel(crd *endpoint.DNSEndpoint, endpoints []*endpoint.Endpoint) {
@@ -19,6 +19,7 @@ package source
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -26,6 +27,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -117,6 +119,7 @@ func TestCRDSource(t *testing.T) {
 	suite.Run(t, new(CRDSuite))
 	t.Run("Interface", testCRDSourceImplementsSource)
 	t.Run("Endpoints", testCRDSourceEndpoints)
+	t.Run("ReportApplyResult", testCRDSourceReportApplyResult)
 }
 
 // testCRDSourceImplementsSource tests that crdSource is a valid Source.
@@ -354,3 +357,75 @@ func validateCRDResource(t *testing.T, src Source, expectError bool) {
 		}
 	}
 }
+
+// testCRDSourceReportApplyResult tests that the results of applying the endpoints are reported in the status.
+func testCRDSourceReportApplyResult(t *testing.T) {
+	groupVersion := schema.GroupVersion{Group: "test.k8s.io", Version: "v1alpha1"}
+	scheme := runtime.NewScheme()
+	require.NoError(t, addKnownTypes(scheme, groupVersion))
+	codecFactory := serializer.WithoutConversionCodecFactory{CodecFactory: serializer.NewCodecFactory(scheme)}
+
+	dnsEndpoint := &endpoint.DNSEndpoint{
+		TypeMeta:   metav1.TypeMeta{APIVersion: groupVersion.String(), Kind: "DNSEndpoint"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", Generation: 1, ResourceVersion: "1"},
+		Spec: endpoint.DNSEndpointSpec{Endpoints: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+			endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+			{DNSName: "invalid.example.org", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"foo.example.org."}},
+		}},
+	}
+	var updates []endpoint.DNSEndpoint
+	client := &fake.RESTClient{
+		GroupVersion:         groupVersion,
+		VersionedAPIPath:     "/apis/" + groupVersion.String(),
+		NegotiatedSerializer: codecFactory,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			codec := codecFactory.LegacyCodec(groupVersion)
+			switch req.Method {
+			case http.MethodGet:
+				list := &endpoint.DNSEndpointList{Items: []endpoint.DNSEndpoint{*dnsEndpoint}}
+				return &http.Response{StatusCode: http.StatusOK, Header: defaultHeader(), Body: objBody(codec, list)}, nil
+			case http.MethodPut:
+				var body endpoint.DNSEndpoint
+				if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+					return nil, err
+				}
+				updates = append(updates, body)
+				dnsEndpoint.Status = body.Status
+				dnsEndpoint.ResourceVersion = fmt.Sprint(len(updates) + 1)
+				return &http.Response{StatusCode: http.StatusOK, Header: defaultHeader(), Body: objBody(codec, dnsEndpoint)}, nil
+			}
+			return nil, fmt.Errorf("unexpected request: %#v", req.URL)
+		}),
+	}
+	cs, err := NewCRDSource(client, "default", "DNSEndpoint", "", scheme)
+	require.NoError(t, err)
+	reporter := cs.(ApplyResultReporter)
+
+	endpoints, err := cs.Endpoints()
+	require.NoError(t, err)
+	require.Len(t, endpoints, 2)
+	require.Len(t, updates, 1)
+	assert.Equal(t, int64(1), updates[0].Status.ObservedGeneration)
+
+	reporter.ReportApplyResult([]*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")}, errors.New("throttled"))
+	require.Len(t, updates, 2)
+	assert.Equal(t, "2", updates[1].ResourceVersion)
+	assert.Equal(t, int64(1), updates[1].Status.ObservedGeneration)
+	require.Len(t, updates[1].Status.Endpoints, 3)
+	assert.Equal(t, endpoint.DNSEndpointRecordStatus{DNSName: "foo.example.org", RecordType: endpoint.RecordTypeA, Status: endpoint.DNSEndpointRecordFailed, Message: "throttled"}, updates[1].Status.Endpoints[0])
+	assert.Equal(t, endpoint.DNSEndpointRecordStatus{DNSName: "bar.example.org", RecordType: endpoint.RecordTypeA, Status: endpoint.DNSEndpointRecordApplied}, updates[1].Status.Endpoints[1])
+	assert.Equal(t, endpoint.DNSEndpointRecordInvalid, updates[1].Status.Endpoints[2].Status)
+	assert.NotEmpty(t, updates[1].Status.Endpoints[2].Message)
+
+	// Unchanged statuses aren't updated.
+	reporter.ReportApplyResult([]*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")}, errors.New("throttled"))
+	assert.Len(t, updates, 2)
+
+	_, err = cs.Endpoints()
+	require.NoError(t, err)
+	reporter.ReportApplyResult([]*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")}, nil)
+	require.Len(t, updates, 3)
+	assert.Equal(t, endpoint.DNSEndpointRecordApplied, updates[2].Status.Endpoints[0].Status)
+	assert.Empty(t, updates[2].Status.Endpoints[0].Message)
+}
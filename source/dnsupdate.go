@@ -0,0 +1,408 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/fileutils"
+)
+
+// dnsUpdateKey identifies an RRset of the dnsUpdateSource.
+type dnsUpdateKey struct {
+	name       string
+	recordType string
+}
+
+// dnsUpdateSource is an implementation of Source that accepts RFC 2136 dynamic updates,
+// so legacy dynamic DNS clients, e.g. DHCP servers, can register records.
+//
+// Updates must be signed with the configured TSIG key and may only touch names in the
+// configured zone. A, CNAME and TXT records are supported. Prerequisites are checked as
+// described in RFC 2136 section 3.2. The records are kept in memory and, if a file is
+// configured, persisted as an endpoints document so they survive restarts.
+type dnsUpdateSource struct {
+	zone string
+	file string
+
+	sync.Mutex
+	records  map[dnsUpdateKey]*endpoint.Endpoint
+	handlers []func()
+}
+
+// NewDNSUpdateSource creates a new dnsUpdateSource accepting updates for the given zone on
+// the given address, via UDP and TCP.
+func NewDNSUpdateSource(listenAddress, zone, tsigKeyName, tsigSecret, file string) (Source, error) {
+	if tsigKeyName == "" || tsigSecret == "" {
+		return nil, fmt.Errorf("dnsupdate source tsig key name and secret must not be empty")
+	}
+
+	ds, err := newDNSUpdateSource(zone, file)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenPacket("udp", listenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %v", listenAddress, err)
+	}
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %v", listenAddress, err)
+	}
+
+	udp := ds.server(tsigKeyName, tsigSecret)
+	udp.PacketConn = conn
+	tcp := ds.server(tsigKeyName, tsigSecret)
+	tcp.Listener = listener
+	for _, srv := range []*dns.Server{udp, tcp} {
+		go func(srv *dns.Server) {
+			log.Fatal(srv.ActivateAndServe())
+		}(srv)
+	}
+
+	return ds, nil
+}
+
+func newDNSUpdateSource(zone, file string) (*dnsUpdateSource, error) {
+	if zone == "" {
+		return nil, fmt.Errorf("dnsupdate source zone must not be empty")
+	}
+
+	ds := &dnsUpdateSource{
+		zone:    strings.ToLower(dns.Fqdn(zone)),
+		file:    file,
+		records: map[dnsUpdateKey]*endpoint.Endpoint{},
+	}
+
+	if file != "" {
+		data, err := ioutil.ReadFile(file)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return nil, err
+		default:
+			endpoints, err := decodeEndpointsDocument(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", file, err)
+			}
+			for _, ep := range endpoints {
+				ds.records[dnsUpdateKey{ep.DNSName, ep.RecordType}] = ep
+			}
+		}
+	}
+
+	return ds, nil
+}
+
+// server returns a DNS server handling updates signed with the given TSIG key.
+func (ds *dnsUpdateSource) server(tsigKeyName, tsigSecret string) *dns.Server {
+	return &dns.Server{
+		Handler:       ds,
+		TsigSecret:    map[string]string{dns.Fqdn(tsigKeyName): tsigSecret},
+		MsgAcceptFunc: acceptDNSUpdate,
+	}
+}
+
+// acceptDNSUpdate accepts requests and leaves the validation of their sections to
+// ServeDNS. The default accept function rejects updates.
+func acceptDNSUpdate(dh dns.Header) dns.MsgAcceptAction {
+	if dh.Bits&(1<<15) != 0 {
+		return dns.MsgIgnore
+	}
+	return dns.MsgAccept
+}
+
+// Endpoints returns endpoint objects.
+func (ds *dnsUpdateSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	ds.Lock()
+	defer ds.Unlock()
+
+	endpoints := ds.endpoints()
+
+	log.Debugf("Found %d endpoints registered via dynamic updates", len(endpoints))
+
+	return endpoints, nil
+}
+
+// endpoints returns copies of the records sorted by name and type. It must be called
+// with the lock held.
+func (ds *dnsUpdateSource) endpoints() []*endpoint.Endpoint {
+	endpoints := make([]*endpoint.Endpoint, 0, len(ds.records))
+	for _, ep := range ds.records {
+		endpoints = append(endpoints, ep.DeepCopy())
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].DNSName != endpoints[j].DNSName {
+			return endpoints[i].DNSName < endpoints[j].DNSName
+		}
+		return endpoints[i].RecordType < endpoints[j].RecordType
+	})
+	return endpoints
+}
+
+// ServeDNS answers a dynamic update request.
+func (ds *dnsUpdateSource) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+
+	if r.Opcode != dns.OpcodeUpdate {
+		m.Rcode = dns.RcodeNotImplemented
+	} else if tsig := r.IsTsig(); tsig == nil || w.TsigStatus() != nil {
+		log.Warnf("Refusing unsigned or badly signed dynamic update from %s", w.RemoteAddr())
+		m.Rcode = dns.RcodeNotAuth
+	} else {
+		m.Rcode = ds.update(r)
+		// Clients treat signed NOTAUTH responses as TSIG failures.
+		if m.Rcode != dns.RcodeNotAuth {
+			m.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+		}
+	}
+
+	if err := w.WriteMsg(m); err != nil {
+		log.Debugf("Failed to answer dynamic update from %s: %v", w.RemoteAddr(), err)
+	}
+}
+
+// update checks the prerequisites of the given update and applies it. It returns the
+// response code.
+func (ds *dnsUpdateSource) update(r *dns.Msg) int {
+	if len(r.Question) != 1 || r.Question[0].Qtype != dns.TypeSOA || !strings.EqualFold(r.Question[0].Name, ds.zone) {
+		return dns.RcodeNotAuth
+	}
+	for _, section := range [][]dns.RR{r.Answer, r.Ns} {
+		for _, rr := range section {
+			if !dns.IsSubDomain(ds.zone, strings.ToLower(rr.Header().Name)) {
+				return dns.RcodeNotZone
+			}
+		}
+	}
+
+	ds.Lock()
+	if rcode := ds.checkPrerequisites(r.Answer); rcode != dns.RcodeSuccess {
+		ds.Unlock()
+		return rcode
+	}
+	for _, rr := range r.Ns {
+		if h := rr.Header(); h.Class == dns.ClassINET {
+			if _, _, ok := dnsUpdateValue(rr); !ok {
+				ds.Unlock()
+				log.Warnf("Refusing dynamic update of unsupported %s record %s", dns.TypeToString[h.Rrtype], h.Name)
+				return dns.RcodeRefused
+			}
+		}
+	}
+
+	changed := false
+	for _, rr := range r.Ns {
+		if ds.apply(rr) {
+			changed = true
+		}
+	}
+	if changed {
+		if err := ds.persist(); err != nil {
+			log.Errorf("Failed to persist dynamic updates to %s: %v", ds.file, err)
+		}
+	}
+	handlers := ds.handlers
+	ds.Unlock()
+
+	if changed {
+		log.Infof("Applied dynamic update with %d records", len(r.Ns))
+		for _, handler := range handlers {
+			handler()
+		}
+	}
+	return dns.RcodeSuccess
+}
+
+// checkPrerequisites checks the prerequisite section of an update. It must be called with
+// the lock held.
+func (ds *dnsUpdateSource) checkPrerequisites(prerequisites []dns.RR) int {
+	values := map[dnsUpdateKey]endpoint.Targets{}
+
+	for _, rr := range prerequisites {
+		h := rr.Header()
+		name := strings.ToLower(strings.TrimSuffix(h.Name, "."))
+		if h.Ttl != 0 {
+			return dns.RcodeFormatError
+		}
+
+		switch h.Class {
+		case dns.ClassANY:
+			if h.Rrtype == dns.TypeANY && !ds.inUse(name) {
+				return dns.RcodeNameError
+			}
+			if h.Rrtype != dns.TypeANY && ds.records[dnsUpdateKey{name, dns.TypeToString[h.Rrtype]}] == nil {
+				return dns.RcodeNXRrset
+			}
+		case dns.ClassNONE:
+			if h.Rrtype == dns.TypeANY && ds.inUse(name) {
+				return dns.RcodeYXDomain
+			}
+			if h.Rrtype != dns.TypeANY && ds.records[dnsUpdateKey{name, dns.TypeToString[h.Rrtype]}] != nil {
+				return dns.RcodeYXRrset
+			}
+		case dns.ClassINET:
+			recordType, value, ok := dnsUpdateValue(rr)
+			if !ok {
+				return dns.RcodeNXRrset
+			}
+			key := dnsUpdateKey{name, recordType}
+			values[key] = append(values[key], value)
+		default:
+			return dns.RcodeFormatError
+		}
+	}
+
+	// Value dependent prerequisites require the RRsets to consist of exactly the given records.
+	for key, targets := range values {
+		ep := ds.records[key]
+		if ep == nil {
+			return dns.RcodeNXRrset
+		}
+		for _, target := range targets {
+			if !containsTarget(ep.Targets, target) {
+				return dns.RcodeNXRrset
+			}
+		}
+		for _, target := range ep.Targets {
+			if !containsTarget(targets, target) {
+				return dns.RcodeNXRrset
+			}
+		}
+	}
+
+	return dns.RcodeSuccess
+}
+
+// inUse returns whether the given name owns any RRset. It must be called with the lock held.
+func (ds *dnsUpdateSource) inUse(name string) bool {
+	for key := range ds.records {
+		if key.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// apply applies a record of the update section and returns whether it changed the records.
+// It must be called with the lock held.
+func (ds *dnsUpdateSource) apply(rr dns.RR) bool {
+	h := rr.Header()
+	name := strings.ToLower(strings.TrimSuffix(h.Name, "."))
+
+	switch h.Class {
+	case dns.ClassINET:
+		recordType, value, _ := dnsUpdateValue(rr)
+		key := dnsUpdateKey{name, recordType}
+		ep := ds.records[key]
+		if ep == nil || recordType == endpoint.RecordTypeCNAME {
+			ds.records[key] = endpoint.NewEndpointWithTTL(name, recordType, endpoint.TTL(h.Ttl), value)
+			return ep == nil || ep.Targets[0] != value || ep.RecordTTL != endpoint.TTL(h.Ttl)
+		}
+		if containsTarget(ep.Targets, value) && ep.RecordTTL == endpoint.TTL(h.Ttl) {
+			return false
+		}
+		if !containsTarget(ep.Targets, value) {
+			ep.Targets = append(ep.Targets, value)
+		}
+		// All records of an RRset share the TTL of the record added last.
+		ep.RecordTTL = endpoint.TTL(h.Ttl)
+		return true
+	case dns.ClassANY:
+		changed := false
+		for key := range ds.records {
+			if key.name == name && (h.Rrtype == dns.TypeANY || key.recordType == dns.TypeToString[h.Rrtype]) {
+				delete(ds.records, key)
+				changed = true
+			}
+		}
+		return changed
+	case dns.ClassNONE:
+		recordType, value, ok := dnsUpdateValue(rr)
+		if !ok {
+			return false
+		}
+		key := dnsUpdateKey{name, recordType}
+		ep := ds.records[key]
+		if ep == nil || !containsTarget(ep.Targets, value) {
+			return false
+		}
+		targets := endpoint.Targets{}
+		for _, target := range ep.Targets {
+			if target != value {
+				targets = append(targets, target)
+			}
+		}
+		if len(targets) == 0 {
+			delete(ds.records, key)
+		} else {
+			ep.Targets = targets
+		}
+		return true
+	}
+	return false
+}
+
+// persist writes the records to the file as an endpoints document. It must be called with
+// the lock held.
+func (ds *dnsUpdateSource) persist() error {
+	if ds.file == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(endpoint.DNSEndpointSpec{Endpoints: ds.endpoints()})
+	if err != nil {
+		return err
+	}
+	return fileutils.WriteFileAtomically(ds.file, data)
+}
+
+// dnsUpdateValue returns the record type and the target of a supported record.
+func dnsUpdateValue(rr dns.RR) (string, string, bool) {
+	switch r := rr.(type) {
+	case *dns.A:
+		return endpoint.RecordTypeA, r.A.String(), true
+	case *dns.CNAME:
+		return endpoint.RecordTypeCNAME, strings.ToLower(strings.TrimSuffix(r.Target, ".")), true
+	case *dns.TXT:
+		return endpoint.RecordTypeTXT, strings.Join(r.Txt, ""), true
+	}
+	return "", "", false
+}
+
+func (ds *dnsUpdateSource) AddEventHandler(ctx context.Context, handler func()) {
+	ds.Lock()
+	defer ds.Unlock()
+
+	ds.handlers = append(ds.handlers, handler)
+}
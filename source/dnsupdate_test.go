@@ -0,0 +1,234 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	testTSIGKeyName = "dhcp-key."
+	testTSIGSecret  = "bWFrZSB0aGlzIHNlY3JldA=="
+)
+
+func TestDNSUpdateSource(t *testing.T) {
+	t.Run("Interface", testDNSUpdateSourceImplementsSource)
+	t.Run("NewDNSUpdateSource", testDNSUpdateSourceNewDNSUpdateSource)
+	t.Run("Endpoints", testDNSUpdateSourceEndpoints)
+	t.Run("Authentication", testDNSUpdateSourceAuthentication)
+}
+
+// testDNSUpdateSourceImplementsSource tests that dnsUpdateSource is a valid Source.
+func testDNSUpdateSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(dnsUpdateSource))
+}
+
+// testDNSUpdateSourceNewDNSUpdateSource tests that NewDNSUpdateSource validates its configuration.
+func testDNSUpdateSourceNewDNSUpdateSource(t *testing.T) {
+	_, err := NewDNSUpdateSource("127.0.0.1:0", "", testTSIGKeyName, testTSIGSecret, "")
+	assert.Error(t, err)
+
+	_, err = NewDNSUpdateSource("127.0.0.1:0", "example.org", "", testTSIGSecret, "")
+	assert.Error(t, err)
+
+	_, err = NewDNSUpdateSource("127.0.0.1:0", "example.org", testTSIGKeyName, "", "")
+	assert.Error(t, err)
+}
+
+// startDNSUpdateServer serves the given source on a local UDP port and returns its address.
+func startDNSUpdateServer(t *testing.T, ds *dnsUpdateSource) (string, func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	srv := ds.server(testTSIGKeyName, testTSIGSecret)
+	srv.PacketConn = conn
+	srv.NotifyStartedFunc = func() { close(started) }
+	go srv.ActivateAndServe()
+	<-started
+
+	return conn.LocalAddr().String(), func() { srv.Shutdown() }
+}
+
+func sendDNSUpdate(t *testing.T, address string, m *dns.Msg, sign bool) int {
+	c := &dns.Client{TsigSecret: map[string]string{testTSIGKeyName: testTSIGSecret}}
+	if sign {
+		m.SetTsig(testTSIGKeyName, dns.HmacSHA256, 300, time.Now().Unix())
+	}
+	r, _, err := c.Exchange(m, address)
+	require.NoError(t, err)
+	return r.Rcode
+}
+
+// testDNSUpdateSourceEndpoints tests that updates are applied, persisted and reported to
+// the event handlers.
+func testDNSUpdateSourceEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-dnsupdate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "endpoints.json")
+
+	ds, err := newDNSUpdateSource("Example.org", file)
+	require.NoError(t, err)
+	address, stop := startDNSUpdateServer(t, ds)
+	defer stop()
+
+	// The handlers run before the response is sent, so the events are queued once it's received.
+	events := make(chan struct{}, 10)
+	ds.AddEventHandler(context.Background(), func() { events <- struct{}{} })
+
+	for _, tc := range []struct {
+		title  string
+		zone   string
+		update func(m *dns.Msg)
+		rcode  int
+	}{
+		{
+			title: "adds records",
+			zone:  "example.org.",
+			update: func(m *dns.Msg) {
+				m.Insert([]dns.RR{
+					mustRR(t, "host-1.example.org. 300 IN A 10.0.0.1"),
+					mustRR(t, "host-1.example.org. 300 IN A 10.0.0.2"),
+					mustRR(t, "host-1.example.org. 300 IN TXT \"owner=dhcp\""),
+				})
+			},
+			rcode: dns.RcodeSuccess,
+		},
+		{
+			title: "checks that names are unused",
+			zone:  "example.org.",
+			update: func(m *dns.Msg) {
+				m.NameNotUsed([]dns.RR{mustRR(t, "host-1.example.org. 300 IN A 10.0.0.3")})
+				m.Insert([]dns.RR{mustRR(t, "host-1.example.org. 300 IN A 10.0.0.3")})
+			},
+			rcode: dns.RcodeYXDomain,
+		},
+		{
+			title: "checks that rrsets exist",
+			zone:  "example.org.",
+			update: func(m *dns.Msg) {
+				m.RRsetUsed([]dns.RR{mustRR(t, "host-2.example.org. 300 IN A 10.0.0.3")})
+				m.Insert([]dns.RR{mustRR(t, "host-2.example.org. 300 IN A 10.0.0.3")})
+			},
+			rcode: dns.RcodeNXRrset,
+		},
+		{
+			title: "checks the values of rrsets",
+			zone:  "example.org.",
+			update: func(m *dns.Msg) {
+				m.Used([]dns.RR{mustRR(t, "host-1.example.org. 0 IN A 10.0.0.1")})
+				m.RemoveRRset([]dns.RR{mustRR(t, "host-1.example.org. 300 IN A 10.0.0.1")})
+			},
+			rcode: dns.RcodeNXRrset,
+		},
+		{
+			title: "deletes records",
+			zone:  "example.org.",
+			update: func(m *dns.Msg) {
+				m.Used([]dns.RR{mustRR(t, "host-1.example.org. 0 IN A 10.0.0.1"), mustRR(t, "host-1.example.org. 0 IN A 10.0.0.2")})
+				m.Remove([]dns.RR{mustRR(t, "host-1.example.org. 300 IN A 10.0.0.1")})
+				m.Insert([]dns.RR{mustRR(t, "alias.example.org. 600 IN CNAME host-1.example.org.")})
+			},
+			rcode: dns.RcodeSuccess,
+		},
+		{
+			title: "rejects names outside of the zone",
+			zone:  "example.org.",
+			update: func(m *dns.Msg) {
+				m.Insert([]dns.RR{mustRR(t, "host-1.example.com. 300 IN A 10.0.0.4")})
+			},
+			rcode: dns.RcodeNotZone,
+		},
+		{
+			title: "rejects other zones",
+			zone:  "example.com.",
+			update: func(m *dns.Msg) {
+				m.Insert([]dns.RR{mustRR(t, "host-1.example.com. 300 IN A 10.0.0.4")})
+			},
+			rcode: dns.RcodeNotAuth,
+		},
+		{
+			title: "refuses unsupported records",
+			zone:  "example.org.",
+			update: func(m *dns.Msg) {
+				m.Insert([]dns.RR{mustRR(t, "host-1.example.org. 300 IN MX 10 mail.example.org.")})
+			},
+			rcode: dns.RcodeRefused,
+		},
+		{
+			title: "deletes rrsets",
+			zone:  "example.org.",
+			update: func(m *dns.Msg) {
+				m.RemoveRRset([]dns.RR{mustRR(t, "host-1.example.org. 300 IN TXT \"owner=dhcp\"")})
+			},
+			rcode: dns.RcodeSuccess,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			m := new(dns.Msg)
+			m.SetUpdate(tc.zone)
+			tc.update(m)
+			assert.Equal(t, tc.rcode, sendDNSUpdate(t, address, m, true))
+		})
+	}
+	assert.Len(t, events, 3)
+
+	expected := []*endpoint.Endpoint{
+		{DNSName: "host-1.example.org", Targets: endpoint.Targets{"10.0.0.2"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+		{DNSName: "alias.example.org", Targets: endpoint.Targets{"host-1.example.org"}, RecordType: endpoint.RecordTypeCNAME, RecordTTL: 600},
+	}
+	endpoints, err := ds.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, expected)
+
+	// The records are restored after a restart.
+	restarted, err := newDNSUpdateSource("example.org", file)
+	require.NoError(t, err)
+	endpoints, err = restarted.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, expected)
+}
+
+// testDNSUpdateSourceAuthentication tests that unsigned updates are rejected.
+func testDNSUpdateSourceAuthentication(t *testing.T) {
+	ds, err := newDNSUpdateSource("example.org", "")
+	require.NoError(t, err)
+	address, stop := startDNSUpdateServer(t, ds)
+	defer stop()
+
+	m := new(dns.Msg)
+	m.SetUpdate("example.org.")
+	m.Insert([]dns.RR{mustRR(t, "host-1.example.org. 300 IN A 10.0.0.1")})
+	assert.Equal(t, dns.RcodeNotAuth, sendDNSUpdate(t, address, m, false))
+
+	endpoints, err := ds.Endpoints()
+	require.NoError(t, err)
+	assert.Empty(t, endpoints)
+}
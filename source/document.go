@@ -0,0 +1,505 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf16"
+
+	log "github.com/sirupsen/logrus"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// ReadEndpointsFile reads an endpoints document in JSON or YAML from a file, see
+// decodeEndpointsDocument.
+func ReadEndpointsFile(path string) ([]*endpoint.Endpoint, error) {
+	data, err := readTextFile(path)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := decodeEndpointsText(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return endpoints, nil
+}
+
+// ReadEndpoints reads an endpoints document in JSON or YAML, see decodeEndpointsDocument.
+func ReadEndpoints(r io.Reader) ([]*endpoint.Endpoint, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data, _, err = decodeText(data)
+	if err != nil {
+		return nil, err
+	}
+	return decodeEndpointsText(data)
+}
+
+// decodeEndpointsText decodes an endpoints document in JSON or YAML, converted by decodeText.
+func decodeEndpointsText(data []byte) ([]*endpoint.Endpoint, error) {
+	document, err := k8syaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode endpoints document: %v", err)
+	}
+	return decodeEndpointsDocument(document)
+}
+
+// readTextFile reads a text file, e.g. an endpoints document, in UTF-8 with LF line endings.
+// Files produced on Windows are converted: byte order marks are dropped, UTF-16 is converted to
+// UTF-8 and CRLF line endings are converted to LF.
+func readTextFile(path string) ([]byte, error) {
+	return readFileSystemText(osFileSystem{}, path)
+}
+
+// readFileSystemText reads a text file of a file system like readTextFile.
+func readFileSystemText(fsys http.FileSystem, path string) ([]byte, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	data, encoding, err := decodeText(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	if encoding != "UTF-8" {
+		log.Debugf("Converted %s from %s", path, encoding)
+	}
+	return data, nil
+}
+
+// decodeText returns the text in UTF-8 with LF line endings and the detected encoding. UTF-16
+// without a byte order mark is detected by the zero bytes of ASCII characters, which don't occur
+// in UTF-8 text.
+func decodeText(data []byte) ([]byte, string, error) {
+	var encoding string
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(data, []byte{0xef, 0xbb, 0xbf}):
+		encoding, data = "UTF-8 with BOM", data[3:]
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		encoding, order, data = "UTF-16LE with BOM", binary.LittleEndian, data[2:]
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		encoding, order, data = "UTF-16BE with BOM", binary.BigEndian, data[2:]
+	case len(data) >= 2 && data[0] != 0 && data[1] == 0:
+		encoding, order = "UTF-16LE", binary.LittleEndian
+	case len(data) >= 2 && data[0] == 0 && data[1] != 0:
+		encoding, order = "UTF-16BE", binary.BigEndian
+	default:
+		encoding = "UTF-8"
+	}
+	if order != nil {
+		if len(data)%2 != 0 {
+			return nil, encoding, fmt.Errorf("invalid %s text: odd number of bytes", encoding)
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = order.Uint16(data[2*i:])
+		}
+		data = []byte(string(utf16.Decode(units)))
+	}
+	if bytes.Contains(data, []byte("\r\n")) {
+		encoding += " with CRLF line endings"
+		data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	}
+	return data, encoding, nil
+}
+
+// decodeEndpointsDocument decodes an endpoints document. The document uses the same
+// schema as the spec of a DNSEndpoint resource, e.g.
+//
+//	{"endpoints": [{"dnsName": "foo.example.org", "recordType": "A", "targets": ["10.0.0.1"]}]}
+//
+// Endpoints without a name or targets are rejected. Targets of MX records hold the preference
+// and the mail exchange, e.g. "10 mail.example.org", targets of SRV records the priority, the
+// weight, the port and the target host, e.g. "10 5 5060 sip.example.org", and both are
+// normalized. Internationalized names, e.g. "bücher.example.org", are converted to punycode, see
+// endpoint.ToPunycode.
+//
+// Endpoints may weight their targets for providers supporting weighted answers, e.g.
+//
+//	{"dnsName": "foo.example.org", "targets": ["10.0.0.1", "10.0.0.2"], "weights": {"10.0.0.1": 3, "10.0.0.2": 1}}
+//
+// The weights are passed on as provider specific properties, see endpoint.ProviderSpecificTargetWeightPrefix.
+//
+// Endpoints may also set the DNS class of their records, e.g. "class": "CH", which is passed on
+// as a provider specific property unless it's the default IN, see endpoint.ProviderSpecificClass.
+//
+// Endpoints may name the DNS provider their records are routed to if several are configured,
+// e.g. "provider": "cloudflare", see endpoint.ProviderSpecificProvider.
+//
+// Targets of TXT records may span several lines, which are joined without their surrounding
+// whitespace, or be base64-encoded with "encoding": "base64", e.g. for long DKIM keys. Targets
+// are quoted, and those longer than 255 bytes split into several quoted strings, see txtTarget.
+//
+// Endpoints with a name template are expanded into one endpoint per target, named by executing
+// the template with the index of the target, starting at 1, e.g. "nameTemplate": "node-{{.Index}}.example.org"
+// names the endpoints of the targets node-1.example.org to node-N.example.org.
+//
+// Endpoints may list aliases, which are created as CNAME records pointing at the name of the
+// endpoint, e.g. "aliases": ["www.example.org"], see aliasEndpoints.
+//
+// Documents may define named groups of addresses once, which endpoints reference in addition to
+// or instead of their targets, so an address is changed in a single place, e.g.
+//
+//	{"addressGroups": {"web-pool": ["10.0.0.1", "10.0.0.2"]},
+//	 "endpoints": [{"dnsName": "foo.example.org", "addressGroups": ["web-pool"]}]}
+//
+// Documents written by generators may record when they were generated in RFC 3339, e.g.
+// "generatedAt": "2020-06-04T11:00:00Z", see filesStalenessSource.
+func decodeEndpointsDocument(data []byte) ([]*endpoint.Endpoint, error) {
+	var document endpointsDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to decode endpoints document: %v", err)
+	}
+	return document.endpoints()
+}
+
+// decodeStrictEndpointsDocument decodes an endpoints document like decodeEndpointsDocument, but
+// rejects unknown fields, so typos like "adresses" fail instead of silently dropping values.
+// Top-level fields starting with "x-" are ignored, e.g. to hold the defaults shared by the
+// anchors of YAML documents.
+func decodeStrictEndpointsDocument(data []byte) ([]*endpoint.Endpoint, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode endpoints document: %v", err)
+	}
+	for name := range fields {
+		if strings.HasPrefix(name, "x-") {
+			delete(fields, name)
+		}
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode endpoints document: %v", err)
+	}
+
+	var document endpointsDocument
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to decode endpoints document: %v", err)
+	}
+	return document.endpoints()
+}
+
+// endpointsDocument is an endpoints document, see decodeEndpointsDocument.
+type endpointsDocument struct {
+	GeneratedAt   string              `json:"generatedAt,omitempty"`
+	AddressGroups map[string][]string `json:"addressGroups,omitempty"`
+	Endpoints     []*documentEndpoint `json:"endpoints,omitempty"`
+}
+
+// endpoints returns the endpoints of the document.
+func (document *endpointsDocument) endpoints() ([]*endpoint.Endpoint, error) {
+	if document.GeneratedAt != "" {
+		if _, err := time.Parse(time.RFC3339, document.GeneratedAt); err != nil {
+			return nil, fmt.Errorf("endpoints document has an invalid generatedAt: %v", err)
+		}
+	}
+	endpoints := make([]*endpoint.Endpoint, 0, len(document.Endpoints))
+	for i, entry := range document.Endpoints {
+		var ep *endpoint.Endpoint
+		if entry != nil {
+			ep = entry.Endpoint
+		}
+		if ep == nil || ep.DNSName == "" {
+			return nil, fmt.Errorf("endpoint %d of endpoints document has no dnsName", i)
+		}
+		for _, group := range entry.AddressGroups {
+			addresses, ok := document.AddressGroups[group]
+			if !ok {
+				return nil, fmt.Errorf("endpoint %s of endpoints document references unknown address group %q", ep.DNSName, group)
+			}
+			for _, address := range addresses {
+				if !targetsContain(ep.Targets, address) {
+					ep.Targets = append(ep.Targets, address)
+				}
+			}
+		}
+		if len(ep.Targets) == 0 {
+			return nil, fmt.Errorf("endpoint %s of endpoints document has no targets", ep.DNSName)
+		}
+		dnsName, err := endpoint.ToPunycode(strings.TrimSuffix(ep.DNSName, "."))
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %s of endpoints document has an invalid name: %v", ep.DNSName, err)
+		}
+		ep.DNSName = dnsName
+		if ep.RecordType == "" {
+			ep.RecordType = suitableType(ep.Targets[0])
+		}
+		switch entry.Encoding {
+		case "":
+		case "base64":
+			if ep.RecordType != endpoint.RecordTypeTXT {
+				return nil, fmt.Errorf("endpoint %s of endpoints document has encoded targets, but isn't a TXT record", ep.DNSName)
+			}
+			for j, target := range ep.Targets {
+				decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(target), ""))
+				if err != nil {
+					return nil, fmt.Errorf("endpoint %s of endpoints document has an invalid base64 target: %v", ep.DNSName, err)
+				}
+				ep.Targets[j] = string(decoded)
+			}
+		default:
+			return nil, fmt.Errorf("endpoint %s of endpoints document has unknown encoding %q", ep.DNSName, entry.Encoding)
+		}
+		if ep.RecordType == endpoint.RecordTypeTXT {
+			for j, target := range ep.Targets {
+				ep.Targets[j] = txtTarget(target)
+			}
+		}
+		if ep.RecordType == endpoint.RecordTypeMX {
+			for j, target := range ep.Targets {
+				mx, err := endpoint.ParseMXTarget(target)
+				if err != nil {
+					return nil, fmt.Errorf("endpoint %s of endpoints document: %v", ep.DNSName, err)
+				}
+				ep.Targets[j] = mx.String()
+			}
+		}
+		if ep.RecordType == endpoint.RecordTypeSRV {
+			for j, target := range ep.Targets {
+				srv, err := endpoint.ParseSRVTarget(target)
+				if err != nil {
+					return nil, fmt.Errorf("endpoint %s of endpoints document: %v", ep.DNSName, err)
+				}
+				ep.Targets[j] = srv.String()
+			}
+		}
+		for target := range entry.Weights {
+			if !targetsContain(ep.Targets, target) {
+				return nil, fmt.Errorf("endpoint %s of endpoints document has a weight for unknown target %q", ep.DNSName, target)
+			}
+		}
+		for _, target := range ep.Targets {
+			if weight, ok := entry.Weights[target]; ok {
+				ep.ProviderSpecific = append(ep.ProviderSpecific, endpoint.ProviderSpecificProperty{
+					Name:  endpoint.ProviderSpecificTargetWeightPrefix + target,
+					Value: strconv.FormatUint(uint64(weight), 10),
+				})
+			}
+		}
+		switch class := strings.ToUpper(entry.Class); class {
+		case "", "IN":
+		case "CH", "HS", "CS":
+			ep.ProviderSpecific = append(ep.ProviderSpecific, endpoint.ProviderSpecificProperty{Name: endpoint.ProviderSpecificClass, Value: class})
+		default:
+			return nil, fmt.Errorf("endpoint %s of endpoints document has unknown class %q", ep.DNSName, entry.Class)
+		}
+		if entry.Provider != "" {
+			ep.ProviderSpecific = append(ep.ProviderSpecific, endpoint.ProviderSpecificProperty{Name: endpoint.ProviderSpecificProvider, Value: entry.Provider})
+		}
+		if ep.Labels == nil {
+			ep.Labels = endpoint.NewLabels()
+		}
+		if entry.NameTemplate == "" {
+			aliases, err := aliasEndpoints(ep, entry.Aliases)
+			if err != nil {
+				return nil, fmt.Errorf("endpoint %s of endpoints document: %v", ep.DNSName, err)
+			}
+			endpoints = append(endpoints, ep)
+			endpoints = append(endpoints, aliases...)
+			continue
+		}
+		if len(entry.Aliases) > 0 {
+			return nil, fmt.Errorf("endpoint %s of endpoints document has both aliases and a name template", ep.DNSName)
+		}
+		expanded, err := expandEndpoint(ep, entry.NameTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %s of endpoints document: %v", ep.DNSName, err)
+		}
+		endpoints = append(endpoints, expanded...)
+	}
+
+	return endpoints, nil
+}
+
+// documentEndpoint is an endpoint of an endpoints document.
+type documentEndpoint struct {
+	*endpoint.Endpoint
+	// The weights of the targets, by target
+	Weights map[string]uint32 `json:"weights,omitempty"`
+	// The DNS class of the records, IN if empty
+	Class string `json:"class,omitempty"`
+	// The name of the DNS provider the records are routed to, the default provider if empty
+	Provider string `json:"provider,omitempty"`
+	// The encoding of the targets of TXT records, "base64" or empty for plain text
+	Encoding string `json:"encoding,omitempty"`
+	// The template of the names of one endpoint per target, see expandEndpoint
+	NameTemplate string `json:"nameTemplate,omitempty"`
+	// The names of CNAME records pointing at the name of the endpoint
+	Aliases []string `json:"aliases,omitempty"`
+	// The names of the address groups of the document whose addresses are added to the targets
+	AddressGroups []string `json:"addressGroups,omitempty"`
+}
+
+// nameTemplateData is the data of the name template of an endpoint of an endpoints document.
+type nameTemplateData struct {
+	// The index of the target, starting at 1
+	Index int
+	// The target
+	Target string
+}
+
+// expandEndpoint expands an endpoint into one endpoint per target, named by executing the name
+// template for the target. The weight of a target is kept for its endpoint only.
+func expandEndpoint(ep *endpoint.Endpoint, nameTemplate string) ([]*endpoint.Endpoint, error) {
+	tmpl, err := template.New("nameTemplate").Option("missingkey=error").Parse(nameTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse name template: %v", err)
+	}
+
+	endpoints := make([]*endpoint.Endpoint, 0, len(ep.Targets))
+	names := make(map[string]bool, len(ep.Targets))
+	for i, target := range ep.Targets {
+		var name strings.Builder
+		if err := tmpl.Execute(&name, nameTemplateData{Index: i + 1, Target: target}); err != nil {
+			return nil, fmt.Errorf("failed to execute name template: %v", err)
+		}
+		dnsName, err := endpoint.ToPunycode(strings.TrimSuffix(name.String(), "."))
+		if err != nil {
+			return nil, fmt.Errorf("name template returns an invalid name for target %s: %v", target, err)
+		}
+		if dnsName == "" {
+			return nil, fmt.Errorf("name template returns no name for target %s", target)
+		}
+		if names[dnsName] {
+			return nil, fmt.Errorf("name template returns %s for several targets", dnsName)
+		}
+		names[dnsName] = true
+
+		expanded := endpoint.NewEndpointWithTTL(dnsName, ep.RecordType, ep.RecordTTL, target)
+		expanded.SetIdentifier = ep.SetIdentifier
+		for k, v := range ep.Labels {
+			expanded.Labels[k] = v
+		}
+		expanded.ProviderSpecific = targetProviderSpecific(ep.ProviderSpecific, target)
+		endpoints = append(endpoints, expanded)
+	}
+	return endpoints, nil
+}
+
+// aliasEndpoints returns the CNAME endpoints of the aliases of an endpoint, pointing at the name
+// of the endpoint. They keep the TTL, the set identifier and the labels of the endpoint, but not
+// its provider specific properties, which apply to its targets.
+func aliasEndpoints(ep *endpoint.Endpoint, aliases []string) ([]*endpoint.Endpoint, error) {
+	endpoints := make([]*endpoint.Endpoint, 0, len(aliases))
+	names := make(map[string]bool, len(aliases))
+	for _, alias := range aliases {
+		dnsName, err := endpoint.ToPunycode(strings.TrimSuffix(alias, "."))
+		if err != nil {
+			return nil, fmt.Errorf("invalid alias %s: %v", alias, err)
+		}
+		if dnsName == "" {
+			return nil, fmt.Errorf("empty alias")
+		}
+		if dnsName == ep.DNSName {
+			return nil, fmt.Errorf("alias %s is the name of the endpoint", alias)
+		}
+		if names[dnsName] {
+			return nil, fmt.Errorf("alias %s is listed several times", alias)
+		}
+		names[dnsName] = true
+
+		cname := endpoint.NewEndpointWithTTL(dnsName, endpoint.RecordTypeCNAME, ep.RecordTTL, ep.DNSName)
+		cname.SetIdentifier = ep.SetIdentifier
+		for k, v := range ep.Labels {
+			cname.Labels[k] = v
+		}
+		endpoints = append(endpoints, cname)
+	}
+	return endpoints, nil
+}
+
+// maxTXTStringLength is the maximum length of a character string of a TXT record, see RFC 1035.
+const maxTXTStringLength = 255
+
+// txtEscaper escapes the quotes and backslashes of quoted character strings of TXT records.
+var txtEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+
+// txtTarget returns the target of a TXT record for a value of an endpoints document. The lines of
+// multi-line values are joined without their surrounding whitespace. Values are quoted like the
+// TXT records of the TXT registry, and values longer than 255 bytes are split into chunks of 255
+// bytes, which are quoted and separated by spaces, e.g. "v=DKIM1; k=rsa; p=MIIB..." "...IDAQAB",
+// so providers create a single record of several strings. Values which are quoted already are
+// kept.
+func txtTarget(value string) string {
+	if strings.Contains(value, "\n") {
+		lines := strings.Split(value, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimSpace(line)
+		}
+		value = strings.Join(lines, "")
+	}
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return value
+	}
+
+	var chunks []string
+	for len(chunks) == 0 || len(value) > 0 {
+		n := maxTXTStringLength
+		if len(value) < n {
+			n = len(value)
+		}
+		chunks = append(chunks, `"`+txtEscaper.Replace(value[:n])+`"`)
+		value = value[n:]
+	}
+	return strings.Join(chunks, " ")
+}
+
+func targetsContain(targets endpoint.Targets, target string) bool {
+	for _, t := range targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// targetProviderSpecific returns the provider specific properties of an endpoint for an endpoint
+// with only one of its targets, dropping the weights of the other targets.
+func targetProviderSpecific(providerSpecific endpoint.ProviderSpecific, target string) endpoint.ProviderSpecific {
+	var result endpoint.ProviderSpecific
+	for _, property := range providerSpecific {
+		if strings.HasPrefix(property.Name, endpoint.ProviderSpecificTargetWeightPrefix) &&
+			property.Name != endpoint.ProviderSpecificTargetWeightPrefix+target {
+			continue
+		}
+		result = append(result, property)
+	}
+	return result
+}
@@ -0,0 +1,397 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	// FilesConflictOverride lets endpoints of later files replace those of earlier files
+	FilesConflictOverride = "override"
+	// FilesConflictFail fails if several files define the same endpoint
+	FilesConflictFail = "fail"
+)
+
+var (
+	filesSourceErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "source",
+			Name:      "files_errors_total",
+			Help:      "Number of errors reading or decoding the files of the files source, partitioned by path",
+		},
+		[]string{"path"},
+	)
+	filesSourceEndpoints = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "source",
+			Name:      "files_endpoints",
+			Help:      "Number of Endpoints read from the files of the files source, partitioned by path",
+		},
+		[]string{"path"},
+	)
+	filesSourceLastReadTimestamp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "source",
+			Name:      "files_last_read_timestamp_seconds",
+			Help:      "Timestamp of the last successful read of all files of the files source",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(filesSourceErrorsTotal)
+	prometheus.MustRegister(filesSourceEndpoints)
+	prometheus.MustRegister(filesSourceLastReadTimestamp)
+}
+
+// filesSource is an implementation of Source that merges the endpoints documents of an
+// ordered list of local files, e.g. a base inventory followed by per-environment overrides.
+// The documents are in JSON or YAML, whose anchors and merge keys let endpoints share defaults.
+//
+// Endpoints are identified by their name, record type and set identifier. If several files
+// define the same endpoint, the one of the file listed last wins, or an error is returned
+// depending on the conflict policy. Endpoints outside of the domain filter are dropped
+// while reading the files, so mistakes in the files are reported where they are made.
+//
+// Paths may also be directories, e.g. ConfigMaps with several keys mounted as directories. Their
+// files, but for hidden ones, are read in the order of their names as fragments of the path, so
+// several controllers can each own a key of the same ConfigMap. A fragment failing to be read
+// doesn't fail the others: its endpoints of the last successful read are kept instead.
+//
+// If a poll interval is set, the files are checked for changes in that interval, which
+// triggers a synchronization independently of the interval of the controller.
+//
+// The files are local files, or those of another file system, e.g. an inventory embedded in the
+// binary or one held in memory by tests, see NewFilesSourceFromFS.
+type filesSource struct {
+	fsys         http.FileSystem
+	paths        []string
+	conflict     string
+	domainFilter endpoint.DomainFilter
+	pollInterval time.Duration
+	strict       bool
+
+	// The endpoints of the last successful read of the fragments of directories, by path
+	fragmentsMux sync.Mutex
+	fragments    map[string][]*endpoint.Endpoint
+
+	// The endpoints of the last read outside of the domain filter, which are warned about once
+	ignoredMux sync.Mutex
+	ignored    map[string]bool
+}
+
+// NewFilesSource creates a new filesSource reading the given endpoints documents in order. Strict
+// sources reject unknown fields, see decodeStrictEndpointsDocument.
+func NewFilesSource(paths []string, conflict string, domainFilter endpoint.DomainFilter, pollInterval time.Duration, strict bool) (Source, error) {
+	return NewFilesSourceFromFS(osFileSystem{}, paths, conflict, domainFilter, pollInterval, strict)
+}
+
+// NewFilesSourceFromFS creates a new filesSource reading the given endpoints documents of a file
+// system in order. The paths are those the file system opens, e.g. /inventory.yaml for an
+// http.Dir or the http.FS of an embedded file system.
+func NewFilesSourceFromFS(fsys http.FileSystem, paths []string, conflict string, domainFilter endpoint.DomainFilter, pollInterval time.Duration, strict bool) (Source, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no endpoints files specified")
+	}
+	if conflict != FilesConflictOverride && conflict != FilesConflictFail {
+		return nil, fmt.Errorf("invalid files source conflict policy %q", conflict)
+	}
+
+	return &filesSource{
+		fsys:         fsys,
+		paths:        paths,
+		conflict:     conflict,
+		domainFilter: domainFilter,
+		pollInterval: pollInterval,
+		strict:       strict,
+		fragments:    map[string][]*endpoint.Endpoint{},
+	}, nil
+}
+
+// Endpoints returns endpoint objects.
+func (fs *filesSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	type key struct {
+		dnsName, recordType, setIdentifier string
+	}
+
+	var keys []key
+	merged := map[key]*endpoint.Endpoint{}
+	origins := map[key]string{}
+	var ignored []string
+
+	files, err := fs.files()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		path := file.path
+		endpoints, err := fs.read(path)
+		if err != nil && !file.fragment {
+			return nil, err
+		}
+		if file.fragment {
+			endpoints = fs.fragmentEndpoints(path, endpoints, err)
+		}
+
+		for _, ep := range endpoints {
+			if !fs.domainFilter.Match(ep.DNSName) {
+				ignored = append(ignored, fmt.Sprintf("Ignoring endpoint %s %s of %s outside of the domain filter", ep.DNSName, ep.RecordType, path))
+				continue
+			}
+			k := key{ep.DNSName, ep.RecordType, ep.SetIdentifier}
+			if origin, ok := origins[k]; ok {
+				if fs.conflict == FilesConflictFail {
+					return nil, fmt.Errorf("endpoint %s %s is defined in both %s and %s", ep.DNSName, ep.RecordType, origin, path)
+				}
+				log.Debugf("Endpoint %s %s of %s is overridden by %s", ep.DNSName, ep.RecordType, origin, path)
+			} else {
+				keys = append(keys, k)
+			}
+			merged[k] = ep
+			origins[k] = path
+		}
+	}
+
+	endpoints := make([]*endpoint.Endpoint, 0, len(keys))
+	for _, k := range keys {
+		endpoints = append(endpoints, merged[k])
+	}
+	fs.logIgnored(ignored)
+
+	filesSourceLastReadTimestamp.SetToCurrentTime()
+	log.Debugf("Found %d endpoints in %d files", len(endpoints), len(files))
+
+	return endpoints, nil
+}
+
+// logIgnored logs the messages on the endpoints outside of the domain filter. As the files are
+// read again on every poll, endpoints which were already ignored by the last read are only
+// logged at debug level.
+func (fs *filesSource) logIgnored(ignored []string) {
+	fs.ignoredMux.Lock()
+	defer fs.ignoredMux.Unlock()
+
+	current := make(map[string]bool, len(ignored))
+	for _, message := range ignored {
+		if fs.ignored[message] {
+			log.Debug(message)
+		} else {
+			log.Warn(message)
+		}
+		current[message] = true
+	}
+	fs.ignored = current
+}
+
+// filesSourceFile is a file read by the files source.
+type filesSourceFile struct {
+	path string
+	// Whether the file is a fragment of a directory
+	fragment bool
+}
+
+// files returns the files to read: the paths, with directories replaced by their fragments.
+func (fs *filesSource) files() ([]filesSourceFile, error) {
+	var files []filesSourceFile
+	for _, path := range fs.paths {
+		info, err := statFile(fs.fsys, path)
+		if err != nil || !info.IsDir() {
+			// Missing files fail to be read.
+			files = append(files, filesSourceFile{path: path})
+			continue
+		}
+		fragments, err := fragmentPaths(fs.fsys, path)
+		if err != nil {
+			filesSourceErrorsTotal.WithLabelValues(path).Inc()
+			return nil, err
+		}
+		for _, fragment := range fragments {
+			files = append(files, filesSourceFile{path: fragment, fragment: true})
+		}
+	}
+
+	// Forget the fragments which were removed, e.g. the keys removed from a ConfigMap.
+	fs.fragmentsMux.Lock()
+	defer fs.fragmentsMux.Unlock()
+	for path := range fs.fragments {
+		removed := true
+		for _, file := range files {
+			removed = removed && file.path != path
+		}
+		if removed {
+			delete(fs.fragments, path)
+		}
+	}
+	return files, nil
+}
+
+// fragmentPaths returns the paths of the fragments of a directory in the order of their names.
+func fragmentPaths(fsys http.FileSystem, dir string) ([]string, error) {
+	f, err := fsys.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	entries, err := f.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	var paths []string
+	for _, entry := range entries {
+		// Mounted ConfigMaps keep their data in hidden directories, e.g. ..data, which
+		// the keys link to.
+		if strings.HasPrefix(entry.Name(), ".") || entry.IsDir() {
+			continue
+		}
+		// The paths of file systems are separated by slashes, which local paths accept as well.
+		paths = append(paths, path.Join(dir, entry.Name()))
+	}
+	return paths, nil
+}
+
+// read reads the endpoints document of a file.
+func (fs *filesSource) read(path string) ([]*endpoint.Endpoint, error) {
+	data, err := readFileSystemText(fs.fsys, path)
+	if err != nil {
+		filesSourceErrorsTotal.WithLabelValues(path).Inc()
+		return nil, err
+	}
+	// YAML is a superset of JSON, so both are read the same way, resolving the anchors,
+	// aliases and merge keys of YAML documents.
+	document, err := k8syaml.YAMLToJSON(data)
+	if err != nil {
+		filesSourceErrorsTotal.WithLabelValues(path).Inc()
+		return nil, fmt.Errorf("failed to decode endpoints document %s: %v", path, err)
+	}
+	decode := decodeEndpointsDocument
+	if fs.strict {
+		decode = decodeStrictEndpointsDocument
+	}
+	endpoints, err := decode(document)
+	if err != nil {
+		filesSourceErrorsTotal.WithLabelValues(path).Inc()
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	filesSourceEndpoints.WithLabelValues(path).Set(float64(len(endpoints)))
+	return endpoints, nil
+}
+
+// fragmentEndpoints returns the endpoints of a fragment, or its endpoints of the last successful
+// read if it failed to be read.
+func (fs *filesSource) fragmentEndpoints(path string, endpoints []*endpoint.Endpoint, err error) []*endpoint.Endpoint {
+	fs.fragmentsMux.Lock()
+	defer fs.fragmentsMux.Unlock()
+
+	if err == nil {
+		fs.fragments[path] = endpoints
+		return copyEndpoints(endpoints)
+	}
+	last, ok := fs.fragments[path]
+	if !ok {
+		log.Errorf("Ignoring fragment %s: %v", path, err)
+		return nil
+	}
+	log.Errorf("Keeping the %d endpoints of the last read of fragment %s: %v", len(last), path, err)
+	return copyEndpoints(last)
+}
+
+func copyEndpoints(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	result := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		result = append(result, ep.DeepCopy())
+	}
+	return result
+}
+
+// CheckHealth verifies that the files can be read and merged.
+func (fs *filesSource) CheckHealth() error {
+	_, err := fs.Endpoints()
+	return err
+}
+
+func (fs *filesSource) AddEventHandler(ctx context.Context, handler func()) {
+	if fs.pollInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(fs.pollInterval)
+		defer ticker.Stop()
+
+		versions := fs.versions()
+		for {
+			select {
+			case <-ticker.C:
+				current := fs.versions()
+				if !reflect.DeepEqual(current, versions) {
+					log.Debugf("Endpoints files changed, triggering synchronization")
+					versions = current
+					handler()
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// filesVersion identifies the contents of a file without reading it.
+type filesVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+// versions returns the versions of the files by path, with directories replaced by their
+// fragments, as changing a fragment doesn't change its directory. Missing files have the zero
+// version.
+func (fs *filesSource) versions() map[string]filesVersion {
+	versions := map[string]filesVersion{}
+	for _, path := range fs.paths {
+		paths := []string{path}
+		if info, err := statFile(fs.fsys, path); err == nil && info.IsDir() {
+			// Directories which can't be listed have no fragments until they can be listed again.
+			paths, _ = fragmentPaths(fs.fsys, path)
+		}
+		for _, path := range paths {
+			var version filesVersion
+			if info, err := statFile(fs.fsys, path); err == nil {
+				version = filesVersion{modTime: info.ModTime(), size: info.Size()}
+			}
+			versions[path] = version
+		}
+	}
+	return versions
+}
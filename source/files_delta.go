@@ -0,0 +1,290 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/fileutils"
+)
+
+// filesDeltaSource is a Source that applies delta documents to a base endpoints document before
+// reading the endpoints of its wrapped source, which reads the base document, so agents with
+// frequent changes don't have to rewrite a whole inventory. Deltas add endpoints, replacing
+// those of the same name, record type and set identifier, and remove endpoints, e.g.
+//
+//	{"add": [{"dnsName": "foo.example.org", "targets": ["10.0.0.1"]}],
+//	 "remove": [{"dnsName": "bar.example.org", "recordType": "A"}]}
+//
+// Removed endpoints without a record type are removed regardless of their record type. Deltas may
+// also define address groups, which replace those of the base document of the same name.
+//
+// The deltas are the files of the delta directory, but for hidden ones, e.g. those agents write
+// before renaming them, applied in the order of their names and deleted once applied. The base
+// document is maintained by the source. A delta failing to be applied is kept and the deltas
+// after it wait for it to be fixed or removed, so changes are never applied out of order.
+type filesDeltaSource struct {
+	source       Source
+	dir          string
+	base         string
+	pollInterval time.Duration
+
+	mux sync.Mutex
+}
+
+// NewFilesDeltaSource creates a new filesDeltaSource applying the deltas of the directory to the
+// base document, which the provided Source reads.
+func NewFilesDeltaSource(source Source, dir, base string, pollInterval time.Duration) Source {
+	return &filesDeltaSource{source: source, dir: dir, base: base, pollInterval: pollInterval}
+}
+
+// filesDelta is a delta document.
+type filesDelta struct {
+	AddressGroups map[string][]string `json:"addressGroups,omitempty"`
+	Add           []json.RawMessage   `json:"add,omitempty"`
+	Remove        []filesDeltaKey     `json:"remove,omitempty"`
+}
+
+// filesDeltaKey identifies the endpoints of an endpoints document.
+type filesDeltaKey struct {
+	DNSName       string   `json:"dnsName"`
+	RecordType    string   `json:"recordType,omitempty"`
+	SetIdentifier string   `json:"setIdentifier,omitempty"`
+	Targets       []string `json:"targets,omitempty"`
+}
+
+// matches returns whether the key of a removed endpoint matches the key of an endpoint.
+func (k filesDeltaKey) matches(other filesDeltaKey) bool {
+	return strings.EqualFold(strings.TrimSuffix(k.DNSName, "."), strings.TrimSuffix(other.DNSName, ".")) &&
+		(k.RecordType == "" || k.RecordType == other.recordType()) &&
+		k.SetIdentifier == other.SetIdentifier
+}
+
+// recordType returns the record type of an endpoint, derived from its first target if it has none.
+func (k filesDeltaKey) recordType() string {
+	if k.RecordType == "" && len(k.Targets) > 0 {
+		return suitableType(k.Targets[0])
+	}
+	return k.RecordType
+}
+
+// filesDeltaBase is the base document maintained by the source, an endpoints document keeping
+// the endpoints as they were added.
+type filesDeltaBase struct {
+	AddressGroups map[string][]string `json:"addressGroups,omitempty"`
+	Endpoints     []json.RawMessage   `json:"endpoints"`
+}
+
+// Endpoints applies the pending deltas and returns the endpoints of the wrapped source.
+func (ds *filesDeltaSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	if err := ds.applyDeltas(); err != nil {
+		return nil, err
+	}
+	return ds.source.Endpoints()
+}
+
+// applyDeltas applies the deltas of the directory to the base document. It only returns errors
+// of the base document, errors of deltas are logged.
+func (ds *filesDeltaSource) applyDeltas() error {
+	ds.mux.Lock()
+	defer ds.mux.Unlock()
+
+	deltas, err := ds.deltas()
+	if err != nil {
+		filesSourceErrorsTotal.WithLabelValues(ds.dir).Inc()
+		return err
+	}
+
+	base := filesDeltaBase{Endpoints: []json.RawMessage{}}
+	data, err := ioutil.ReadFile(ds.base)
+	if err != nil && !os.IsNotExist(err) {
+		filesSourceErrorsTotal.WithLabelValues(ds.base).Inc()
+		return err
+	}
+	// A missing base document is created, so the wrapped source can read it.
+	missing := err != nil
+	if !missing {
+		if len(deltas) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(data, &base); err != nil {
+			filesSourceErrorsTotal.WithLabelValues(ds.base).Inc()
+			return fmt.Errorf("failed to decode endpoints document %s: %v", ds.base, err)
+		}
+	}
+
+	var applied []string
+	for _, path := range deltas {
+		next, err := applyDelta(base, path)
+		if err != nil {
+			filesSourceErrorsTotal.WithLabelValues(path).Inc()
+			log.Errorf("Failed to apply delta %s, waiting for it to be fixed or removed: %v", path, err)
+			break
+		}
+		base = next
+		applied = append(applied, path)
+	}
+	if len(applied) == 0 && !missing {
+		return nil
+	}
+
+	data, err = json.MarshalIndent(base, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fileutils.WriteFileAtomically(ds.base, data); err != nil {
+		filesSourceErrorsTotal.WithLabelValues(ds.base).Inc()
+		return err
+	}
+	for _, path := range applied {
+		if err := os.Remove(path); err != nil {
+			// The delta would be applied again, which adds and removes the same endpoints.
+			log.Warnf("Failed to delete applied delta %s: %v", path, err)
+		}
+	}
+	if len(applied) > 0 {
+		log.Infof("Applied %d deltas to %s", len(applied), ds.base)
+	}
+	return nil
+}
+
+// deltas returns the paths of the deltas in the order of their names.
+func (ds *filesDeltaSource) deltas() ([]string, error) {
+	entries, err := ioutil.ReadDir(ds.dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") || entry.IsDir() {
+			continue
+		}
+		paths = append(paths, filepath.Join(ds.dir, entry.Name()))
+	}
+	return paths, nil
+}
+
+// applyDelta returns the base document with the delta of the path applied. The result must be
+// a valid endpoints document.
+func applyDelta(base filesDeltaBase, path string) (filesDeltaBase, error) {
+	data, err := readTextFile(path)
+	if err != nil {
+		return base, err
+	}
+	data, err = k8syaml.YAMLToJSON(data)
+	if err != nil {
+		return base, err
+	}
+	var delta filesDelta
+	if err := json.Unmarshal(data, &delta); err != nil {
+		return base, err
+	}
+
+	result := filesDeltaBase{AddressGroups: map[string][]string{}, Endpoints: []json.RawMessage{}}
+	for name, addresses := range base.AddressGroups {
+		result.AddressGroups[name] = addresses
+	}
+	for name, addresses := range delta.AddressGroups {
+		result.AddressGroups[name] = addresses
+	}
+
+	// Added endpoints replace those of the same name, record type and set identifier.
+	removed := append([]filesDeltaKey{}, delta.Remove...)
+	for i, entry := range delta.Add {
+		var key filesDeltaKey
+		if err := json.Unmarshal(entry, &key); err != nil {
+			return base, fmt.Errorf("added endpoint %d: %v", i, err)
+		}
+		key.RecordType = key.recordType()
+		removed = append(removed, key)
+	}
+	for _, entry := range base.Endpoints {
+		var key filesDeltaKey
+		if err := json.Unmarshal(entry, &key); err != nil {
+			return base, err
+		}
+		keep := true
+		for _, k := range removed {
+			keep = keep && !k.matches(key)
+		}
+		if keep {
+			result.Endpoints = append(result.Endpoints, entry)
+		}
+	}
+	result.Endpoints = append(result.Endpoints, delta.Add...)
+
+	// Invalid endpoints fail the delta rather than the base document.
+	document, err := json.Marshal(result)
+	if err != nil {
+		return base, err
+	}
+	if _, err := decodeEndpointsDocument(document); err != nil {
+		return base, err
+	}
+	return result, nil
+}
+
+// CheckHealth verifies the health of the wrapped source.
+func (ds *filesDeltaSource) CheckHealth() error {
+	if checker, ok := ds.source.(HealthChecker); ok {
+		return checker.CheckHealth()
+	}
+	return nil
+}
+
+// AddEventHandler adds the handler to the wrapped source and, if a poll interval is set, checks
+// the delta directory for deltas in that interval, which triggers a synchronization.
+func (ds *filesDeltaSource) AddEventHandler(ctx context.Context, handler func()) {
+	ds.source.AddEventHandler(ctx, handler)
+	if ds.pollInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(ds.pollInterval)
+		defer ticker.Stop()
+
+		var pending []string
+		for {
+			select {
+			case <-ticker.C:
+				// Deltas failing to be applied don't trigger synchronizations again.
+				deltas, err := ds.deltas()
+				if err == nil && len(deltas) > 0 && !reflect.DeepEqual(deltas, pending) {
+					log.Debugf("Found %d deltas, triggering synchronization", len(deltas))
+					handler()
+				}
+				pending = deltas
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
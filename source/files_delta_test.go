@@ -0,0 +1,179 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// Validates that filesDeltaSource is a Source
+var _ Source = &filesDeltaSource{}
+
+func TestFilesDeltaSource(t *testing.T) {
+	t.Run("Endpoints", testFilesDeltaSourceEndpoints)
+	t.Run("InvalidDelta", testFilesDeltaSourceInvalidDelta)
+	t.Run("AddEventHandler", testFilesDeltaSourceAddEventHandler)
+}
+
+// newTestFilesDeltaSource returns a files source reading an inventory and the base document of
+// the deltas of a directory.
+func newTestFilesDeltaSource(t *testing.T, dir string) (Source, string) {
+	inventory := filepath.Join(dir, "inventory.json")
+	require.NoError(t, ioutil.WriteFile(inventory, []byte(`{"endpoints": [{"dnsName": "static.example.org", "targets": ["10.0.0.1"]}]}`), 0644))
+	deltas := filepath.Join(dir, "deltas")
+	require.NoError(t, os.Mkdir(deltas, 0755))
+	base := filepath.Join(dir, "base.json")
+
+	files, err := NewFilesSource([]string{inventory, base}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+	return NewFilesDeltaSource(files, deltas, base, 0), deltas
+}
+
+// testFilesDeltaSourceEndpoints tests that deltas are applied to the base document in order.
+func testFilesDeltaSourceEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ds, deltas := newTestFilesDeltaSource(t, dir)
+
+	// Without deltas, an empty base document is created.
+	endpoints, err := ds.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("static.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+	})
+	assert.FileExists(t, filepath.Join(dir, "base.json"))
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(deltas, "0001.json"), []byte(`{
+		"addressGroups": {"pool": ["10.0.1.1", "10.0.1.2"]},
+		"add": [
+			{"dnsName": "foo.example.org", "targets": ["10.0.0.2"]},
+			{"dnsName": "foo.example.org", "recordType": "TXT", "targets": ["owner=agent"]},
+			{"dnsName": "bar.example.org", "addressGroups": ["pool"]},
+			{"dnsName": "baz.example.org", "targets": ["10.0.0.3"]}
+		]
+	}`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(deltas, "0002.yaml"), []byte(`
+add:
+  - dnsName: foo.example.org
+    targets: [10.0.0.4]
+remove:
+  - dnsName: baz.example.org
+`), 0644))
+	// Hidden files, e.g. deltas being written, aren't applied.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(deltas, ".0003.json"), []byte(`{"add": [`), 0644))
+
+	endpoints, err = ds.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("static.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeTXT, `"owner=agent"`),
+		endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "10.0.1.1", "10.0.1.2"),
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "10.0.0.4"),
+	})
+	for _, name := range []string{"0001.json", "0002.yaml"} {
+		_, err := os.Stat(filepath.Join(deltas, name))
+		assert.True(t, os.IsNotExist(err), name)
+	}
+
+	// The base document keeps the endpoints without deltas.
+	require.NoError(t, os.Remove(filepath.Join(deltas, ".0003.json")))
+	endpoints, err = ds.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 4)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(deltas, "0004.json"), []byte(`{"remove": [{"dnsName": "foo.example.org", "recordType": "A"}, {"dnsName": "bar.example.org"}]}`), 0644))
+	endpoints, err = ds.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("static.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeTXT, `"owner=agent"`),
+	})
+}
+
+// testFilesDeltaSourceInvalidDelta tests that the deltas after an invalid delta wait for it.
+func testFilesDeltaSourceInvalidDelta(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ds, deltas := newTestFilesDeltaSource(t, dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(deltas, "0001.json"), []byte(`{"add": [{"dnsName": "foo.example.org", "targets": ["10.0.0.2"]}]}`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(deltas, "0002.json"), []byte(`{"add": [{"dnsName": "bar.example.org"}]}`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(deltas, "0003.json"), []byte(`{"remove": [{"dnsName": "foo.example.org"}]}`), 0644))
+
+	endpoints, err := ds.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("static.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "10.0.0.2"),
+	})
+	assert.FileExists(t, filepath.Join(deltas, "0002.json"))
+	assert.FileExists(t, filepath.Join(deltas, "0003.json"))
+
+	// Once the invalid delta is fixed, the deltas after it are applied.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(deltas, "0002.json"), []byte(`{"add": [{"dnsName": "bar.example.org", "targets": ["10.0.0.3"]}]}`), 0644))
+	endpoints, err = ds.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("static.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+		endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "10.0.0.3"),
+	})
+}
+
+// testFilesDeltaSourceAddEventHandler tests that new deltas trigger a synchronization.
+func testFilesDeltaSourceAddEventHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	deltas := filepath.Join(dir, "deltas")
+	require.NoError(t, os.Mkdir(deltas, 0755))
+	base := filepath.Join(dir, "base.json")
+	files, err := NewFilesSource([]string{base}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+	ds := NewFilesDeltaSource(files, deltas, base, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	triggered := make(chan struct{}, 1)
+	ds.AddEventHandler(ctx, func() {
+		select {
+		case triggered <- struct{}{}:
+		default:
+		}
+	})
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(deltas, "0001.json"), []byte(`{"add": []}`), 0644))
+	select {
+	case <-triggered:
+	case <-time.After(time.Second):
+		t.Fatal("no synchronization triggered")
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// The files source reads the files of an http.FileSystem, which the local files, the files
+// embedded in the binary, e.g. by http.FS or statik, and the files held in memory all implement.
+
+// NewFilesSourceFromReader creates a new filesSource reading the endpoints document of a reader,
+// e.g. a default inventory embedded in the binary. The document is read once, name identifies it
+// in errors and metrics.
+func NewFilesSourceFromReader(name string, r io.Reader, domainFilter endpoint.DomainFilter, strict bool) (Source, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read endpoints document %s: %v", name, err)
+	}
+	return NewFilesSourceFromFS(memoryFileSystem{name: data}, []string{name}, FilesConflictOverride, domainFilter, 0, strict)
+}
+
+// osFileSystem is the http.FileSystem of the local files, opening paths as they are.
+type osFileSystem struct{}
+
+func (osFileSystem) Open(name string) (http.File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// statFile returns the FileInfo of a file of a file system.
+func statFile(fsys http.FileSystem, name string) (os.FileInfo, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Stat()
+}
+
+// memoryFileSystem is an http.FileSystem of files held in memory, by name.
+type memoryFileSystem map[string][]byte
+
+func (m memoryFileSystem) Open(name string) (http.File, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return &memoryFile{Reader: bytes.NewReader(data), info: memoryFileInfo{name: path.Base(name), size: int64(len(data))}}, nil
+}
+
+// memoryFile is a file of a memoryFileSystem.
+type memoryFile struct {
+	*bytes.Reader
+	info memoryFileInfo
+}
+
+func (f *memoryFile) Close() error {
+	return nil
+}
+
+func (f *memoryFile) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, fmt.Errorf("%s is not a directory", f.info.name)
+}
+
+func (f *memoryFile) Stat() (os.FileInfo, error) {
+	return f.info, nil
+}
+
+// memoryFileInfo is the FileInfo of a memoryFile, which is never modified.
+type memoryFileInfo struct {
+	name string
+	size int64
+}
+
+func (i memoryFileInfo) Name() string       { return i.name }
+func (i memoryFileInfo) Size() int64        { return i.size }
+func (i memoryFileInfo) Mode() os.FileMode  { return 0444 }
+func (i memoryFileInfo) ModTime() time.Time { return time.Time{} }
+func (i memoryFileInfo) IsDir() bool        { return false }
+func (i memoryFileInfo) Sys() interface{}   { return nil }
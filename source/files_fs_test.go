@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestFilesSourceFromFS(t *testing.T) {
+	t.Run("MemoryFileSystem", testFilesSourceFromMemoryFileSystem)
+	t.Run("Directory", testFilesSourceFromDirectory)
+	t.Run("Reader", testFilesSourceFromReader)
+	t.Run("ReadEndpoints", testReadEndpoints)
+}
+
+// testFilesSourceFromMemoryFileSystem tests that the files of a file system are merged in order.
+func testFilesSourceFromMemoryFileSystem(t *testing.T) {
+	fsys := memoryFileSystem{
+		"/base.yaml":     []byte("endpoints:\n  - dnsName: a.example.org\n    targets: [10.0.0.1]\n  - dnsName: b.example.org\n    targets: [10.0.0.2]\n"),
+		"/override.json": []byte(`{"endpoints": [{"dnsName": "b.example.org", "targets": ["10.0.0.3"]}]}`),
+	}
+	fs, err := NewFilesSourceFromFS(fsys, []string{"/base.yaml", "/override.json"}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+
+	endpoints, err := fs.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+		endpoint.NewEndpoint("b.example.org", endpoint.RecordTypeA, "10.0.0.3"),
+	})
+	assert.NoError(t, fs.(HealthChecker).CheckHealth())
+
+	// Files missing from the file system fail to be read.
+	fs, err = NewFilesSourceFromFS(fsys, []string{"/base.yaml", "/missing.json"}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+	_, err = fs.Endpoints()
+	assert.Error(t, err)
+}
+
+// testFilesSourceFromDirectory tests that the directories of a file system are read as fragments.
+func testFilesSourceFromDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "teams"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "teams", "b.json"), []byte(`{"endpoints": [{"dnsName": "b.example.org", "targets": ["10.0.0.2"]}]}`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "teams", "a.json"), []byte(`{"endpoints": [{"dnsName": "a.example.org", "targets": ["10.0.0.1"]}]}`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "teams", ".hidden"), []byte("invalid"), 0644))
+
+	fs, err := NewFilesSourceFromFS(http.Dir(dir), []string{"/teams"}, FilesConflictFail, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+
+	endpoints, err := fs.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+		endpoint.NewEndpoint("b.example.org", endpoint.RecordTypeA, "10.0.0.2"),
+	})
+}
+
+// testFilesSourceFromReader tests that the endpoints document of a reader is read once.
+func testFilesSourceFromReader(t *testing.T) {
+	fs, err := NewFilesSourceFromReader("default.yaml", strings.NewReader("endpoints:\n  - dnsName: a.example.org\n    targets: [10.0.0.1]\n  - dnsName: a.example.com\n    targets: [10.0.0.2]\n"), endpoint.NewDomainFilter([]string{"example.org"}), false)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		endpoints, err := fs.Endpoints()
+		require.NoError(t, err)
+		validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+			endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+		})
+	}
+
+	fs, err = NewFilesSourceFromReader("default.yaml", strings.NewReader("endpoints:\n  - dnsName: a.example.org\n    unknown: true\n"), endpoint.DomainFilter{}, true)
+	require.NoError(t, err)
+	_, err = fs.Endpoints()
+	assert.Error(t, err)
+}
+
+// testReadEndpoints tests that endpoints documents are read from readers.
+func testReadEndpoints(t *testing.T) {
+	endpoints, err := ReadEndpoints(strings.NewReader("\ufeffendpoints:\r\n  - dnsName: a.example.org\r\n    targets: [10.0.0.1]\r\n"))
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+	})
+
+	_, err = ReadEndpoints(strings.NewReader(`{"endpoints": [`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+var (
+	filesSourceHeldTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "source",
+			Name:      "files_held_total",
+			Help:      "Number of sets of endpoints of the files source held back because their count changed by more than the maximum change",
+		},
+	)
+	filesSourceHeld = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "source",
+			Name:      "files_held",
+			Help:      "Whether the files source holds back a set of endpoints whose count changed by more than the maximum change",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(filesSourceHeldTotal)
+	prometheus.MustRegister(filesSourceHeld)
+}
+
+// filesGuardSource is a Source that holds back the endpoints of its wrapped source if their
+// count changed by more than a maximum percentage since the last accepted endpoints, e.g.
+// because an inventory file was truncated, returning the last accepted endpoints instead.
+//
+// Held back endpoints are accepted once the same count was read for the confirmAfter duration,
+// or right away while the force file exists.
+type filesGuardSource struct {
+	source       Source
+	maxChange    int
+	confirmAfter time.Duration
+	forceFile    string
+	now          func() time.Time
+
+	mux       sync.Mutex
+	accepted  []*endpoint.Endpoint
+	heldCount int
+	heldSince time.Time
+}
+
+// NewFilesGuardSource creates a new filesGuardSource wrapping the provided Source. A
+// confirmAfter of 0 accepts held back endpoints only while the force file exists.
+func NewFilesGuardSource(source Source, maxChange int, confirmAfter time.Duration, forceFile string) Source {
+	return &filesGuardSource{
+		source:       source,
+		maxChange:    maxChange,
+		confirmAfter: confirmAfter,
+		forceFile:    forceFile,
+		now:          time.Now,
+	}
+}
+
+// Endpoints collects endpoints from its wrapped source and returns them, or the last accepted
+// endpoints if their count changed by too much.
+func (gs *filesGuardSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	endpoints, err := gs.source.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	gs.mux.Lock()
+	defer gs.mux.Unlock()
+
+	if !gs.exceedsMaxChange(len(endpoints)) {
+		gs.accept(endpoints)
+		return endpoints, nil
+	}
+
+	now := gs.now()
+	if gs.heldSince.IsZero() || gs.heldCount != len(endpoints) {
+		filesSourceHeldTotal.Inc()
+		gs.heldCount = len(endpoints)
+		gs.heldSince = now
+	}
+	if gs.forced() {
+		log.Warnf("Accepting %d endpoints of the files source instead of %d as %s exists", len(endpoints), len(gs.accepted), gs.forceFile)
+		gs.accept(endpoints)
+		return endpoints, nil
+	}
+	if gs.confirmAfter > 0 && now.Sub(gs.heldSince) >= gs.confirmAfter {
+		log.Infof("Accepting %d endpoints of the files source instead of %d after reading them for %s", len(endpoints), len(gs.accepted), gs.confirmAfter)
+		gs.accept(endpoints)
+		return endpoints, nil
+	}
+
+	filesSourceHeld.Set(1)
+	log.Warnf("Holding back %d endpoints of the files source, keeping the %d last accepted endpoints: the count changed by more than %d%%", len(endpoints), len(gs.accepted), gs.maxChange)
+	return copyEndpoints(gs.accepted), nil
+}
+
+// exceedsMaxChange returns whether a count of endpoints changed by more than the maximum change
+// since the last accepted endpoints. Any count is accepted if no endpoints were accepted yet.
+func (gs *filesGuardSource) exceedsMaxChange(count int) bool {
+	last := len(gs.accepted)
+	if last == 0 {
+		return false
+	}
+	change := count - last
+	if change < 0 {
+		change = -change
+	}
+	return change*100 > gs.maxChange*last
+}
+
+func (gs *filesGuardSource) accept(endpoints []*endpoint.Endpoint) {
+	gs.accepted = copyEndpoints(endpoints)
+	gs.heldSince = time.Time{}
+	filesSourceHeld.Set(0)
+}
+
+func (gs *filesGuardSource) forced() bool {
+	if gs.forceFile == "" {
+		return false
+	}
+	_, err := os.Stat(gs.forceFile)
+	return err == nil
+}
+
+// CheckHealth verifies the health of the wrapped source.
+func (gs *filesGuardSource) CheckHealth() error {
+	if checker, ok := gs.source.(HealthChecker); ok {
+		return checker.CheckHealth()
+	}
+	return nil
+}
+
+func (gs *filesGuardSource) AddEventHandler(ctx context.Context, handler func()) {
+	gs.source.AddEventHandler(ctx, handler)
+}
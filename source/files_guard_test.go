@@ -0,0 +1,157 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// Validates that filesGuardSource is a Source
+var _ Source = &filesGuardSource{}
+
+func TestFilesGuardSource(t *testing.T) {
+	t.Run("Endpoints", testFilesGuardSourceEndpoints)
+	t.Run("ForceFile", testFilesGuardSourceForceFile)
+	t.Run("CheckHealth", testFilesGuardSourceCheckHealth)
+}
+
+// writeEndpointsFile writes an endpoints document with the given number of endpoints.
+func writeEndpointsFile(t *testing.T, path string, count int) {
+	var endpoints []string
+	for i := 0; i < count; i++ {
+		endpoints = append(endpoints, fmt.Sprintf(`{"dnsName": "host-%d.example.org", "targets": ["10.0.0.%d"]}`, i, i+1))
+	}
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [`+strings.Join(endpoints, ",")+`]}`), 0644))
+}
+
+// testFilesGuardSourceEndpoints tests that endpoints whose count changed by too much are held
+// back until the same count was read for the confirmation time.
+func testFilesGuardSourceEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.json")
+	files, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+	gs := NewFilesGuardSource(files, 20, time.Minute, "")
+	now := time.Date(2020, 6, 4, 11, 0, 0, 0, time.UTC)
+	gs.(*filesGuardSource).now = func() time.Time { return now }
+
+	held := testutil.ToFloat64(filesSourceHeldTotal)
+
+	writeEndpointsFile(t, path, 10)
+	endpoints, err := gs.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 10)
+
+	// Changes within the maximum change are accepted.
+	writeEndpointsFile(t, path, 12)
+	endpoints, err = gs.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 12)
+
+	// A truncated file is held back.
+	writeEndpointsFile(t, path, 3)
+	endpoints, err = gs.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 12)
+	assert.Equal(t, 1.0, testutil.ToFloat64(filesSourceHeld))
+	assert.Equal(t, held+1, testutil.ToFloat64(filesSourceHeldTotal))
+
+	// A different count restarts the confirmation.
+	now = now.Add(50 * time.Second)
+	writeEndpointsFile(t, path, 4)
+	endpoints, err = gs.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 12)
+	assert.Equal(t, held+2, testutil.ToFloat64(filesSourceHeldTotal))
+
+	now = now.Add(50 * time.Second)
+	endpoints, err = gs.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 12)
+
+	// The same count read for the confirmation time is accepted.
+	now = now.Add(10 * time.Second)
+	endpoints, err = gs.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 4)
+	assert.Equal(t, 0.0, testutil.ToFloat64(filesSourceHeld))
+
+	// The accepted endpoints are the base of later changes.
+	writeEndpointsFile(t, path, 5)
+	endpoints, err = gs.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 4)
+	assert.Equal(t, held+3, testutil.ToFloat64(filesSourceHeldTotal))
+
+	// Errors of the files are returned.
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [`), 0644))
+	_, err = gs.Endpoints()
+	assert.Error(t, err)
+}
+
+// testFilesGuardSourceForceFile tests that held back endpoints are accepted while the force file exists.
+func testFilesGuardSourceForceFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.json")
+	force := filepath.Join(dir, "force")
+	files, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+	gs := NewFilesGuardSource(files, 20, 0, force)
+
+	writeEndpointsFile(t, path, 10)
+	endpoints, err := gs.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 10)
+
+	// Without a confirmation time, held back endpoints are kept until forced.
+	writeEndpointsFile(t, path, 20)
+	gs.(*filesGuardSource).now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+	endpoints, err = gs.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 10)
+
+	require.NoError(t, ioutil.WriteFile(force, nil, 0644))
+	endpoints, err = gs.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 20)
+}
+
+// testFilesGuardSourceCheckHealth tests that the health of the files is checked.
+func testFilesGuardSourceCheckHealth(t *testing.T) {
+	files, err := NewFilesSource([]string{"/nonexistent/endpoints.json"}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+
+	assert.Error(t, NewFilesGuardSource(files, 20, 0, "").(HealthChecker).CheckHealth())
+}
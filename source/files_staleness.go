@@ -0,0 +1,200 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+var (
+	filesSourceAge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "source",
+			Name:      "files_age_seconds",
+			Help:      "Age of the most recently generated file of the files source",
+		},
+	)
+	filesSourceStale = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "source",
+			Name:      "files_stale",
+			Help:      "Whether the files of the files source are older than the maximum age",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(filesSourceAge)
+	prometheus.MustRegister(filesSourceStale)
+}
+
+// filesStalenessSource is a Source that raises an alarm if the files of its wrapped source are
+// older than a maximum age, which usually means their generator died. The age of a file is the
+// time since its generatedAt field, or since it was modified if it has none, and the files are
+// stale if even the most recently generated one is older than the maximum age.
+//
+// If deletions are frozen, endpoints removed from stale files are kept, so an inventory whose
+// generator died doesn't delete records, e.g. when a stale file is replaced by an older backup.
+// Added and updated endpoints are still returned.
+type filesStalenessSource struct {
+	source          Source
+	paths           []string
+	maxAge          time.Duration
+	freezeDeletions bool
+	now             func() time.Time
+
+	mux  sync.Mutex
+	last []*endpoint.Endpoint
+}
+
+// NewFilesStalenessSource creates a new filesStalenessSource checking the age of the files of the
+// given paths, which may be directories like those of the files source.
+func NewFilesStalenessSource(source Source, paths []string, maxAge time.Duration, freezeDeletions bool) Source {
+	return &filesStalenessSource{
+		source:          source,
+		paths:           paths,
+		maxAge:          maxAge,
+		freezeDeletions: freezeDeletions,
+		now:             time.Now,
+	}
+}
+
+// Endpoints collects endpoints from its wrapped source and returns them, with the endpoints
+// removed from stale files added back if deletions are frozen.
+func (ss *filesStalenessSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	endpoints, err := ss.source.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	ss.mux.Lock()
+	defer ss.mux.Unlock()
+
+	generated, ok := ss.generated()
+	if !ok {
+		filesSourceStale.Set(0)
+		ss.last = copyEndpoints(endpoints)
+		return endpoints, nil
+	}
+	age := ss.now().Sub(generated)
+	filesSourceAge.Set(age.Seconds())
+	if age <= ss.maxAge {
+		filesSourceStale.Set(0)
+		ss.last = copyEndpoints(endpoints)
+		return endpoints, nil
+	}
+
+	filesSourceStale.Set(1)
+	log.Warnf("The files of the files source were generated %s ago, more than the maximum age of %s", age.Round(time.Second), ss.maxAge)
+	if ss.freezeDeletions {
+		endpoints = append(endpoints, ss.removed(endpoints)...)
+	}
+	ss.last = copyEndpoints(endpoints)
+	return endpoints, nil
+}
+
+// removed returns the endpoints of the last read missing from the given endpoints.
+func (ss *filesStalenessSource) removed(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	type key struct {
+		dnsName, recordType, setIdentifier string
+	}
+
+	current := map[key]bool{}
+	for _, ep := range endpoints {
+		current[key{ep.DNSName, ep.RecordType, ep.SetIdentifier}] = true
+	}
+	var removed []*endpoint.Endpoint
+	for _, ep := range ss.last {
+		if !current[key{ep.DNSName, ep.RecordType, ep.SetIdentifier}] {
+			removed = append(removed, ep.DeepCopy())
+		}
+	}
+	if len(removed) > 0 {
+		log.Warnf("Keeping %d endpoints removed from the stale files of the files source", len(removed))
+	}
+	return removed
+}
+
+// generated returns when the most recently generated file was generated. Files which can't be
+// read are ignored, the wrapped source reports them.
+func (ss *filesStalenessSource) generated() (time.Time, bool) {
+	var latest time.Time
+	for _, path := range ss.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		paths := []string{path}
+		if info.IsDir() {
+			if paths, err = fragmentPaths(osFileSystem{}, path); err != nil {
+				continue
+			}
+		}
+		for _, path := range paths {
+			if generated, err := fileGenerated(path); err == nil && generated.After(latest) {
+				latest = generated
+			}
+		}
+	}
+	return latest, !latest.IsZero()
+}
+
+// fileGenerated returns the generatedAt time of the endpoints document of a file, or the time
+// it was modified if the document has none.
+func fileGenerated(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	data, err := readTextFile(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var document struct {
+		GeneratedAt string `json:"generatedAt"`
+	}
+	if data, err := k8syaml.YAMLToJSON(data); err == nil && json.Unmarshal(data, &document) == nil && document.GeneratedAt != "" {
+		if generated, err := time.Parse(time.RFC3339, document.GeneratedAt); err == nil {
+			return generated, nil
+		}
+	}
+	return info.ModTime(), nil
+}
+
+// CheckHealth verifies the health of the wrapped source.
+func (ss *filesStalenessSource) CheckHealth() error {
+	if checker, ok := ss.source.(HealthChecker); ok {
+		return checker.CheckHealth()
+	}
+	return nil
+}
+
+func (ss *filesStalenessSource) AddEventHandler(ctx context.Context, handler func()) {
+	ss.source.AddEventHandler(ctx, handler)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// Validates that filesStalenessSource is a Source
+var _ Source = &filesStalenessSource{}
+
+func TestFilesStalenessSource(t *testing.T) {
+	t.Run("Endpoints", testFilesStalenessSourceEndpoints)
+	t.Run("GeneratedAt", testFilesStalenessSourceGeneratedAt)
+	t.Run("InvalidGeneratedAt", testFilesStalenessSourceInvalidGeneratedAt)
+}
+
+// testFilesStalenessSourceEndpoints tests that stale files are reported and that endpoints removed
+// from them are kept if deletions are frozen.
+func testFilesStalenessSourceEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.json")
+	files, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+	ss := NewFilesStalenessSource(files, []string{path}, time.Hour, true)
+	now := time.Now()
+	ss.(*filesStalenessSource).now = func() time.Time { return now }
+
+	writeEndpointsFile(t, path, 3)
+	endpoints, err := ss.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 3)
+	assert.Equal(t, 0.0, testutil.ToFloat64(filesSourceStale))
+
+	// Endpoints removed from stale files are kept, added ones are returned.
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [
+		{"dnsName": "host-0.example.org", "targets": ["10.0.1.1"]},
+		{"dnsName": "new.example.org", "targets": ["10.0.1.2"]}
+	]}`), 0644))
+	require.NoError(t, os.Chtimes(path, now.Add(-2*time.Hour), now.Add(-2*time.Hour)))
+	endpoints, err = ss.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("host-0.example.org", endpoint.RecordTypeA, "10.0.1.1"),
+		endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "10.0.1.2"),
+		endpoint.NewEndpoint("host-1.example.org", endpoint.RecordTypeA, "10.0.0.2"),
+		endpoint.NewEndpoint("host-2.example.org", endpoint.RecordTypeA, "10.0.0.3"),
+	})
+	assert.Equal(t, 1.0, testutil.ToFloat64(filesSourceStale))
+	assert.Equal(t, (2 * time.Hour).Seconds(), testutil.ToFloat64(filesSourceAge))
+
+	// Once the files are fresh again, removed endpoints are dropped.
+	require.NoError(t, os.Chtimes(path, now, now))
+	endpoints, err = ss.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 2)
+	assert.Equal(t, 0.0, testutil.ToFloat64(filesSourceStale))
+
+	// Without freezing deletions, stale files are only reported.
+	ss.(*filesStalenessSource).freezeDeletions = false
+	now = now.Add(2 * time.Hour)
+	writeEndpointsFile(t, path, 1)
+	require.NoError(t, os.Chtimes(path, now.Add(-2*time.Hour), now.Add(-2*time.Hour)))
+	endpoints, err = ss.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 1)
+	assert.Equal(t, 1.0, testutil.ToFloat64(filesSourceStale))
+}
+
+// testFilesStalenessSourceGeneratedAt tests that the generatedAt fields of the documents take
+// precedence over the modification times and that the most recent file counts.
+func testFilesStalenessSourceGeneratedAt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fragments := filepath.Join(dir, "fragments")
+	require.NoError(t, os.Mkdir(fragments, 0755))
+	base := filepath.Join(dir, "base.yaml")
+	require.NoError(t, ioutil.WriteFile(base, []byte(`
+generatedAt: "2020-06-04T10:00:00Z"
+endpoints:
+  - dnsName: foo.example.org
+    targets: [10.0.0.1]
+`), 0644))
+	files, err := NewFilesSource([]string{base, fragments}, FilesConflictOverride, endpoint.DomainFilter{}, 0, true)
+	require.NoError(t, err)
+	ss := NewFilesStalenessSource(files, []string{base, fragments}, time.Hour, false)
+	ss.(*filesStalenessSource).now = func() time.Time { return time.Date(2020, 6, 4, 11, 30, 0, 0, time.UTC) }
+
+	// Strict files sources accept the generatedAt field.
+	_, err = ss.Endpoints()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(filesSourceStale))
+	assert.Equal(t, (90 * time.Minute).Seconds(), testutil.ToFloat64(filesSourceAge))
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(fragments, "bar.json"), []byte(`{"generatedAt": "2020-06-04T11:00:00Z", "endpoints": [{"dnsName": "bar.example.org", "targets": ["10.0.0.2"]}]}`), 0644))
+	_, err = ss.Endpoints()
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, testutil.ToFloat64(filesSourceStale))
+	assert.Equal(t, (30 * time.Minute).Seconds(), testutil.ToFloat64(filesSourceAge))
+}
+
+// testFilesStalenessSourceInvalidGeneratedAt tests that documents with invalid generatedAt fields are rejected.
+func testFilesStalenessSourceInvalidGeneratedAt(t *testing.T) {
+	_, err := decodeEndpointsDocument([]byte(`{"generatedAt": "yesterday", "endpoints": [{"dnsName": "foo.example.org", "targets": ["10.0.0.1"]}]}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,833 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestFilesSource(t *testing.T) {
+	t.Run("Interface", testFilesSourceImplementsSource)
+	t.Run("NewFilesSource", testFilesSourceNewFilesSource)
+	t.Run("Endpoints", testFilesSourceEndpoints)
+	t.Run("MXEndpoints", testFilesSourceMXEndpoints)
+	t.Run("SRVEndpoints", testFilesSourceSRVEndpoints)
+	t.Run("WeightedEndpoints", testFilesSourceWeightedEndpoints)
+	t.Run("ClassEndpoints", testFilesSourceClassEndpoints)
+	t.Run("ProviderEndpoints", testFilesSourceProviderEndpoints)
+	t.Run("TXTEndpoints", testFilesSourceTXTEndpoints)
+	t.Run("NameTemplateEndpoints", testFilesSourceNameTemplateEndpoints)
+	t.Run("InternationalizedEndpoints", testFilesSourceInternationalizedEndpoints)
+	t.Run("AliasEndpoints", testFilesSourceAliasEndpoints)
+	t.Run("AddressGroupEndpoints", testFilesSourceAddressGroupEndpoints)
+	t.Run("YAMLEndpoints", testFilesSourceYAMLEndpoints)
+	t.Run("WindowsEndpoints", testFilesSourceWindowsEndpoints)
+	t.Run("StrictEndpoints", testFilesSourceStrictEndpoints)
+	t.Run("DirectoryEndpoints", testFilesSourceDirectoryEndpoints)
+	t.Run("AddEventHandler", testFilesSourceAddEventHandler)
+	t.Run("AddEventHandlerDirectory", testFilesSourceAddEventHandlerDirectory)
+	t.Run("IgnoredEndpoints", testFilesSourceIgnoredEndpoints)
+	t.Run("CheckHealth", testFilesSourceCheckHealth)
+}
+
+// testFilesSourceImplementsSource tests that filesSource is a valid Source.
+func testFilesSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(filesSource))
+}
+
+// testFilesSourceNewFilesSource tests that NewFilesSource validates its configuration.
+func testFilesSourceNewFilesSource(t *testing.T) {
+	_, err := NewFilesSource(nil, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	assert.Error(t, err)
+
+	_, err = NewFilesSource([]string{"base.json"}, "merge", endpoint.DomainFilter{}, 0, false)
+	assert.Error(t, err)
+
+	_, err = NewFilesSource([]string{"base.json"}, FilesConflictFail, endpoint.DomainFilter{}, 0, false)
+	assert.NoError(t, err)
+}
+
+// testFilesSourceEndpoints tests that the files are merged according to the conflict policy.
+func testFilesSourceEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	base := filepath.Join(dir, "base.json")
+	require.NoError(t, ioutil.WriteFile(base, []byte(`{"endpoints": [
+		{"dnsName": "api.example.org", "targets": ["10.0.0.1"]},
+		{"dnsName": "api.example.org", "recordType": "TXT", "targets": ["owner=base"]},
+		{"dnsName": "db.example.org", "targets": ["10.0.0.2"]}
+	]}`), 0644))
+	staging := filepath.Join(dir, "staging.json")
+	require.NoError(t, ioutil.WriteFile(staging, []byte(`{"endpoints": [
+		{"dnsName": "api.example.org.", "targets": ["10.1.0.1"]},
+		{"dnsName": "cache.example.org", "targets": ["10.1.0.3"]}
+	]}`), 0644))
+
+	for _, tc := range []struct {
+		title        string
+		paths        []string
+		conflict     string
+		domainFilter endpoint.DomainFilter
+		expected     []*endpoint.Endpoint
+		expectErr    bool
+	}{
+		{
+			title:    "later files override earlier files",
+			paths:    []string{base, staging},
+			conflict: FilesConflictOverride,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "api.example.org", Targets: endpoint.Targets{"10.1.0.1"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "api.example.org", Targets: endpoint.Targets{`"owner=base"`}, RecordType: endpoint.RecordTypeTXT},
+				{DNSName: "db.example.org", Targets: endpoint.Targets{"10.0.0.2"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "cache.example.org", Targets: endpoint.Targets{"10.1.0.3"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:    "the order of the files decides",
+			paths:    []string{staging, base},
+			conflict: FilesConflictOverride,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "api.example.org", Targets: endpoint.Targets{"10.0.0.1"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "api.example.org", Targets: endpoint.Targets{`"owner=base"`}, RecordType: endpoint.RecordTypeTXT},
+				{DNSName: "db.example.org", Targets: endpoint.Targets{"10.0.0.2"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "cache.example.org", Targets: endpoint.Targets{"10.1.0.3"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:    "distinct files don't conflict",
+			paths:    []string{base},
+			conflict: FilesConflictFail,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "api.example.org", Targets: endpoint.Targets{"10.0.0.1"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "api.example.org", Targets: endpoint.Targets{`"owner=base"`}, RecordType: endpoint.RecordTypeTXT},
+				{DNSName: "db.example.org", Targets: endpoint.Targets{"10.0.0.2"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:        "endpoints outside of the domain filter are dropped",
+			paths:        []string{base, staging},
+			conflict:     FilesConflictFail,
+			domainFilter: endpoint.NewDomainFilterWithExclusions([]string{"example.org"}, []string{"api.example.org", "cache.example.org"}),
+			expected: []*endpoint.Endpoint{
+				{DNSName: "db.example.org", Targets: endpoint.Targets{"10.0.0.2"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:     "conflicts fail",
+			paths:     []string{base, staging},
+			conflict:  FilesConflictFail,
+			expectErr: true,
+		},
+		{
+			title:     "missing files fail",
+			paths:     []string{base, filepath.Join(dir, "missing.json")},
+			conflict:  FilesConflictOverride,
+			expectErr: true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			fs, err := NewFilesSource(tc.paths, tc.conflict, tc.domainFilter, 0, false)
+			require.NoError(t, err)
+
+			endpoints, err := fs.Endpoints()
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, tc.expected)
+		})
+	}
+
+	// Unreadable files are counted per path.
+	missing := filepath.Join(dir, "missing.json")
+	assert.Equal(t, 1.0, testutil.ToFloat64(filesSourceErrorsTotal.WithLabelValues(missing)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(filesSourceEndpoints.WithLabelValues(staging)))
+}
+
+// testFilesSourceMXEndpoints tests that the preference of MX records is kept and validated.
+func testFilesSourceMXEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.json")
+	fs, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [
+		{"dnsName": "example.org", "recordType": "MX", "targets": ["10 mail.example.org.", "20  backup.example.org"]}
+	]}`), 0644))
+	endpoints, err := fs.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "example.org", Targets: endpoint.Targets{"10 mail.example.org", "20 backup.example.org"}, RecordType: endpoint.RecordTypeMX},
+	})
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [
+		{"dnsName": "example.org", "recordType": "MX", "targets": ["mail.example.org"]}
+	]}`), 0644))
+	_, err = fs.Endpoints()
+	assert.Error(t, err)
+}
+
+// testFilesSourceSRVEndpoints tests that the priority, weight and port of SRV records are kept
+// and validated.
+func testFilesSourceSRVEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.json")
+	fs, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [
+		{"dnsName": "_sip._tcp.example.org", "recordType": "SRV", "targets": ["10 5 5060 sip.example.org.", "20  0 5060 backup.example.org"]}
+	]}`), 0644))
+	endpoints, err := fs.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "_sip._tcp.example.org", Targets: endpoint.Targets{"10 5 5060 sip.example.org", "20 0 5060 backup.example.org"}, RecordType: endpoint.RecordTypeSRV},
+	})
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [
+		{"dnsName": "_sip._tcp.example.org", "recordType": "SRV", "targets": ["sip.example.org"]}
+	]}`), 0644))
+	_, err = fs.Endpoints()
+	assert.Error(t, err)
+}
+
+// testFilesSourceWeightedEndpoints tests that the weights of targets are passed on as provider specific properties.
+func testFilesSourceWeightedEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.json")
+	fs, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [
+		{"dnsName": "foo.example.org", "targets": ["10.0.0.1", "10.0.0.2", "10.0.0.3"], "weights": {"10.0.0.2": 1, "10.0.0.1": 3}},
+		{"dnsName": "bar.example.org", "targets": ["10.0.0.1"], "providerSpecific": [{"name": "alias", "value": "false"}]}
+	]}`), 0644))
+	endpoints, err := fs.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "foo.example.org", Targets: endpoint.Targets{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "bar.example.org", Targets: endpoint.Targets{"10.0.0.1"}, RecordType: endpoint.RecordTypeA},
+	})
+	for _, ep := range endpoints {
+		if ep.DNSName == "foo.example.org" {
+			assert.Equal(t, endpoint.ProviderSpecific{
+				{Name: "weight/10.0.0.1", Value: "3"},
+				{Name: "weight/10.0.0.2", Value: "1"},
+			}, ep.ProviderSpecific)
+		} else {
+			assert.Equal(t, endpoint.ProviderSpecific{{Name: "alias", Value: "false"}}, ep.ProviderSpecific)
+		}
+	}
+
+	for _, document := range []string{
+		`{"endpoints": [{"dnsName": "foo.example.org", "targets": ["10.0.0.1"], "weights": {"10.0.0.2": 1}}]}`,
+		`{"endpoints": [{"dnsName": "foo.example.org", "targets": ["10.0.0.1"], "weights": {"10.0.0.1": -1}}]}`,
+	} {
+		require.NoError(t, ioutil.WriteFile(path, []byte(document), 0644))
+		_, err = fs.Endpoints()
+		assert.Error(t, err, document)
+	}
+}
+
+// testFilesSourceClassEndpoints tests that DNS classes other than IN are passed on as provider specific properties.
+func testFilesSourceClassEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.json")
+	fs, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [
+		{"dnsName": "version.example.org", "recordType": "TXT", "targets": ["1.0"], "class": "ch"},
+		{"dnsName": "foo.example.org", "targets": ["10.0.0.1"], "class": "IN"}
+	]}`), 0644))
+	endpoints, err := fs.Endpoints()
+	require.NoError(t, err)
+	require.Len(t, endpoints, 2)
+	for _, ep := range endpoints {
+		if ep.DNSName == "version.example.org" {
+			assert.Equal(t, endpoint.ProviderSpecific{{Name: endpoint.ProviderSpecificClass, Value: "CH"}}, ep.ProviderSpecific)
+		} else {
+			assert.Empty(t, ep.ProviderSpecific)
+		}
+	}
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [{"dnsName": "foo.example.org", "targets": ["10.0.0.1"], "class": "ANY"}]}`), 0644))
+	_, err = fs.Endpoints()
+	assert.Error(t, err)
+}
+
+// testFilesSourceProviderEndpoints tests that the providers of endpoints are passed on as provider specific properties.
+func testFilesSourceProviderEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.json")
+	fs, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, true)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [
+		{"dnsName": "foo.example.org", "targets": ["10.0.0.1"], "provider": "cloudflare"},
+		{"dnsName": "bar.example.org", "targets": ["10.0.0.2"]}
+	]}`), 0644))
+	endpoints, err := fs.Endpoints()
+	require.NoError(t, err)
+	require.Len(t, endpoints, 2)
+	assert.Equal(t, endpoint.ProviderSpecific{{Name: endpoint.ProviderSpecificProvider, Value: "cloudflare"}}, endpoints[0].ProviderSpecific)
+	assert.Empty(t, endpoints[1].ProviderSpecific)
+}
+
+// testFilesSourceTXTEndpoints tests that targets of TXT records may be multi-line or base64-encoded
+// and that long targets are split into several strings.
+func testFilesSourceTXTEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	key := strings.Repeat("A", 300)
+	path := filepath.Join(dir, "endpoints.json")
+	fs, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [
+		{"dnsName": "multiline.example.org", "recordType": "TXT", "targets": ["v=DKIM1; k=rsa;\n  p=MIIB\n"]},
+		{"dnsName": "encoded.example.org", "recordType": "TXT", "encoding": "base64", "targets": ["`+base64.StdEncoding.EncodeToString([]byte(`v=spf1 include:"example.org" -all`))+`"]},
+		{"dnsName": "long.example.org", "recordType": "TXT", "targets": ["p=`+key+`"]}
+	]}`), 0644))
+	endpoints, err := fs.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "multiline.example.org", RecordType: endpoint.RecordTypeTXT, Targets: endpoint.Targets{`"v=DKIM1; k=rsa;p=MIIB"`}},
+		{DNSName: "encoded.example.org", RecordType: endpoint.RecordTypeTXT, Targets: endpoint.Targets{`"v=spf1 include:\"example.org\" -all"`}},
+		{DNSName: "long.example.org", RecordType: endpoint.RecordTypeTXT, Targets: endpoint.Targets{`"p=` + key[:253] + `" "` + key[253:] + `"`}},
+	})
+
+	for _, document := range []string{
+		`{"endpoints": [{"dnsName": "foo.example.org", "recordType": "TXT", "encoding": "base64", "targets": ["not base64!"]}]}`,
+		`{"endpoints": [{"dnsName": "foo.example.org", "recordType": "TXT", "encoding": "hex", "targets": ["00"]}]}`,
+		`{"endpoints": [{"dnsName": "foo.example.org", "recordType": "A", "encoding": "base64", "targets": ["MTAuMC4wLjE="]}]}`,
+	} {
+		require.NoError(t, ioutil.WriteFile(path, []byte(document), 0644))
+		_, err = fs.Endpoints()
+		assert.Error(t, err, document)
+	}
+}
+
+// testFilesSourceNameTemplateEndpoints tests that endpoints with a name template are expanded into
+// one endpoint per target.
+func testFilesSourceNameTemplateEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.json")
+	fs, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [{
+		"dnsName": "node.example.org",
+		"nameTemplate": "node-{{.Index}}.example.org",
+		"recordTTL": 60,
+		"targets": ["10.0.0.1", "10.0.0.2", "10.0.0.3"],
+		"weights": {"10.0.0.2": 5}
+	}]}`), 0644))
+	endpoints, err := fs.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "node-1.example.org", RecordType: endpoint.RecordTypeA, RecordTTL: 60, Targets: endpoint.Targets{"10.0.0.1"}},
+		{DNSName: "node-2.example.org", RecordType: endpoint.RecordTypeA, RecordTTL: 60, Targets: endpoint.Targets{"10.0.0.2"}},
+		{DNSName: "node-3.example.org", RecordType: endpoint.RecordTypeA, RecordTTL: 60, Targets: endpoint.Targets{"10.0.0.3"}},
+	})
+	for _, ep := range endpoints {
+		if ep.DNSName == "node-2.example.org" {
+			assert.Equal(t, endpoint.ProviderSpecific{{Name: endpoint.ProviderSpecificTargetWeightPrefix + "10.0.0.2", Value: "5"}}, ep.ProviderSpecific)
+		} else {
+			assert.Empty(t, ep.ProviderSpecific)
+		}
+	}
+
+	for _, nameTemplate := range []string{"node-{{.Index", "node-{{.Name}}.example.org", "node.example.org", "{{if false}}x{{end}}"} {
+		require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [{"dnsName": "node.example.org", "nameTemplate": "`+nameTemplate+`", "targets": ["10.0.0.1", "10.0.0.2"]}]}`), 0644))
+		_, err = fs.Endpoints()
+		assert.Error(t, err, nameTemplate)
+	}
+}
+
+// testFilesSourceInternationalizedEndpoints tests that internationalized names are converted to punycode.
+func testFilesSourceInternationalizedEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.json")
+	fs, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [
+		{"dnsName": "Bücher.example.org", "targets": ["10.0.0.1"]},
+		{"dnsName": "xn--wgv71a119e.example.org", "targets": ["10.0.0.2"]},
+		{"dnsName": "_acme-challenge.例え.example.org", "recordType": "TXT", "targets": ["token"]}
+	]}`), 0644))
+	endpoints, err := fs.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "xn--bcher-kva.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"10.0.0.1"}},
+		{DNSName: "xn--wgv71a119e.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"10.0.0.2"}},
+		{DNSName: "_acme-challenge.xn--r8jz45g.example.org", RecordType: endpoint.RecordTypeTXT, Targets: endpoint.Targets{`"token"`}},
+	})
+
+	for _, dnsName := range []string{"bü_cher.example.org", "xn--a.example.org"} {
+		require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [{"dnsName": "`+dnsName+`", "targets": ["10.0.0.1"]}]}`), 0644))
+		_, err = fs.Endpoints()
+		assert.Error(t, err, dnsName)
+	}
+}
+
+// testFilesSourceAliasEndpoints tests that aliases are created as CNAMEs pointing at the name of their endpoint.
+func testFilesSourceAliasEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.json")
+	fs, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [{
+		"dnsName": "web.example.org",
+		"recordTTL": 60,
+		"targets": ["10.0.0.1", "10.0.0.2"],
+		"weights": {"10.0.0.1": 5},
+		"aliases": ["www.example.org.", "bücher.example.org"]
+	}]}`), 0644))
+	endpoints, err := fs.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "web.example.org", RecordType: endpoint.RecordTypeA, RecordTTL: 60, Targets: endpoint.Targets{"10.0.0.1", "10.0.0.2"}},
+		{DNSName: "www.example.org", RecordType: endpoint.RecordTypeCNAME, RecordTTL: 60, Targets: endpoint.Targets{"web.example.org"}},
+		{DNSName: "xn--bcher-kva.example.org", RecordType: endpoint.RecordTypeCNAME, RecordTTL: 60, Targets: endpoint.Targets{"web.example.org"}},
+	})
+	for _, ep := range endpoints {
+		if ep.RecordType == endpoint.RecordTypeCNAME {
+			assert.Empty(t, ep.ProviderSpecific)
+		}
+	}
+
+	for _, entry := range []string{
+		`{"dnsName": "web.example.org", "targets": ["10.0.0.1"], "aliases": ["web.example.org"]}`,
+		`{"dnsName": "web.example.org", "targets": ["10.0.0.1"], "aliases": ["www.example.org", "www.example.org"]}`,
+		`{"dnsName": "web.example.org", "targets": ["10.0.0.1"], "aliases": [""]}`,
+		`{"dnsName": "web.example.org", "targets": ["10.0.0.1"], "aliases": ["bü_cher.example.org"]}`,
+		`{"dnsName": "web.example.org", "nameTemplate": "web-{{.Index}}.example.org", "targets": ["10.0.0.1"], "aliases": ["www.example.org"]}`,
+	} {
+		require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [`+entry+`]}`), 0644))
+		_, err = fs.Endpoints()
+		assert.Error(t, err, entry)
+	}
+}
+
+// testFilesSourceAddressGroupEndpoints tests that endpoints get the addresses of the address groups they reference.
+func testFilesSourceAddressGroupEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.json")
+	fs, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{
+		"addressGroups": {
+			"web-pool": ["10.0.0.1", "10.0.0.2"],
+			"extra-pool": ["10.0.0.2", "10.0.0.3"],
+			"lb-pool": ["lb.example.com"]
+		},
+		"endpoints": [
+			{"dnsName": "web.example.org", "addressGroups": ["web-pool"]},
+			{"dnsName": "api.example.org", "targets": ["10.0.0.4"], "addressGroups": ["web-pool", "extra-pool"]},
+			{"dnsName": "lb.example.org", "addressGroups": ["lb-pool"]}
+		]
+	}`), 0644))
+	endpoints, err := fs.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "web.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"10.0.0.1", "10.0.0.2"}},
+		{DNSName: "api.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"10.0.0.4", "10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+		{DNSName: "lb.example.org", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"lb.example.com"}},
+	})
+
+	for _, document := range []string{
+		`{"endpoints": [{"dnsName": "web.example.org", "addressGroups": ["web-pool"]}]}`,
+		`{"addressGroups": {"web-pool": []}, "endpoints": [{"dnsName": "web.example.org", "addressGroups": ["web-pool"]}]}`,
+	} {
+		require.NoError(t, ioutil.WriteFile(path, []byte(document), 0644))
+		_, err = fs.Endpoints()
+		assert.Error(t, err, document)
+	}
+}
+
+// testFilesSourceWindowsEndpoints tests that documents produced on Windows, with byte order marks,
+// in UTF-16 or with CRLF line endings, are read.
+func testFilesSourceWindowsEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.yaml")
+	fs, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+
+	document := "endpoints:\r\n  - dnsName: bücher.example.org\r\n    targets: [10.0.0.1]\r\n  - dnsName: bücher.example.org\r\n    recordType: TXT\r\n    targets:\r\n      - |\r\n        v=spf1\r\n        -all\r\n"
+	utf16 := func(order binary.ByteOrder, bom bool) []byte {
+		var data []byte
+		if bom {
+			data = make([]byte, 2)
+			order.PutUint16(data, 0xfeff)
+		}
+		for _, r := range document {
+			unit := make([]byte, 2)
+			order.PutUint16(unit, uint16(r))
+			data = append(data, unit...)
+		}
+		return data
+	}
+	for _, tc := range []struct {
+		title string
+		data  []byte
+	}{
+		{title: "UTF-8", data: []byte(document)},
+		{title: "UTF-8 with BOM", data: append([]byte{0xef, 0xbb, 0xbf}, document...)},
+		{title: "UTF-16LE with BOM", data: utf16(binary.LittleEndian, true)},
+		{title: "UTF-16BE with BOM", data: utf16(binary.BigEndian, true)},
+		{title: "UTF-16LE", data: utf16(binary.LittleEndian, false)},
+		{title: "UTF-16BE", data: utf16(binary.BigEndian, false)},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			require.NoError(t, ioutil.WriteFile(path, tc.data, 0644))
+			endpoints, err := fs.Endpoints()
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+				endpoint.NewEndpoint("xn--bcher-kva.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+				endpoint.NewEndpoint("xn--bcher-kva.example.org", endpoint.RecordTypeTXT, `"v=spf1-all"`),
+			})
+		})
+	}
+
+	require.NoError(t, ioutil.WriteFile(path, append([]byte{0xff, 0xfe}, 'e', 0, 'n'), 0644))
+	_, err = fs.Endpoints()
+	assert.Error(t, err)
+}
+
+// testFilesSourceYAMLEndpoints tests that YAML documents are read with their anchors, aliases and merge keys resolved.
+func testFilesSourceYAMLEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.yaml")
+	fs, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+defaults: &defaults
+  recordTTL: 60
+  labels:
+    team: web
+pool: &pool
+  - 10.0.0.1
+  - 10.0.0.2
+endpoints:
+  - <<: *defaults
+    dnsName: foo.example.org
+    targets: *pool
+  - <<: *defaults
+    dnsName: bar.example.org
+    recordTTL: 30
+    targets: *pool
+  - <<: [*defaults]
+    dnsName: baz.example.org
+    targets: [10.0.0.3]
+`), 0644))
+	endpoints, err := fs.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "foo.example.org", RecordType: endpoint.RecordTypeA, RecordTTL: 60, Targets: endpoint.Targets{"10.0.0.1", "10.0.0.2"}},
+		{DNSName: "bar.example.org", RecordType: endpoint.RecordTypeA, RecordTTL: 30, Targets: endpoint.Targets{"10.0.0.1", "10.0.0.2"}},
+		{DNSName: "baz.example.org", RecordType: endpoint.RecordTypeA, RecordTTL: 60, Targets: endpoint.Targets{"10.0.0.3"}},
+	})
+	for _, ep := range endpoints {
+		assert.Equal(t, "web", ep.Labels["team"], ep.DNSName)
+	}
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("endpoints:\n  - <<: *missing\n    dnsName: foo.example.org\n"), 0644))
+	_, err = fs.Endpoints()
+	assert.Error(t, err)
+}
+
+// testFilesSourceStrictEndpoints tests that strict sources reject unknown fields.
+func testFilesSourceStrictEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.yaml")
+	lenient, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+	strict, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, true)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+x-defaults: &defaults
+  recordTTL: 60
+endpoints:
+  - <<: *defaults
+    dnsName: foo.example.org
+    targets: [10.0.0.1]
+    labels: {team: web}
+`), 0644))
+	for _, fs := range []Source{lenient, strict} {
+		endpoints, err := fs.Endpoints()
+		require.NoError(t, err)
+		validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+			{DNSName: "foo.example.org", RecordType: endpoint.RecordTypeA, RecordTTL: 60, Targets: endpoint.Targets{"10.0.0.1"}},
+		})
+	}
+
+	for _, document := range []string{
+		"endpoints:\n  - dnsName: foo.example.org\n    targets: [10.0.0.1]\n    adressGroups: [web-pool]\n",
+		"defaults: {recordTTL: 60}\nendpoints: []\n",
+	} {
+		require.NoError(t, ioutil.WriteFile(path, []byte(document), 0644))
+		_, err = lenient.Endpoints()
+		assert.NoError(t, err, document)
+		_, err = strict.Endpoints()
+		assert.Error(t, err, document)
+	}
+}
+
+// testFilesSourceDirectoryEndpoints tests that the files of directories are merged as fragments,
+// laid out like a mounted ConfigMap, and that a failing fragment doesn't fail the others.
+func testFilesSourceDirectoryEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	data := filepath.Join(dir, "..data")
+	require.NoError(t, os.Mkdir(data, 0755))
+	writeKey := func(key, content string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(data, key), []byte(content), 0644))
+		if _, err := os.Lstat(filepath.Join(dir, key)); os.IsNotExist(err) {
+			require.NoError(t, os.Symlink(filepath.Join("..data", key), filepath.Join(dir, key)))
+		}
+	}
+	writeKey("team-a.json", `{"endpoints": [{"dnsName": "a.example.org", "targets": ["10.0.0.1"]}]}`)
+	writeKey("team-b.yaml", "endpoints:\n  - dnsName: b.example.org\n    targets: [10.0.0.2]\n")
+	writeKey("team-c.json", `{"endpoints": [`)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".hidden"), []byte("invalid"), 0644))
+
+	fs, err := NewFilesSource([]string{dir}, FilesConflictFail, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+
+	// The invalid fragment without a successful read is ignored.
+	endpoints, err := fs.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+		endpoint.NewEndpoint("b.example.org", endpoint.RecordTypeA, "10.0.0.2"),
+	})
+
+	// A fragment failing to be read keeps its endpoints, while the others are updated.
+	writeKey("team-a.json", `{"endpoints": [{"dnsName": "a.example.org", "targets": ["10.0.0.3"]}]}`)
+	writeKey("team-b.yaml", "endpoints: [")
+	writeKey("team-c.json", `{"endpoints": [{"dnsName": "c.example.org", "targets": ["10.0.0.4"]}]}`)
+	endpoints, err = fs.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "10.0.0.3"),
+		endpoint.NewEndpoint("b.example.org", endpoint.RecordTypeA, "10.0.0.2"),
+		endpoint.NewEndpoint("c.example.org", endpoint.RecordTypeA, "10.0.0.4"),
+	})
+
+	// Removed fragments are forgotten.
+	require.NoError(t, os.Remove(filepath.Join(dir, "team-b.yaml")))
+	endpoints, err = fs.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "10.0.0.3"),
+		endpoint.NewEndpoint("c.example.org", endpoint.RecordTypeA, "10.0.0.4"),
+	})
+
+	// Conflicts between fragments still fail.
+	writeKey("team-c.json", `{"endpoints": [{"dnsName": "a.example.org", "targets": ["10.0.0.4"]}]}`)
+	_, err = fs.Endpoints()
+	assert.Error(t, err)
+}
+
+// testFilesSourceAddEventHandler tests that changes of the files trigger the event handler.
+func testFilesSourceAddEventHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	base := filepath.Join(dir, "base.json")
+	require.NoError(t, ioutil.WriteFile(base, []byte(`{"endpoints": []}`), 0644))
+	override := filepath.Join(dir, "override.json")
+
+	fs, err := NewFilesSource([]string{base, override}, FilesConflictOverride, endpoint.DomainFilter{}, 10*time.Millisecond, false)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan struct{}, 10)
+	fs.AddEventHandler(ctx, func() { events <- struct{}{} })
+
+	select {
+	case <-events:
+		t.Fatal("unexpected event for unchanged files")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, ioutil.WriteFile(override, []byte(`{"endpoints": [{"dnsName": "foo.example.org", "targets": ["10.0.0.1"]}]}`), 0644))
+	select {
+	case <-events:
+	case <-time.After(time.Second):
+		t.Fatal("no event for a created file")
+	}
+}
+
+// testFilesSourceAddEventHandlerDirectory tests that changes of the fragments of directories
+// trigger the event handler, although they don't change their directory.
+func testFilesSourceAddEventHandlerDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fragment := filepath.Join(dir, "team-a.json")
+	require.NoError(t, ioutil.WriteFile(fragment, []byte(`{"endpoints": []}`), 0644))
+
+	fs, err := NewFilesSource([]string{dir}, FilesConflictOverride, endpoint.DomainFilter{}, 10*time.Millisecond, false)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan struct{}, 10)
+	fs.AddEventHandler(ctx, func() { events <- struct{}{} })
+
+	select {
+	case <-events:
+		t.Fatal("unexpected event for unchanged fragments")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, ioutil.WriteFile(fragment, []byte(`{"endpoints": [{"dnsName": "foo.example.org", "targets": ["10.0.0.1"]}]}`), 0644))
+	select {
+	case <-events:
+	case <-time.After(time.Second):
+		t.Fatal("no event for a changed fragment")
+	}
+}
+
+// testFilesSourceIgnoredEndpoints tests that endpoints outside of the domain filter are only
+// warned about the first time they're read.
+func testFilesSourceIgnoredEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "endpoints.json")
+	require.NoError(t, ioutil.WriteFile(file, []byte(`{"endpoints": [{"dnsName": "foo.example.com", "targets": ["10.0.0.1"]}]}`), 0644))
+
+	fs, err := NewFilesSource([]string{file}, FilesConflictOverride, endpoint.NewDomainFilter([]string{"example.org"}), 0, false)
+	require.NoError(t, err)
+
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+	warnings := func() int {
+		count := 0
+		for _, entry := range hook.AllEntries() {
+			if entry.Level == log.WarnLevel {
+				count++
+			}
+		}
+		return count
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err = fs.Endpoints()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, warnings())
+
+	// Endpoints ignored again after being fixed are warned about again.
+	require.NoError(t, ioutil.WriteFile(file, []byte(`{"endpoints": []}`), 0644))
+	_, err = fs.Endpoints()
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(file, []byte(`{"endpoints": [{"dnsName": "foo.example.com", "targets": ["10.0.0.1"]}]}`), 0644))
+	_, err = fs.Endpoints()
+	require.NoError(t, err)
+	assert.Equal(t, 2, warnings())
+}
+
+// testFilesSourceCheckHealth tests that unreadable files fail the health check.
+func testFilesSourceCheckHealth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "endpoints.json")
+	fs, err := NewFilesSource([]string{path}, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+	require.NoError(t, err)
+	require.Implements(t, (*HealthChecker)(nil), fs)
+
+	assert.Error(t, fs.(HealthChecker).CheckHealth())
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [{"dnsName": "foo.example.org"}]}`), 0644))
+	assert.Error(t, fs.(HealthChecker).CheckHealth())
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"endpoints": [{"dnsName": "foo.example.org", "targets": ["10.0.0.1"]}]}`), 0644))
+	assert.NoError(t, fs.(HealthChecker).CheckHealth())
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// httpSource is an implementation of Source that provides endpoints by periodically
+// fetching an endpoints document from a remote HTTP server.
+//
+// The last successfully fetched document is cached together with its ETag, so unchanged
+// documents are not transferred and decoded again. If fetching fails, the cached endpoints
+// keep being served for up to staleWhileError before the error is propagated.
+type httpSource struct {
+	client           *http.Client
+	url              string
+	headers          http.Header
+	pollInterval     time.Duration
+	staleWhileError  time.Duration
+	mutex            sync.Mutex
+	etag             string
+	endpoints        []*endpoint.Endpoint
+	lastSuccessfulAt time.Time
+}
+
+// NewHTTPSource creates a new httpSource fetching the endpoints document from the given url.
+// Headers are given in the "Name: value" form.
+func NewHTTPSource(client *http.Client, url string, headers []string, pollInterval, staleWhileError time.Duration) (Source, error) {
+	if url == "" {
+		return nil, fmt.Errorf("http source url must not be empty")
+	}
+
+	header := http.Header{}
+	for _, h := range headers {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid http source header %q, expected \"Name: value\"", h)
+		}
+		header.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+
+	return &httpSource{
+		client:          client,
+		url:             url,
+		headers:         header,
+		pollInterval:    pollInterval,
+		staleWhileError: staleWhileError,
+	}, nil
+}
+
+// Endpoints returns endpoint objects.
+func (hs *httpSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+
+	if _, err := hs.refresh(); err != nil {
+		if hs.lastSuccessfulAt.IsZero() || time.Since(hs.lastSuccessfulAt) > hs.staleWhileError {
+			return nil, err
+		}
+		log.Warnf("Serving endpoints fetched from %s at %s: %v", hs.url, hs.lastSuccessfulAt.Format(time.RFC3339), err)
+	}
+
+	return hs.endpoints, nil
+}
+
+// refresh fetches the endpoints document unless it didn't change since the last
+// fetch and reports whether the cached endpoints were updated.
+func (hs *httpSource) refresh() (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, hs.url, nil)
+	if err != nil {
+		return false, err
+	}
+	for name, values := range hs.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if hs.etag != "" {
+		req.Header.Set("If-None-Match", hs.etag)
+	}
+
+	resp, err := hs.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		log.Debugf("Endpoints document at %s did not change", hs.url)
+		hs.lastSuccessfulAt = time.Now()
+		return false, nil
+	case http.StatusOK:
+	default:
+		return false, fmt.Errorf("failed to fetch endpoints document from %s: %s", hs.url, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+
+	endpoints, err := decodeEndpointsDocument(data)
+	if err != nil {
+		return false, err
+	}
+
+	log.Debugf("Received endpoints from %s: %#v", hs.url, endpoints)
+
+	hs.etag = resp.Header.Get("ETag")
+	hs.endpoints = endpoints
+	hs.lastSuccessfulAt = time.Now()
+
+	return true, nil
+}
+
+// AddEventHandler polls the endpoints document every poll interval, if configured,
+// and triggers the handler whenever it changed.
+func (hs *httpSource) AddEventHandler(ctx context.Context, handler func()) {
+	if hs.pollInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(hs.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				hs.mutex.Lock()
+				changed, err := hs.refresh()
+				hs.mutex.Unlock()
+				if err != nil {
+					log.Warnf("Failed to poll endpoints document from %s: %v", hs.url, err)
+					continue
+				}
+				if changed {
+					handler()
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const testEndpointsDocument = `{"endpoints": [
+	{"dnsName": "abc.example.org.", "recordType": "A", "targets": ["1.2.3.4"], "recordTTL": 180},
+	{"dnsName": "xyz.example.org", "targets": ["abc.example.org"]}
+]}`
+
+func TestHTTPSource(t *testing.T) {
+	t.Run("Interface", testHTTPSourceImplementsSource)
+	t.Run("NewHTTPSource", testHTTPSourceNewHTTPSource)
+	t.Run("Endpoints", testHTTPSourceEndpoints)
+	t.Run("ETag", testHTTPSourceETag)
+	t.Run("StaleWhileError", testHTTPSourceStaleWhileError)
+	t.Run("EventHandler", testHTTPSourceEventHandler)
+}
+
+// testHTTPSourceImplementsSource tests that httpSource is a valid Source.
+func testHTTPSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(httpSource))
+}
+
+// testHTTPSourceNewHTTPSource tests that NewHTTPSource validates its configuration.
+func testHTTPSourceNewHTTPSource(t *testing.T) {
+	for _, ti := range []struct {
+		title       string
+		url         string
+		headers     []string
+		expectError bool
+	}{
+		{title: "missing url", expectError: true},
+		{title: "invalid header", url: "http://localhost", headers: []string{"Authorization"}, expectError: true},
+		{title: "empty header name", url: "http://localhost", headers: []string{": value"}, expectError: true},
+		{title: "valid", url: "http://localhost", headers: []string{"Authorization: Bearer token"}},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			_, err := NewHTTPSource(http.DefaultClient, ti.url, ti.headers, 0, 0)
+			if ti.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// testHTTPSourceEndpoints tests that endpoints are decoded from the served document.
+func testHTTPSourceEndpoints(t *testing.T) {
+	for _, ti := range []struct {
+		title       string
+		status      int
+		body        string
+		expected    []*endpoint.Endpoint
+		expectError bool
+	}{
+		{
+			title:  "valid document",
+			status: http.StatusOK,
+			body:   testEndpointsDocument,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "abc.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA, RecordTTL: 180},
+				{DNSName: "xyz.example.org", Targets: endpoint.Targets{"abc.example.org"}, RecordType: endpoint.RecordTypeCNAME},
+			},
+		},
+		{
+			title:    "empty document",
+			status:   http.StatusOK,
+			body:     `{}`,
+			expected: []*endpoint.Endpoint{},
+		},
+		{
+			title:       "endpoint without targets",
+			status:      http.StatusOK,
+			body:        `{"endpoints": [{"dnsName": "abc.example.org"}]}`,
+			expectError: true,
+		},
+		{
+			title:       "invalid document",
+			status:      http.StatusOK,
+			body:        `endpoints`,
+			expectError: true,
+		},
+		{
+			title:       "server error",
+			status:      http.StatusInternalServerError,
+			expectError: true,
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+				w.WriteHeader(ti.status)
+				w.Write([]byte(ti.body))
+			}))
+			defer server.Close()
+
+			hs, err := NewHTTPSource(server.Client(), server.URL, []string{"Authorization: Bearer token"}, 0, 0)
+			require.NoError(t, err)
+
+			endpoints, err := hs.Endpoints()
+			if ti.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			validateEndpoints(t, endpoints, ti.expected)
+		})
+	}
+}
+
+// testHTTPSourceETag tests that unchanged documents are served from the cache.
+func testHTTPSourceETag(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(testEndpointsDocument))
+	}))
+	defer server.Close()
+
+	hs, err := NewHTTPSource(server.Client(), server.URL, nil, 0, 0)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		endpoints, err := hs.Endpoints()
+		require.NoError(t, err)
+		assert.Len(t, endpoints, 2)
+	}
+	assert.Equal(t, 2, requests)
+}
+
+// testHTTPSourceStaleWhileError tests that cached endpoints are served while fetching fails.
+func testHTTPSourceStaleWhileError(t *testing.T) {
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(testEndpointsDocument))
+	}))
+	defer server.Close()
+
+	hs, err := NewHTTPSource(server.Client(), server.URL, nil, 0, time.Minute)
+	require.NoError(t, err)
+
+	_, err = hs.Endpoints()
+	require.NoError(t, err)
+
+	failing = true
+	endpoints, err := hs.Endpoints()
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 2)
+
+	hs.(*httpSource).lastSuccessfulAt = time.Now().Add(-2 * time.Minute)
+	_, err = hs.Endpoints()
+	assert.Error(t, err)
+}
+
+// testHTTPSourceEventHandler tests that the handler is triggered when the document changes.
+func testHTTPSourceEventHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testEndpointsDocument))
+	}))
+	defer server.Close()
+
+	hs, err := NewHTTPSource(server.Client(), server.URL, nil, 10*time.Millisecond, 0)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	triggered := make(chan struct{}, 1)
+	hs.AddEventHandler(ctx, func() {
+		select {
+		case triggered <- struct{}{}:
+		default:
+		}
+	})
+
+	select {
+	case <-triggered:
+	case <-time.After(time.Second):
+		t.Fatal("expected the event handler to be triggered")
+	}
+}
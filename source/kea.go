@@ -0,0 +1,183 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	keaResultSuccess = 0
+	keaResultEmpty   = 3
+
+	keaLeaseStateDefault = 0
+)
+
+type keaCommand struct {
+	Command   string              `json:"command"`
+	Service   []string            `json:"service"`
+	Arguments *keaLeaseGetAllArgs `json:"arguments,omitempty"`
+}
+
+type keaLeaseGetAllArgs struct {
+	Subnets []int `json:"subnets"`
+}
+
+type keaResponse struct {
+	Result    int    `json:"result"`
+	Text      string `json:"text"`
+	Arguments struct {
+		Leases []keaLease `json:"leases"`
+	} `json:"arguments"`
+}
+
+type keaLease struct {
+	IPAddress string `json:"ip-address"`
+	Hostname  string `json:"hostname"`
+	SubnetID  int    `json:"subnet-id"`
+	ValidLft  int64  `json:"valid-lft"`
+	Cltt      int64  `json:"cltt"`
+	State     int    `json:"state"`
+}
+
+// keaSource is an implementation of Source that provides endpoints for the active
+// DHCPv4 leases reported by a Kea control agent.
+type keaSource struct {
+	client         *http.Client
+	url            string
+	subnets        []int
+	hostnameFilter *regexp.Regexp
+	domain         string
+	maxTTL         time.Duration
+	now            func() time.Time
+}
+
+// NewKeaSource creates a new keaSource querying the Kea control agent at the given url.
+// Leases can be restricted to the given subnet IDs and to hostnames matching hostnameFilter.
+func NewKeaSource(client *http.Client, url string, subnets []int, hostnameFilter, domain string, maxTTL time.Duration) (Source, error) {
+	if url == "" {
+		return nil, fmt.Errorf("kea control agent url must not be empty")
+	}
+
+	var filter *regexp.Regexp
+	if hostnameFilter != "" {
+		var err error
+		filter, err = regexp.Compile(hostnameFilter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kea hostname filter: %v", err)
+		}
+	}
+
+	return &keaSource{
+		client:         client,
+		url:            url,
+		subnets:        subnets,
+		hostnameFilter: filter,
+		domain:         strings.Trim(domain, "."),
+		maxTTL:         maxTTL,
+		now:            time.Now,
+	}, nil
+}
+
+// Endpoints returns endpoint objects.
+func (ks *keaSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	keaLeases, err := ks.leases()
+	if err != nil {
+		return nil, err
+	}
+
+	var leases []lease
+	for _, kl := range keaLeases {
+		if kl.State != keaLeaseStateDefault {
+			continue
+		}
+		hostname := strings.TrimSuffix(kl.Hostname, ".")
+		if ks.hostnameFilter != nil && !ks.hostnameFilter.MatchString(hostname) {
+			continue
+		}
+		l := lease{hostname: hostname, ip: kl.IPAddress}
+		if kl.ValidLft > 0 {
+			l.expiry = time.Unix(kl.Cltt+kl.ValidLft, 0)
+		}
+		leases = append(leases, l)
+	}
+
+	endpoints := endpointsForLeases(leases, ks.domain, ks.maxTTL, ks.now())
+
+	log.Debugf("Found %d endpoints in %d kea leases", len(endpoints), len(keaLeases))
+
+	return endpoints, nil
+}
+
+// leases fetches all DHCPv4 leases of the configured subnets.
+func (ks *keaSource) leases() ([]keaLease, error) {
+	command := keaCommand{
+		Command: "lease4-get-all",
+		Service: []string{"dhcp4"},
+	}
+	if len(ks.subnets) > 0 {
+		command.Arguments = &keaLeaseGetAllArgs{Subnets: ks.subnets}
+	}
+
+	body, err := json.Marshal(command)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ks.client.Post(ks.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("kea control agent returned %s", resp.Status)
+	}
+
+	// The control agent returns one response per service.
+	var responses []keaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&responses); err != nil {
+		return nil, fmt.Errorf("failed to decode kea response: %v", err)
+	}
+
+	var leases []keaLease
+	for _, r := range responses {
+		switch r.Result {
+		case keaResultSuccess:
+			leases = append(leases, r.Arguments.Leases...)
+		case keaResultEmpty:
+		default:
+			return nil, fmt.Errorf("kea command lease4-get-all failed: %s", r.Text)
+		}
+	}
+
+	return leases, nil
+}
+
+func (ks *keaSource) AddEventHandler(ctx context.Context, handler func()) {
+}
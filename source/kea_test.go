@@ -0,0 +1,145 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const testKeaLeases = `[{
+	"result": 0,
+	"text": "3 IPv4 lease(s) found.",
+	"arguments": {"leases": [
+		{"ip-address": "192.0.2.10", "hostname": "web-1.", "subnet-id": 1, "valid-lft": 7200, "cltt": 1591268400, "state": 0},
+		{"ip-address": "192.0.2.11", "hostname": "db-1", "subnet-id": 1, "valid-lft": 3600, "cltt": 1591268400, "state": 0},
+		{"ip-address": "192.0.2.12", "hostname": "web-2", "subnet-id": 1, "valid-lft": 3600, "cltt": 1591268400, "state": 2},
+		{"ip-address": "192.0.2.13", "hostname": "web-3", "subnet-id": 1, "valid-lft": 60, "cltt": 1591268400, "state": 0}
+	]}
+}]`
+
+func TestKeaSource(t *testing.T) {
+	t.Run("Interface", testKeaSourceImplementsSource)
+	t.Run("NewKeaSource", testKeaSourceNewKeaSource)
+	t.Run("Endpoints", testKeaSourceEndpoints)
+}
+
+// testKeaSourceImplementsSource tests that keaSource is a valid Source.
+func testKeaSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(keaSource))
+}
+
+// testKeaSourceNewKeaSource tests that NewKeaSource validates its configuration.
+func testKeaSourceNewKeaSource(t *testing.T) {
+	_, err := NewKeaSource(http.DefaultClient, "", nil, "", "lan.example.org", time.Hour)
+	assert.Error(t, err)
+
+	_, err = NewKeaSource(http.DefaultClient, "http://localhost:8000", nil, "[", "lan.example.org", time.Hour)
+	assert.Error(t, err)
+
+	_, err = NewKeaSource(http.DefaultClient, "http://localhost:8000", []int{1}, "^web-", "lan.example.org", time.Hour)
+	assert.NoError(t, err)
+}
+
+// testKeaSourceEndpoints tests that active leases are converted to endpoints.
+func testKeaSourceEndpoints(t *testing.T) {
+	now := time.Unix(1591268400, 0).Add(30 * time.Minute)
+
+	for _, ti := range []struct {
+		title           string
+		subnets         []int
+		hostnameFilter  string
+		response        string
+		status          int
+		expectedCommand string
+		expected        []*endpoint.Endpoint
+		expectError     bool
+	}{
+		{
+			title:           "all leases",
+			response:        testKeaLeases,
+			status:          http.StatusOK,
+			expectedCommand: `{"command":"lease4-get-all","service":["dhcp4"]}`,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "web-1.lan.example.org", Targets: endpoint.Targets{"192.0.2.10"}, RecordType: endpoint.RecordTypeA, RecordTTL: 3600},
+				{DNSName: "db-1.lan.example.org", Targets: endpoint.Targets{"192.0.2.11"}, RecordType: endpoint.RecordTypeA, RecordTTL: 1024},
+			},
+		},
+		{
+			title:           "filtered leases",
+			subnets:         []int{1, 2},
+			hostnameFilter:  "^web-",
+			response:        testKeaLeases,
+			status:          http.StatusOK,
+			expectedCommand: `{"command":"lease4-get-all","service":["dhcp4"],"arguments":{"subnets":[1,2]}}`,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "web-1.lan.example.org", Targets: endpoint.Targets{"192.0.2.10"}, RecordType: endpoint.RecordTypeA, RecordTTL: 3600},
+			},
+		},
+		{
+			title:    "no leases",
+			response: `[{"result": 3, "text": "0 IPv4 lease(s) found."}]`,
+			status:   http.StatusOK,
+			expected: []*endpoint.Endpoint{},
+		},
+		{
+			title:       "command error",
+			response:    `[{"result": 1, "text": "unable to forward command to the dhcp4 service"}]`,
+			status:      http.StatusOK,
+			expectError: true,
+		},
+		{
+			title:       "http error",
+			status:      http.StatusUnauthorized,
+			expectError: true,
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if ti.expectedCommand != "" {
+					var command json.RawMessage
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&command))
+					assert.JSONEq(t, ti.expectedCommand, string(command))
+				}
+				w.WriteHeader(ti.status)
+				w.Write([]byte(ti.response))
+			}))
+			defer server.Close()
+
+			ks, err := NewKeaSource(server.Client(), server.URL, ti.subnets, ti.hostnameFilter, "lan.example.org", time.Hour)
+			require.NoError(t, err)
+			ks.(*keaSource).now = func() time.Time { return now }
+
+			endpoints, err := ks.Endpoints()
+			if ti.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			validateEndpoints(t, endpoints, ti.expected)
+		})
+	}
+}
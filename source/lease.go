@@ -0,0 +1,281 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	// LeaseFormatDnsmasq is the format of dnsmasq.leases files
+	LeaseFormatDnsmasq = "dnsmasq"
+	// LeaseFormatISC is the format of ISC dhcpd.leases files
+	LeaseFormatISC = "isc"
+
+	iscLeaseTimeLayout = "2006/01/02 15:04:05"
+)
+
+// lease is a single active DHCP lease. A zero expiry means the lease never expires.
+type lease struct {
+	hostname string
+	ip       string
+	expiry   time.Time
+}
+
+// leaseSource is an implementation of Source that provides endpoints for the hostnames
+// found in a DHCP lease file.
+//
+// The TTL of every endpoint is the remaining time of its lease, capped by maxTTL, so
+// resolvers don't cache addresses longer than they are leased, see leaseTTL.
+type leaseSource struct {
+	path   string
+	format string
+	domain string
+	maxTTL time.Duration
+	now    func() time.Time
+}
+
+// NewLeaseSource creates a new leaseSource for the lease file at the given path.
+func NewLeaseSource(path, format, domain string, maxTTL time.Duration) (Source, error) {
+	if path == "" {
+		return nil, fmt.Errorf("lease file path must not be empty")
+	}
+	if domain == "" {
+		return nil, fmt.Errorf("lease source domain must not be empty")
+	}
+	switch format {
+	case LeaseFormatDnsmasq, LeaseFormatISC:
+	default:
+		return nil, fmt.Errorf("unsupported lease file format %q", format)
+	}
+
+	return &leaseSource{
+		path:   path,
+		format: format,
+		domain: strings.Trim(domain, "."),
+		maxTTL: maxTTL,
+		now:    time.Now,
+	}, nil
+}
+
+// Endpoints returns endpoint objects.
+func (ls *leaseSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	f, err := os.Open(ls.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var leases []lease
+	switch ls.format {
+	case LeaseFormatDnsmasq:
+		leases, err = parseDnsmasqLeases(f)
+	case LeaseFormatISC:
+		leases, err = parseISCLeases(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lease file %s: %v", ls.path, err)
+	}
+
+	endpoints := endpointsForLeases(leases, ls.domain, ls.maxTTL, ls.now())
+
+	log.Debugf("Found %d endpoints in lease file %s", len(endpoints), ls.path)
+
+	return endpoints, nil
+}
+
+func (ls *leaseSource) AddEventHandler(ctx context.Context, handler func()) {
+}
+
+// endpointsForLeases returns an A record for every hostname holding an unexpired lease.
+// Unqualified hostnames are qualified with the given domain. The kea source doesn't require a
+// domain, so without one they are skipped. The TTL of every record is that of its shortest lease.
+func endpointsForLeases(leases []lease, domain string, maxTTL time.Duration, now time.Time) []*endpoint.Endpoint {
+	targets := map[string]endpoint.Targets{}
+	ttls := map[string]endpoint.TTL{}
+
+	for _, l := range leases {
+		if !l.expiry.IsZero() && !l.expiry.After(now) {
+			continue
+		}
+
+		hostname := strings.ToLower(strings.TrimSuffix(l.hostname, "."))
+		if hostname == "" || hostname == "*" || net.ParseIP(l.ip) == nil {
+			continue
+		}
+		dnsName := hostname
+		if domain != "" {
+			dnsName = hostname + "." + domain
+		} else if !strings.Contains(hostname, ".") {
+			log.Debugf("Skipping lease of unqualified hostname %s", hostname)
+			continue
+		}
+
+		targets[dnsName] = append(targets[dnsName], l.ip)
+		ttl := leaseTTL(l, maxTTL, now)
+		if current, ok := ttls[dnsName]; !ok || (ttl.IsConfigured() && (!current.IsConfigured() || ttl < current)) {
+			ttls[dnsName] = ttl
+		}
+	}
+
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, name := range names {
+		endpoints = append(endpoints, endpoint.NewEndpointWithTTL(name, endpoint.RecordTypeA, ttls[name], targets[name]...))
+	}
+
+	return endpoints
+}
+
+// leaseTTL returns the TTL for the given lease: maxTTL, or the remaining time of a lease expiring
+// before, rounded down to a power of two seconds. The remaining time changes on every
+// synchronization, the rounded one only a few times during a lease, so records aren't updated
+// on every synchronization while their leases run out.
+func leaseTTL(l lease, maxTTL time.Duration, now time.Time) endpoint.TTL {
+	remaining := l.expiry.Sub(now)
+	if l.expiry.IsZero() || (maxTTL > 0 && remaining >= maxTTL) {
+		return endpoint.TTL(maxTTL / time.Second)
+	}
+	ttl := endpoint.TTL(1)
+	for ttl*2 <= endpoint.TTL(remaining/time.Second) {
+		ttl *= 2
+	}
+	return ttl
+}
+
+// parseDnsmasqLeases parses a dnsmasq.leases file. Every line is of the form
+// "<expiry> <mac> <ip> <hostname> <client-id>" with an expiry of 0 for infinite leases.
+func parseDnsmasqLeases(r io.Reader) ([]lease, error) {
+	var leases []lease
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "duid") {
+			continue
+		}
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("line %d: expected at least 4 fields, got %d", line, len(fields))
+		}
+
+		seconds, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry %q", line, fields[0])
+		}
+		l := lease{ip: fields[2], hostname: fields[3]}
+		if seconds > 0 {
+			l.expiry = time.Unix(seconds, 0)
+		}
+		leases = append(leases, l)
+	}
+
+	return leases, scanner.Err()
+}
+
+// parseISCLeases parses a dhcpd.leases file. Only leases in the active binding state
+// are returned. Later declarations of the same address replace earlier ones, as the
+// file is an append-only journal.
+func parseISCLeases(r io.Reader) ([]lease, error) {
+	var (
+		order  []string
+		byIP   = map[string]lease{}
+		active = map[string]bool{}
+		ip     string
+		cur    lease
+		state  string
+	)
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if ip == "" {
+			fields := strings.Fields(text)
+			if len(fields) == 3 && fields[0] == "lease" && fields[2] == "{" {
+				ip = fields[1]
+				cur = lease{ip: ip}
+				state = ""
+			}
+			continue
+		}
+
+		if text == "}" {
+			if _, ok := byIP[ip]; !ok {
+				order = append(order, ip)
+			}
+			byIP[ip] = cur
+			active[ip] = state == "active"
+			ip = ""
+			continue
+		}
+
+		statement := strings.TrimSuffix(text, ";")
+		fields := strings.Fields(statement)
+		switch {
+		case len(fields) >= 2 && fields[0] == "ends":
+			if fields[1] == "never" {
+				cur.expiry = time.Time{}
+				continue
+			}
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("line %d: invalid lease end %q", line, statement)
+			}
+			expiry, err := time.Parse(iscLeaseTimeLayout, fields[2]+" "+fields[3])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid lease end %q", line, statement)
+			}
+			cur.expiry = expiry
+		case len(fields) == 3 && fields[0] == "binding" && fields[1] == "state":
+			state = fields[2]
+		case len(fields) >= 2 && fields[0] == "client-hostname":
+			cur.hostname = strings.Trim(strings.Join(fields[1:], " "), `"`)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	leases := make([]lease, 0, len(order))
+	for _, ip := range order {
+		if active[ip] {
+			leases = append(leases, byIP[ip])
+		}
+	}
+
+	return leases, nil
+}
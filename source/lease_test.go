@@ -0,0 +1,201 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	testDnsmasqLeases = `1591272000 00:11:22:33:44:55 192.168.1.10 laptop 01:00:11:22:33:44:55
+1591272600 00:11:22:33:44:56 192.168.1.11 Printer *
+0 00:11:22:33:44:57 192.168.1.12 nas *
+1591264800 00:11:22:33:44:58 192.168.1.13 expired *
+1591272000 00:11:22:33:44:59 192.168.1.14 * *
+duid 00:01:00:01:26:4a:5b:6c:00:11:22:33:44:55
+`
+
+	testISCLeases = `# The format of this file is documented in the dhcpd.leases(5) manual page.
+lease 192.168.1.10 {
+  starts 4 2020/06/04 10:00:00;
+  ends 4 2020/06/04 12:00:00;
+  binding state active;
+  hardware ethernet 00:11:22:33:44:55;
+  client-hostname "laptop";
+}
+lease 192.168.1.11 {
+  starts 4 2020/06/04 09:00:00;
+  ends 4 2020/06/04 10:00:00;
+  binding state active;
+  client-hostname "phone";
+}
+lease 192.168.1.11 {
+  starts 4 2020/06/04 10:00:00;
+  ends 4 2020/06/04 10:05:00;
+  binding state free;
+  client-hostname "phone";
+}
+lease 192.168.1.12 {
+  starts 4 2020/06/04 10:00:00;
+  ends never;
+  binding state active;
+  client-hostname "nas";
+}
+`
+)
+
+func TestLeaseSource(t *testing.T) {
+	t.Run("Interface", testLeaseSourceImplementsSource)
+	t.Run("NewLeaseSource", testLeaseSourceNewLeaseSource)
+	t.Run("Endpoints", testLeaseSourceEndpoints)
+	t.Run("TTL", testLeaseSourceTTL)
+}
+
+// testLeaseSourceImplementsSource tests that leaseSource is a valid Source.
+func testLeaseSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(leaseSource))
+}
+
+// testLeaseSourceNewLeaseSource tests that NewLeaseSource validates its configuration.
+func testLeaseSourceNewLeaseSource(t *testing.T) {
+	for _, ti := range []struct {
+		title       string
+		path        string
+		format      string
+		domain      string
+		expectError bool
+	}{
+		{title: "missing path", format: LeaseFormatDnsmasq, domain: "lan.example.org", expectError: true},
+		{title: "missing domain", path: "/var/lib/misc/dnsmasq.leases", format: LeaseFormatDnsmasq, expectError: true},
+		{title: "unsupported format", path: "/var/lib/misc/dnsmasq.leases", format: "kea", domain: "lan.example.org", expectError: true},
+		{title: "valid", path: "/var/lib/dhcp/dhcpd.leases", format: LeaseFormatISC, domain: "lan.example.org"},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			_, err := NewLeaseSource(ti.path, ti.format, ti.domain, time.Hour)
+			if ti.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// testLeaseSourceEndpoints tests that active leases are converted to endpoints.
+func testLeaseSourceEndpoints(t *testing.T) {
+	now := time.Date(2020, 6, 4, 11, 0, 0, 0, time.UTC)
+
+	for _, ti := range []struct {
+		title       string
+		format      string
+		content     string
+		maxTTL      time.Duration
+		expected    []*endpoint.Endpoint
+		expectError bool
+	}{
+		{
+			title:   "dnsmasq leases",
+			format:  LeaseFormatDnsmasq,
+			content: testDnsmasqLeases,
+			maxTTL:  30 * time.Minute,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "laptop.lan.example.org", Targets: endpoint.Targets{"192.168.1.10"}, RecordType: endpoint.RecordTypeA, RecordTTL: 1800},
+				{DNSName: "printer.lan.example.org", Targets: endpoint.Targets{"192.168.1.11"}, RecordType: endpoint.RecordTypeA, RecordTTL: 1800},
+				{DNSName: "nas.lan.example.org", Targets: endpoint.Targets{"192.168.1.12"}, RecordType: endpoint.RecordTypeA, RecordTTL: 1800},
+			},
+		},
+		{
+			title:   "dnsmasq leases without ttl cap",
+			format:  LeaseFormatDnsmasq,
+			content: testDnsmasqLeases,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "laptop.lan.example.org", Targets: endpoint.Targets{"192.168.1.10"}, RecordType: endpoint.RecordTypeA, RecordTTL: 2048},
+				{DNSName: "printer.lan.example.org", Targets: endpoint.Targets{"192.168.1.11"}, RecordType: endpoint.RecordTypeA, RecordTTL: 4096},
+				{DNSName: "nas.lan.example.org", Targets: endpoint.Targets{"192.168.1.12"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:       "invalid dnsmasq leases",
+			format:      LeaseFormatDnsmasq,
+			content:     "soon 00:11:22:33:44:55 192.168.1.10 laptop *\n",
+			expectError: true,
+		},
+		{
+			title:   "isc leases",
+			format:  LeaseFormatISC,
+			content: testISCLeases,
+			maxTTL:  time.Hour,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "laptop.lan.example.org", Targets: endpoint.Targets{"192.168.1.10"}, RecordType: endpoint.RecordTypeA, RecordTTL: 3600},
+				{DNSName: "nas.lan.example.org", Targets: endpoint.Targets{"192.168.1.12"}, RecordType: endpoint.RecordTypeA, RecordTTL: 3600},
+			},
+		},
+		{
+			title:       "invalid isc leases",
+			format:      LeaseFormatISC,
+			content:     "lease 192.168.1.10 {\n  ends 4 tomorrow;\n}\n",
+			expectError: true,
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			f, err := ioutil.TempFile("", "leases")
+			require.NoError(t, err)
+			defer os.Remove(f.Name())
+			_, err = f.WriteString(ti.content)
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+
+			ls, err := NewLeaseSource(f.Name(), ti.format, "lan.example.org.", ti.maxTTL)
+			require.NoError(t, err)
+			ls.(*leaseSource).now = func() time.Time { return now }
+
+			endpoints, err := ls.Endpoints()
+			if ti.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			validateEndpoints(t, endpoints, ti.expected)
+		})
+	}
+}
+
+// testLeaseSourceTTL tests that the TTL of a lease only changes a few times while it runs out.
+func testLeaseSourceTTL(t *testing.T) {
+	now := time.Date(2020, 6, 4, 11, 0, 0, 0, time.UTC)
+	l := lease{hostname: "laptop", ip: "192.168.1.10", expiry: now.Add(time.Hour)}
+
+	assert.Equal(t, endpoint.TTL(1800), leaseTTL(l, 30*time.Minute, now))
+	assert.Equal(t, endpoint.TTL(3600), leaseTTL(lease{hostname: "nas", ip: "192.168.1.12"}, time.Hour, now))
+
+	ttls := map[endpoint.TTL]bool{}
+	for elapsed := time.Duration(0); elapsed < time.Hour; elapsed += time.Minute {
+		ttl := leaseTTL(l, 2*time.Hour, now.Add(elapsed))
+		assert.True(t, ttl <= endpoint.TTL((time.Hour-elapsed)/time.Second), "TTL %d outlives the lease after %s", ttl, elapsed)
+		ttls[ttl] = true
+	}
+	assert.Len(t, ttls, 7)
+}
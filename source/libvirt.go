@@ -0,0 +1,187 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	// LibvirtAddressSourceLease reads guest addresses from the DHCP leases of libvirt networks
+	LibvirtAddressSourceLease = "lease"
+	// LibvirtAddressSourceAgent reads guest addresses from the QEMU guest agent
+	LibvirtAddressSourceAgent = "agent"
+	// LibvirtAddressSourceARP reads guest addresses from the ARP table of the host
+	LibvirtAddressSourceARP = "arp"
+)
+
+// virshRunner runs virsh with the given arguments and returns its standard output.
+type virshRunner func(args ...string) ([]byte, error)
+
+// libvirtSource is an implementation of Source that provides endpoints for the running
+// guests of a libvirt hypervisor. Guests are queried through virsh, so the source works
+// with any hypervisor libvirt can connect to without linking against libvirt itself.
+type libvirtSource struct {
+	uri           string
+	addressSource string
+	domain        string
+	virsh         virshRunner
+}
+
+// NewLibvirtSource creates a new libvirtSource connecting to the given libvirt uri.
+func NewLibvirtSource(uri, addressSource, domain string) (Source, error) {
+	if domain == "" {
+		return nil, fmt.Errorf("libvirt source domain must not be empty")
+	}
+	switch addressSource {
+	case LibvirtAddressSourceLease, LibvirtAddressSourceAgent, LibvirtAddressSourceARP:
+	default:
+		return nil, fmt.Errorf("unsupported libvirt address source %q", addressSource)
+	}
+
+	return &libvirtSource{
+		uri:           uri,
+		addressSource: addressSource,
+		domain:        strings.Trim(domain, "."),
+		virsh:         runVirsh,
+	}, nil
+}
+
+func runVirsh(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("virsh", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("virsh %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// Endpoints returns endpoint objects.
+func (ls *libvirtSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	guests, err := ls.guests()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, guest := range guests {
+		addresses, err := ls.addresses(guest)
+		if err != nil {
+			// The guest agent isn't necessarily installed in every guest.
+			log.Warnf("Failed to get the addresses of libvirt guest %s: %v", guest, err)
+			continue
+		}
+		if len(addresses) == 0 {
+			log.Debugf("No addresses found for libvirt guest %s", guest)
+			continue
+		}
+
+		hostname := strings.ToLower(guest) + "." + ls.domain
+		endpoints = append(endpoints, endpoint.NewEndpoint(hostname, endpoint.RecordTypeA, addresses...))
+	}
+
+	log.Debugf("Found %d endpoints for %d libvirt guests", len(endpoints), len(guests))
+
+	return endpoints, nil
+}
+
+// guests returns the names of all running guests.
+func (ls *libvirtSource) guests() ([]string, error) {
+	out, err := ls.virsh(ls.args("list", "--state-running", "--name")...)
+	if err != nil {
+		return nil, err
+	}
+
+	var guests []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" {
+			guests = append(guests, name)
+		}
+	}
+	sort.Strings(guests)
+
+	return guests, scanner.Err()
+}
+
+// addresses returns the IPv4 addresses of the given guest.
+func (ls *libvirtSource) addresses(guest string) ([]string, error) {
+	out, err := ls.virsh(ls.args("domifaddr", guest, "--source", ls.addressSource)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseDomifaddr(out)
+}
+
+func (ls *libvirtSource) args(args ...string) []string {
+	if ls.uri == "" {
+		return args
+	}
+	return append([]string{"--connect", ls.uri}, args...)
+}
+
+// parseDomifaddr parses the table printed by "virsh domifaddr", which has the columns
+// "Name MAC-address Protocol Address". Interfaces with several addresses print "-" in
+// the name and MAC columns of the additional rows. Loopback interfaces reported by the
+// guest agent are ignored.
+func parseDomifaddr(out []byte) ([]string, error) {
+	var (
+		addresses []string
+		iface     string
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 4 || fields[0] == "Name" {
+			continue
+		}
+		if fields[0] != "-" {
+			iface = fields[0]
+		}
+		if iface == "lo" || fields[2] != "ipv4" {
+			continue
+		}
+
+		ip, _, err := net.ParseCIDR(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q of interface %s", fields[3], iface)
+		}
+		if ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+			continue
+		}
+		addresses = append(addresses, ip.String())
+	}
+
+	return addresses, scanner.Err()
+}
+
+func (ls *libvirtSource) AddEventHandler(ctx context.Context, handler func()) {
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const testDomifaddrWeb = ` Name       MAC address          Protocol     Address
+-------------------------------------------------------------------------------
+ lo         00:00:00:00:00:00    ipv4         127.0.0.1/8
+ -          -                    ipv6         ::1/128
+ eth0       52:54:00:01:02:03    ipv4         192.168.122.10/24
+ -          -                    ipv4         192.168.122.11/24
+ -          -                    ipv6         fe80::5054:ff:fe01:203/64
+ eth1       52:54:00:01:02:04    ipv4         169.254.0.2/16
+
+`
+
+const testDomifaddrDB = ` Name       MAC address          Protocol     Address
+-------------------------------------------------------------------------------
+ vnet1      52:54:00:04:05:06    ipv4         192.168.122.20/24
+
+`
+
+func TestLibvirtSource(t *testing.T) {
+	t.Run("Interface", testLibvirtSourceImplementsSource)
+	t.Run("NewLibvirtSource", testLibvirtSourceNewLibvirtSource)
+	t.Run("Endpoints", testLibvirtSourceEndpoints)
+}
+
+// testLibvirtSourceImplementsSource tests that libvirtSource is a valid Source.
+func testLibvirtSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(libvirtSource))
+}
+
+// testLibvirtSourceNewLibvirtSource tests that NewLibvirtSource validates its configuration.
+func testLibvirtSourceNewLibvirtSource(t *testing.T) {
+	_, err := NewLibvirtSource("qemu:///system", LibvirtAddressSourceLease, "")
+	assert.Error(t, err)
+
+	_, err = NewLibvirtSource("qemu:///system", "dns", "vm.example.org")
+	assert.Error(t, err)
+
+	_, err = NewLibvirtSource("qemu:///system", LibvirtAddressSourceAgent, "vm.example.org")
+	assert.NoError(t, err)
+}
+
+// testLibvirtSourceEndpoints tests that guest addresses are converted to endpoints.
+func testLibvirtSourceEndpoints(t *testing.T) {
+	for _, ti := range []struct {
+		title       string
+		outputs     map[string]string
+		expected    []*endpoint.Endpoint
+		expectError bool
+	}{
+		{
+			title: "running guests",
+			outputs: map[string]string{
+				"list":              "Web\ndb\nstopped\n\n",
+				"domifaddr Web":     testDomifaddrWeb,
+				"domifaddr db":      testDomifaddrDB,
+				"domifaddr stopped": "",
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "web.vm.example.org", Targets: endpoint.Targets{"192.168.122.10", "192.168.122.11"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "db.vm.example.org", Targets: endpoint.Targets{"192.168.122.20"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title: "guest agent unavailable",
+			outputs: map[string]string{
+				"list":         "web\ndb\n",
+				"domifaddr db": testDomifaddrDB,
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "db.vm.example.org", Targets: endpoint.Targets{"192.168.122.20"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:       "libvirt unavailable",
+			outputs:     map[string]string{},
+			expectError: true,
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			ls, err := NewLibvirtSource("qemu:///system", LibvirtAddressSourceAgent, "vm.example.org.")
+			require.NoError(t, err)
+			ls.(*libvirtSource).virsh = func(args ...string) ([]byte, error) {
+				require.Equal(t, []string{"--connect", "qemu:///system"}, args[:2])
+				key := args[2]
+				if key == "domifaddr" {
+					assert.Equal(t, []string{"--source", "agent"}, args[4:])
+					key = strings.Join(args[2:4], " ")
+				}
+				out, ok := ti.outputs[key]
+				if !ok {
+					return nil, errors.New("failed to connect to the hypervisor")
+				}
+				return []byte(out), nil
+			}
+
+			endpoints, err := ls.Endpoints()
+			if ti.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			validateEndpoints(t, endpoints, ti.expected)
+		})
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	mdnsAddress = "224.0.0.251:5353"
+	mdnsDomain  = "local."
+)
+
+// mdnsQuerier sends the given questions and returns all records received until the timeout.
+type mdnsQuerier func(questions []dns.Question, timeout time.Duration) ([]dns.RR, error)
+
+// mdnsSource is an implementation of Source that browses services announced via mDNS
+// and republishes the hosts providing them in a unicast domain, bridging .local into
+// real DNS.
+//
+// Services are resolved to hosts via their SRV records. Records missing from the answers
+// to the browse queries are queried for in a second round.
+type mdnsSource struct {
+	services []string
+	domain   string
+	timeout  time.Duration
+	query    mdnsQuerier
+}
+
+// NewMDNSSource creates a new mdnsSource browsing the given service types, e.g. _workstation._tcp.
+func NewMDNSSource(services []string, domain string, timeout time.Duration) (Source, error) {
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no mdns service types specified")
+	}
+	if domain == "" {
+		return nil, fmt.Errorf("mdns source domain must not be empty")
+	}
+
+	return &mdnsSource{
+		services: services,
+		domain:   strings.Trim(domain, "."),
+		timeout:  timeout,
+		query:    queryMDNS,
+	}, nil
+}
+
+// Endpoints returns endpoint objects.
+func (ms *mdnsSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	var questions []dns.Question
+	for _, service := range ms.services {
+		name := dns.Fqdn(strings.TrimSuffix(dns.Fqdn(service), mdnsDomain) + mdnsDomain)
+		questions = append(questions, dns.Question{Name: name, Qtype: dns.TypePTR, Qclass: dns.ClassINET})
+	}
+
+	records, err := ms.query(questions, ms.timeout)
+	if err != nil {
+		return nil, err
+	}
+	instances, hosts, addresses := indexMDNSRecords(records)
+
+	// Ask for the records of instances and hosts that weren't announced along with them.
+	questions = nil
+	for instance := range instances {
+		if _, ok := hosts[instance]; !ok {
+			questions = append(questions, dns.Question{Name: instance, Qtype: dns.TypeSRV, Qclass: dns.ClassINET})
+		}
+	}
+	if len(questions) > 0 {
+		records, err := ms.query(questions, ms.timeout)
+		if err != nil {
+			return nil, err
+		}
+		addMDNSRecords(records, instances, hosts, addresses)
+	}
+	questions = nil
+	for _, host := range hosts {
+		if _, ok := addresses[host]; !ok {
+			questions = append(questions, dns.Question{Name: host, Qtype: dns.TypeA, Qclass: dns.ClassINET})
+		}
+	}
+	if len(questions) > 0 {
+		records, err := ms.query(questions, ms.timeout)
+		if err != nil {
+			return nil, err
+		}
+		addMDNSRecords(records, instances, hosts, addresses)
+	}
+
+	targets := map[string]endpoint.Targets{}
+	for instance := range instances {
+		host, ok := hosts[instance]
+		if !ok || len(addresses[host]) == 0 {
+			log.Debugf("Skipping mdns service %s without address", instance)
+			continue
+		}
+		name := strings.ToLower(strings.TrimSuffix(host, "."+mdnsDomain)) + "." + ms.domain
+		targets[name] = addresses[host]
+	}
+
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, name := range names {
+		endpoints = append(endpoints, endpoint.NewEndpoint(name, endpoint.RecordTypeA, targets[name]...))
+	}
+
+	log.Debugf("Found %d endpoints for %d mdns services", len(endpoints), len(instances))
+
+	return endpoints, nil
+}
+
+// indexMDNSRecords indexes the service instances, the hosts of the instances and the
+// addresses of the hosts found in the given records.
+func indexMDNSRecords(records []dns.RR) (map[string]bool, map[string]string, map[string]endpoint.Targets) {
+	instances := map[string]bool{}
+	hosts := map[string]string{}
+	addresses := map[string]endpoint.Targets{}
+	addMDNSRecords(records, instances, hosts, addresses)
+	return instances, hosts, addresses
+}
+
+func addMDNSRecords(records []dns.RR, instances map[string]bool, hosts map[string]string, addresses map[string]endpoint.Targets) {
+	for _, rr := range records {
+		switch r := rr.(type) {
+		case *dns.PTR:
+			instances[strings.ToLower(r.Ptr)] = true
+		case *dns.SRV:
+			hosts[strings.ToLower(r.Hdr.Name)] = strings.ToLower(r.Target)
+		case *dns.A:
+			host := strings.ToLower(r.Hdr.Name)
+			ip := r.A.String()
+			if ip4 := r.A.To4(); ip4 != nil && ip4.IsLinkLocalUnicast() {
+				continue
+			}
+			if !containsTarget(addresses[host], ip) {
+				addresses[host] = append(addresses[host], ip)
+			}
+		}
+	}
+}
+
+func containsTarget(targets endpoint.Targets, target string) bool {
+	for _, t := range targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// queryMDNS sends the questions to the mDNS multicast group. As the query isn't sent
+// from port 5353, responders answer directly to the querying socket.
+func queryMDNS(questions []dns.Question, timeout time.Duration) ([]dns.RR, error) {
+	group, err := net.ResolveUDPAddr("udp4", mdnsAddress)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	msg := new(dns.Msg)
+	msg.Question = questions
+	packet, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.WriteToUDP(packet, group); err != nil {
+		return nil, fmt.Errorf("failed to send mdns query: %v", err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	var records []dns.RR
+	buf := make([]byte, dns.MaxMsgSize)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				return records, nil
+			}
+			return nil, err
+		}
+		response := new(dns.Msg)
+		if err := response.Unpack(buf[:n]); err != nil {
+			log.Debugf("Ignoring invalid mdns response: %v", err)
+			continue
+		}
+		records = append(records, response.Answer...)
+		records = append(records, response.Extra...)
+	}
+}
+
+func (ms *mdnsSource) AddEventHandler(ctx context.Context, handler func()) {
+}
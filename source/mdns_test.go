@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func mustRR(t *testing.T, s string) dns.RR {
+	rr, err := dns.NewRR(s)
+	require.NoError(t, err)
+	return rr
+}
+
+func TestMDNSSource(t *testing.T) {
+	t.Run("Interface", testMDNSSourceImplementsSource)
+	t.Run("NewMDNSSource", testMDNSSourceNewMDNSSource)
+	t.Run("Endpoints", testMDNSSourceEndpoints)
+}
+
+// testMDNSSourceImplementsSource tests that mdnsSource is a valid Source.
+func testMDNSSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(mdnsSource))
+}
+
+// testMDNSSourceNewMDNSSource tests that NewMDNSSource validates its configuration.
+func testMDNSSourceNewMDNSSource(t *testing.T) {
+	_, err := NewMDNSSource(nil, "lan.example.org", time.Second)
+	assert.Error(t, err)
+
+	_, err = NewMDNSSource([]string{"_workstation._tcp"}, "", time.Second)
+	assert.Error(t, err)
+
+	_, err = NewMDNSSource([]string{"_workstation._tcp"}, "lan.example.org", time.Second)
+	assert.NoError(t, err)
+}
+
+// testMDNSSourceEndpoints tests that browsed services are resolved to endpoints.
+func testMDNSSourceEndpoints(t *testing.T) {
+	responses := map[string][]dns.RR{
+		"_workstation._tcp.local.": {
+			mustRR(t, "_workstation._tcp.local. 120 IN PTR nas\\ [00:11:22:33:44:55]._workstation._tcp.local."),
+			mustRR(t, "nas\\ [00:11:22:33:44:55]._workstation._tcp.local. 120 IN SRV 0 0 9 NAS.local."),
+			mustRR(t, "NAS.local. 120 IN A 192.168.1.20"),
+			mustRR(t, "NAS.local. 120 IN A 169.254.10.20"),
+			mustRR(t, "_workstation._tcp.local. 120 IN PTR laptop\\ [00:11:22:33:44:56]._workstation._tcp.local."),
+			mustRR(t, "_workstation._tcp.local. 120 IN PTR gone\\ [00:11:22:33:44:57]._workstation._tcp.local."),
+		},
+		"_ipp._tcp.local.": {
+			mustRR(t, "_ipp._tcp.local. 120 IN PTR Printer._ipp._tcp.local."),
+			mustRR(t, "printer._ipp._tcp.local. 120 IN SRV 0 0 631 printer.local."),
+		},
+		"laptop\\ [00:11:22:33:44:56]._workstation._tcp.local.": {
+			mustRR(t, "laptop\\ [00:11:22:33:44:56]._workstation._tcp.local. 120 IN SRV 0 0 9 laptop.local."),
+		},
+		"laptop.local.": {
+			mustRR(t, "laptop.local. 120 IN A 192.168.1.21"),
+		},
+		"printer.local.": {
+			mustRR(t, "printer.local. 120 IN A 192.168.1.22"),
+			mustRR(t, "printer.local. 120 IN A 192.168.1.22"),
+		},
+	}
+
+	ms, err := NewMDNSSource([]string{"_workstation._tcp", "_ipp._tcp.local."}, "lan.example.org.", time.Second)
+	require.NoError(t, err)
+
+	var queries [][]string
+	ms.(*mdnsSource).query = func(questions []dns.Question, timeout time.Duration) ([]dns.RR, error) {
+		var names []string
+		var records []dns.RR
+		for _, q := range questions {
+			names = append(names, q.Name)
+			records = append(records, responses[q.Name]...)
+		}
+		queries = append(queries, names)
+		return records, nil
+	}
+
+	endpoints, err := ms.Endpoints()
+	require.NoError(t, err)
+
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "nas.lan.example.org", Targets: endpoint.Targets{"192.168.1.20"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "laptop.lan.example.org", Targets: endpoint.Targets{"192.168.1.21"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "printer.lan.example.org", Targets: endpoint.Targets{"192.168.1.22"}, RecordType: endpoint.RecordTypeA},
+	})
+	require.Len(t, queries, 3)
+	assert.Equal(t, []string{"_workstation._tcp.local.", "_ipp._tcp.local."}, queries[0])
+	assert.ElementsMatch(t, []string{"laptop\\ [00:11:22:33:44:56]._workstation._tcp.local.", "gone\\ [00:11:22:33:44:57]._workstation._tcp.local."}, queries[1])
+	assert.ElementsMatch(t, []string{"laptop.local.", "printer.local."}, queries[2])
+
+	ms.(*mdnsSource).query = func(questions []dns.Question, timeout time.Duration) ([]dns.RR, error) {
+		return nil, errors.New("network is unreachable")
+	}
+	_, err = ms.Endpoints()
+	assert.Error(t, err)
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/fileutils"
+)
+
+// mqttClient is the subset of the MQTT client used by mqttSource.
+type mqttClient interface {
+	Connect() mqtt.Token
+	Subscribe(topic string, qos byte, callback mqtt.MessageHandler) mqtt.Token
+}
+
+// mqttRegistration is the message a device publishes to register its address.
+// An empty IP removes the registration. Expiry overrides the default expiry
+// of the source, in seconds.
+type mqttRegistration struct {
+	Name   string `json:"name"`
+	IP     string `json:"ip"`
+	Expiry int64  `json:"expiry,omitempty"`
+}
+
+// mqttDevice is a registered device, as kept in memory and in the state file.
+type mqttDevice struct {
+	IP      string    `json:"ip"`
+	Expires time.Time `json:"expires"`
+}
+
+// mqttSource is an implementation of Source that provides endpoints for the devices
+// registering themselves on an MQTT topic.
+//
+// Registrations expire unless they are renewed by the device. The table of registered
+// devices is kept in memory and, if a state file is configured, persisted so restarts
+// don't drop devices that only publish infrequently.
+type mqttSource struct {
+	expiry    time.Duration
+	stateFile string
+	now       func() time.Time
+
+	sync.Mutex
+	devices  map[string]mqttDevice
+	handlers []func()
+}
+
+// NewMQTTSource creates a new mqttSource subscribing to the given topic.
+func NewMQTTSource(client mqttClient, topic string, expiry time.Duration, stateFile string) (Source, error) {
+	if topic == "" {
+		return nil, fmt.Errorf("mqtt topic must not be empty")
+	}
+
+	ms, err := newMQTTSource(expiry, stateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		return nil, fmt.Errorf("failed to connect to mqtt broker: %v", token.Error())
+	}
+	if token := client.Subscribe(topic, 1, ms.handleMessage); token.Wait() && token.Error() != nil {
+		return nil, fmt.Errorf("failed to subscribe to mqtt topic %s: %v", topic, token.Error())
+	}
+
+	return ms, nil
+}
+
+func newMQTTSource(expiry time.Duration, stateFile string) (*mqttSource, error) {
+	ms := &mqttSource{
+		expiry:    expiry,
+		stateFile: stateFile,
+		now:       time.Now,
+		devices:   map[string]mqttDevice{},
+	}
+
+	if stateFile != "" {
+		data, err := ioutil.ReadFile(stateFile)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return nil, err
+		default:
+			if err := json.Unmarshal(data, &ms.devices); err != nil {
+				return nil, fmt.Errorf("failed to parse mqtt state file %s: %v", stateFile, err)
+			}
+		}
+	}
+
+	return ms, nil
+}
+
+// Endpoints returns endpoint objects.
+func (ms *mqttSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	ms.Lock()
+	defer ms.Unlock()
+
+	now := ms.now()
+
+	names := make([]string, 0, len(ms.devices))
+	for name, device := range ms.devices {
+		if !device.Expires.After(now) {
+			delete(ms.devices, name)
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, name := range names {
+		endpoints = append(endpoints, endpoint.NewEndpoint(name, endpoint.RecordTypeA, ms.devices[name].IP))
+	}
+
+	log.Debugf("Found %d endpoints for registered mqtt devices", len(endpoints))
+
+	return endpoints, nil
+}
+
+// handleMessage updates the device table with the registration in the given message.
+func (ms *mqttSource) handleMessage(_ mqtt.Client, msg mqtt.Message) {
+	var registration mqttRegistration
+	if err := json.Unmarshal(msg.Payload(), &registration); err != nil {
+		log.Warnf("Ignoring invalid mqtt registration on topic %s: %v", msg.Topic(), err)
+		return
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(registration.Name, "."))
+	if name == "" {
+		log.Warnf("Ignoring mqtt registration without name on topic %s", msg.Topic())
+		return
+	}
+	if registration.IP != "" && net.ParseIP(registration.IP) == nil {
+		log.Warnf("Ignoring mqtt registration of %s with invalid ip %q", name, registration.IP)
+		return
+	}
+
+	ms.Lock()
+	previous, registered := ms.devices[name]
+	if registration.IP == "" {
+		delete(ms.devices, name)
+	} else {
+		expiry := ms.expiry
+		if registration.Expiry > 0 {
+			expiry = time.Duration(registration.Expiry) * time.Second
+		}
+		ms.devices[name] = mqttDevice{IP: registration.IP, Expires: ms.now().Add(expiry)}
+	}
+	changed := registered != (registration.IP != "") || previous.IP != registration.IP
+	if err := ms.persist(); err != nil {
+		log.Errorf("Failed to persist mqtt devices to %s: %v", ms.stateFile, err)
+	}
+	handlers := ms.handlers
+	ms.Unlock()
+
+	if changed {
+		log.Debugf("mqtt device %s registered with ip %q", name, registration.IP)
+		for _, handler := range handlers {
+			handler()
+		}
+	}
+}
+
+// persist writes the device table to the state file. It must be called with the lock held.
+func (ms *mqttSource) persist() error {
+	if ms.stateFile == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(ms.devices)
+	if err != nil {
+		return err
+	}
+	return fileutils.WriteFileAtomically(ms.stateFile, data)
+}
+
+func (ms *mqttSource) AddEventHandler(ctx context.Context, handler func()) {
+	ms.Lock()
+	defer ms.Unlock()
+
+	ms.handlers = append(ms.handlers, handler)
+}
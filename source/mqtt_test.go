@@ -0,0 +1,163 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+type fakeMQTTToken struct {
+	err error
+}
+
+func (t *fakeMQTTToken) Wait() bool                       { return true }
+func (t *fakeMQTTToken) WaitTimeout(_ time.Duration) bool { return true }
+func (t *fakeMQTTToken) Error() error                     { return t.err }
+
+type fakeMQTTClient struct {
+	connectErr error
+	topic      string
+	callback   mqtt.MessageHandler
+}
+
+func (c *fakeMQTTClient) Connect() mqtt.Token {
+	return &fakeMQTTToken{err: c.connectErr}
+}
+
+func (c *fakeMQTTClient) Subscribe(topic string, qos byte, callback mqtt.MessageHandler) mqtt.Token {
+	c.topic = topic
+	c.callback = callback
+	return &fakeMQTTToken{}
+}
+
+func (c *fakeMQTTClient) publish(payload string) {
+	c.callback(nil, &fakeMQTTMessage{topic: c.topic, payload: []byte(payload)})
+}
+
+type fakeMQTTMessage struct {
+	mqtt.Message
+	topic   string
+	payload []byte
+}
+
+func (m *fakeMQTTMessage) Topic() string   { return m.topic }
+func (m *fakeMQTTMessage) Payload() []byte { return m.payload }
+
+func TestMQTTSource(t *testing.T) {
+	t.Run("Interface", testMQTTSourceImplementsSource)
+	t.Run("NewMQTTSource", testMQTTSourceNewMQTTSource)
+	t.Run("Endpoints", testMQTTSourceEndpoints)
+	t.Run("StateFile", testMQTTSourceStateFile)
+}
+
+// testMQTTSourceImplementsSource tests that mqttSource is a valid Source.
+func testMQTTSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(mqttSource))
+}
+
+// testMQTTSourceNewMQTTSource tests that NewMQTTSource connects and subscribes.
+func testMQTTSourceNewMQTTSource(t *testing.T) {
+	_, err := NewMQTTSource(&fakeMQTTClient{}, "", time.Hour, "")
+	assert.Error(t, err)
+
+	_, err = NewMQTTSource(&fakeMQTTClient{connectErr: errors.New("connection refused")}, "devices/register", time.Hour, "")
+	assert.Error(t, err)
+
+	client := &fakeMQTTClient{}
+	_, err = NewMQTTSource(client, "devices/register", time.Hour, "")
+	require.NoError(t, err)
+	assert.Equal(t, "devices/register", client.topic)
+}
+
+// testMQTTSourceEndpoints tests that registrations are converted to endpoints and expire.
+func testMQTTSourceEndpoints(t *testing.T) {
+	client := &fakeMQTTClient{}
+	ms, err := NewMQTTSource(client, "devices/register", time.Hour, "")
+	require.NoError(t, err)
+
+	now := time.Date(2020, 6, 4, 10, 0, 0, 0, time.UTC)
+	ms.(*mqttSource).now = func() time.Time { return now }
+
+	triggered := 0
+	ms.AddEventHandler(context.Background(), func() { triggered++ })
+
+	client.publish(`{"name": "Sensor-1.iot.example.org.", "ip": "10.0.0.1"}`)
+	client.publish(`{"name": "sensor-2.iot.example.org", "ip": "10.0.0.2", "expiry": 60}`)
+	client.publish(`{"name": "sensor-3.iot.example.org", "ip": "10.0.0.3"}`)
+	client.publish(`{"name": "sensor-3.iot.example.org", "ip": ""}`)
+	client.publish(`{"name": "sensor-4.iot.example.org", "ip": "not-an-ip"}`)
+	client.publish(`not json`)
+	assert.Equal(t, 4, triggered)
+
+	// renewing a registration doesn't trigger the handlers
+	client.publish(`{"name": "sensor-1.iot.example.org", "ip": "10.0.0.1"}`)
+	assert.Equal(t, 4, triggered)
+
+	endpoints, err := ms.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "sensor-1.iot.example.org", Targets: endpoint.Targets{"10.0.0.1"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "sensor-2.iot.example.org", Targets: endpoint.Targets{"10.0.0.2"}, RecordType: endpoint.RecordTypeA},
+	})
+
+	now = now.Add(10 * time.Minute)
+
+	endpoints, err = ms.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "sensor-1.iot.example.org", Targets: endpoint.Targets{"10.0.0.1"}, RecordType: endpoint.RecordTypeA},
+	})
+}
+
+// testMQTTSourceStateFile tests that registrations survive a restart with a state file.
+func testMQTTSourceStateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-mqtt")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	stateFile := filepath.Join(dir, "devices.json")
+
+	client := &fakeMQTTClient{}
+	_, err = NewMQTTSource(client, "devices/register", time.Hour, stateFile)
+	require.NoError(t, err)
+	client.publish(`{"name": "sensor-1.iot.example.org", "ip": "10.0.0.1"}`)
+
+	ms, err := NewMQTTSource(&fakeMQTTClient{}, "devices/register", time.Hour, stateFile)
+	require.NoError(t, err)
+
+	endpoints, err := ms.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "sensor-1.iot.example.org", Targets: endpoint.Targets{"10.0.0.1"}, RecordType: endpoint.RecordTypeA},
+	})
+
+	require.NoError(t, ioutil.WriteFile(stateFile, []byte("{"), 0600))
+	_, err = NewMQTTSource(&fakeMQTTClient{}, "devices/register", time.Hour, stateFile)
+	assert.Error(t, err)
+}
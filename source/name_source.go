@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// nameSource is a Source that rewrites the names of the endpoints of its wrapped source, e.g. to
+// qualify the host names of a source with a base domain: the strip suffix is removed from names
+// ending with it, then the prefix is prepended to and the suffix appended to all names. Sources
+// of unqualified host names can thereby share a single implementation of their base domain,
+// and name sources can be chained.
+//
+// The suffixes are domains, which are separated from the names by a dot, while the prefix is
+// prepended as is, e.g. "lab-" or "internal.". Targets aren't rewritten.
+type nameSource struct {
+	source      Source
+	prefix      string
+	suffix      string
+	stripSuffix string
+}
+
+// NewNameSource creates a new nameSource wrapping the provided Source. Empty affixes aren't applied.
+func NewNameSource(source Source, prefix, suffix, stripSuffix string) Source {
+	return &nameSource{
+		source:      source,
+		prefix:      prefix,
+		suffix:      strings.Trim(suffix, "."),
+		stripSuffix: strings.Trim(stripSuffix, "."),
+	}
+}
+
+// Endpoints collects endpoints from its wrapped source and rewrites their names.
+func (ns *nameSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	endpoints, err := ns.source.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if name := ns.name(ep.DNSName); name != ep.DNSName {
+			// Renaming the endpoint of the wrapped source in place would rename it again on its next call.
+			ep = ep.DeepCopy()
+			ep.DNSName = name
+		}
+		result = append(result, ep)
+	}
+
+	return result, nil
+}
+
+// name returns the rewritten name, keeping its trailing dot.
+func (ns *nameSource) name(dnsName string) string {
+	name := strings.TrimSuffix(dnsName, ".")
+	if ns.stripSuffix != "" && strings.HasSuffix(strings.ToLower(name), "."+strings.ToLower(ns.stripSuffix)) {
+		name = name[:len(name)-len(ns.stripSuffix)-1]
+	}
+	name = ns.prefix + name
+	if ns.suffix != "" {
+		name += "." + ns.suffix
+	}
+	if strings.HasSuffix(dnsName, ".") {
+		name += "."
+	}
+	return name
+}
+
+func (ns *nameSource) AddEventHandler(ctx context.Context, handler func()) {
+	ns.source.AddEventHandler(ctx, handler)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+// Validates that nameSource is a Source
+var _ Source = &nameSource{}
+
+func TestNameSource(t *testing.T) {
+	t.Run("Endpoints", testNameSourceEndpoints)
+	t.Run("Chained", testNameSourceChained)
+	t.Run("Error", testNameSourceError)
+}
+
+// testNameSourceEndpoints tests that the names of the endpoints are rewritten.
+func testNameSourceEndpoints(t *testing.T) {
+	for _, tc := range []struct {
+		title                       string
+		prefix, suffix, stripSuffix string
+		names, expected             []string
+	}{
+		{
+			title:    "suffix is appended",
+			suffix:   "example.org",
+			names:    []string{"nas", "printer.lan"},
+			expected: []string{"nas.example.org", "printer.lan.example.org"},
+		},
+		{
+			title:       "suffix is replaced",
+			suffix:      ".example.org.",
+			stripSuffix: "lan",
+			names:       []string{"nas", "printer.LAN", "lan", "router.plan"},
+			expected:    []string{"nas.example.org", "printer.example.org", "lan.example.org", "router.plan.example.org"},
+		},
+		{
+			title:       "suffix is stripped",
+			stripSuffix: "example.com",
+			names:       []string{"nas.example.com", "nas.example.org"},
+			expected:    []string{"nas", "nas.example.org"},
+		},
+		{
+			title:    "prefix is prepended",
+			prefix:   "lab-",
+			suffix:   "example.org",
+			names:    []string{"nas"},
+			expected: []string{"lab-nas.example.org"},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			var endpoints []*endpoint.Endpoint
+			for _, name := range tc.names {
+				endpoints = append(endpoints, endpoint.NewEndpoint(name, endpoint.RecordTypeA, "10.0.0.1"))
+			}
+			mockSource := new(testutils.MockSource)
+			mockSource.On("Endpoints").Return(endpoints, nil)
+
+			result, err := NewNameSource(mockSource, tc.prefix, tc.suffix, tc.stripSuffix).Endpoints()
+			require.NoError(t, err)
+			var names []string
+			for _, ep := range result {
+				names = append(names, ep.DNSName)
+			}
+			assert.Equal(t, tc.expected, names)
+			// The endpoints of the wrapped source are left unchanged.
+			for i, ep := range endpoints {
+				assert.Equal(t, tc.names[i], ep.DNSName)
+			}
+		})
+	}
+}
+
+// testNameSourceChained tests that name sources can be chained.
+func testNameSourceChained(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("nas.lan", endpoint.RecordTypeA, "10.0.0.1"),
+	}, nil)
+
+	endpoints, err := NewNameSource(NewNameSource(mockSource, "", "home", "lan"), "", "example.org", "").Endpoints()
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, "nas.home.example.org", endpoints[0].DNSName)
+}
+
+// testNameSourceError tests that errors of the wrapped source are returned.
+func testNameSourceError(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return(nil, errors.New("some error"))
+
+	_, err := NewNameSource(mockSource, "", "example.org", "").Endpoints()
+	assert.EqualError(t, err, "some error")
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	// arpFlagComplete marks resolved entries of /proc/net/arp
+	arpFlagComplete = 0x2
+
+	// neighborMaxProbes limits the number of addresses probed per synchronization
+	neighborMaxProbes = 4096
+	// neighborProbePort is the discard port; probes only need to trigger address resolution
+	neighborProbePort = "9"
+)
+
+// neighbor is a resolved entry of the neighbor table.
+type neighbor struct {
+	ip  net.IP
+	mac string
+}
+
+// neighborSource is an implementation of Source that provides endpoints for the hosts
+// found in the neighbor (ARP) table of the node, for zero-config lab DNS.
+//
+// Hosts are named after their MAC address if it is mapped to a name, or else after the
+// name their address reverse-resolves to. Hosts without a name are skipped. If subnets
+// are configured, only hosts in them are published and every address in them is probed
+// first, so hosts the node hasn't talked to yet show up in the table.
+type neighborSource struct {
+	table    string
+	subnets  []*net.IPNet
+	macNames map[string]string
+	domain   string
+	lookup   func(addr string) ([]string, error)
+	probe    func(ip net.IP)
+}
+
+// NewNeighborSource creates a new neighborSource reading the neighbor table at the given path.
+// macNames are of the form "<mac>=<name>".
+func NewNeighborSource(table string, subnets, macNames []string, domain string) (Source, error) {
+	if table == "" {
+		return nil, fmt.Errorf("neighbor table path must not be empty")
+	}
+
+	ns := &neighborSource{
+		table:    table,
+		macNames: map[string]string{},
+		domain:   strings.Trim(domain, "."),
+		lookup:   net.LookupAddr,
+		probe:    probeNeighbor,
+	}
+
+	for _, subnet := range subnets {
+		_, ipNet, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return nil, fmt.Errorf("invalid neighbor subnet %q: %v", subnet, err)
+		}
+		ns.subnets = append(ns.subnets, ipNet)
+	}
+
+	for _, macName := range macNames {
+		parts := strings.SplitN(macName, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("invalid mac name mapping %q, expected <mac>=<name>", macName)
+		}
+		mac, err := net.ParseMAC(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid mac name mapping %q: %v", macName, err)
+		}
+		ns.macNames[mac.String()] = strings.ToLower(strings.TrimSuffix(parts[1], "."))
+	}
+
+	return ns, nil
+}
+
+// Endpoints returns endpoint objects.
+func (ns *neighborSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	ns.probeSubnets()
+
+	f, err := os.Open(ns.table)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	neighbors, err := parseARPTable(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse neighbor table %s: %v", ns.table, err)
+	}
+
+	targets := map[string]endpoint.Targets{}
+	for _, n := range neighbors {
+		if !ns.inSubnets(n.ip) {
+			continue
+		}
+		name := ns.name(n)
+		if name == "" {
+			log.Debugf("Skipping neighbor %s (%s) without name", n.ip, n.mac)
+			continue
+		}
+		targets[name] = append(targets[name], n.ip.String())
+	}
+
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, name := range names {
+		endpoints = append(endpoints, endpoint.NewEndpoint(name, endpoint.RecordTypeA, targets[name]...))
+	}
+
+	log.Debugf("Found %d endpoints for %d neighbors", len(endpoints), len(neighbors))
+
+	return endpoints, nil
+}
+
+// name returns the DNS name of the given neighbor, or an empty string if it has none.
+func (ns *neighborSource) name(n neighbor) string {
+	name, ok := ns.macNames[n.mac]
+	if !ok {
+		names, err := ns.lookup(n.ip.String())
+		if err != nil || len(names) == 0 {
+			return ""
+		}
+		name = strings.ToLower(strings.TrimSuffix(names[0], "."))
+	}
+
+	if strings.Contains(name, ".") {
+		return name
+	}
+	if ns.domain == "" {
+		return ""
+	}
+	return name + "." + ns.domain
+}
+
+func (ns *neighborSource) inSubnets(ip net.IP) bool {
+	if len(ns.subnets) == 0 {
+		return true
+	}
+	for _, subnet := range ns.subnets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// probeSubnets sends a probe to every host address of the configured IPv4 subnets.
+func (ns *neighborSource) probeSubnets() {
+	probes := 0
+	for _, subnet := range ns.subnets {
+		ip := subnet.IP.To4()
+		if ip == nil {
+			continue
+		}
+		// Skip the network and broadcast addresses.
+		for ip := nextIP(ip.Mask(subnet.Mask)); subnet.Contains(nextIP(ip)); ip = nextIP(ip) {
+			if probes == neighborMaxProbes {
+				log.Warnf("Stopped probing neighbors after %d addresses", neighborMaxProbes)
+				return
+			}
+			ns.probe(ip)
+			probes++
+		}
+	}
+}
+
+// probeNeighbor sends an empty datagram to the given address, which makes the kernel
+// resolve its link layer address.
+func probeNeighbor(ip net.IP) {
+	conn, err := net.Dial("udp", net.JoinHostPort(ip.String(), neighborProbePort))
+	if err != nil {
+		return
+	}
+	conn.Write(nil)
+	conn.Close()
+}
+
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+// parseARPTable parses the format of /proc/net/arp, which has the columns
+// "IP address, HW type, Flags, HW address, Mask, Device". Incomplete entries are skipped.
+func parseARPTable(r io.Reader) ([]neighbor, error) {
+	var neighbors []neighbor
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if line == 1 || len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("line %d: expected at least 4 fields, got %d", line, len(fields))
+		}
+
+		ip := net.ParseIP(fields[0])
+		if ip == nil {
+			return nil, fmt.Errorf("line %d: invalid ip %q", line, fields[0])
+		}
+		var flags int
+		if _, err := fmt.Sscanf(fields[2], "0x%x", &flags); err != nil {
+			return nil, fmt.Errorf("line %d: invalid flags %q", line, fields[2])
+		}
+		mac, err := net.ParseMAC(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid hardware address %q", line, fields[3])
+		}
+		if flags&arpFlagComplete == 0 {
+			continue
+		}
+
+		neighbors = append(neighbors, neighbor{ip: ip, mac: mac.String()})
+	}
+
+	return neighbors, scanner.Err()
+}
+
+func (ns *neighborSource) AddEventHandler(ctx context.Context, handler func()) {
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const testARPTable = `IP address       HW type     Flags       HW address            Mask     Device
+192.168.1.1      0x1         0x2         aa:bb:cc:00:00:01     *        eth0
+192.168.1.10     0x1         0x2         AA:BB:CC:00:00:10     *        eth0
+192.168.1.11     0x1         0x2         aa:bb:cc:00:00:11     *        eth0
+192.168.1.12     0x1         0x0         00:00:00:00:00:00     *        eth0
+192.168.1.13     0x1         0x2         aa:bb:cc:00:00:13     *        eth0
+10.0.0.5         0x1         0x2         aa:bb:cc:00:00:05     *        eth1
+`
+
+func TestNeighborSource(t *testing.T) {
+	t.Run("Interface", testNeighborSourceImplementsSource)
+	t.Run("NewNeighborSource", testNeighborSourceNewNeighborSource)
+	t.Run("Endpoints", testNeighborSourceEndpoints)
+	t.Run("Probes", testNeighborSourceProbes)
+}
+
+// testNeighborSourceImplementsSource tests that neighborSource is a valid Source.
+func testNeighborSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(neighborSource))
+}
+
+// testNeighborSourceNewNeighborSource tests that NewNeighborSource validates its configuration.
+func testNeighborSourceNewNeighborSource(t *testing.T) {
+	for _, ti := range []struct {
+		title       string
+		table       string
+		subnets     []string
+		macNames    []string
+		expectError bool
+	}{
+		{title: "missing table", expectError: true},
+		{title: "invalid subnet", table: "/proc/net/arp", subnets: []string{"192.168.1.0"}, expectError: true},
+		{title: "invalid mac", table: "/proc/net/arp", macNames: []string{"printer=aa:bb"}, expectError: true},
+		{title: "missing name", table: "/proc/net/arp", macNames: []string{"aa:bb:cc:00:00:10="}, expectError: true},
+		{title: "valid", table: "/proc/net/arp", subnets: []string{"192.168.1.0/24"}, macNames: []string{"aa:bb:cc:00:00:10=printer"}},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			_, err := NewNeighborSource(ti.table, ti.subnets, ti.macNames, "lab.example.org")
+			if ti.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// testNeighborSourceEndpoints tests that named neighbors are converted to endpoints.
+func testNeighborSourceEndpoints(t *testing.T) {
+	f, err := ioutil.TempFile("", "arp")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(testARPTable)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	ptrs := map[string][]string{
+		"192.168.1.1":  {"gateway.lab.example.org."},
+		"192.168.1.11": {"Laptop"},
+		"10.0.0.5":     {"build.ci.example.org."},
+	}
+
+	for _, ti := range []struct {
+		title    string
+		subnets  []string
+		domain   string
+		expected []*endpoint.Endpoint
+	}{
+		{
+			title:  "all neighbors",
+			domain: "lab.example.org",
+			expected: []*endpoint.Endpoint{
+				{DNSName: "gateway.lab.example.org", Targets: endpoint.Targets{"192.168.1.1"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "printer.lab.example.org", Targets: endpoint.Targets{"192.168.1.10"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "laptop.lab.example.org", Targets: endpoint.Targets{"192.168.1.11"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "build.ci.example.org", Targets: endpoint.Targets{"10.0.0.5"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:   "subnet without domain",
+			subnets: []string{"192.168.1.0/30"},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "gateway.lab.example.org", Targets: endpoint.Targets{"192.168.1.1"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			ns, err := NewNeighborSource(f.Name(), ti.subnets, []string{"aa:bb:cc:00:00:10=printer"}, ti.domain)
+			require.NoError(t, err)
+			ns.(*neighborSource).probe = func(ip net.IP) {}
+			ns.(*neighborSource).lookup = func(addr string) ([]string, error) {
+				if names, ok := ptrs[addr]; ok {
+					return names, nil
+				}
+				return nil, errors.New("no such host")
+			}
+
+			endpoints, err := ns.Endpoints()
+			require.NoError(t, err)
+
+			validateEndpoints(t, endpoints, ti.expected)
+		})
+	}
+}
+
+// testNeighborSourceProbes tests that every host address of the subnets is probed.
+func testNeighborSourceProbes(t *testing.T) {
+	ns, err := NewNeighborSource("/nonexistent/arp", []string{"192.168.1.5/30", "fd00::/64"}, nil, "lab.example.org")
+	require.NoError(t, err)
+
+	var probed []string
+	ns.(*neighborSource).probe = func(ip net.IP) { probed = append(probed, ip.String()) }
+
+	_, err = ns.Endpoints()
+	assert.Error(t, err)
+	assert.Equal(t, []string{"192.168.1.5", "192.168.1.6"}, probed)
+}
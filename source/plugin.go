@@ -0,0 +1,155 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/plugin"
+)
+
+const (
+	pluginRetryInterval = 5 * time.Second
+)
+
+// pluginSource is an implementation of Source that provides endpoints by calling
+// a plugin serving the EndpointSource gRPC service on a unix socket.
+type pluginSource struct {
+	client  plugin.EndpointSourceClient
+	timeout time.Duration
+}
+
+// NewPluginSource creates a new pluginSource connecting to the plugin listening on the given unix socket.
+func NewPluginSource(socket string, timeout time.Duration) (Source, error) {
+	conn, err := grpc.Dial(socket,
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", strings.TrimPrefix(addr, "unix://"))
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPluginSourceWithClient(plugin.NewEndpointSourceClient(conn), timeout), nil
+}
+
+func newPluginSourceWithClient(client plugin.EndpointSourceClient, timeout time.Duration) *pluginSource {
+	return &pluginSource{
+		client:  client,
+		timeout: timeout,
+	}
+}
+
+// Endpoints returns endpoint objects.
+func (ps *pluginSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	ctx := context.Background()
+	if ps.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ps.timeout)
+		defer cancel()
+	}
+
+	resp, err := ps.client.ListEndpoints(ctx, &plugin.ListEndpointsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make([]*endpoint.Endpoint, 0, len(resp.GetEndpoints()))
+	for _, ep := range resp.GetEndpoints() {
+		if ep.GetDnsName() == "" || len(ep.GetTargets()) == 0 {
+			log.Warnf("Skipping endpoint %q without targets received from plugin", ep.GetDnsName())
+			continue
+		}
+		endpoints = append(endpoints, endpointFromPlugin(ep))
+	}
+
+	log.Debugf("Received endpoints from plugin: %#v", endpoints)
+
+	return endpoints, nil
+}
+
+// AddEventHandler watches the plugin for changes and triggers the handler for every
+// notification. The watch is re-established if the plugin closes the stream.
+func (ps *pluginSource) AddEventHandler(ctx context.Context, handler func()) {
+	go func() {
+		for {
+			if err := ps.watch(ctx, handler); err != nil {
+				log.Warnf("Watching plugin endpoints failed: %v", err)
+			}
+
+			select {
+			case <-time.After(pluginRetryInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (ps *pluginSource) watch(ctx context.Context, handler func()) error {
+	stream, err := ps.client.WatchEndpoints(ctx, &plugin.WatchEndpointsRequest{})
+	if err != nil {
+		return err
+	}
+
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		handler()
+	}
+}
+
+// endpointFromPlugin converts an endpoint received from a plugin.
+func endpointFromPlugin(ep *plugin.Endpoint) *endpoint.Endpoint {
+	recordType := ep.GetRecordType()
+	if recordType == "" {
+		recordType = suitableType(ep.GetTargets()[0])
+	}
+
+	labels := endpoint.NewLabels()
+	for k, v := range ep.GetLabels() {
+		labels[k] = v
+	}
+
+	var providerSpecific endpoint.ProviderSpecific
+	for _, p := range ep.GetProviderSpecific() {
+		providerSpecific = append(providerSpecific, endpoint.ProviderSpecificProperty{Name: p.GetName(), Value: p.GetValue()})
+	}
+
+	return &endpoint.Endpoint{
+		DNSName:          strings.TrimSuffix(ep.GetDnsName(), "."),
+		Targets:          endpoint.NewTargets(ep.GetTargets()...),
+		RecordType:       recordType,
+		SetIdentifier:    ep.GetSetIdentifier(),
+		RecordTTL:        endpoint.TTL(ep.GetRecordTtl()),
+		Labels:           labels,
+		ProviderSpecific: providerSpecific,
+	}
+}
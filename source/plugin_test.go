@@ -0,0 +1,145 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/plugin"
+)
+
+type fakePluginServer struct {
+	endpoints []*plugin.Endpoint
+	changes   chan struct{}
+}
+
+func (s *fakePluginServer) ListEndpoints(ctx context.Context, req *plugin.ListEndpointsRequest) (*plugin.ListEndpointsResponse, error) {
+	return &plugin.ListEndpointsResponse{Endpoints: s.endpoints}, nil
+}
+
+func (s *fakePluginServer) WatchEndpoints(req *plugin.WatchEndpointsRequest, stream plugin.EndpointSource_WatchEndpointsServer) error {
+	for {
+		select {
+		case <-s.changes:
+			if err := stream.Send(&plugin.WatchEndpointsResponse{}); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func startPluginServer(t *testing.T, server *fakePluginServer) (string, func()) {
+	dir, err := ioutil.TempDir("", "external-dns-plugin")
+	require.NoError(t, err)
+
+	socket := filepath.Join(dir, "plugin.sock")
+	ln, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	s := grpc.NewServer()
+	plugin.RegisterEndpointSourceServer(s, server)
+	go s.Serve(ln)
+
+	return socket, func() {
+		s.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestPluginSource(t *testing.T) {
+	t.Run("Interface", testPluginSourceImplementsSource)
+	t.Run("Endpoints", testPluginSourceEndpoints)
+	t.Run("EventHandler", testPluginSourceEventHandler)
+	t.Run("Unavailable", testPluginSourceUnavailable)
+}
+
+// testPluginSourceImplementsSource tests that pluginSource is a valid Source.
+func testPluginSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(pluginSource))
+}
+
+// testPluginSourceEndpoints tests that endpoints are converted from the plugin response.
+func testPluginSourceEndpoints(t *testing.T) {
+	socket, stop := startPluginServer(t, &fakePluginServer{
+		endpoints: []*plugin.Endpoint{
+			{DnsName: "abc.example.org.", Targets: []string{"1.2.3.4"}, RecordTtl: 180},
+			{DnsName: "xyz.example.org", Targets: []string{"abc.example.org"}, RecordType: endpoint.RecordTypeCNAME, SetIdentifier: "eu",
+				ProviderSpecific: []*plugin.ProviderSpecificProperty{{Name: "alias", Value: "true"}}},
+			{DnsName: "empty.example.org"},
+		},
+	})
+	defer stop()
+
+	ps, err := NewPluginSource(socket, time.Second)
+	require.NoError(t, err)
+
+	endpoints, err := ps.Endpoints()
+	require.NoError(t, err)
+
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "abc.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA, RecordTTL: 180},
+		{DNSName: "xyz.example.org", Targets: endpoint.Targets{"abc.example.org"}, RecordType: endpoint.RecordTypeCNAME},
+	})
+	assert.Equal(t, "eu", endpoints[1].SetIdentifier)
+	assert.Equal(t, endpoint.ProviderSpecific{{Name: "alias", Value: "true"}}, endpoints[1].ProviderSpecific)
+}
+
+// testPluginSourceEventHandler tests that watch notifications trigger the handler.
+func testPluginSourceEventHandler(t *testing.T) {
+	server := &fakePluginServer{changes: make(chan struct{})}
+	socket, stop := startPluginServer(t, server)
+	defer stop()
+
+	ps, err := NewPluginSource(socket, time.Second)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	triggered := make(chan struct{}, 1)
+	ps.AddEventHandler(ctx, func() { triggered <- struct{}{} })
+
+	server.changes <- struct{}{}
+
+	select {
+	case <-triggered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the event handler to be triggered")
+	}
+}
+
+// testPluginSourceUnavailable tests that an unreachable plugin results in an error.
+func testPluginSourceUnavailable(t *testing.T) {
+	ps, err := NewPluginSource(filepath.Join(os.TempDir(), "external-dns-missing.sock"), 100*time.Millisecond)
+	require.NoError(t, err)
+
+	_, err = ps.Endpoints()
+	assert.Error(t, err)
+}
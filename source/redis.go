@@ -0,0 +1,155 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-redis/redis"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	redisKeyTypeHash = "hash"
+	redisKeyTypeSet  = "set"
+	redisKeyTypeNone = "none"
+)
+
+// redisClient is the subset of the redis client used by redisSource.
+type redisClient interface {
+	Type(key string) *redis.StatusCmd
+	HGetAll(key string) *redis.StringStringMapCmd
+	SMembers(key string) *redis.StringSliceCmd
+	Subscribe(channels ...string) *redis.PubSub
+}
+
+// redisSource is an implementation of Source that provides endpoints stored in a redis key.
+//
+// If the key holds a hash, every field is a DNS name and its value a comma separated list
+// of targets. If the key holds a set, every member is of the form "<dns name>=<target>".
+type redisSource struct {
+	client        redisClient
+	key           string
+	db            int
+	notifications bool
+}
+
+// NewRedisSource creates a new redisSource reading endpoints from the given key.
+func NewRedisSource(client redisClient, key string, db int, notifications bool) (Source, error) {
+	if key == "" {
+		return nil, fmt.Errorf("redis key must not be empty")
+	}
+
+	return &redisSource{
+		client:        client,
+		key:           key,
+		db:            db,
+		notifications: notifications,
+	}, nil
+}
+
+// Endpoints returns endpoint objects.
+func (rs *redisSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	keyType, err := rs.client.Type(rs.key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	targetsByName := map[string]endpoint.Targets{}
+
+	switch keyType {
+	case redisKeyTypeHash:
+		fields, err := rs.client.HGetAll(rs.key).Result()
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range fields {
+			for _, target := range strings.Split(value, ",") {
+				if target = strings.TrimSpace(target); target != "" {
+					targetsByName[name] = append(targetsByName[name], target)
+				}
+			}
+		}
+	case redisKeyTypeSet:
+		members, err := rs.client.SMembers(rs.key).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			parts := strings.SplitN(member, "=", 2)
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				log.Warnf("Skipping invalid member %q of redis set %s", member, rs.key)
+				continue
+			}
+			targetsByName[parts[0]] = append(targetsByName[parts[0]], parts[1])
+		}
+	case redisKeyTypeNone:
+		log.Debugf("Redis key %s does not exist", rs.key)
+	default:
+		return nil, fmt.Errorf("redis key %s has unsupported type %q, expected hash or set", rs.key, keyType)
+	}
+
+	names := make([]string, 0, len(targetsByName))
+	for name := range targetsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, name := range names {
+		endpoints = append(endpoints, endpointsForHostname(name, targetsByName[name], 0, nil, "")...)
+	}
+
+	log.Debugf("Received endpoints from redis: %#v", endpoints)
+
+	return endpoints, nil
+}
+
+// AddEventHandler subscribes to the keyspace notifications of the configured key, if enabled,
+// and triggers the handler whenever the key is modified. Notifications must be enabled on the
+// redis server itself (notify-keyspace-events).
+func (rs *redisSource) AddEventHandler(ctx context.Context, handler func()) {
+	if !rs.notifications {
+		return
+	}
+
+	channel := fmt.Sprintf("__keyspace@%d__:%s", rs.db, rs.key)
+	pubsub := rs.client.Subscribe(channel)
+
+	go func() {
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				log.Debugf("Received redis keyspace notification %q on %s", msg.Payload, msg.Channel)
+				handler()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
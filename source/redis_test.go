@@ -0,0 +1,141 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+type fakeRedisClient struct {
+	keyType string
+	hash    map[string]string
+	set     []string
+	err     error
+}
+
+func (c *fakeRedisClient) Type(key string) *redis.StatusCmd {
+	return redis.NewStatusResult(c.keyType, c.err)
+}
+
+func (c *fakeRedisClient) HGetAll(key string) *redis.StringStringMapCmd {
+	return redis.NewStringStringMapResult(c.hash, nil)
+}
+
+func (c *fakeRedisClient) SMembers(key string) *redis.StringSliceCmd {
+	return redis.NewStringSliceResult(c.set, nil)
+}
+
+func (c *fakeRedisClient) Subscribe(channels ...string) *redis.PubSub {
+	return nil
+}
+
+func TestRedisSource(t *testing.T) {
+	t.Run("Interface", testRedisSourceImplementsSource)
+	t.Run("NewRedisSource", testRedisSourceNewRedisSource)
+	t.Run("Endpoints", testRedisSourceEndpoints)
+}
+
+// testRedisSourceImplementsSource tests that redisSource is a valid Source.
+func testRedisSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(redisSource))
+}
+
+// testRedisSourceNewRedisSource tests that NewRedisSource validates its key.
+func testRedisSourceNewRedisSource(t *testing.T) {
+	_, err := NewRedisSource(&fakeRedisClient{}, "", 0, false)
+	assert.Error(t, err)
+
+	_, err = NewRedisSource(&fakeRedisClient{}, "external-dns", 0, false)
+	assert.NoError(t, err)
+}
+
+// testRedisSourceEndpoints tests that endpoints are read from hashes and sets.
+func testRedisSourceEndpoints(t *testing.T) {
+	for _, ti := range []struct {
+		title       string
+		client      *fakeRedisClient
+		expected    []*endpoint.Endpoint
+		expectError bool
+	}{
+		{
+			title:    "missing key",
+			client:   &fakeRedisClient{keyType: "none"},
+			expected: []*endpoint.Endpoint{},
+		},
+		{
+			title:       "client error",
+			client:      &fakeRedisClient{err: errors.New("connection refused")},
+			expectError: true,
+		},
+		{
+			title:       "unsupported key type",
+			client:      &fakeRedisClient{keyType: "list"},
+			expectError: true,
+		},
+		{
+			title: "hash with address and hostname targets",
+			client: &fakeRedisClient{
+				keyType: "hash",
+				hash: map[string]string{
+					"agent-1.example.org": "10.0.0.1, 10.0.0.2",
+					"agent-2.example.org": "lb.example.org",
+					"agent-3.example.org": "",
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "agent-1.example.org", Targets: endpoint.Targets{"10.0.0.1", "10.0.0.2"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "agent-2.example.org", Targets: endpoint.Targets{"lb.example.org"}, RecordType: endpoint.RecordTypeCNAME},
+			},
+		},
+		{
+			title: "set with invalid members",
+			client: &fakeRedisClient{
+				keyType: "set",
+				set: []string{
+					"agent-1.example.org=10.0.0.1",
+					"agent-1.example.org=10.0.0.2",
+					"agent-2.example.org",
+					"=10.0.0.3",
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "agent-1.example.org", Targets: endpoint.Targets{"10.0.0.1", "10.0.0.2"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			rs, err := NewRedisSource(ti.client, "external-dns", 0, false)
+			require.NoError(t, err)
+
+			endpoints, err := rs.Endpoints()
+			if ti.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			validateEndpoints(t, endpoints, ti.expected)
+		})
+	}
+}
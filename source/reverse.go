@@ -0,0 +1,154 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// reverseSource is a Source that derives the PTR endpoints of the reverse zones it manages from
+// the A endpoints of its wrapped source, e.g. the files source reading a forward endpoints file.
+// It emits only the PTR endpoints, so a second instance manages reverse DNS independently of the
+// instance managing the forward zones, with its own policy and registry.
+//
+// Addresses named by several endpoints get a PTR endpoint with all their names as targets.
+// Addresses outside of the reverse zones are skipped.
+type reverseSource struct {
+	source Source
+	zones  []string
+}
+
+// NewReverseSource creates a new reverseSource wrapping the provided Source, managing the given
+// reverse zones, e.g. 10.in-addr.arpa.
+func NewReverseSource(source Source, zones []string) (Source, error) {
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("no reverse zones specified")
+	}
+	normalized := make([]string, 0, len(zones))
+	for _, zone := range zones {
+		zone = strings.ToLower(strings.Trim(zone, "."))
+		if !strings.HasSuffix(zone, "in-addr.arpa") && !strings.HasSuffix(zone, "ip6.arpa") {
+			return nil, fmt.Errorf("%s is not a reverse zone", zone)
+		}
+		normalized = append(normalized, zone)
+	}
+	return &reverseSource{source: source, zones: normalized}, nil
+}
+
+// Endpoints collects the A endpoints of its wrapped source and returns their PTR endpoints.
+func (rs *reverseSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	endpoints, err := rs.source.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+	return rs.ptrEndpoints(endpoints), nil
+}
+
+// ptrEndpoints returns the PTR endpoints of the addresses of the A endpoints in the reverse zones.
+func (rs *reverseSource) ptrEndpoints(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	var names []string
+	ptrs := map[string]*endpoint.Endpoint{}
+	for _, ep := range endpoints {
+		if ep.RecordType != endpoint.RecordTypeA {
+			continue
+		}
+		for _, target := range ep.Targets {
+			name, err := dns.ReverseAddr(target)
+			if err != nil {
+				log.Warnf("Ignoring invalid address %s of %s", target, ep.DNSName)
+				continue
+			}
+			name = strings.TrimSuffix(name, ".")
+			if !rs.managed(name) {
+				log.Debugf("Ignoring address %s of %s outside of the reverse zones", target, ep.DNSName)
+				continue
+			}
+			ptr, ok := ptrs[name]
+			if !ok {
+				ptr = endpoint.NewEndpointWithTTL(name, endpoint.RecordTypePTR, ep.RecordTTL)
+				ptrs[name] = ptr
+				names = append(names, name)
+			}
+			if !targetsContain(ptr.Targets, ep.DNSName) {
+				ptr.Targets = append(ptr.Targets, ep.DNSName)
+			}
+		}
+	}
+
+	result := make([]*endpoint.Endpoint, 0, len(names))
+	for _, name := range names {
+		sort.Strings(ptrs[name].Targets)
+		result = append(result, ptrs[name])
+	}
+
+	log.Debugf("Derived %d PTR endpoints from %d endpoints", len(result), len(endpoints))
+
+	return result
+}
+
+// managed returns whether the name is in one of the reverse zones.
+func (rs *reverseSource) managed(name string) bool {
+	for _, zone := range rs.zones {
+		if name == zone || strings.HasSuffix(name, "."+zone) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rs *reverseSource) AddEventHandler(ctx context.Context, handler func()) {
+	rs.source.AddEventHandler(ctx, handler)
+}
+
+// ptrSource is a Source that adds the PTR endpoints of the reverse zones it manages to the
+// endpoints of its wrapped source, so a single instance manages the forward and reverse zones.
+// The PTR endpoints are derived like those of the reverseSource.
+type ptrSource struct {
+	reverse *reverseSource
+}
+
+// NewPTRSource creates a new ptrSource wrapping the provided Source, managing the given reverse
+// zones, e.g. 10.in-addr.arpa.
+func NewPTRSource(source Source, zones []string) (Source, error) {
+	reverse, err := NewReverseSource(source, zones)
+	if err != nil {
+		return nil, err
+	}
+	return &ptrSource{reverse: reverse.(*reverseSource)}, nil
+}
+
+// Endpoints collects the endpoints of its wrapped source and returns them with the PTR endpoints
+// of their A endpoints.
+func (ps *ptrSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	endpoints, err := ps.reverse.source.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+	return append(endpoints, ps.reverse.ptrEndpoints(endpoints)...), nil
+}
+
+func (ps *ptrSource) AddEventHandler(ctx context.Context, handler func()) {
+	ps.reverse.AddEventHandler(ctx, handler)
+}
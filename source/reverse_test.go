@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+// Validates that reverseSource and ptrSource are Sources
+var _ Source = &reverseSource{}
+var _ Source = &ptrSource{}
+
+func TestReverseSource(t *testing.T) {
+	t.Run("NewReverseSource", testReverseSourceNewReverseSource)
+	t.Run("Endpoints", testReverseSourceEndpoints)
+	t.Run("Error", testReverseSourceError)
+	t.Run("PTRSource", testPTRSourceEndpoints)
+}
+
+func testReverseSourceNewReverseSource(t *testing.T) {
+	_, err := NewReverseSource(new(testutils.MockSource), nil)
+	assert.Error(t, err)
+
+	_, err = NewReverseSource(new(testutils.MockSource), []string{"example.org"})
+	assert.Error(t, err)
+
+	_, err = NewReverseSource(new(testutils.MockSource), []string{"10.in-addr.arpa.", "8.b.d.0.1.0.0.2.ip6.arpa"})
+	assert.NoError(t, err)
+}
+
+// testReverseSourceEndpoints tests that the PTR endpoints of the addresses in the reverse zones are derived.
+func testReverseSourceEndpoints(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 60, "10.0.0.1", "10.0.0.2"),
+		endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "10.0.0.2"),
+		endpoint.NewEndpoint("public.example.org", endpoint.RecordTypeA, "192.0.2.1"),
+		endpoint.NewEndpoint("invalid.example.org", endpoint.RecordTypeA, "10.0.0"),
+		endpoint.NewEndpoint("www.example.org", endpoint.RecordTypeCNAME, "foo.example.org"),
+	}, nil)
+
+	rs, err := NewReverseSource(mockSource, []string{"10.IN-ADDR.ARPA"})
+	require.NoError(t, err)
+	endpoints, err := rs.Endpoints()
+	require.NoError(t, err)
+
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("1.0.0.10.in-addr.arpa", endpoint.RecordTypePTR, 60, "foo.example.org"),
+		endpoint.NewEndpointWithTTL("2.0.0.10.in-addr.arpa", endpoint.RecordTypePTR, 60, "bar.example.org", "foo.example.org"),
+	})
+
+	mockSource.AssertExpectations(t)
+}
+
+// testReverseSourceError tests that errors of the wrapped source are returned.
+func testReverseSourceError(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return(nil, errors.New("some error"))
+
+	rs, err := NewReverseSource(mockSource, []string{"10.in-addr.arpa"})
+	require.NoError(t, err)
+	_, err = rs.Endpoints()
+	assert.EqualError(t, err, "some error")
+}
+
+// testPTRSourceEndpoints tests that the PTR endpoints are added to the endpoints of the wrapped source.
+func testPTRSourceEndpoints(t *testing.T) {
+	forward := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 60, "10.0.0.1"),
+		endpoint.NewEndpoint("public.example.org", endpoint.RecordTypeA, "192.0.2.1"),
+		endpoint.NewEndpoint("www.example.org", endpoint.RecordTypeCNAME, "foo.example.org"),
+	}
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return(forward, nil)
+
+	_, err := NewPTRSource(mockSource, []string{"example.org"})
+	assert.Error(t, err)
+
+	ps, err := NewPTRSource(mockSource, []string{"10.in-addr.arpa"})
+	require.NoError(t, err)
+	endpoints, err := ps.Endpoints()
+	require.NoError(t, err)
+
+	validateEndpoints(t, endpoints, append(forward,
+		endpoint.NewEndpointWithTTL("1.0.0.10.in-addr.arpa", endpoint.RecordTypePTR, 60, "foo.example.org"),
+	))
+
+	mockSource.AssertExpectations(t)
+}
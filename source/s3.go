@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// s3Client is the subset of the S3 API used by s3Source.
+type s3Client interface {
+	GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error)
+}
+
+// s3Source is an implementation of Source that provides endpoints from an endpoints
+// document stored in an S3 compatible bucket.
+//
+// The ETag of the last downloaded document is remembered, so unchanged documents aren't
+// downloaded again.
+type s3Source struct {
+	client s3Client
+	bucket string
+	key    string
+
+	sync.Mutex
+	etag      string
+	endpoints []*endpoint.Endpoint
+}
+
+// NewS3Source creates a new s3Source reading the document at key in bucket.
+func NewS3Source(client s3Client, bucket, key string) (Source, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("s3 bucket must not be empty")
+	}
+	if key == "" {
+		return nil, fmt.Errorf("s3 key must not be empty")
+	}
+
+	return &s3Source{
+		client: client,
+		bucket: bucket,
+		key:    key,
+	}, nil
+}
+
+// newS3Client creates an S3 client for the given endpoint, or AWS if the endpoint is empty.
+// Static credentials are used if given, the default credential chain otherwise.
+func newS3Client(endpoint, region, accessKeyID, secretAccessKey string) (s3Client, error) {
+	config := aws.NewConfig().WithRegion(region)
+	if endpoint != "" {
+		// Most S3 compatible object stores don't support virtual hosted buckets.
+		config = config.WithEndpoint(endpoint).WithS3ForcePathStyle(true)
+	}
+	if accessKeyID != "" {
+		config = config.WithCredentials(credentials.NewStaticCredentials(accessKeyID, secretAccessKey, ""))
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s3.New(sess), nil
+}
+
+// Endpoints returns endpoint objects.
+func (ss *s3Source) Endpoints() ([]*endpoint.Endpoint, error) {
+	ss.Lock()
+	defer ss.Unlock()
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(ss.bucket),
+		Key:    aws.String(ss.key),
+	}
+	if ss.etag != "" {
+		input.IfNoneMatch = aws.String(ss.etag)
+	}
+
+	output, err := ss.client.GetObject(input)
+	if err != nil {
+		if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusNotModified {
+			log.Debugf("Endpoints document s3://%s/%s not modified", ss.bucket, ss.key)
+			return ss.endpoints, nil
+		}
+		return nil, fmt.Errorf("failed to get s3://%s/%s: %v", ss.bucket, ss.key, err)
+	}
+	defer output.Body.Close()
+
+	data, err := ioutil.ReadAll(output.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := decodeEndpointsDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ss.etag = aws.StringValue(output.ETag)
+	ss.endpoints = endpoints
+
+	log.Debugf("Found %d endpoints in s3://%s/%s", len(endpoints), ss.bucket, ss.key)
+
+	return endpoints, nil
+}
+
+func (ss *s3Source) AddEventHandler(ctx context.Context, handler func()) {
+}
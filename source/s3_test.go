@@ -0,0 +1,112 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestS3Source(t *testing.T) {
+	t.Run("Interface", testS3SourceImplementsSource)
+	t.Run("NewS3Source", testS3SourceNewS3Source)
+	t.Run("Endpoints", testS3SourceEndpoints)
+}
+
+// testS3SourceImplementsSource tests that s3Source is a valid Source.
+func testS3SourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(s3Source))
+}
+
+// testS3SourceNewS3Source tests that NewS3Source validates its configuration.
+func testS3SourceNewS3Source(t *testing.T) {
+	_, err := NewS3Source(nil, "", "endpoints.json")
+	assert.Error(t, err)
+
+	_, err = NewS3Source(nil, "inventory", "")
+	assert.Error(t, err)
+
+	_, err = NewS3Source(nil, "inventory", "endpoints.json")
+	assert.NoError(t, err)
+}
+
+// testS3SourceEndpoints tests that the document is downloaded from an S3 compatible
+// object store and only downloaded again when it changes.
+func testS3SourceEndpoints(t *testing.T) {
+	document := `{"endpoints": [{"dnsName": "abc.example.org", "targets": ["1.2.3.4"]}]}`
+	etag := `"v1"`
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/inventory/clusters/endpoints.json", r.URL.Path)
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access-key/"))
+
+		switch {
+		case etag == "":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code></Error>`)
+		case r.Header.Get("If-None-Match") == etag:
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("ETag", etag)
+			fmt.Fprint(w, document)
+		}
+	}))
+	defer server.Close()
+
+	client, err := newS3Client(server.URL, "us-east-1", "access-key", "secret-key")
+	require.NoError(t, err)
+	ss, err := NewS3Source(client, "inventory", "clusters/endpoints.json")
+	require.NoError(t, err)
+
+	expected := []*endpoint.Endpoint{
+		{DNSName: "abc.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+	}
+
+	endpoints, err := ss.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, expected)
+
+	// unchanged documents are served from the cache
+	endpoints, err = ss.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, expected)
+	assert.Equal(t, 2, requests)
+
+	document = `{"endpoints": [{"dnsName": "xyz.example.org", "targets": ["abc.example.org"]}]}`
+	etag = `"v2"`
+
+	endpoints, err = ss.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "xyz.example.org", Targets: endpoint.Targets{"abc.example.org"}, RecordType: endpoint.RecordTypeCNAME},
+	})
+
+	etag = ""
+
+	_, err = ss.Endpoints()
+	assert.Error(t, err)
+}
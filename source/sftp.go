@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"time"
+
+	"github.com/pkg/sftp"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const sftpDefaultPort = "22"
+
+// sftpSource is an implementation of Source that provides endpoints from an endpoints
+// document fetched over SFTP, for appliances that only expose their inventory via SSH.
+//
+// The document is fetched on every synchronization. The host key of the server is pinned
+// and only public key authentication is supported.
+type sftpSource struct {
+	address string
+	path    string
+	config  *ssh.ClientConfig
+}
+
+// NewSFTPSource creates a new sftpSource fetching the document at path from the SSH server
+// at address. hostKey is the public key of the server in authorized_keys format and
+// keyFile the private key used to authenticate as user.
+func NewSFTPSource(address, user, keyFile, hostKey, path string, timeout time.Duration) (Source, error) {
+	if address == "" {
+		return nil, fmt.Errorf("sftp address must not be empty")
+	}
+	if path == "" {
+		return nil, fmt.Errorf("sftp path must not be empty")
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, sftpDefaultPort)
+	}
+
+	if hostKey == "" {
+		return nil, fmt.Errorf("sftp host key must not be empty")
+	}
+	pinned, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid sftp host key: %v", err)
+	}
+
+	pem, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(pem)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sftp private key %s: %v", keyFile, err)
+	}
+
+	return &sftpSource{
+		address: address,
+		path:    path,
+		config: &ssh.ClientConfig{
+			User:              user,
+			Auth:              []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback:   ssh.FixedHostKey(pinned),
+			HostKeyAlgorithms: []string{pinned.Type()},
+			Timeout:           timeout,
+		},
+	}, nil
+}
+
+// Endpoints returns endpoint objects.
+func (ss *sftpSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	data, err := ss.fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := decodeEndpointsDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debugf("Found %d endpoints in %s on %s", len(endpoints), ss.path, ss.address)
+
+	return endpoints, nil
+}
+
+// fetch downloads the endpoints document.
+func (ss *sftpSource) fetch() ([]byte, error) {
+	conn, err := ssh.Dial("tcp", ss.address, ss.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", ss.address, err)
+	}
+	defer conn.Close()
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start sftp session on %s: %v", ss.address, err)
+	}
+	defer client.Close()
+
+	f, err := client.Open(ss.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s on %s: %v", ss.path, ss.address, err)
+	}
+	defer f.Close()
+
+	return ioutil.ReadAll(f)
+}
+
+func (ss *sftpSource) AddEventHandler(ctx context.Context, handler func()) {
+}
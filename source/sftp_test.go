@@ -0,0 +1,183 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// startSFTPServer starts an SSH server accepting the given client key and serving the
+// local file system over SFTP. It returns the address and the authorized_keys line of
+// its host key.
+func startSFTPServer(t *testing.T, clientKey ssh.PublicKey) (string, string, func()) {
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "inventory" && bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, assert.AnError
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTP(conn, config)
+		}
+	}()
+
+	return ln.Addr().String(), string(ssh.MarshalAuthorizedKey(hostSigner.PublicKey())), func() { ln.Close() }
+}
+
+func serveSFTP(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func(in <-chan *ssh.Request) {
+			for req := range in {
+				req.Reply(req.Type == "subsystem" && string(req.Payload[4:]) == "sftp", nil)
+			}
+		}(requests)
+
+		server, err := sftp.NewServer(channel, sftp.ReadOnly())
+		if err != nil {
+			return
+		}
+		server.Serve()
+		server.Close()
+	}
+}
+
+func TestSFTPSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-sftp")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	clientKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyFile := filepath.Join(dir, "id_rsa")
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(clientKey)}), 0600))
+	clientPub, err := ssh.NewPublicKey(&clientKey.PublicKey)
+	require.NoError(t, err)
+
+	document := filepath.Join(dir, "endpoints.json")
+	require.NoError(t, ioutil.WriteFile(document, []byte(`{"endpoints": [{"dnsName": "switch-1.example.org", "targets": ["10.0.0.1"], "recordTTL": 300}]}`), 0600))
+
+	address, hostKey, stop := startSFTPServer(t, clientPub)
+	defer stop()
+
+	_, otherHostKey, stopOther := startSFTPServer(t, clientPub)
+	stopOther()
+
+	t.Run("Interface", func(t *testing.T) {
+		assert.Implements(t, (*Source)(nil), new(sftpSource))
+	})
+
+	t.Run("NewSFTPSource", func(t *testing.T) {
+		for _, ti := range []struct {
+			title   string
+			address string
+			keyFile string
+			hostKey string
+			path    string
+		}{
+			{title: "missing address", keyFile: keyFile, hostKey: hostKey, path: document},
+			{title: "missing path", address: address, keyFile: keyFile, hostKey: hostKey},
+			{title: "missing host key", address: address, keyFile: keyFile, path: document},
+			{title: "invalid host key", address: address, keyFile: keyFile, hostKey: "ssh-ed25519 invalid", path: document},
+			{title: "invalid private key", address: address, keyFile: document, hostKey: hostKey, path: document},
+		} {
+			t.Run(ti.title, func(t *testing.T) {
+				_, err := NewSFTPSource(ti.address, "inventory", ti.keyFile, ti.hostKey, ti.path, time.Second)
+				assert.Error(t, err)
+			})
+		}
+
+		ss, err := NewSFTPSource("appliance.example.org", "inventory", keyFile, hostKey, document, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "appliance.example.org:22", ss.(*sftpSource).address)
+	})
+
+	t.Run("Endpoints", func(t *testing.T) {
+		ss, err := NewSFTPSource(address, "inventory", keyFile, hostKey, document, 5*time.Second)
+		require.NoError(t, err)
+
+		endpoints, err := ss.Endpoints()
+		require.NoError(t, err)
+		validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+			{DNSName: "switch-1.example.org", Targets: endpoint.Targets{"10.0.0.1"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+		})
+	})
+
+	t.Run("MissingDocument", func(t *testing.T) {
+		ss, err := NewSFTPSource(address, "inventory", keyFile, hostKey, filepath.Join(dir, "missing.json"), 5*time.Second)
+		require.NoError(t, err)
+
+		_, err = ss.Endpoints()
+		assert.Error(t, err)
+	})
+
+	t.Run("HostKeyMismatch", func(t *testing.T) {
+		ss, err := NewSFTPSource(address, "inventory", keyFile, otherHostKey, document, 5*time.Second)
+		require.NoError(t, err)
+
+		_, err = ss.Endpoints()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	snmpDefaultPort = "161"
+
+	oidSysName        = ".1.3.6.1.2.1.1.5.0"
+	oidIPAdEntIfIndex = ".1.3.6.1.2.1.4.20.1.2"
+	oidIfName         = ".1.3.6.1.2.1.31.1.1.1.1"
+)
+
+var invalidLabelChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// snmpClient is the subset of the SNMP client used by snmpSource.
+type snmpClient interface {
+	Get(oids []string) (*gosnmp.SnmpPacket, error)
+	BulkWalk(rootOid string, walkFn gosnmp.WalkFunc) error
+}
+
+// snmpDialer connects to the SNMP agent at the given host and port.
+type snmpDialer func(host string, port uint16) (snmpClient, func(), error)
+
+// snmpSource is an implementation of Source that provides endpoints for network devices
+// discovered via SNMP.
+//
+// Every device is published under its sysName, pointing to the address it is polled at.
+// Every interface address of the device is published as <ifName>.<sysName>.
+type snmpSource struct {
+	targets []string
+	domain  string
+	dial    snmpDialer
+	lookup  func(host string) ([]net.IP, error)
+}
+
+// NewSNMPSource creates a new snmpSource polling the given host[:port] targets with SNMP v2c.
+func NewSNMPSource(targets []string, community, domain string, timeout time.Duration) (Source, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no snmp targets specified")
+	}
+	if domain == "" {
+		return nil, fmt.Errorf("snmp source domain must not be empty")
+	}
+
+	return &snmpSource{
+		targets: targets,
+		domain:  strings.Trim(domain, "."),
+		lookup:  net.LookupIP,
+		dial: func(host string, port uint16) (snmpClient, func(), error) {
+			client := &gosnmp.GoSNMP{
+				Target:             host,
+				Port:               port,
+				Community:          community,
+				Version:            gosnmp.Version2c,
+				Timeout:            timeout,
+				Retries:            1,
+				MaxOids:            gosnmp.MaxOids,
+				MaxRepetitions:     50,
+				ExponentialTimeout: true,
+			}
+			if err := client.Connect(); err != nil {
+				return nil, nil, err
+			}
+			return client, func() { client.Conn.Close() }, nil
+		},
+	}, nil
+}
+
+// Endpoints returns endpoint objects.
+func (ss *snmpSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	targets := map[string]endpoint.Targets{}
+
+	for _, target := range ss.targets {
+		addresses, err := ss.device(target)
+		if err != nil {
+			// A single unreachable device shouldn't remove the records of all others.
+			log.Warnf("Failed to poll snmp target %s: %v", target, err)
+			continue
+		}
+		for name, ip := range addresses {
+			if !containsTarget(targets[name], ip) {
+				targets[name] = append(targets[name], ip)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, name := range names {
+		endpoints = append(endpoints, endpoint.NewEndpoint(name, endpoint.RecordTypeA, targets[name]...))
+	}
+
+	log.Debugf("Found %d endpoints for %d snmp targets", len(endpoints), len(ss.targets))
+
+	return endpoints, nil
+}
+
+// device polls the given target and returns the addresses of its DNS names.
+func (ss *snmpSource) device(target string) (map[string]string, error) {
+	host, portStr, err := net.SplitHostPort(target)
+	if err != nil {
+		host, portStr = target, snmpDefaultPort
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", portStr)
+	}
+
+	managementIP := net.ParseIP(host)
+	if managementIP == nil {
+		ips, err := ss.lookup(host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			if ip.To4() != nil {
+				managementIP = ip
+				break
+			}
+		}
+		if managementIP == nil {
+			return nil, fmt.Errorf("no IPv4 address found for %s", host)
+		}
+	}
+
+	client, closeFn, err := ss.dial(host, uint16(port))
+	if err != nil {
+		return nil, err
+	}
+	defer closeFn()
+
+	packet, err := client.Get([]string{oidSysName})
+	if err != nil {
+		return nil, err
+	}
+	if len(packet.Variables) != 1 || packet.Variables[0].Type != gosnmp.OctetString {
+		return nil, fmt.Errorf("no sysName returned")
+	}
+	deviceName := ss.deviceName(string(packet.Variables[0].Value.([]byte)))
+	if deviceName == "" {
+		return nil, fmt.Errorf("empty sysName returned")
+	}
+
+	ifNames := map[string]string{}
+	err = client.BulkWalk(oidIfName, func(pdu gosnmp.SnmpPDU) error {
+		if value, ok := pdu.Value.([]byte); ok {
+			ifNames[strings.TrimPrefix(pdu.Name, oidIfName+".")] = string(value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := map[string]string{deviceName: managementIP.String()}
+
+	// The index of the ipAddrTable is the address itself, the value the interface index.
+	err = client.BulkWalk(oidIPAdEntIfIndex, func(pdu gosnmp.SnmpPDU) error {
+		ip := net.ParseIP(strings.TrimPrefix(pdu.Name, oidIPAdEntIfIndex+"."))
+		if ip == nil || ip.IsLoopback() {
+			return nil
+		}
+		label := snmpLabel(ifNames[fmt.Sprint(gosnmp.ToBigInt(pdu.Value))])
+		if label == "" {
+			return nil
+		}
+		addresses[label+"."+deviceName] = ip.String()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
+
+// deviceName returns the DNS name of a device with the given sysName.
+func (ss *snmpSource) deviceName(sysName string) string {
+	name := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(sysName), "."))
+	if strings.Contains(name, ".") {
+		return name
+	}
+	if name = snmpLabel(name); name == "" {
+		return ""
+	}
+	return name + "." + ss.domain
+}
+
+// snmpLabel converts an interface or device name into a DNS label, e.g.
+// GigabitEthernet0/1 into gigabitethernet0-1.
+func snmpLabel(name string) string {
+	return strings.Trim(invalidLabelChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func (ss *snmpSource) AddEventHandler(ctx context.Context, handler func()) {
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+type fakeSNMPClient struct {
+	pdus []gosnmp.SnmpPDU
+}
+
+func (c *fakeSNMPClient) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	packet := &gosnmp.SnmpPacket{}
+	for _, oid := range oids {
+		for _, pdu := range c.pdus {
+			if pdu.Name == oid {
+				packet.Variables = append(packet.Variables, pdu)
+			}
+		}
+	}
+	return packet, nil
+}
+
+func (c *fakeSNMPClient) BulkWalk(rootOid string, walkFn gosnmp.WalkFunc) error {
+	for _, pdu := range c.pdus {
+		if strings.HasPrefix(pdu.Name, rootOid+".") {
+			if err := walkFn(pdu); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func TestSNMPSource(t *testing.T) {
+	t.Run("Interface", testSNMPSourceImplementsSource)
+	t.Run("NewSNMPSource", testSNMPSourceNewSNMPSource)
+	t.Run("Endpoints", testSNMPSourceEndpoints)
+}
+
+// testSNMPSourceImplementsSource tests that snmpSource is a valid Source.
+func testSNMPSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(snmpSource))
+}
+
+// testSNMPSourceNewSNMPSource tests that NewSNMPSource validates its configuration.
+func testSNMPSourceNewSNMPSource(t *testing.T) {
+	_, err := NewSNMPSource(nil, "public", "net.example.org", time.Second)
+	assert.Error(t, err)
+
+	_, err = NewSNMPSource([]string{"10.0.0.1"}, "public", "", time.Second)
+	assert.Error(t, err)
+
+	_, err = NewSNMPSource([]string{"10.0.0.1"}, "public", "net.example.org", time.Second)
+	assert.NoError(t, err)
+}
+
+// testSNMPSourceEndpoints tests that devices and their interfaces are converted to endpoints.
+func testSNMPSourceEndpoints(t *testing.T) {
+	devices := map[string]*fakeSNMPClient{
+		"10.0.0.1:161": {pdus: []gosnmp.SnmpPDU{
+			{Name: oidSysName, Type: gosnmp.OctetString, Value: []byte("Core-1")},
+			{Name: oidIfName + ".1", Type: gosnmp.OctetString, Value: []byte("GigabitEthernet0/1")},
+			{Name: oidIfName + ".2", Type: gosnmp.OctetString, Value: []byte("Vlan10")},
+			{Name: oidIfName + ".3", Type: gosnmp.OctetString, Value: []byte("Loopback0")},
+			{Name: oidIPAdEntIfIndex + ".10.0.0.1", Type: gosnmp.Integer, Value: 1},
+			{Name: oidIPAdEntIfIndex + ".192.168.10.1", Type: gosnmp.Integer, Value: 2},
+			{Name: oidIPAdEntIfIndex + ".127.0.0.1", Type: gosnmp.Integer, Value: 3},
+			{Name: oidIPAdEntIfIndex + ".192.168.20.1", Type: gosnmp.Integer, Value: 4},
+		}},
+		"10.0.0.2:1161": {pdus: []gosnmp.SnmpPDU{
+			{Name: oidSysName, Type: gosnmp.OctetString, Value: []byte("access-2.sw.example.org")},
+		}},
+	}
+
+	ss, err := NewSNMPSource([]string{"10.0.0.1", "switch-2.example.org:1161", "10.0.0.3", "10.0.0.4:snmp"}, "public", "net.example.org.", time.Second)
+	require.NoError(t, err)
+	ss.(*snmpSource).lookup = func(host string) ([]net.IP, error) {
+		if host == "switch-2.example.org" {
+			return []net.IP{net.ParseIP("fd00::2"), net.ParseIP("10.0.0.2")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	ss.(*snmpSource).dial = func(host string, port uint16) (snmpClient, func(), error) {
+		if host == "switch-2.example.org" {
+			host = "10.0.0.2"
+		}
+		client, ok := devices[net.JoinHostPort(host, strconv.Itoa(int(port)))]
+		if !ok {
+			return nil, nil, errors.New("request timeout")
+		}
+		return client, func() {}, nil
+	}
+
+	endpoints, err := ss.Endpoints()
+	require.NoError(t, err)
+
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "core-1.net.example.org", Targets: endpoint.Targets{"10.0.0.1"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "gigabitethernet0-1.core-1.net.example.org", Targets: endpoint.Targets{"10.0.0.1"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "vlan10.core-1.net.example.org", Targets: endpoint.Targets{"192.168.10.1"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "access-2.sw.example.org", Targets: endpoint.Targets{"10.0.0.2"}, RecordType: endpoint.RecordTypeA},
+	})
+}
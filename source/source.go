@@ -67,6 +67,20 @@ type Source interface {
 	AddEventHandler(context.Context, func())
 }
 
+// HealthChecker is implemented by Sources that can verify their backend, e.g. that their
+// files can be read, for readiness checks.
+type HealthChecker interface {
+	CheckHealth() error
+}
+
+// ApplyResultReporter is implemented by Sources reporting the results of applying the changes
+// of their endpoints, e.g. in the status of their resources.
+type ApplyResultReporter interface {
+	// ReportApplyResult reports the created and updated endpoints of the changes, which failed
+	// to be applied if err is set.
+	ReportApplyResult(changed []*endpoint.Endpoint, err error)
+}
+
 func getTTLFromAnnotations(annotations map[string]string) (endpoint.TTL, error) {
 	ttlNotConfigured := endpoint.TTL(0)
 	ttlAnnotation, exists := annotations[ttlAnnotationKey]
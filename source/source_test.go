@@ -83,6 +83,36 @@ func TestGetTTLFromAnnotations(t *testing.T) {
 			expectedTTL: endpoint.TTL(20),
 			expectedErr: nil,
 		},
+		{
+			title:       "TTL annotation value is larger than a byte",
+			annotations: map[string]string{ttlAnnotationKey: "3600"},
+			expectedTTL: endpoint.TTL(3600),
+			expectedErr: nil,
+		},
+		{
+			title:       "TTL annotation value is larger than 16 bits",
+			annotations: map[string]string{ttlAnnotationKey: "24h"},
+			expectedTTL: endpoint.TTL(86400),
+			expectedErr: nil,
+		},
+		{
+			title:       "TTL annotation value is the minimum",
+			annotations: map[string]string{ttlAnnotationKey: fmt.Sprintf("%d", ttlMinimum)},
+			expectedTTL: endpoint.TTL(ttlMinimum),
+			expectedErr: nil,
+		},
+		{
+			title:       "TTL annotation value is the maximum",
+			annotations: map[string]string{ttlAnnotationKey: fmt.Sprintf("%d", ttlMaximum)},
+			expectedTTL: endpoint.TTL(ttlMaximum),
+			expectedErr: nil,
+		},
+		{
+			title:       "TTL annotation value is above the maximum",
+			annotations: map[string]string{ttlAnnotationKey: fmt.Sprintf("%d", ttlMaximum+1)},
+			expectedTTL: endpoint.TTL(0),
+			expectedErr: fmt.Errorf("TTL value must be between [%d, %d]", ttlMinimum, ttlMaximum),
+		},
 	} {
 		t.Run(tc.title, func(t *testing.T) {
 			ttl, err := getTTLFromAnnotations(tc.annotations)
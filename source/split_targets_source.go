@@ -0,0 +1,70 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// splitTargetsSource is a Source that splits the endpoints of its wrapped source with several
+// targets into one endpoint per target, for providers and policies handling separate records
+// better than record sets. The endpoints are told apart by their set identifier, which is the
+// target, prefixed with the set identifier of the original endpoint if it has one.
+type splitTargetsSource struct {
+	source Source
+}
+
+// NewSplitTargetsSource creates a new splitTargetsSource wrapping the provided Source.
+func NewSplitTargetsSource(source Source) Source {
+	return &splitTargetsSource{source: source}
+}
+
+// Endpoints collects endpoints from its wrapped source and splits them by target.
+func (ss *splitTargetsSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	endpoints, err := ss.source.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if len(ep.Targets) < 2 {
+			result = append(result, ep)
+			continue
+		}
+		for _, target := range ep.Targets {
+			split := endpoint.NewEndpointWithTTL(ep.DNSName, ep.RecordType, ep.RecordTTL, target)
+			split.SetIdentifier = target
+			if ep.SetIdentifier != "" {
+				split.SetIdentifier = ep.SetIdentifier + "/" + target
+			}
+			for k, v := range ep.Labels {
+				split.Labels[k] = v
+			}
+			split.ProviderSpecific = targetProviderSpecific(ep.ProviderSpecific, target)
+			result = append(result, split)
+		}
+	}
+
+	return result, nil
+}
+
+func (ss *splitTargetsSource) AddEventHandler(ctx context.Context, handler func()) {
+	ss.source.AddEventHandler(ctx, handler)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+// Validates that splitTargetsSource is a Source
+var _ Source = &splitTargetsSource{}
+
+func TestSplitTargetsSource(t *testing.T) {
+	t.Run("Endpoints", testSplitTargetsSourceEndpoints)
+	t.Run("Error", testSplitTargetsSourceError)
+}
+
+// testSplitTargetsSourceEndpoints tests that endpoints with several targets are split into one endpoint per target.
+func testSplitTargetsSourceEndpoints(t *testing.T) {
+	weighted := endpoint.NewEndpointWithTTL("bar.example.org", endpoint.RecordTypeA, 60, "10.0.0.3", "10.0.0.4").WithSetIdentifier("eu")
+	weighted.ProviderSpecific = endpoint.ProviderSpecific{
+		{Name: endpoint.ProviderSpecificTargetWeightPrefix + "10.0.0.3", Value: "2"},
+		{Name: endpoint.ProviderSpecificClass, Value: "CH"},
+	}
+
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "10.0.0.1", "10.0.0.2"),
+		weighted,
+		endpoint.NewEndpoint("baz.example.org", endpoint.RecordTypeCNAME, "baz.example.com"),
+	}, nil)
+
+	endpoints, err := NewSplitTargetsSource(mockSource).Endpoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "10.0.0.1").WithSetIdentifier("10.0.0.1"),
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "10.0.0.2").WithSetIdentifier("10.0.0.2"),
+		endpoint.NewEndpointWithTTL("bar.example.org", endpoint.RecordTypeA, 60, "10.0.0.3").WithSetIdentifier("eu/10.0.0.3"),
+		endpoint.NewEndpointWithTTL("bar.example.org", endpoint.RecordTypeA, 60, "10.0.0.4").WithSetIdentifier("eu/10.0.0.4"),
+		endpoint.NewEndpoint("baz.example.org", endpoint.RecordTypeCNAME, "baz.example.com"),
+	})
+	for _, ep := range endpoints {
+		switch ep.SetIdentifier {
+		case "eu/10.0.0.3":
+			assert.Equal(t, endpoint.ProviderSpecific{
+				{Name: endpoint.ProviderSpecificTargetWeightPrefix + "10.0.0.3", Value: "2"},
+				{Name: endpoint.ProviderSpecificClass, Value: "CH"},
+			}, ep.ProviderSpecific)
+		case "eu/10.0.0.4":
+			assert.Equal(t, endpoint.ProviderSpecific{{Name: endpoint.ProviderSpecificClass, Value: "CH"}}, ep.ProviderSpecific)
+		}
+	}
+
+	mockSource.AssertExpectations(t)
+}
+
+// testSplitTargetsSourceError tests that errors of the wrapped source are returned.
+func testSplitTargetsSourceError(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return(nil, errors.New("some error"))
+
+	_, err := NewSplitTargetsSource(mockSource).Endpoints()
+	assert.EqualError(t, err, "some error")
+}
@@ -24,6 +24,8 @@ import (
 	"time"
 
 	"github.com/cloudfoundry-community/go-cfclient"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/go-redis/redis"
 	"github.com/linki/instrumented_http"
 	openshift "github.com/openshift/client-go/route/clientset/versioned"
 	"github.com/pkg/errors"
@@ -33,6 +35,8 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+
+	"sigs.k8s.io/external-dns/endpoint"
 )
 
 // ErrSourceNotFound is returned when a requested source doesn't exist.
@@ -62,6 +66,91 @@ type Config struct {
 	ContourLoadBalancerService     string
 	SkipperRouteGroupVersion       string
 	RequestTimeout                 time.Duration
+	RedisAddress                   string
+	RedisPassword                  string
+	RedisDB                        int
+	RedisKey                       string
+	RedisKeyspaceNotifications     bool
+	HTTPSourceURL                  string
+	HTTPSourceHeaders              []string
+	HTTPSourcePollInterval         time.Duration
+	HTTPSourceStaleWhileError      time.Duration
+	PluginSocket                   string
+	LeaseSourcePath                string
+	LeaseSourceFormat              string
+	LeaseSourceDomain              string
+	LeaseSourceMaxTTL              time.Duration
+	KeaSourceURL                   string
+	KeaSourceSubnets               []int
+	KeaSourceHostnameFilter        string
+	LibvirtSourceURI               string
+	LibvirtSourceAddressSource     string
+	LibvirtSourceDomain            string
+	MQTTBroker                     string
+	MQTTTopic                      string
+	MQTTClientID                   string
+	MQTTUsername                   string
+	MQTTPassword                   string
+	MQTTExpiry                     time.Duration
+	MQTTStateFile                  string
+	SFTPAddress                    string
+	SFTPUser                       string
+	SFTPKeyFile                    string
+	SFTPHostKey                    string
+	SFTPPath                       string
+	S3Endpoint                     string
+	S3Region                       string
+	S3Bucket                       string
+	S3Key                          string
+	S3AccessKeyID                  string
+	S3SecretAccessKey              string
+	VaultAddress                   string
+	VaultPaths                     []string
+	VaultToken                     string
+	VaultRoleID                    string
+	VaultSecretID                  string
+	NeighborTable                  string
+	NeighborSubnets                []string
+	NeighborMACNames               []string
+	NeighborDomain                 string
+	MDNSServices                   []string
+	MDNSDomain                     string
+	MDNSTimeout                    time.Duration
+	SNMPTargets                    []string
+	SNMPCommunity                  string
+	SNMPDomain                     string
+	FilesPaths                     []string
+	FilesConflict                  string
+	FilesDomainFilter              endpoint.DomainFilter
+	FilesPollInterval              time.Duration
+	FilesStrict                    bool
+	FilesMaxChange                 int
+	FilesConfirmAfter              time.Duration
+	FilesForceFile                 string
+	FilesDeltaDir                  string
+	FilesDeltaBase                 string
+	FilesMaxAge                    time.Duration
+	FilesFreezeStale               bool
+	WebhookListenAddress           string
+	WebhookToken                   string
+	WebhookFile                    string
+	TemplateFile                   string
+	TemplateDataFile               string
+	DNSUpdateListenAddress         string
+	DNSUpdateZone                  string
+	DNSUpdateTSIGKeyName           string
+	DNSUpdateTSIGSecret            string
+	DNSUpdateFile                  string
+	ReversePaths                   []string
+	ReverseZones                   []string
+	SplitTargetsSources            []string
+	NamePrefixes                   map[string]string
+	NameSuffixes                   map[string]string
+	NameStripSuffixes              map[string]string
+	TargetRewriteSources           []string
+	TargetRewrites                 map[string]string
+	DefaultTTLs                    map[string]string
+	ForcedTTLs                     map[string]string
 }
 
 // ClientGenerator provides clients
@@ -159,12 +248,52 @@ func ByNames(p ClientGenerator, names []string, cfg *Config) ([]Source, error) {
 		if err != nil {
 			return nil, err
 		}
+		if cfg.NamePrefixes[name] != "" || cfg.NameSuffixes[name] != "" || cfg.NameStripSuffixes[name] != "" {
+			source = NewNameSource(source, cfg.NamePrefixes[name], cfg.NameSuffixes[name], cfg.NameStripSuffixes[name])
+		}
+		for _, rewriteName := range cfg.TargetRewriteSources {
+			if rewriteName == name {
+				source, err = NewTargetRewriteSource(source, cfg.TargetRewrites)
+				if err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+		if cfg.DefaultTTLs[name] != "" || cfg.ForcedTTLs[name] != "" {
+			source, err = newTTLSourceWithConfig(source, cfg.DefaultTTLs[name], cfg.ForcedTTLs[name])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid TTL of source %s", name)
+			}
+		}
+		for _, splitName := range cfg.SplitTargetsSources {
+			if splitName == name {
+				source = NewSplitTargetsSource(source)
+				break
+			}
+		}
 		sources = append(sources, source)
 	}
 
 	return sources, nil
 }
 
+// newTTLSourceWithConfig wraps a source in a ttlSource with the given TTLs in duration format.
+func newTTLSourceWithConfig(source Source, defaultTTL, forcedTTL string) (Source, error) {
+	ttls := make([]time.Duration, 2)
+	for i, value := range []string{defaultTTL, forcedTTL} {
+		if value == "" {
+			continue
+		}
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+		ttls[i] = ttl
+	}
+	return NewTTLSource(source, ttls[0], ttls[1]), nil
+}
+
 // BuildWithConfig allows to generate a Source implementation from the shared config
 func BuildWithConfig(source string, p ClientGenerator, cfg *Config) (Source, error) {
 	switch source {
@@ -222,6 +351,94 @@ func BuildWithConfig(source string, p ClientGenerator, cfg *Config) (Source, err
 		return NewFakeSource(cfg.FQDNTemplate)
 	case "connector":
 		return NewConnectorSource(cfg.ConnectorServer)
+	case "lease":
+		return NewLeaseSource(cfg.LeaseSourcePath, cfg.LeaseSourceFormat, cfg.LeaseSourceDomain, cfg.LeaseSourceMaxTTL)
+	case "kea":
+		client := &http.Client{Timeout: cfg.RequestTimeout}
+		return NewKeaSource(client, cfg.KeaSourceURL, cfg.KeaSourceSubnets, cfg.KeaSourceHostnameFilter, cfg.LeaseSourceDomain, cfg.LeaseSourceMaxTTL)
+	case "libvirt":
+		return NewLibvirtSource(cfg.LibvirtSourceURI, cfg.LibvirtSourceAddressSource, cfg.LibvirtSourceDomain)
+	case "mqtt":
+		// A persistent session keeps the subscription across reconnects.
+		opts := mqtt.NewClientOptions().
+			AddBroker(cfg.MQTTBroker).
+			SetClientID(cfg.MQTTClientID).
+			SetUsername(cfg.MQTTUsername).
+			SetPassword(cfg.MQTTPassword).
+			SetCleanSession(false).
+			SetAutoReconnect(true).
+			SetConnectTimeout(cfg.RequestTimeout)
+		return NewMQTTSource(mqtt.NewClient(opts), cfg.MQTTTopic, cfg.MQTTExpiry, cfg.MQTTStateFile)
+	case "sftp":
+		return NewSFTPSource(cfg.SFTPAddress, cfg.SFTPUser, cfg.SFTPKeyFile, cfg.SFTPHostKey, cfg.SFTPPath, cfg.RequestTimeout)
+	case "s3":
+		client, err := newS3Client(cfg.S3Endpoint, cfg.S3Region, cfg.S3AccessKeyID, cfg.S3SecretAccessKey)
+		if err != nil {
+			return nil, err
+		}
+		return NewS3Source(client, cfg.S3Bucket, cfg.S3Key)
+	case "vault":
+		client := &http.Client{Timeout: cfg.RequestTimeout}
+		return NewVaultSource(client, cfg.VaultAddress, cfg.VaultPaths, cfg.VaultToken, cfg.VaultRoleID, cfg.VaultSecretID)
+	case "neighbor":
+		return NewNeighborSource(cfg.NeighborTable, cfg.NeighborSubnets, cfg.NeighborMACNames, cfg.NeighborDomain)
+	case "mdns":
+		return NewMDNSSource(cfg.MDNSServices, cfg.MDNSDomain, cfg.MDNSTimeout)
+	case "snmp":
+		return NewSNMPSource(cfg.SNMPTargets, cfg.SNMPCommunity, cfg.SNMPDomain, cfg.RequestTimeout)
+	case "files":
+		paths := cfg.FilesPaths
+		if cfg.FilesDeltaDir != "" {
+			// The base document of the deltas is read last, so its endpoints win.
+			paths = append(append([]string{}, paths...), cfg.FilesDeltaBase)
+		}
+		files, err := NewFilesSource(paths, cfg.FilesConflict, cfg.FilesDomainFilter, cfg.FilesPollInterval, cfg.FilesStrict)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.FilesDeltaDir != "" {
+			files = NewFilesDeltaSource(files, cfg.FilesDeltaDir, cfg.FilesDeltaBase, cfg.FilesPollInterval)
+		}
+		if cfg.FilesMaxAge > 0 {
+			files = NewFilesStalenessSource(files, paths, cfg.FilesMaxAge, cfg.FilesFreezeStale)
+		}
+		if cfg.FilesMaxChange > 0 {
+			files = NewFilesGuardSource(files, cfg.FilesMaxChange, cfg.FilesConfirmAfter, cfg.FilesForceFile)
+		}
+		return files, nil
+	case "webhook":
+		return NewWebhookSource(cfg.WebhookListenAddress, cfg.WebhookToken, cfg.WebhookFile)
+	case "template":
+		return NewTemplateSource(cfg.TemplateFile, cfg.TemplateDataFile)
+	case "dnsupdate":
+		return NewDNSUpdateSource(cfg.DNSUpdateListenAddress, cfg.DNSUpdateZone, cfg.DNSUpdateTSIGKeyName, cfg.DNSUpdateTSIGSecret, cfg.DNSUpdateFile)
+	case "reverse":
+		// The forward file names records outside of the reverse zones, so it isn't domain filtered.
+		forward, err := NewFilesSource(cfg.ReversePaths, FilesConflictOverride, endpoint.DomainFilter{}, 0, false)
+		if err != nil {
+			return nil, err
+		}
+		return NewReverseSource(forward, cfg.ReverseZones)
+	case "plugin":
+		return NewPluginSource(cfg.PluginSocket, cfg.RequestTimeout)
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddress,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		return NewRedisSource(client, cfg.RedisKey, cfg.RedisDB, cfg.RedisKeyspaceNotifications)
+	case "http":
+		client := &http.Client{
+			Timeout: cfg.RequestTimeout,
+			Transport: instrumented_http.NewTransport(http.DefaultTransport, &instrumented_http.Callbacks{
+				PathProcessor: func(path string) string {
+					parts := strings.Split(path, "/")
+					return parts[len(parts)-1]
+				},
+			}),
+		}
+		return NewHTTPSource(client, cfg.HTTPSourceURL, cfg.HTTPSourceHeaders, cfg.HTTPSourcePollInterval, cfg.HTTPSourceStaleWhileError)
 	case "crd":
 		client, err := p.KubeClient()
 		if err != nil {
@@ -28,6 +28,8 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	fakeKube "k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
 )
 
 type MockClientGenerator struct {
@@ -111,6 +113,24 @@ func (suite *ByNamesTestSuite) TestOnlyFake() {
 	suite.Nil(mockClientGenerator.kubeClient, "client should not be created")
 }
 
+func (suite *ByNamesTestSuite) TestTTLs() {
+	mockClientGenerator := new(MockClientGenerator)
+
+	cfg := &Config{DefaultTTLs: map[string]string{"fake": "5m"}}
+	sources, err := ByNames(mockClientGenerator, []string{"fake"}, cfg)
+	suite.NoError(err, "should not generate errors")
+	suite.IsType(&ttlSource{}, sources[0], "should wrap the fake source")
+	endpoints, err := sources[0].Endpoints()
+	suite.NoError(err)
+	for _, ep := range endpoints {
+		suite.Equal(endpoint.TTL(300), ep.RecordTTL)
+	}
+
+	cfg = &Config{ForcedTTLs: map[string]string{"fake": "300"}}
+	_, err = ByNames(mockClientGenerator, []string{"fake"}, cfg)
+	suite.Error(err, "should reject TTLs without a unit")
+}
+
 func (suite *ByNamesTestSuite) TestSourceNotFound() {
 	mockClientGenerator := new(MockClientGenerator)
 	mockClientGenerator.On("KubeClient").Return(fakeKube.NewSimpleClientset(), nil)
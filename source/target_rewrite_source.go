@@ -0,0 +1,184 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// targetRewrite rewrites the addresses of a network to a single address, or to the addresses of
+// a network of the same size keeping their host bits, e.g. for 1:1 NAT.
+type targetRewrite struct {
+	network *net.IPNet
+	to      net.IP
+	// The network the addresses are mapped to, if they aren't rewritten to a single address
+	toNetwork *net.IPNet
+}
+
+// targetRewriteSource is a Source that rewrites the targets of the A records of its wrapped
+// source, e.g. to publish the external addresses of hosts behind NAT from an inventory of their
+// internal addresses. Addresses are rewritten according to the most specific network containing
+// them, and the targets of names with a host rewrite are replaced with its address. Other
+// targets are kept.
+type targetRewriteSource struct {
+	source Source
+	// The network rewrites, the most specific ones first
+	networks []targetRewrite
+	hosts    map[string]string
+}
+
+// NewTargetRewriteSource creates a new targetRewriteSource wrapping the provided Source. The
+// rewrites map networks in CIDR notation, addresses or host names to an address, or networks to
+// networks of the same size, e.g. "10.0.0.0/24" to "203.0.113.0/24".
+func NewTargetRewriteSource(source Source, rewrites map[string]string) (Source, error) {
+	ts := &targetRewriteSource{source: source, hosts: map[string]string{}}
+	for from, to := range rewrites {
+		rewrite, err := parseTargetRewrite(from, to)
+		if err != nil {
+			return nil, err
+		}
+		if rewrite == nil {
+			ts.hosts[strings.ToLower(strings.TrimSuffix(from, "."))] = to
+			continue
+		}
+		ts.networks = append(ts.networks, *rewrite)
+	}
+	sort.Slice(ts.networks, func(i, j int) bool {
+		ones, _ := ts.networks[i].network.Mask.Size()
+		other, _ := ts.networks[j].network.Mask.Size()
+		if ones != other {
+			return ones > other
+		}
+		return ts.networks[i].network.String() < ts.networks[j].network.String()
+	})
+	return ts, nil
+}
+
+// parseTargetRewrite parses the rewrite of a network or address, or returns nil if the rewrite
+// is a host rewrite.
+func parseTargetRewrite(from, to string) (*targetRewrite, error) {
+	toIP, toNetwork, err := net.ParseCIDR(to)
+	if err != nil {
+		if toIP = net.ParseIP(to); toIP == nil {
+			return nil, fmt.Errorf("invalid target rewrite of %s: %s is neither an address nor a network", from, to)
+		}
+		toNetwork = nil
+	}
+
+	_, network, err := net.ParseCIDR(from)
+	if err != nil {
+		ip := net.ParseIP(from)
+		if ip == nil {
+			if toNetwork != nil {
+				return nil, fmt.Errorf("invalid target rewrite of host %s: %s is not an address", from, to)
+			}
+			return nil, nil
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	}
+	if toNetwork != nil && (len(network.IP) != len(toNetwork.IP) || network.Mask.String() != toNetwork.Mask.String()) {
+		return nil, fmt.Errorf("invalid target rewrite of %s: %s is not a network of the same size", from, to)
+	}
+	return &targetRewrite{network: network, to: toIP, toNetwork: toNetwork}, nil
+}
+
+// Endpoints collects endpoints from its wrapped source and rewrites their targets.
+func (ts *targetRewriteSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	endpoints, err := ts.source.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if ep.RecordType != endpoint.RecordTypeA {
+			result = append(result, ep)
+			continue
+		}
+		targets := endpoint.Targets{}
+		if host, ok := ts.hosts[strings.ToLower(strings.TrimSuffix(ep.DNSName, "."))]; ok {
+			targets = endpoint.Targets{host}
+		} else {
+			for _, target := range ep.Targets {
+				target = ts.rewrite(target)
+				if !containsTarget(targets, target) {
+					targets = append(targets, target)
+				}
+			}
+		}
+		if !equalTargets(targets, ep.Targets) {
+			// The wrapped source may return the endpoint again, so its targets must not be rewritten twice.
+			ep = ep.DeepCopy()
+			ep.Targets = targets
+		}
+		result = append(result, ep)
+	}
+
+	return result, nil
+}
+
+// rewrite returns the rewritten address, or the target if no network contains it.
+func (ts *targetRewriteSource) rewrite(target string) string {
+	ip := net.ParseIP(target)
+	if ip == nil {
+		return target
+	}
+	for _, rewrite := range ts.networks {
+		if !rewrite.network.Contains(ip) {
+			continue
+		}
+		if rewrite.toNetwork == nil {
+			return rewrite.to.String()
+		}
+		if len(rewrite.network.IP) == net.IPv4len {
+			ip = ip.To4()
+		}
+		rewritten := make(net.IP, len(ip))
+		for i := range ip {
+			rewritten[i] = rewrite.toNetwork.IP[i] | ip[i]&^rewrite.toNetwork.Mask[i]
+		}
+		return rewritten.String()
+	}
+	return target
+}
+
+// equalTargets returns whether the targets are equal, in the same order.
+func equalTargets(targets, other endpoint.Targets) bool {
+	if len(targets) != len(other) {
+		return false
+	}
+	for i := range targets {
+		if targets[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (ts *targetRewriteSource) AddEventHandler(ctx context.Context, handler func()) {
+	ts.source.AddEventHandler(ctx, handler)
+}
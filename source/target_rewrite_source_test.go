@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+// Validates that targetRewriteSource is a Source
+var _ Source = &targetRewriteSource{}
+
+func TestTargetRewriteSource(t *testing.T) {
+	t.Run("NewTargetRewriteSource", testTargetRewriteSourceNew)
+	t.Run("Endpoints", testTargetRewriteSourceEndpoints)
+	t.Run("Error", testTargetRewriteSourceError)
+}
+
+// testTargetRewriteSourceNew tests that invalid rewrites are rejected.
+func testTargetRewriteSourceNew(t *testing.T) {
+	for _, tc := range []struct {
+		from, to string
+		valid    bool
+	}{
+		{"10.0.0.0/24", "203.0.113.0/24", true},
+		{"10.0.0.0/24", "203.0.113.10", true},
+		{"10.0.0.5", "203.0.113.10", true},
+		{"nas.example.org", "203.0.113.20", true},
+		{"fd00::/64", "2001:db8::/64", true},
+		{"10.0.0.0/24", "203.0.113.0/25", false},
+		{"10.0.0.0/24", "2001:db8::/120", false},
+		{"10.0.0.0/24", "nas.example.com", false},
+		{"nas.example.org", "203.0.113.0/24", false},
+	} {
+		_, err := NewTargetRewriteSource(new(testutils.MockSource), map[string]string{tc.from: tc.to})
+		if tc.valid {
+			assert.NoError(t, err, tc.from+"="+tc.to)
+		} else {
+			assert.Error(t, err, tc.from+"="+tc.to)
+		}
+	}
+}
+
+// testTargetRewriteSourceEndpoints tests that the targets of A records are rewritten.
+func testTargetRewriteSourceEndpoints(t *testing.T) {
+	endpoints := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("web.example.org", endpoint.RecordTypeA, "10.0.0.7", "10.0.1.8"),
+		endpoint.NewEndpoint("db.example.org", endpoint.RecordTypeA, "10.0.0.5", "192.168.0.1"),
+		endpoint.NewEndpoint("NAS.example.org", endpoint.RecordTypeA, "10.0.0.9"),
+		endpoint.NewEndpoint("www.example.org", endpoint.RecordTypeCNAME, "web.example.org"),
+		endpoint.NewEndpoint("lan.example.org", endpoint.RecordTypeA, "192.168.0.1"),
+	}
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return(endpoints, nil)
+
+	ts, err := NewTargetRewriteSource(mockSource, map[string]string{
+		"10.0.0.0/24":     "203.0.113.0/24",
+		"10.0.0.5":        "203.0.113.100",
+		"10.0.0.0/8":      "198.51.100.1",
+		"nas.example.org": "203.0.113.20",
+	})
+	require.NoError(t, err)
+	result, err := ts.Endpoints()
+	require.NoError(t, err)
+
+	targets := map[string]endpoint.Targets{}
+	for _, ep := range result {
+		targets[ep.DNSName] = ep.Targets
+	}
+	assert.Equal(t, map[string]endpoint.Targets{
+		"web.example.org": {"203.0.113.7", "198.51.100.1"},
+		"db.example.org":  {"203.0.113.100", "192.168.0.1"},
+		"NAS.example.org": {"203.0.113.20"},
+		"www.example.org": {"web.example.org"},
+		"lan.example.org": {"192.168.0.1"},
+	}, targets)
+	// Unchanged endpoints are kept and the endpoints of the wrapped source are left unchanged.
+	assert.Same(t, endpoints[4], result[4])
+	assert.Equal(t, endpoint.Targets{"10.0.0.7", "10.0.1.8"}, endpoints[0].Targets)
+}
+
+// testTargetRewriteSourceError tests that errors of the wrapped source are returned.
+func testTargetRewriteSourceError(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return(nil, errors.New("some error"))
+
+	ts, err := NewTargetRewriteSource(mockSource, map[string]string{"10.0.0.0/24": "203.0.113.10"})
+	require.NoError(t, err)
+	_, err = ts.Endpoints()
+	assert.EqualError(t, err, "some error")
+}
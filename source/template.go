@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"text/template"
+
+	log "github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// templateSource is an implementation of Source that renders a Go template into an
+// endpoints document, to generate large regular sets of records instead of maintaining
+// them by hand.
+//
+// The template is rendered with the contents of an optional YAML or JSON data file and
+// may render the document as YAML or JSON. Besides the builtin functions of text/template,
+// the following functions are available:
+//
+//	seq FIRST LAST   the integers from FIRST to LAST, e.g. {{ range seq 1 200 }}
+//	add A B, sub A B integer arithmetic
+//	ipAdd IP N       the address N addresses after IP, e.g. {{ ipAdd "10.0.0.0" 5 }}
+type templateSource struct {
+	templateFile string
+	dataFile     string
+}
+
+// NewTemplateSource creates a new templateSource rendering the given template file.
+func NewTemplateSource(templateFile, dataFile string) (Source, error) {
+	if templateFile == "" {
+		return nil, fmt.Errorf("template source template must not be empty")
+	}
+
+	return &templateSource{
+		templateFile: templateFile,
+		dataFile:     dataFile,
+	}, nil
+}
+
+// Endpoints returns endpoint objects.
+func (ts *templateSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	tmpl, err := template.New(filepath.Base(ts.templateFile)).Funcs(templateFuncs).Option("missingkey=error").ParseFiles(ts.templateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var data interface{}
+	if ts.dataFile != "" {
+		raw, err := ioutil.ReadFile(ts.dataFile)
+		if err != nil {
+			return nil, err
+		}
+		// Unlike the JSON based decoder, yaml.v2 keeps integers as integers for arithmetic.
+		if err := yaml.Unmarshal(raw, &data); err != nil {
+			return nil, fmt.Errorf("failed to parse template data %s: %v", ts.dataFile, err)
+		}
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return nil, err
+	}
+	document, err := k8syaml.YAMLToJSON(rendered.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rendered template %s: %v", ts.templateFile, err)
+	}
+
+	endpoints, err := decodeEndpointsDocument(document)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", ts.templateFile, err)
+	}
+
+	log.Debugf("Rendered %d endpoints from template %s", len(endpoints), ts.templateFile)
+
+	return endpoints, nil
+}
+
+func (ts *templateSource) AddEventHandler(ctx context.Context, handler func()) {
+}
+
+var templateFuncs = template.FuncMap{
+	"seq": func(first, last int) []int {
+		var seq []int
+		for i := first; i <= last; i++ {
+			seq = append(seq, i)
+		}
+		return seq
+	},
+	"add": func(a, b int) int { return a + b },
+	"sub": func(a, b int) int { return a - b },
+	"ipAdd": func(s string, n int) (string, error) {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return "", fmt.Errorf("invalid ip %q", s)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		sum := new(big.Int).Add(new(big.Int).SetBytes(ip), big.NewInt(int64(n)))
+		if sum.Sign() < 0 || sum.BitLen() > len(ip)*8 {
+			return "", fmt.Errorf("%s + %d is out of range", s, n)
+		}
+		result := make(net.IP, len(ip))
+		b := sum.Bytes()
+		copy(result[len(result)-len(b):], b)
+		return result.String(), nil
+	},
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestTemplateSource(t *testing.T) {
+	t.Run("Interface", testTemplateSourceImplementsSource)
+	t.Run("NewTemplateSource", testTemplateSourceNewTemplateSource)
+	t.Run("Endpoints", testTemplateSourceEndpoints)
+}
+
+// testTemplateSourceImplementsSource tests that templateSource is a valid Source.
+func testTemplateSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(templateSource))
+}
+
+// testTemplateSourceNewTemplateSource tests that NewTemplateSource validates its configuration.
+func testTemplateSourceNewTemplateSource(t *testing.T) {
+	_, err := NewTemplateSource("", "data.yaml")
+	assert.Error(t, err)
+
+	_, err = NewTemplateSource("endpoints.tmpl", "")
+	assert.NoError(t, err)
+}
+
+// testTemplateSourceEndpoints tests that rendered templates are converted to endpoints.
+func testTemplateSourceEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-template")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	data := write("data.yaml", `
+domain: cluster.example.org
+base: 10.0.0.254
+nodes: 3
+`)
+
+	for _, tc := range []struct {
+		title     string
+		template  string
+		data      string
+		expected  []*endpoint.Endpoint
+		expectErr bool
+	}{
+		{
+			title: "ranges over the data",
+			template: `endpoints:
+{{- range $i := seq 1 .nodes }}
+- dnsName: node-{{ printf "%03d" $i }}.{{ $.domain }}
+  targets: ["{{ ipAdd $.base $i }}"]
+{{- end }}
+- dnsName: last.{{ .domain }}
+  targets: ["{{ ipAdd .base (sub .nodes 1) }}"]
+`,
+			data: data,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "node-001.cluster.example.org", Targets: endpoint.Targets{"10.0.0.255"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "node-002.cluster.example.org", Targets: endpoint.Targets{"10.0.1.0"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "node-003.cluster.example.org", Targets: endpoint.Targets{"10.0.1.1"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "last.cluster.example.org", Targets: endpoint.Targets{"10.0.1.0"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:    "renders JSON without data",
+			template: `{"endpoints": [{"dnsName": "json.example.org", "targets": ["{{ ipAdd "192.168.0.255" (add 1 1) }}"]}]}`,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "json.example.org", Targets: endpoint.Targets{"192.168.1.1"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:     "missing keys fail",
+			template:  `endpoints: [{dnsName: "{{ .missing }}", targets: ["10.0.0.1"]}]`,
+			data:      data,
+			expectErr: true,
+		},
+		{
+			title:     "overflowing addresses fail",
+			template:  `endpoints: [{dnsName: "foo.example.org", targets: ["{{ ipAdd "255.255.255.255" 1 }}"]}]`,
+			expectErr: true,
+		},
+		{
+			title:     "invalid documents fail",
+			template:  `endpoints: [{dnsName: "foo.example.org"}]`,
+			expectErr: true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			ts, err := NewTemplateSource(write("endpoints.tmpl", tc.template), tc.data)
+			require.NoError(t, err)
+
+			endpoints, err := ts.Endpoints()
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, tc.expected)
+		})
+	}
+}
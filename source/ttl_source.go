@@ -0,0 +1,73 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// ttlSource is a Source that enforces a TTL policy on the endpoints of its wrapped source: the
+// default TTL is set on endpoints without a TTL, and the forced TTL, if set, on all endpoints
+// regardless of their TTL.
+type ttlSource struct {
+	source     Source
+	defaultTTL endpoint.TTL
+	forcedTTL  endpoint.TTL
+}
+
+// NewTTLSource creates a new ttlSource wrapping the provided Source. A TTL of 0 is not applied.
+func NewTTLSource(source Source, defaultTTL, forcedTTL time.Duration) Source {
+	return &ttlSource{
+		source:     source,
+		defaultTTL: endpoint.TTL(defaultTTL.Seconds()),
+		forcedTTL:  endpoint.TTL(forcedTTL.Seconds()),
+	}
+}
+
+// Endpoints collects endpoints from its wrapped source and applies the TTLs to them.
+func (ts *ttlSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	endpoints, err := ts.source.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		ttl := ep.RecordTTL
+		if !ttl.IsConfigured() && ts.defaultTTL.IsConfigured() {
+			ttl = ts.defaultTTL
+		}
+		if ts.forcedTTL.IsConfigured() {
+			ttl = ts.forcedTTL
+		}
+		if ttl != ep.RecordTTL {
+			// A copy keeps a default TTL from looking configured on the wrapped source's next call.
+			ep = ep.DeepCopy()
+			ep.RecordTTL = ttl
+		}
+		result = append(result, ep)
+	}
+
+	return result, nil
+}
+
+func (ts *ttlSource) AddEventHandler(ctx context.Context, handler func()) {
+	ts.source.AddEventHandler(ctx, handler)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+// Validates that ttlSource is a Source
+var _ Source = &ttlSource{}
+
+func TestTTLSource(t *testing.T) {
+	t.Run("Endpoints", testTTLSourceEndpoints)
+	t.Run("Error", testTTLSourceError)
+}
+
+// testTTLSourceEndpoints tests that the default and forced TTLs are applied.
+func testTTLSourceEndpoints(t *testing.T) {
+	foo := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "10.0.0.1")
+	bar := endpoint.NewEndpointWithTTL("bar.example.org", endpoint.RecordTypeA, 60, "10.0.0.2")
+
+	for _, tc := range []struct {
+		title                 string
+		defaultTTL, forcedTTL time.Duration
+		expected              []endpoint.TTL
+	}{
+		{"no TTLs keep the TTLs", 0, 0, []endpoint.TTL{0, 60}},
+		{"the default TTL is set on endpoints without a TTL", 5 * time.Minute, 0, []endpoint.TTL{300, 60}},
+		{"the forced TTL is set on all endpoints", 0, 30 * time.Second, []endpoint.TTL{30, 30}},
+		{"the forced TTL wins over the default TTL", 5 * time.Minute, 30 * time.Second, []endpoint.TTL{30, 30}},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockSource := new(testutils.MockSource)
+			mockSource.On("Endpoints").Return([]*endpoint.Endpoint{foo, bar}, nil)
+
+			endpoints, err := NewTTLSource(mockSource, tc.defaultTTL, tc.forcedTTL).Endpoints()
+			require.NoError(t, err)
+			require.Len(t, endpoints, 2)
+			assert.Equal(t, tc.expected, []endpoint.TTL{endpoints[0].RecordTTL, endpoints[1].RecordTTL})
+			// The endpoints of the wrapped source are left unchanged.
+			assert.Equal(t, endpoint.TTL(0), foo.RecordTTL)
+			assert.Equal(t, endpoint.TTL(60), bar.RecordTTL)
+		})
+	}
+}
+
+// testTTLSourceError tests that errors of the wrapped source are returned.
+func testTTLSourceError(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return(nil, errors.New("some error"))
+
+	_, err := NewTTLSource(mockSource, time.Minute, 0).Endpoints()
+	assert.EqualError(t, err, "some error")
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+var invalidEndpointsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "external_dns",
+		Subsystem: "source",
+		Name:      "invalid_endpoints_total",
+		Help:      "Number of Endpoints rejected by the validation, partitioned by reason",
+	},
+	[]string{"reason"},
+)
+
+func init() {
+	prometheus.MustRegister(invalidEndpointsTotal)
+}
+
+// validatingSource is a Source that drops the endpoints of its wrapped source that don't
+// pass the validation rules, so a single invalid endpoint doesn't fail the changes of all others.
+type validatingSource struct {
+	source Source
+	rules  endpoint.ValidationRules
+}
+
+// NewValidatingSource creates a new validatingSource wrapping the provided Source.
+func NewValidatingSource(source Source, rules endpoint.ValidationRules) Source {
+	return &validatingSource{source: source, rules: rules}
+}
+
+// Endpoints collects endpoints from its wrapped source and returns the valid ones.
+func (vs *validatingSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	endpoints, err := vs.source.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if err := vs.rules.Validate(ep); err != nil {
+			reason := "unknown"
+			if verr, ok := err.(*endpoint.ValidationError); ok {
+				reason = verr.Reason
+			}
+			invalidEndpointsTotal.WithLabelValues(reason).Inc()
+			log.Warnf("Skipping invalid endpoint %s: %v", ep, err)
+			continue
+		}
+		result = append(result, ep)
+	}
+
+	return result, nil
+}
+
+func (vs *validatingSource) AddEventHandler(ctx context.Context, handler func()) {
+	vs.source.AddEventHandler(ctx, handler)
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+// Validates that validatingSource is a Source
+var _ Source = &validatingSource{}
+
+func TestValidatingSource(t *testing.T) {
+	t.Run("Endpoints", testValidatingSourceEndpoints)
+	t.Run("Error", testValidatingSourceError)
+}
+
+// testValidatingSourceEndpoints tests that invalid endpoints of the wrapped source are dropped and counted.
+func testValidatingSourceEndpoints(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "bar.example.com"),
+		endpoint.NewEndpoint("-baz.example.org", endpoint.RecordTypeCNAME, "baz.example.com"),
+		endpoint.NewEndpointWithTTL("qux.example.org", endpoint.RecordTypeCNAME, 10, "qux.example.com"),
+		endpoint.NewEndpoint("quux.example.org", endpoint.RecordTypeCNAME, "quux.example.com"),
+	}, nil)
+
+	targets := testutil.ToFloat64(invalidEndpointsTotal.WithLabelValues(endpoint.ValidationReasonTargets))
+	names := testutil.ToFloat64(invalidEndpointsTotal.WithLabelValues(endpoint.ValidationReasonName))
+	ttls := testutil.ToFloat64(invalidEndpointsTotal.WithLabelValues(endpoint.ValidationReasonTTL))
+
+	source := NewValidatingSource(mockSource, endpoint.ValidationRules{MinTTL: 60})
+
+	endpoints, err := source.Endpoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpoint("quux.example.org", endpoint.RecordTypeCNAME, "quux.example.com"),
+	})
+	assert.Equal(t, targets+1, testutil.ToFloat64(invalidEndpointsTotal.WithLabelValues(endpoint.ValidationReasonTargets)))
+	assert.Equal(t, names+1, testutil.ToFloat64(invalidEndpointsTotal.WithLabelValues(endpoint.ValidationReasonName)))
+	assert.Equal(t, ttls+1, testutil.ToFloat64(invalidEndpointsTotal.WithLabelValues(endpoint.ValidationReasonTTL)))
+
+	mockSource.AssertExpectations(t)
+}
+
+// testValidatingSourceError tests that errors of the wrapped source are returned.
+func testValidatingSourceError(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return(nil, errors.New("some error"))
+
+	_, err := NewValidatingSource(mockSource, endpoint.ValidationRules{}).Endpoints()
+	assert.EqualError(t, err, "some error")
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const vaultTokenHeader = "X-Vault-Token"
+
+// vaultAuth is the auth block returned by logins and token renewals.
+type vaultAuth struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int64  `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+type vaultResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Auth   *vaultAuth      `json:"auth"`
+	Errors []string        `json:"errors"`
+}
+
+// vaultSource is an implementation of Source that provides endpoints from endpoints
+// documents stored as secrets in HashiCorp Vault KV secrets engines. Both versions of the
+// engine are supported; for version 2 the path must include the data/ segment, e.g.
+// secret/data/dns.
+//
+// The source authenticates with a static token or with AppRole. Tokens are renewed once
+// half of their TTL has passed; AppRole tokens that can't be renewed are replaced by
+// logging in again.
+type vaultSource struct {
+	client   *http.Client
+	address  string
+	paths    []string
+	roleID   string
+	secretID string
+	now      func() time.Time
+
+	sync.Mutex
+	token     string
+	renewable bool
+	ttl       time.Duration
+	obtained  time.Time
+}
+
+// NewVaultSource creates a new vaultSource reading the given KV paths from the Vault
+// server at address. Either token or roleID and secretID must be given.
+func NewVaultSource(client *http.Client, address string, paths []string, token, roleID, secretID string) (Source, error) {
+	if address == "" {
+		return nil, fmt.Errorf("vault address must not be empty")
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no vault paths specified")
+	}
+	if token == "" && (roleID == "" || secretID == "") {
+		return nil, fmt.Errorf("either a vault token or an approle role and secret ID must be specified")
+	}
+
+	return &vaultSource{
+		client:   client,
+		address:  strings.TrimSuffix(address, "/"),
+		paths:    paths,
+		roleID:   roleID,
+		secretID: secretID,
+		token:    token,
+		now:      time.Now,
+	}, nil
+}
+
+// Endpoints returns endpoint objects.
+func (vs *vaultSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	vs.Lock()
+	defer vs.Unlock()
+
+	if err := vs.ensureToken(); err != nil {
+		return nil, err
+	}
+
+	endpoints := []*endpoint.Endpoint{}
+	for _, path := range vs.paths {
+		eps, err := vs.readDocument(path)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, eps...)
+	}
+
+	log.Debugf("Found %d endpoints in %d vault paths", len(endpoints), len(vs.paths))
+
+	return endpoints, nil
+}
+
+// readDocument reads the endpoints document stored in the secret at path.
+func (vs *vaultSource) readDocument(path string) ([]*endpoint.Endpoint, error) {
+	resp, err := vs.do(http.MethodGet, "/v1/"+strings.Trim(path, "/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault secret %s: %v", path, err)
+	}
+
+	// Version 2 of the KV engine wraps the secret along with its metadata.
+	var versioned struct {
+		Data     json.RawMessage `json:"data"`
+		Metadata json.RawMessage `json:"metadata"`
+	}
+	data := resp.Data
+	if err := json.Unmarshal(resp.Data, &versioned); err == nil && versioned.Data != nil && versioned.Metadata != nil {
+		data = versioned.Data
+	}
+
+	endpoints, err := decodeEndpointsDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("vault secret %s: %v", path, err)
+	}
+	return endpoints, nil
+}
+
+// ensureToken logs in or renews the token when needed. It must be called with the lock held.
+func (vs *vaultSource) ensureToken() error {
+	if vs.token == "" {
+		return vs.login()
+	}
+	if vs.ttl <= 0 || vs.now().Before(vs.obtained.Add(vs.ttl/2)) {
+		return nil
+	}
+
+	if vs.renewable {
+		resp, err := vs.do(http.MethodPost, "/v1/auth/token/renew-self", struct{}{})
+		if err == nil && resp.Auth != nil {
+			vs.setToken(resp.Auth)
+			log.Debugf("Renewed vault token, valid for %s", vs.ttl)
+			return nil
+		}
+		log.Warnf("Failed to renew vault token: %v", err)
+	}
+
+	if vs.roleID != "" {
+		return vs.login()
+	}
+	// A static token can still be used until it expires.
+	return nil
+}
+
+// login obtains a new token using AppRole.
+func (vs *vaultSource) login() error {
+	vs.token = ""
+	resp, err := vs.do(http.MethodPost, "/v1/auth/approle/login", map[string]string{
+		"role_id":   vs.roleID,
+		"secret_id": vs.secretID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to log in to vault with approle: %v", err)
+	}
+	if resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return fmt.Errorf("failed to log in to vault with approle: no token returned")
+	}
+
+	vs.setToken(resp.Auth)
+	log.Debugf("Logged in to vault with approle, token valid for %s", vs.ttl)
+
+	return nil
+}
+
+func (vs *vaultSource) setToken(auth *vaultAuth) {
+	if auth.ClientToken != "" {
+		vs.token = auth.ClientToken
+	}
+	vs.renewable = auth.Renewable
+	vs.ttl = time.Duration(auth.LeaseDuration) * time.Second
+	vs.obtained = vs.now()
+}
+
+// do sends a request to the Vault API and decodes its response.
+func (vs *vaultSource) do(method, path string, body interface{}) (*vaultResponse, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, vs.address+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if vs.token != "" {
+		req.Header.Set(vaultTokenHeader, vs.token)
+	}
+
+	resp, err := vs.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var vr vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&vr); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to decode vault response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if len(vr.Errors) > 0 {
+			return nil, fmt.Errorf("vault returned %s: %s", resp.Status, strings.Join(vr.Errors, ", "))
+		}
+		return nil, fmt.Errorf("vault returned %s", resp.Status)
+	}
+
+	return &vr, nil
+}
+
+func (vs *vaultSource) AddEventHandler(ctx context.Context, handler func()) {
+}
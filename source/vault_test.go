@@ -0,0 +1,209 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// fakeVault serves a KV version 1 engine at secret/ and a version 2 engine at kv/.
+type fakeVault struct {
+	logins    int
+	renewals  int
+	renewable bool
+	token     string
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/v1/auth/approle/login" {
+		var login map[string]string
+		json.NewDecoder(r.Body).Decode(&login)
+		if login["role_id"] != "role" || login["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"errors": ["invalid role or secret ID"]}`)
+			return
+		}
+		v.logins++
+		v.token = fmt.Sprintf("token-%d", v.logins)
+		fmt.Fprintf(w, `{"auth": {"client_token": %q, "lease_duration": 3600, "renewable": %t}}`, v.token, v.renewable)
+		return
+	}
+
+	if r.Header.Get(vaultTokenHeader) != v.token {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"errors": ["permission denied"]}`)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/v1/auth/token/renew-self":
+		v.renewals++
+		fmt.Fprint(w, `{"auth": {"client_token": "", "lease_duration": 3600, "renewable": true}}`)
+	case "/v1/secret/dns":
+		fmt.Fprint(w, `{"data": {"endpoints": [{"dnsName": "abc.example.org", "targets": ["1.2.3.4"]}]}}`)
+	case "/v1/kv/data/dns":
+		fmt.Fprint(w, `{"data": {"data": {"endpoints": [{"dnsName": "xyz.example.org", "targets": ["abc.example.org"], "recordTTL": 60}]}, "metadata": {"version": 3}}}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errors": []}`)
+	}
+}
+
+func TestVaultSource(t *testing.T) {
+	t.Run("Interface", testVaultSourceImplementsSource)
+	t.Run("NewVaultSource", testVaultSourceNewVaultSource)
+	t.Run("Endpoints", testVaultSourceEndpoints)
+	t.Run("Renewal", testVaultSourceRenewal)
+}
+
+// testVaultSourceImplementsSource tests that vaultSource is a valid Source.
+func testVaultSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(vaultSource))
+}
+
+// testVaultSourceNewVaultSource tests that NewVaultSource validates its configuration.
+func testVaultSourceNewVaultSource(t *testing.T) {
+	paths := []string{"secret/dns"}
+
+	_, err := NewVaultSource(http.DefaultClient, "", paths, "token", "", "")
+	assert.Error(t, err)
+
+	_, err = NewVaultSource(http.DefaultClient, "https://vault:8200", nil, "token", "", "")
+	assert.Error(t, err)
+
+	_, err = NewVaultSource(http.DefaultClient, "https://vault:8200", paths, "", "role", "")
+	assert.Error(t, err)
+
+	_, err = NewVaultSource(http.DefaultClient, "https://vault:8200", paths, "token", "", "")
+	assert.NoError(t, err)
+
+	_, err = NewVaultSource(http.DefaultClient, "https://vault:8200", paths, "", "role", "secret")
+	assert.NoError(t, err)
+}
+
+// testVaultSourceEndpoints tests that documents are read from both KV engine versions.
+func testVaultSourceEndpoints(t *testing.T) {
+	vault := &fakeVault{token: "static-token"}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	for _, ti := range []struct {
+		title       string
+		paths       []string
+		token       string
+		roleID      string
+		secretID    string
+		expected    []*endpoint.Endpoint
+		expectError bool
+	}{
+		{
+			title: "static token",
+			paths: []string{"secret/dns", "/kv/data/dns/"},
+			token: "static-token",
+			expected: []*endpoint.Endpoint{
+				{DNSName: "abc.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "xyz.example.org", Targets: endpoint.Targets{"abc.example.org"}, RecordType: endpoint.RecordTypeCNAME, RecordTTL: 60},
+			},
+		},
+		{
+			title:    "approle",
+			paths:    []string{"secret/dns"},
+			roleID:   "role",
+			secretID: "secret",
+			expected: []*endpoint.Endpoint{
+				{DNSName: "abc.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:       "invalid approle",
+			paths:       []string{"secret/dns"},
+			roleID:      "role",
+			secretID:    "guessed",
+			expectError: true,
+		},
+		{
+			title:       "missing secret",
+			paths:       []string{"secret/dns", "secret/missing"},
+			roleID:      "role",
+			secretID:    "secret",
+			expectError: true,
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			vs, err := NewVaultSource(server.Client(), server.URL+"/", ti.paths, ti.token, ti.roleID, ti.secretID)
+			require.NoError(t, err)
+
+			endpoints, err := vs.Endpoints()
+			if ti.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			validateEndpoints(t, endpoints, ti.expected)
+		})
+	}
+}
+
+// testVaultSourceRenewal tests that tokens are renewed, or replaced if they aren't renewable.
+func testVaultSourceRenewal(t *testing.T) {
+	for _, ti := range []struct {
+		title            string
+		renewable        bool
+		expectedLogins   int
+		expectedRenewals int
+	}{
+		{title: "renewable token", renewable: true, expectedLogins: 1, expectedRenewals: 1},
+		{title: "non-renewable token", renewable: false, expectedLogins: 2},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			vault := &fakeVault{renewable: ti.renewable}
+			server := httptest.NewServer(vault)
+			defer server.Close()
+
+			vs, err := NewVaultSource(server.Client(), server.URL, []string{"secret/dns"}, "", "role", "secret")
+			require.NoError(t, err)
+
+			now := time.Date(2020, 6, 4, 10, 0, 0, 0, time.UTC)
+			vs.(*vaultSource).now = func() time.Time { return now }
+
+			_, err = vs.Endpoints()
+			require.NoError(t, err)
+
+			now = now.Add(10 * time.Minute)
+			_, err = vs.Endpoints()
+			require.NoError(t, err)
+			assert.Equal(t, 1, vault.logins)
+
+			now = now.Add(30 * time.Minute)
+			_, err = vs.Endpoints()
+			require.NoError(t, err)
+			assert.Equal(t, ti.expectedLogins, vault.logins)
+			assert.Equal(t, ti.expectedRenewals, vault.renewals)
+		})
+	}
+}
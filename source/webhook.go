@@ -0,0 +1,164 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/fileutils"
+)
+
+// webhookMaxDocumentSize limits the size of pushed endpoints documents
+const webhookMaxDocumentSize = 4 << 20
+
+// webhookSource is an implementation of Source that serves an HTTP endpoint agents push
+// endpoints documents to, instead of being polled.
+//
+// Every pushed document replaces the previous one and is persisted to a file, so the
+// endpoints survive restarts. Requests must carry the configured bearer token.
+type webhookSource struct {
+	token string
+	file  string
+
+	sync.Mutex
+	endpoints []*endpoint.Endpoint
+	handlers  []func()
+}
+
+// NewWebhookSource creates a new webhookSource listening on the given address and
+// persisting the pushed endpoints document to the given file.
+func NewWebhookSource(listenAddress, token, file string) (Source, error) {
+	ws, err := newWebhookSource(token, file)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %v", listenAddress, err)
+	}
+	go func() {
+		log.Fatal(http.Serve(listener, ws))
+	}()
+
+	return ws, nil
+}
+
+func newWebhookSource(token, file string) (*webhookSource, error) {
+	if token == "" {
+		return nil, fmt.Errorf("webhook source token must not be empty")
+	}
+	if file == "" {
+		return nil, fmt.Errorf("webhook source file must not be empty")
+	}
+
+	ws := &webhookSource{
+		token:     token,
+		file:      file,
+		endpoints: []*endpoint.Endpoint{},
+	}
+
+	data, err := ioutil.ReadFile(file)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		if ws.endpoints, err = decodeEndpointsDocument(data); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+	}
+
+	return ws, nil
+}
+
+// Endpoints returns endpoint objects.
+func (ws *webhookSource) Endpoints() ([]*endpoint.Endpoint, error) {
+	ws.Lock()
+	defer ws.Unlock()
+
+	endpoints := make([]*endpoint.Endpoint, 0, len(ws.endpoints))
+	for _, ep := range ws.endpoints {
+		endpoints = append(endpoints, ep.DeepCopy())
+	}
+
+	log.Debugf("Found %d endpoints in the pushed endpoints document", len(endpoints))
+
+	return endpoints, nil
+}
+
+// ServeHTTP accepts a pushed endpoints document.
+func (ws *webhookSource) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(ws.token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, webhookMaxDocumentSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	endpoints, err := decodeEndpointsDocument(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ws.Lock()
+	if err := fileutils.WriteFileAtomically(ws.file, data); err != nil {
+		ws.Unlock()
+		log.Errorf("Failed to persist pushed endpoints document to %s: %v", ws.file, err)
+		http.Error(w, "failed to persist endpoints document", http.StatusInternalServerError)
+		return
+	}
+	ws.endpoints = endpoints
+	handlers := ws.handlers
+	ws.Unlock()
+
+	log.Infof("Accepted endpoints document with %d endpoints from %s", len(endpoints), r.RemoteAddr)
+	w.WriteHeader(http.StatusNoContent)
+
+	for _, handler := range handlers {
+		handler()
+	}
+}
+
+func (ws *webhookSource) AddEventHandler(ctx context.Context, handler func()) {
+	ws.Lock()
+	defer ws.Unlock()
+
+	ws.handlers = append(ws.handlers, handler)
+}
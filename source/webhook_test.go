@@ -0,0 +1,124 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestWebhookSource(t *testing.T) {
+	t.Run("Interface", testWebhookSourceImplementsSource)
+	t.Run("NewWebhookSource", testWebhookSourceNewWebhookSource)
+	t.Run("Endpoints", testWebhookSourceEndpoints)
+}
+
+// testWebhookSourceImplementsSource tests that webhookSource is a valid Source.
+func testWebhookSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(webhookSource))
+}
+
+// testWebhookSourceNewWebhookSource tests that NewWebhookSource validates its configuration.
+func testWebhookSourceNewWebhookSource(t *testing.T) {
+	_, err := NewWebhookSource("127.0.0.1:0", "", "endpoints.json")
+	assert.Error(t, err)
+
+	_, err = NewWebhookSource("127.0.0.1:0", "secret", "")
+	assert.Error(t, err)
+
+	dir, err := ioutil.TempDir("", "external-dns-webhook")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, ioutil.WriteFile(invalid, []byte(`{"endpoints": [{"dnsName": "foo.example.org"}]}`), 0644))
+	_, err = NewWebhookSource("127.0.0.1:0", "secret", invalid)
+	assert.Error(t, err)
+
+	_, err = NewWebhookSource("127.0.0.1:0", "secret", filepath.Join(dir, "endpoints.json"))
+	assert.NoError(t, err)
+}
+
+// testWebhookSourceEndpoints tests that pushed documents are served, persisted and
+// reported to the event handlers.
+func testWebhookSourceEndpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-dns-webhook")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "endpoints.json")
+
+	ws, err := newWebhookSource("secret", file)
+	require.NoError(t, err)
+	server := httptest.NewServer(ws)
+	defer server.Close()
+
+	events := 0
+	ws.AddEventHandler(context.Background(), func() { events++ })
+
+	endpoints, err := ws.Endpoints()
+	require.NoError(t, err)
+	assert.Empty(t, endpoints)
+
+	push := func(method, token, body string) int {
+		req, err := http.NewRequest(method, server.URL, strings.NewReader(body))
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	document := `{"endpoints": [{"dnsName": "agent-1.example.org", "targets": ["10.0.0.1"]}]}`
+	assert.Equal(t, http.StatusMethodNotAllowed, push(http.MethodGet, "secret", ""))
+	assert.Equal(t, http.StatusUnauthorized, push(http.MethodPost, "", document))
+	assert.Equal(t, http.StatusUnauthorized, push(http.MethodPost, "wrong", document))
+	assert.Equal(t, http.StatusBadRequest, push(http.MethodPost, "secret", `{"endpoints": [{"targets": ["10.0.0.1"]}]}`))
+	assert.Equal(t, 0, events)
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.Equal(t, http.StatusNoContent, push(http.MethodPost, "secret", document))
+	assert.Equal(t, 1, events)
+
+	expected := []*endpoint.Endpoint{
+		{DNSName: "agent-1.example.org", Targets: endpoint.Targets{"10.0.0.1"}, RecordType: endpoint.RecordTypeA},
+	}
+	endpoints, err = ws.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, expected)
+
+	// The pushed document is restored after a restart.
+	restarted, err := newWebhookSource("secret", file)
+	require.NoError(t, err)
+	endpoints, err = restarted.Endpoints()
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, expected)
+}